@@ -5,6 +5,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var cfgPath string
+
 // rootCmd is the root of all sub commands in the binary
 // it doesn't have a Run method as it executes other sub commands
 var rootCmd = &cobra.Command{
@@ -14,7 +16,13 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVarP(&cfgPath, "config", "c", "config.yml", "config file path")
+
 	// Here all other sub commands should be registered to the rootCmd
+	rootCmd.AddCommand(srvCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(purgeCmd)
+	rootCmd.AddCommand(ratingsCmd)
 }
 
 func main() {
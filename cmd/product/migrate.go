@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/log"
+	"github.com/msyrus/simple-product-inv/migration"
+)
+
+var migrationDir string
+
+// migrateCmd is the migrate sub command to manage the database schema
+// it doesn't have a Run method as it executes other sub commands
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "migrate manages the database schema",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "up applies all pending migrations",
+	Args:  cobra.NoArgs,
+	RunE:  migrateUp,
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "down reverts the latest applied migration",
+	Args:  cobra.NoArgs,
+	RunE:  migrateDown,
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "status lists the migrations and whether they are applied",
+	Args:  cobra.NoArgs,
+	RunE:  migrateStatus,
+}
+
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "create writes a new pair of up and down migration files",
+	Args:  cobra.ExactArgs(1),
+	RunE:  migrateCreate,
+}
+
+func init() {
+	migrateCreateCmd.Flags().StringVar(&migrationDir, "dir", "migration/sql", "migration source directory")
+
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+	migrateCmd.AddCommand(migrateCreateCmd)
+}
+
+func newMigrator(db infra.TxDB) (*migration.Migrator, error) {
+	mgrs, err := migration.Embedded()
+	if err != nil {
+		return nil, err
+	}
+	return migration.NewMigrator(db, mgrs, log.DefaultOutputLogger), nil
+}
+
+func openMigrator() (*migration.Migrator, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	pg, err := openPostgres(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newMigrator(pg)
+}
+
+// checkMigrated returns an error if db has pending migrations
+func checkMigrated(ctx context.Context, db infra.TxDB) error {
+	m, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+	pdg, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pdg) != 0 {
+		return fmt.Errorf("database schema is behind by %d migrations, run migrate up", len(pdg))
+	}
+	return nil
+}
+
+func migrateUp(cmd *cobra.Command, args []string) error {
+	m, err := openMigrator()
+	if err != nil {
+		return err
+	}
+	done, err := m.Up(context.Background())
+	for _, mgr := range done {
+		fmt.Fprintf(cmd.OutOrStdout(), "applied %04d_%s\n", mgr.Version, mgr.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(done) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "no pending migration")
+	}
+	return nil
+}
+
+func migrateDown(cmd *cobra.Command, args []string) error {
+	m, err := openMigrator()
+	if err != nil {
+		return err
+	}
+	mgr, err := m.Down(context.Background())
+	if err != nil {
+		return err
+	}
+	if mgr == nil {
+		fmt.Fprintln(cmd.OutOrStdout(), "no applied migration")
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "reverted %04d_%s\n", mgr.Version, mgr.Name)
+	return nil
+}
+
+func migrateStatus(cmd *cobra.Command, args []string) error {
+	m, err := openMigrator()
+	if err != nil {
+		return err
+	}
+	sts, err := m.Status(context.Background())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, st := range sts {
+		at := "pending"
+		if st.Applied {
+			at = st.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", st.Version, st.Name, at)
+	}
+	return w.Flush()
+}
+
+func migrateCreate(cmd *cobra.Command, args []string) error {
+	up, down, err := migration.Create(migrationDir, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "created", up)
+	fmt.Fprintln(cmd.OutOrStdout(), "created", down)
+	return nil
+}
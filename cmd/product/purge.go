@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/msyrus/simple-product-inv/metrics"
+	"github.com/msyrus/simple-product-inv/service"
+)
+
+var purgeOlderThan string
+
+// purgeCmd is the purge sub command to permanently remove the deleted products
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "purge permanently removes the products deleted before the retention with their ratings",
+	Args:  cobra.NoArgs,
+	RunE:  purge,
+}
+
+func init() {
+	purgeCmd.Flags().StringVar(&purgeOlderThan, "older-than", "90d", "retention of the deleted products, in days like 90d or a duration like 36h")
+}
+
+// parseRetention parses s as a number of days with the suffix d or as a time.Duration
+func parseRetention(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid retention %q, want a positive number of days like 90d or a duration like 36h", s)
+	}
+	return d, nil
+}
+
+func purge(cmd *cobra.Command, args []string) error {
+	ret, err := parseRetention(purgeOlderThan)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	stg, err := openStorage(cfg, metrics.NewRegistry())
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if stg.pg != nil {
+		if err := checkMigrated(ctx, stg.pg); err != nil {
+			return err
+		}
+	}
+
+	pdtSvc := service.NewProduct(stg.repos.Product, service.NewRating(stg.repos.Rating), service.SetProductUnitOfWork(stg.uow))
+	before := time.Now().Add(-ret)
+	n, err := pdtSvc.Purge(ctx, before)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "purged %d products deleted before %s\n", n, before.Format("2006-01-02 15:04:05"))
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/msyrus/simple-product-inv/metrics"
+	"github.com/msyrus/simple-product-inv/service"
+)
+
+// ratingsCmd is the ratings sub command to maintain the product ratings
+// it doesn't have a Run method as it executes other sub commands
+var ratingsCmd = &cobra.Command{
+	Use:   "ratings",
+	Short: "ratings maintains the product ratings",
+}
+
+var ratingsRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "rebuild recomputes the rating summaries of the products from the ratings",
+	Args:  cobra.NoArgs,
+	RunE:  ratingsRebuild,
+}
+
+func init() {
+	ratingsCmd.AddCommand(ratingsRebuildCmd)
+}
+
+func ratingsRebuild(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	stg, err := openStorage(cfg, metrics.NewRegistry())
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if stg.pg != nil {
+		if err := checkMigrated(ctx, stg.pg); err != nil {
+			return err
+		}
+	}
+
+	ratSvc := service.NewRating(stg.repos.Rating, service.SetRatingUnitOfWork(stg.uow))
+	n, err := ratSvc.Rebuild(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "rebuilt rating summaries of %d products\n", n)
+	return nil
+}
@@ -2,7 +2,8 @@ package main
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,18 +11,18 @@ import (
 	"syscall"
 
 	"github.com/go-chi/chi"
-	_ "github.com/lib/pq"
 	"github.com/spf13/cobra"
 
 	"github.com/msyrus/simple-product-inv/config"
-	"github.com/msyrus/simple-product-inv/infra/pgsql"
 	"github.com/msyrus/simple-product-inv/log"
-	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/metrics"
+	"github.com/msyrus/simple-product-inv/model"
 	"github.com/msyrus/simple-product-inv/service"
 	"github.com/msyrus/simple-product-inv/web"
+	"github.com/msyrus/simple-product-inv/web/middleware"
 )
 
-var cfgPath string
+var requireMigrated bool
 
 // srvCmd is the serve sub command to start the api server
 var srvCmd = &cobra.Command{
@@ -31,19 +32,13 @@ var srvCmd = &cobra.Command{
 }
 
 func init() {
-	srvCmd.PersistentFlags().StringVarP(&cfgPath, "config", "c", "config.yml", "config file path")
-	rootCmd.AddCommand(srvCmd)
+	srvCmd.Flags().BoolVar(&requireMigrated, "require-migrations", false, "refuse to start if the database schema has pending migrations")
 }
 
 func serve(cmd *cobra.Command, args []string) error {
 	lgr := log.DefaultOutputLogger
 	errorLgr := log.DefaultOutputLogger
-	f, err := os.Open(cfgPath)
-	if err != nil {
-		return err
-	}
-
-	cfg, err := config.Parse(f)
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
@@ -53,19 +48,53 @@ func serve(cmd *cobra.Command, args []string) error {
 		addr = addr + ":" + strconv.Itoa(cfg.Port)
 	}
 
-	db, err := sql.Open("postgres", cfg.Postgres.URI)
+	reg := metrics.NewRegistry()
+	stg, err := openStorage(cfg, reg)
 	if err != nil {
 		return err
 	}
 
-	pg := pgsql.NewDB(db, nil)
+	if requireMigrated && stg.pg != nil {
+		if err := checkMigrated(context.Background(), stg.pg); err != nil {
+			return err
+		}
+	}
+
+	ratSvc := service.NewRating(stg.repos.Rating, service.SetRatingUnitOfWork(stg.uow), service.SetRatingBannedWords(cfg.BannedWords))
+	catSvc := service.NewCategory(stg.repos.Category, stg.repos.Product, service.SetCategoryUnitOfWork(stg.uow))
+	vrtSvc := service.NewVariant(stg.repos.Variant, stg.repos.Product, service.SetVariantUnitOfWork(stg.uow))
+	prcSvc := service.NewPrice(stg.repos.Price, stg.repos.Product, service.SetPriceUnitOfWork(stg.uow))
+	prmSvc := service.NewPromotion(stg.repos.Promotion, service.SetPromotionUnitOfWork(stg.uow), service.SetPromotionCategoryService(catSvc))
+	rates, err := exchangeRates(cfg)
+	if err != nil {
+		return err
+	}
+	pdtSvc := service.NewProduct(stg.repos.Product, ratSvc, service.SetProductUnitOfWork(stg.uow), service.SetProductCategoryService(catSvc), service.SetProductVariantService(vrtSvc), service.SetProductPriceService(prcSvc), service.SetProductPromotionService(prmSvc), service.SetProductExchangeRates(rates))
+	stkSvc := service.NewStock(stg.repos.Stock, stg.repos.Product, stg.repos.StockLevel, stg.repos.Location, service.SetStockUnitOfWork(stg.uow))
+	locSvc := service.NewLocation(stg.repos.Location)
+	rsvSvc := service.NewReservation(stg.repos.Reservation, stg.repos.Product, stg.repos.Stock, stg.repos.StockLevel, service.SetReservationUnitOfWork(stg.uow))
+	sysSvc, err := newSystem(cfg, stg)
+	if err != nil {
+		return err
+	}
 
-	ratSvc := service.NewRating(repo.NewCritic("ratings", pg))
-	pdtSvc := service.NewProduct(repo.NewChef("products", pg), ratSvc)
-	sysSvc := service.NewSystem()
+	reg.Register(metrics.NewGaugeFunc("products_active", "Number of products that are not deleted.", func(ctx context.Context) (float64, error) {
+		n, err := stg.repos.Product.Count(ctx)
+		return float64(n), err
+	}))
 
 	r := chi.NewMux()
-	r.Mount("/api/v1", web.NewRouter(web.NewProductController(pdtSvc), web.NewSystemController(sysSvc)))
+	r.Use(middleware.Metrics(reg))
+	r.Use(middleware.Timeout(cfg.RequestTimeout))
+	r.Mount("/api/v1", web.NewRouter(web.NewProductController(pdtSvc), web.NewSystemController(sysSvc, reg), web.NewStockController(stkSvc), web.NewReservationController(rsvSvc), web.NewLocationController(locSvc), web.NewCategoryController(catSvc), web.NewVariantController(vrtSvc), web.NewPriceController(prcSvc), web.NewPromotionController(prmSvc), web.NewReviewController(ratSvc)))
+
+	// baseCtx is the parent of every request context, it is cancelled
+	// when graceful shutdown times out to abort in-flight db queries
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+
+	go rsvSvc.RunReaper(baseCtx, cfg.ReapInterval)
+	go prcSvc.RunScheduler(baseCtx, cfg.PriceInterval)
 
 	srvr := http.Server{
 		Addr:         addr,
@@ -74,6 +103,9 @@ func serve(cmd *cobra.Command, args []string) error {
 		WriteTimeout: cfg.WriteTimeout,
 		ReadTimeout:  cfg.ReadTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
 	}
 
 	go func() {
@@ -92,8 +124,48 @@ func serve(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.GracefulWait)
 	defer cancel()
 
-	srvr.Shutdown(ctx)
+	if err := srvr.Shutdown(ctx); err != nil {
+		lgr.Println(err)
+		cancelBase()
+	}
 
 	lgr.Println("Server shutteddown gracefully")
 	return nil
 }
+
+// exchangeRates returns the validated exchange rates of cfg
+// the base currency is model.DefaultCurrency unless configured
+func exchangeRates(cfg config.Application) (model.ExchangeRates, error) {
+	rates := model.ExchangeRates{
+		Base:  cfg.Currency,
+		Rates: cfg.ExchangeRates,
+	}
+	if rates.Base == "" {
+		rates.Base = model.DefaultCurrency
+	}
+	if err := rates.Validate(); err != nil {
+		return model.ExchangeRates{}, fmt.Errorf("invalid currency config: %v", map[string][]string(err.(model.ValidationError)))
+	}
+	return rates, nil
+}
+
+// newSystem returns the System service checking the dependencies of stg
+// a dead postgres connection or a behind schema makes the system unready,
+// health only reports the connection so that pods are not restarted for it
+func newSystem(cfg config.Application, stg *storage) (*service.System, error) {
+	opts := []service.SystemOpt{
+		service.SetSystemCheckTimeout(cfg.CheckTimeout),
+	}
+	if stg.pg != nil {
+		m, err := newMigrator(stg.pg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts,
+			service.AddReadyCheck("postgres", service.PingChecker(stg.pg), true),
+			service.AddReadyCheck("migrations", service.MigrationChecker(m), true),
+			service.AddHealthCheck("postgres", service.PingChecker(stg.pg), false),
+		)
+	}
+	return service.NewSystem(opts...), nil
+}
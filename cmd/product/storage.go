@@ -0,0 +1,80 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	_ "github.com/lib/pq"
+
+	"github.com/msyrus/simple-product-inv/config"
+	"github.com/msyrus/simple-product-inv/infra/pgsql"
+	"github.com/msyrus/simple-product-inv/metrics"
+	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+)
+
+// loadConfig parses the config file at cfgPath
+func loadConfig() (config.Application, error) {
+	f, err := os.Open(cfgPath)
+	if err != nil {
+		return config.Application{}, err
+	}
+	defer f.Close()
+
+	return config.Parse(f)
+}
+
+// openPostgres returns the postgres DB of cfg
+func openPostgres(cfg config.Application) (*pgsql.DB, error) {
+	db, err := sql.Open("postgres", cfg.Postgres.URI)
+	if err != nil {
+		return nil, err
+	}
+	return pgsql.NewDB(db, nil), nil
+}
+
+// storage holds the repos of the configured storage backend
+type storage struct {
+	repos repo.Repos
+	uow   repo.UnitOfWork
+	// pg is the postgres DB, it is nil for other backends
+	pg *pgsql.DB
+}
+
+// openStorage returns the storage configured in cfg
+// the statements and pool of sql storages are measured into reg
+func openStorage(cfg config.Application, reg *metrics.Registry) (*storage, error) {
+	switch cfg.Storage {
+	case config.StorageMemory:
+		s := memory.NewStore()
+		return &storage{
+			repos: s.Repos(),
+			uow:   s,
+		}, nil
+
+	case config.StoragePostgres:
+		pg, err := openPostgres(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		reg.Register(metrics.NewDBStatsCollector("postgres", pg.Stats))
+		db := metrics.InstrumentDB(pg, reg)
+
+		rps, err := repo.NewSQLRepos(db, repo.DefaultTables)
+		if err != nil {
+			return nil, err
+		}
+		uow, err := repo.NewSQLUnitOfWork(db, repo.DefaultTables)
+		if err != nil {
+			return nil, err
+		}
+		return &storage{
+			repos: rps,
+			uow:   uow,
+			pg:    pg,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown storage %q", cfg.Storage)
+}
@@ -9,35 +9,63 @@ import (
 
 // Application holds application configuration
 type Application struct {
-	GracefulWait time.Duration `yaml:"gracefulWait"`
-	ReadTimeout  time.Duration `yaml:"readTimeout"`
-	WriteTimeout time.Duration `yaml:"writeTimeout"`
-	IdleTimeout  time.Duration `yaml:"idleTimeout"`
-	Host         string        `yaml:"host"`
-	Port         int           `yaml:"port"`
-	Postgres     Postgres      `yaml:"postgres"`
+	GracefulWait   time.Duration      `yaml:"gracefulWait"`
+	ReadTimeout    time.Duration      `yaml:"readTimeout"`
+	WriteTimeout   time.Duration      `yaml:"writeTimeout"`
+	IdleTimeout    time.Duration      `yaml:"idleTimeout"`
+	RequestTimeout time.Duration      `yaml:"requestTimeout"`
+	CheckTimeout   time.Duration      `yaml:"checkTimeout"`
+	ReapInterval   time.Duration      `yaml:"reapInterval"`
+	PriceInterval  time.Duration      `yaml:"priceInterval"`
+	Currency       string             `yaml:"currency"`
+	ExchangeRates  map[string]float64 `yaml:"exchangeRates"`
+	BannedWords    []string           `yaml:"bannedWords"`
+	Host           string             `yaml:"host"`
+	Port           int                `yaml:"port"`
+	Storage        string             `yaml:"storage"`
+	Postgres       Postgres           `yaml:"postgres"`
 }
 
+// Storage backends of Application
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
 // Postgres holds postgres configuration
 type Postgres struct {
 	URI string `yml:"uri"`
 }
 
 // Parse return Application configuration from reader r
-// GracefulWait, ReadTimeout, WriteTimeout, IdleTimeout are read as second
+// GracefulWait, ReadTimeout, WriteTimeout, IdleTimeout, RequestTimeout, CheckTimeout, ReapInterval, PriceInterval are read as second
+// Currency is the ISO 4217 code of the product prices and
+// ExchangeRates maps other currencies to their units per a unit of Currency
+// Storage defaults to StoragePostgres
 func Parse(r io.Reader) (Application, error) {
 	cfg := Application{}
 	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil {
 		return Application{}, err
 	}
 	app := Application{
-		GracefulWait: cfg.GracefulWait * time.Second,
-		ReadTimeout:  cfg.ReadTimeout * time.Second,
-		WriteTimeout: cfg.WriteTimeout * time.Second,
-		IdleTimeout:  cfg.IdleTimeout * time.Second,
-		Host:         cfg.Host,
-		Port:         cfg.Port,
-		Postgres:     cfg.Postgres,
+		GracefulWait:   cfg.GracefulWait * time.Second,
+		ReadTimeout:    cfg.ReadTimeout * time.Second,
+		WriteTimeout:   cfg.WriteTimeout * time.Second,
+		IdleTimeout:    cfg.IdleTimeout * time.Second,
+		RequestTimeout: cfg.RequestTimeout * time.Second,
+		CheckTimeout:   cfg.CheckTimeout * time.Second,
+		ReapInterval:   cfg.ReapInterval * time.Second,
+		PriceInterval:  cfg.PriceInterval * time.Second,
+		Currency:       cfg.Currency,
+		ExchangeRates:  cfg.ExchangeRates,
+		BannedWords:    cfg.BannedWords,
+		Host:           cfg.Host,
+		Port:           cfg.Port,
+		Storage:        cfg.Storage,
+		Postgres:       cfg.Postgres,
+	}
+	if app.Storage == "" {
+		app.Storage = StoragePostgres
 	}
 	return app, nil
 }
@@ -16,6 +16,18 @@ gracefulWait: 10
 readTimeout: 5
 writeTimeout: 0
 idleTimeout: 2
+requestTimeout: 15
+checkTimeout: 3
+reapInterval: 20
+priceInterval: 60
+currency: EUR
+exchangeRates:
+  USD: 1.08
+  GBP: 0.86
+bannedWords:
+  - scam
+  - rip off
+storage: memory
 postgres:
   uri: "postgres://db:5432/test"
 `
@@ -39,7 +51,7 @@ postgres:
 			args: args{
 				r: bytes.NewBufferString("asd: 123"),
 			},
-			want:    Application{},
+			want:    Application{Storage: StoragePostgres},
 			wantErr: false,
 		},
 		{
@@ -47,11 +59,19 @@ postgres:
 				r: bytes.NewBufferString(testYml),
 			},
 			want: Application{
-				GracefulWait: 10 * time.Second,
-				ReadTimeout:  5 * time.Second,
-				IdleTimeout:  2 * time.Second,
-				Host:         "example.com",
-				Port:         500,
+				GracefulWait:   10 * time.Second,
+				ReadTimeout:    5 * time.Second,
+				IdleTimeout:    2 * time.Second,
+				RequestTimeout: 15 * time.Second,
+				CheckTimeout:   3 * time.Second,
+				ReapInterval:   20 * time.Second,
+				PriceInterval:  time.Minute,
+				Currency:       "EUR",
+				ExchangeRates:  map[string]float64{"USD": 1.08, "GBP": 0.86},
+				BannedWords:    []string{"scam", "rip off"},
+				Host:           "example.com",
+				Port:           500,
+				Storage:        StorageMemory,
 				Postgres: Postgres{
 					URI: "postgres://db:5432/test",
 				},
@@ -1,9 +1,22 @@
 package infra
 
+import "context"
+
 // DB represents the DB infrastructure
+// the ctx bounds the lifetime of the statement execution
 type DB interface {
-	Exec(stmt string, args ...interface{}) error
-	Query(stmt string, args ...interface{}) (Row, error)
+	Exec(ctx context.Context, stmt string, args ...interface{}) error
+	Query(ctx context.Context, stmt string, args ...interface{}) (Row, error)
+}
+
+// UniqueViolationError is returned by a DB when a statement
+// violates the unique constraint or index named Constraint
+type UniqueViolationError struct {
+	Constraint string
+}
+
+func (e UniqueViolationError) Error() string {
+	return "infra: unique violation of " + e.Constraint
 }
 
 // Row represents a data row of DB
@@ -12,3 +25,17 @@ type Row interface {
 	Next() bool
 	Close() error
 }
+
+// Tx represents a DB transaction
+// statements executed through Tx are applied only after Commit
+type Tx interface {
+	DB
+	Commit() error
+	Rollback() error
+}
+
+// TxDB represents a DB that supports transactions
+type TxDB interface {
+	DB
+	Begin(ctx context.Context) (Tx, error)
+}
@@ -0,0 +1,17 @@
+package infra
+
+import "context"
+
+type operationKey struct{}
+
+// WithOperation returns a copy of ctx naming the operation
+// that the statements executed with it belong to, like product.fetch
+func WithOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// Operation returns the operation named in ctx, empty if there is none
+func Operation(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(string)
+	return op
+}
@@ -1,8 +1,11 @@
 package pgsql
 
 import (
+	"context"
 	"database/sql"
 
+	"github.com/lib/pq"
+
 	"github.com/msyrus/simple-product-inv/infra"
 	"github.com/msyrus/simple-product-inv/log"
 )
@@ -28,19 +31,78 @@ func (d *DB) println(stmt string, args ...interface{}) {
 }
 
 // Exec executes a sql command
-func (d *DB) Exec(stmt string, args ...interface{}) error {
+// the command is cancelled when ctx is done
+func (d *DB) Exec(ctx context.Context, stmt string, args ...interface{}) error {
 	d.println(stmt, args...)
-	_, err := d.conn.Exec(stmt, args...)
-	return err
+	_, err := d.conn.ExecContext(ctx, stmt, args...)
+	return translate(err)
 }
 
 // Query executes a db query and return row
-func (d *DB) Query(stmt string, args ...interface{}) (infra.Row, error) {
+// the query is cancelled when ctx is done
+func (d *DB) Query(ctx context.Context, stmt string, args ...interface{}) (infra.Row, error) {
 	d.println(stmt, args...)
-	rows, err := d.conn.Query(stmt, args...)
+	rows, err := d.conn.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, translate(err)
+	}
+
+	r := &Row{
+		rows: rows,
+	}
+	return r, nil
+}
+
+// Ping verifies the connection to the database is alive
+func (d *DB) Ping(ctx context.Context) error {
+	return d.conn.PingContext(ctx)
+}
+
+// Stats returns the connection pool stats of the database
+func (d *DB) Stats() sql.DBStats {
+	return d.conn.Stats()
+}
+
+// Begin starts a new transaction
+// the transaction is rolled back if ctx is done before Commit
+func (d *DB) Begin(ctx context.Context) (infra.Tx, error) {
+	tx, err := d.conn.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
+	return &Tx{
+		tx:  tx,
+		lgr: d.lgr,
+	}, nil
+}
+
+// Tx is the postgres transaction
+// it is an implementation of infra.Tx
+type Tx struct {
+	tx  *sql.Tx
+	lgr log.Logger
+}
+
+func (t *Tx) println(stmt string, args ...interface{}) {
+	if t.lgr != nil {
+		t.lgr.Println(args...)
+	}
+}
+
+// Exec executes a sql command within the transaction
+func (t *Tx) Exec(ctx context.Context, stmt string, args ...interface{}) error {
+	t.println(stmt, args...)
+	_, err := t.tx.ExecContext(ctx, stmt, args...)
+	return translate(err)
+}
+
+// Query executes a db query within the transaction and return row
+func (t *Tx) Query(ctx context.Context, stmt string, args ...interface{}) (infra.Row, error) {
+	t.println(stmt, args...)
+	rows, err := t.tx.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, translate(err)
+	}
 
 	r := &Row{
 		rows: rows,
@@ -48,6 +110,16 @@ func (d *DB) Query(stmt string, args ...interface{}) (infra.Row, error) {
 	return r, nil
 }
 
+// Commit commits the transaction
+func (t *Tx) Commit() error {
+	return t.tx.Commit()
+}
+
+// Rollback aborts the transaction
+func (t *Tx) Rollback() error {
+	return t.tx.Rollback()
+}
+
 // Row is an implementation of infra.Row
 // it holds postgres query result rows
 type Row struct {
@@ -68,3 +140,14 @@ func (r *Row) Next() bool {
 func (r *Row) Close() error {
 	return r.rows.Close()
 }
+
+// uniqueViolation is the postgres error code of unique_violation
+const uniqueViolation = "23505"
+
+// translate converts the postgres errors the repos act on into infra errors
+func translate(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return infra.UniqueViolationError{Constraint: pqErr.Constraint}
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+package pgsql
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/msyrus/simple-product-inv/infra"
+)
+
+func Test_translate(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{err: nil, want: nil},
+		{err: sql.ErrConnDone, want: sql.ErrConnDone},
+		{err: &pq.Error{Code: "23503", Constraint: "fk"}, want: &pq.Error{Code: "23503", Constraint: "fk"}},
+		{err: &pq.Error{Code: "23505", Constraint: "products_sku_key"}, want: infra.UniqueViolationError{Constraint: "products_sku_key"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := translate(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("translate() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
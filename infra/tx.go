@@ -0,0 +1,26 @@
+package infra
+
+import "context"
+
+// WithTx begins a transaction on db and calls fn with it
+// the transaction is committed if fn returns nil and rolled back
+// if fn returns an error or panics, a panic is re-raised after rollback
+func WithTx(ctx context.Context, db TxDB, fn func(tx Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
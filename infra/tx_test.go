@@ -0,0 +1,89 @@
+package infra_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/mock_infra"
+)
+
+func TestWithTx(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db := mock_infra.NewMockTxDB(mockCtrl)
+	tx := mock_infra.NewMockTx(mockCtrl)
+
+	errFn := errors.New("fn failed")
+	errBegin := errors.New("begin failed")
+
+	gomock.InOrder(
+		db.EXPECT().Begin(gomock.Any()).Return(nil, errBegin),
+		db.EXPECT().Begin(gomock.Any()).Return(tx, nil),
+		tx.EXPECT().Commit().Return(nil),
+		db.EXPECT().Begin(gomock.Any()).Return(tx, nil),
+		tx.EXPECT().Rollback().Return(nil),
+	)
+
+	tests := []struct {
+		name    string
+		fn      func(tx infra.Tx) error
+		wantErr error
+	}{
+		{
+			fn: func(infra.Tx) error {
+				t.Error("fn called without transaction")
+				return nil
+			},
+			wantErr: errBegin,
+		},
+		{
+			fn: func(got infra.Tx) error {
+				if got != tx {
+					t.Errorf("WithTx() tx = %v, want %v", got, tx)
+				}
+				return nil
+			},
+			wantErr: nil,
+		},
+		{
+			fn: func(infra.Tx) error {
+				return errFn
+			},
+			wantErr: errFn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := infra.WithTx(context.Background(), db, tt.fn); err != tt.wantErr {
+				t.Errorf("WithTx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithTx_Panic(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db := mock_infra.NewMockTxDB(mockCtrl)
+	tx := mock_infra.NewMockTx(mockCtrl)
+
+	gomock.InOrder(
+		db.EXPECT().Begin(gomock.Any()).Return(tx, nil),
+		tx.EXPECT().Rollback().Return(nil),
+	)
+
+	defer func() {
+		if p := recover(); p != "fn panicked" {
+			t.Errorf("WithTx() panic = %v, want %v", p, "fn panicked")
+		}
+	}()
+
+	infra.WithTx(context.Background(), db, func(infra.Tx) error {
+		panic("fn panicked")
+	})
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+)
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	vals   map[string][]string
+	counts map[string]float64
+}
+
+// NewCounterVec returns a new CounterVec with label names labels
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		vals:   map[string][]string{},
+		counts: map[string]float64{},
+	}
+}
+
+// Inc increments the counter of label values vals by one
+func (c *CounterVec) Inc(vals ...string) {
+	c.Add(1, vals...)
+}
+
+// Add adds v to the counter of label values vals
+// it panics if v is negative or vals do not match the labels
+func (c *CounterVec) Add(v float64, vals ...string) {
+	if v < 0 {
+		panic("metrics: counter decreased")
+	}
+	checkLabels(c.labels, vals)
+	k := labelKey(vals)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.vals[k]; !ok {
+		c.vals[k] = append([]string{}, vals...)
+	}
+	c.counts[k] += v
+}
+
+// Collect returns the counter family
+func (c *CounterVec) Collect(context.Context) []Family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := Family{Name: c.name, Help: c.help, Type: TypeCounter}
+	for _, k := range sortedKeys(c.vals) {
+		f.Samples = append(f.Samples, Sample{
+			Labels: labels(c.labels, c.vals[k]),
+			Value:  c.counts[k],
+		})
+	}
+	return []Family{f}
+}
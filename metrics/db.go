@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/infra"
+)
+
+// unknownOperation labels statements whose ctx names no operation
+const unknownOperation = "unknown"
+
+// DB is an infra.TxDB that measures the statements executed through it
+// statements are labelled by the operation named with infra.WithOperation
+type DB struct {
+	db       infra.TxDB
+	duration *HistogramVec
+	errors   *CounterVec
+}
+
+// InstrumentDB returns db measured into reg
+func InstrumentDB(db infra.TxDB, reg *Registry) *DB {
+	d := &DB{
+		db:       db,
+		duration: NewHistogramVec("db_query_duration_seconds", "Duration of database statements by repo operation.", nil, "operation"),
+		errors:   NewCounterVec("db_query_errors_total", "Failed database statements by repo operation.", "operation"),
+	}
+	reg.Register(d.duration, d.errors)
+	return d
+}
+
+func (d *DB) observe(ctx context.Context, start time.Time, err error) {
+	op := infra.Operation(ctx)
+	if op == "" {
+		op = unknownOperation
+	}
+	d.duration.Observe(time.Since(start).Seconds(), op)
+	if err != nil {
+		d.errors.Inc(op)
+	}
+}
+
+// Exec executes stmt on the underlying db
+func (d *DB) Exec(ctx context.Context, stmt string, args ...interface{}) error {
+	start := time.Now()
+	err := d.db.Exec(ctx, stmt, args...)
+	d.observe(ctx, start, err)
+	return err
+}
+
+// Query executes stmt on the underlying db
+func (d *DB) Query(ctx context.Context, stmt string, args ...interface{}) (infra.Row, error) {
+	start := time.Now()
+	row, err := d.db.Query(ctx, stmt, args...)
+	d.observe(ctx, start, err)
+	return row, err
+}
+
+// Begin starts a transaction whose statements are measured as well
+func (d *DB) Begin(ctx context.Context) (infra.Tx, error) {
+	tx, err := d.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, d: d}, nil
+}
+
+// Tx is an infra.Tx measured by DB
+type Tx struct {
+	infra.Tx
+	d *DB
+}
+
+// Exec executes stmt within the transaction
+func (t *Tx) Exec(ctx context.Context, stmt string, args ...interface{}) error {
+	start := time.Now()
+	err := t.Tx.Exec(ctx, stmt, args...)
+	t.d.observe(ctx, start, err)
+	return err
+}
+
+// Query executes stmt within the transaction
+func (t *Tx) Query(ctx context.Context, stmt string, args ...interface{}) (infra.Row, error) {
+	start := time.Now()
+	row, err := t.Tx.Query(ctx, stmt, args...)
+	t.d.observe(ctx, start, err)
+	return row, err
+}
+
+// DBStatsCollector collects the connection pool stats of a database/sql pool
+type DBStatsCollector struct {
+	db    string
+	stats func() sql.DBStats
+}
+
+// NewDBStatsCollector returns a new DBStatsCollector of stats
+// the samples are labelled with db
+func NewDBStatsCollector(db string, stats func() sql.DBStats) *DBStatsCollector {
+	return &DBStatsCollector{
+		db:    db,
+		stats: stats,
+	}
+}
+
+// Collect returns the pool stat families
+func (c *DBStatsCollector) Collect(context.Context) []Family {
+	st := c.stats()
+	ls := []Label{{Name: "db", Value: c.db}}
+	fam := func(name, help, typ string, v float64) Family {
+		return Family{Name: name, Help: help, Type: typ, Samples: []Sample{{Labels: ls, Value: v}}}
+	}
+	return []Family{
+		fam("db_max_open_connections", "Maximum number of open connections to the database.", TypeGauge, float64(st.MaxOpenConnections)),
+		fam("db_open_connections", "Number of established connections both in use and idle.", TypeGauge, float64(st.OpenConnections)),
+		fam("db_in_use_connections", "Number of connections currently in use.", TypeGauge, float64(st.InUse)),
+		fam("db_idle_connections", "Number of idle connections.", TypeGauge, float64(st.Idle)),
+		fam("db_wait_count_total", "Total number of connections waited for.", TypeCounter, float64(st.WaitCount)),
+		fam("db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", TypeCounter, st.WaitDuration.Seconds()),
+		fam("db_max_idle_closed_total", "Total number of connections closed due to SetMaxIdleConns.", TypeCounter, float64(st.MaxIdleClosed)),
+		fam("db_max_lifetime_closed_total", "Total number of connections closed due to SetConnMaxLifetime.", TypeCounter, float64(st.MaxLifetimeClosed)),
+	}
+}
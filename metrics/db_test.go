@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/mock_infra"
+)
+
+// sampleValue returns the value of the sample of fams with suffix and labels
+func sampleValue(fams []Family, name, suffix string, ls ...Label) (float64, bool) {
+	for _, f := range fams {
+		if f.Name != name {
+			continue
+		}
+	next:
+		for _, s := range f.Samples {
+			if s.Suffix != suffix || len(s.Labels) != len(ls) {
+				continue
+			}
+			for i := range ls {
+				if s.Labels[i] != ls[i] {
+					continue next
+				}
+			}
+			return s.Value, true
+		}
+	}
+	return 0, false
+}
+
+func TestInstrumentDB(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db := mock_infra.NewMockTxDB(mockCtrl)
+	tx := mock_infra.NewMockTx(mockCtrl)
+	errExec := errors.New("exec failed")
+
+	gomock.InOrder(
+		db.EXPECT().Exec(gomock.Any(), "INSERT").Return(nil),
+		db.EXPECT().Query(gomock.Any(), "SELECT").Return(nil, errExec),
+		db.EXPECT().Exec(gomock.Any(), "UPDATE").Return(nil),
+		db.EXPECT().Begin(gomock.Any()).Return(tx, nil),
+		tx.EXPECT().Exec(gomock.Any(), "DELETE").Return(errExec),
+		tx.EXPECT().Rollback().Return(nil),
+	)
+
+	reg := NewRegistry()
+	idb := InstrumentDB(db, reg)
+	ctx := infra.WithOperation(context.Background(), "product.create")
+
+	idb.Exec(ctx, "INSERT")
+	idb.Query(ctx, "SELECT")
+	idb.Exec(context.Background(), "UPDATE")
+	itx, err := idb.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	itx.Exec(infra.WithOperation(ctx, "product.delete"), "DELETE")
+	itx.Rollback()
+
+	fams := reg.Gather(context.Background())
+	tests := []struct {
+		name   string
+		family string
+		suffix string
+		op     string
+		want   float64
+	}{
+		{
+			family: "db_query_duration_seconds",
+			suffix: "_count",
+			op:     "product.create",
+			want:   2,
+		},
+		{
+			family: "db_query_errors_total",
+			op:     "product.create",
+			want:   1,
+		},
+		{
+			family: "db_query_duration_seconds",
+			suffix: "_count",
+			op:     unknownOperation,
+			want:   1,
+		},
+		{
+			family: "db_query_errors_total",
+			op:     "product.delete",
+			want:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.family+tt.suffix+"/"+tt.op, func(t *testing.T) {
+			got, ok := sampleValue(fams, tt.family, tt.suffix, Label{"operation", tt.op})
+			if !ok || got != tt.want {
+				t.Errorf("%s%s{operation=%q} = %v, want %v", tt.family, tt.suffix, tt.op, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDBStatsCollector(t *testing.T) {
+	c := NewDBStatsCollector("postgres", func() sql.DBStats {
+		return sql.DBStats{OpenConnections: 4, InUse: 3, Idle: 1}
+	})
+	fams := c.Collect(context.Background())
+
+	tests := []struct {
+		name string
+		want float64
+	}{
+		{name: "db_open_connections", want: 4},
+		{name: "db_in_use_connections", want: 3},
+		{name: "db_idle_connections", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sampleValue(fams, tt.name, "", Label{"db", "postgres"})
+			if !ok || got != tt.want {
+				t.Errorf("DBStatsCollector %s = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"context"
+)
+
+// GaugeFunc is a gauge whose value is read on every collection
+type GaugeFunc struct {
+	name string
+	help string
+	fn   func(ctx context.Context) (float64, error)
+}
+
+// NewGaugeFunc returns a new GaugeFunc reading its value from fn
+// the gauge has no sample if fn fails
+func NewGaugeFunc(name, help string, fn func(ctx context.Context) (float64, error)) *GaugeFunc {
+	return &GaugeFunc{
+		name: name,
+		help: help,
+		fn:   fn,
+	}
+}
+
+// Collect returns the gauge family
+func (g *GaugeFunc) Collect(ctx context.Context) []Family {
+	f := Family{Name: g.name, Help: g.help, Type: TypeGauge}
+	if v, err := g.fn(ctx); err == nil {
+		f.Samples = []Sample{{Value: v}}
+	}
+	return []Family{f}
+}
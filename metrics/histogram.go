@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+)
+
+// DefaultBuckets are the latency buckets in seconds used when none are given
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	vals   map[string][]string
+	series map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec returns a new HistogramVec with label names labels
+// buckets are the upper bounds of the buckets, DefaultBuckets if empty
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	bs := append([]float64{}, buckets...)
+	sort.Float64s(bs)
+	return &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: bs,
+		vals:    map[string][]string{},
+		series:  map[string]*histogram{},
+	}
+}
+
+// Observe adds v to the histogram of label values vals
+func (h *HistogramVec) Observe(v float64, vals ...string) {
+	checkLabels(h.labels, vals)
+	k := labelKey(vals)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[k] = s
+		h.vals[k] = append([]string{}, vals...)
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+// Collect returns the histogram family with cumulative buckets
+func (h *HistogramVec) Collect(context.Context) []Family {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f := Family{Name: h.name, Help: h.help, Type: TypeHistogram}
+	for _, k := range sortedKeys(h.vals) {
+		s := h.series[k]
+		ls := labels(h.labels, h.vals[k])
+
+		cum := uint64(0)
+		for i, ub := range h.buckets {
+			cum += s.counts[i]
+			f.Samples = append(f.Samples, Sample{
+				Suffix: "_bucket",
+				Labels: append(append([]Label{}, ls...), Label{Name: "le", Value: formatFloat(ub)}),
+				Value:  float64(cum),
+			})
+		}
+		f.Samples = append(f.Samples,
+			Sample{
+				Suffix: "_bucket",
+				Labels: append(append([]Label{}, ls...), Label{Name: "le", Value: formatFloat(math.Inf(1))}),
+				Value:  float64(s.count),
+			},
+			Sample{Suffix: "_sum", Labels: ls, Value: s.sum},
+			Sample{Suffix: "_count", Labels: ls, Value: float64(s.count)},
+		)
+	}
+	return []Family{f}
+}
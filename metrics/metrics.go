@@ -0,0 +1,162 @@
+// Package metrics collects application metrics and exposes them
+// in the Prometheus text exposition format
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types of a Family
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Label is a metric label pair
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a single value of a Family
+// Suffix is appended to the family name, like _bucket of histograms
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family is a named group of samples of the same metric
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Collector collects metric families
+// ctx is the context of the scrape
+type Collector interface {
+	Collect(ctx context.Context) []Family
+}
+
+// Registry holds the collectors exposed together
+type Registry struct {
+	mu   sync.RWMutex
+	cols []Collector
+}
+
+// NewRegistry returns a new empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds cs to r
+func (r *Registry) Register(cs ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cols = append(r.cols, cs...)
+}
+
+// Gather collects the families of all collectors sorted by name
+func (r *Registry) Gather(ctx context.Context) []Family {
+	r.mu.RLock()
+	cols := append([]Collector{}, r.cols...)
+	r.mu.RUnlock()
+
+	fams := []Family{}
+	for _, c := range cols {
+		fams = append(fams, c.Collect(ctx)...)
+	}
+	sort.SliceStable(fams, func(i, j int) bool {
+		return fams[i].Name < fams[j].Name
+	})
+	return fams
+}
+
+// ServeHTTP serves the gathered families in text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	WriteText(w, r.Gather(req.Context()))
+}
+
+// WriteText writes fams into w in the Prometheus text format
+func WriteText(w io.Writer, fams []Family) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range fams {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.Name, helpReplacer.Replace(f.Help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.Name, f.Type)
+		for _, s := range f.Samples {
+			bw.WriteString(f.Name + s.Suffix)
+			if len(s.Labels) != 0 {
+				bw.WriteByte('{')
+				for i, l := range s.Labels {
+					if i != 0 {
+						bw.WriteByte(',')
+					}
+					bw.WriteString(l.Name + `="` + labelReplacer.Replace(l.Value) + `"`)
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteString(" " + formatFloat(s.Value) + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// checkLabels panics if vals do not match the label names
+func checkLabels(names, vals []string) {
+	if len(names) != len(vals) {
+		panic(fmt.Errorf("metrics: %d label values for %d labels", len(vals), len(names)))
+	}
+}
+
+// labels pairs names with vals
+func labels(names, vals []string) []Label {
+	ls := make([]Label, len(names))
+	for i := range names {
+		ls[i] = Label{Name: names[i], Value: vals[i]}
+	}
+	return ls
+}
+
+// labelKey joins vals into a map key
+func labelKey(vals []string) string {
+	return strings.Join(vals, "\xff")
+}
+
+// sortedKeys returns the keys of a series map in order
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	tests := []struct {
+		name string
+		fams []Family
+		want string
+	}{
+		{
+			name: "empty",
+			fams: []Family{},
+			want: "",
+		},
+		{
+			name: "unlabelled",
+			fams: []Family{
+				{Name: "up", Help: "Up.", Type: TypeGauge, Samples: []Sample{{Value: 1}}},
+			},
+			want: "# HELP up Up.\n# TYPE up gauge\nup 1\n",
+		},
+		{
+			name: "escaped",
+			fams: []Family{
+				{Name: "m", Help: "a\\b\nc", Type: TypeCounter, Samples: []Sample{
+					{Labels: []Label{{"a", `"x"`}, {"b", "y\\z\n"}}, Value: 0.5},
+					{Suffix: "_sum", Value: math.Inf(1)},
+				}},
+			},
+			want: "# HELP m a\\\\b\\nc\n# TYPE m counter\n" +
+				`m{a="\"x\"",b="y\\z\n"} 0.5` + "\n" +
+				"m_sum +Inf\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := WriteText(buf, tt.fams); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("requests_total", "Requests.", "code")
+	c.Inc("200")
+	c.Add(2, "500")
+	c.Inc("200")
+
+	buf := &bytes.Buffer{}
+	WriteText(buf, c.Collect(context.Background()))
+	want := "# HELP requests_total Requests.\n# TYPE requests_total counter\n" +
+		"requests_total{code=\"200\"} 2\n" +
+		"requests_total{code=\"500\"} 2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CounterVec.Collect() = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{
+			name: "negative",
+			fn:   func() { c.Add(-1, "200") },
+		},
+		{
+			name: "label mismatch",
+			fn:   func() { c.Inc("200", "GET") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("CounterVec did not panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("latency_seconds", "Latency.", []float64{1, 0.1}, "op")
+	h.Observe(0.05, "fetch")
+	h.Observe(0.1, "fetch")
+	h.Observe(0.5, "fetch")
+	h.Observe(3, "fetch")
+
+	buf := &bytes.Buffer{}
+	WriteText(buf, h.Collect(context.Background()))
+	want := "# HELP latency_seconds Latency.\n# TYPE latency_seconds histogram\n" +
+		"latency_seconds_bucket{op=\"fetch\",le=\"0.1\"} 2\n" +
+		"latency_seconds_bucket{op=\"fetch\",le=\"1\"} 3\n" +
+		"latency_seconds_bucket{op=\"fetch\",le=\"+Inf\"} 4\n" +
+		"latency_seconds_sum{op=\"fetch\"} 3.65\n" +
+		"latency_seconds_count{op=\"fetch\"} 4\n"
+	if got := buf.String(); got != want {
+		t.Errorf("HistogramVec.Collect() = %q, want %q", got, want)
+	}
+}
+
+func TestGaugeFunc(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(context.Context) (float64, error)
+		want int
+	}{
+		{
+			name: "ok",
+			fn:   func(context.Context) (float64, error) { return 7, nil },
+			want: 1,
+		},
+		{
+			name: "failing",
+			fn:   func(context.Context) (float64, error) { return 0, errors.New("failed") },
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fams := NewGaugeFunc("g", "G.", tt.fn).Collect(context.Background())
+			if got := len(fams[0].Samples); got != tt.want {
+				t.Errorf("GaugeFunc.Collect() samples = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(
+		NewGaugeFunc("b_gauge", "B.", func(context.Context) (float64, error) { return 1, nil }),
+		NewGaugeFunc("a_gauge", "A.", func(context.Context) (float64, error) { return 2, nil }),
+	)
+
+	rr := httptest.NewRecorder()
+	reg.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+	if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Registry.ServeHTTP() Content-Type = %v", got)
+	}
+	body := rr.Body.String()
+	if strings.Index(body, "a_gauge 2") > strings.Index(body, "b_gauge 1") {
+		t.Errorf("Registry.ServeHTTP() families not sorted: %q", body)
+	}
+}
@@ -0,0 +1,43 @@
+package migration
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var nameRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes a new pair of empty up and down migration files into dir
+// versioned after the latest migration in dir and returns their paths
+func Create(dir, name string) (string, string, error) {
+	name = strings.Trim(nameRegexp.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", ErrInvalidFileName
+	}
+
+	mgrs, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	ver := 1
+	if len(mgrs) != 0 {
+		ver = mgrs[len(mgrs)-1].Version + 1
+	}
+
+	base := fmt.Sprintf("%04d_%s", ver, name)
+	up := filepath.Join(dir, base+".up.sql")
+	down := filepath.Join(dir, base+".down.sql")
+
+	if err := ioutil.WriteFile(up, []byte("-- "+base+" up\n"), 0644); err != nil {
+		return "", "", err
+	}
+	if err := ioutil.WriteFile(down, []byte("-- "+base+" down\n"), 0644); err != nil {
+		os.Remove(up)
+		return "", "", err
+	}
+	return up, down, nil
+}
@@ -0,0 +1,71 @@
+package migration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		arg      string
+		wantUp   string
+		wantDown string
+		wantErr  bool
+	}{
+		{
+			name:     "first",
+			arg:      "init",
+			wantUp:   "0001_init.up.sql",
+			wantDown: "0001_init.down.sql",
+		},
+		{
+			name:     "next",
+			arg:      "Add Stock Movements",
+			wantUp:   "0002_add_stock_movements.up.sql",
+			wantDown: "0002_add_stock_movements.down.sql",
+		},
+		{
+			name:    "empty",
+			arg:     "--",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, down, err := Create(dir, tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if up != filepath.Join(dir, tt.wantUp) {
+				t.Errorf("Create() up = %v, want %v", up, tt.wantUp)
+			}
+			if down != filepath.Join(dir, tt.wantDown) {
+				t.Errorf("Create() down = %v, want %v", down, tt.wantDown)
+			}
+			for _, f := range []string{up, down} {
+				if _, err := os.Stat(f); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 {
+		t.Errorf("Create() wrote %d files, want 4", len(files))
+	}
+	if _, err := Load(os.DirFS(dir)); err != nil {
+		t.Errorf("Load() created files error = %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package migration keeps the database schema versioned
+// migrations are sql files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql, the ones under sql/ are embedded in the binary
+package migration
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+// ErrInvalidFileName is returned when a migration file name is not well formed
+var ErrInvalidFileName = errors.New("migration: invalid file name")
+
+// ErrDuplicateVersion is returned when two migrations share a version
+var ErrDuplicateVersion = errors.New("migration: duplicate version")
+
+// ErrMissingUp is returned when a migration has no up sql
+var ErrMissingUp = errors.New("migration: missing up sql")
+
+// ErrIrreversible is returned when reverting a migration without down sql
+var ErrIrreversible = errors.New("migration: irreversible migration")
+
+// ErrUnknownVersion is returned when the database is at a version
+// that is not known to the binary
+var ErrUnknownVersion = errors.New("migration: unknown version")
+
+// Migration holds a single schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status holds a migration and when it was applied
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+var fileRegexp = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Embedded returns the migrations embedded in the binary
+func Embedded() ([]Migration, error) {
+	sub, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// Load reads the migrations from the root of fsys sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVer := map[int]*Migration{}
+	for _, f := range files {
+		if f.IsDir() || path.Ext(f.Name()) != ".sql" {
+			continue
+		}
+		m := fileRegexp.FindStringSubmatch(f.Name())
+		if m == nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFileName, f.Name())
+		}
+		ver, err := strconv.Atoi(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFileName, f.Name())
+		}
+		b, err := fs.ReadFile(fsys, f.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mgr, ok := byVer[ver]
+		if !ok {
+			mgr = &Migration{Version: ver, Name: m[2]}
+			byVer[ver] = mgr
+		}
+		if mgr.Name != m[2] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, ver)
+		}
+		if m[3] == "up" {
+			mgr.Up = string(b)
+		} else {
+			mgr.Down = string(b)
+		}
+	}
+
+	mgrs := []Migration{}
+	for _, m := range byVer {
+		if m.Up == "" {
+			return nil, fmt.Errorf("%w: %d_%s", ErrMissingUp, m.Version, m.Name)
+		}
+		mgrs = append(mgrs, *m)
+	}
+	sort.Slice(mgrs, func(i, j int) bool {
+		return mgrs[i].Version < mgrs[j].Version
+	})
+	return mgrs, nil
+}
@@ -0,0 +1,95 @@
+package migration
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []Migration
+		wantErr error
+	}{
+		{
+			name: "sorted",
+			fsys: fstest.MapFS{
+				"0002_tags.up.sql":     {Data: []byte("up 2")},
+				"0001_init.up.sql":     {Data: []byte("up 1")},
+				"0001_init.down.sql":   {Data: []byte("down 1")},
+				"README.md":            {Data: []byte("ignored")},
+				"0010_prices.up.sql":   {Data: []byte("up 10")},
+				"0002_tags.down.sql":   {Data: []byte("down 2")},
+				"0010_prices.down.sql": {Data: []byte("down 10")},
+			},
+			want: []Migration{
+				{Version: 1, Name: "init", Up: "up 1", Down: "down 1"},
+				{Version: 2, Name: "tags", Up: "up 2", Down: "down 2"},
+				{Version: 10, Name: "prices", Up: "up 10", Down: "down 10"},
+			},
+		},
+		{
+			name: "without down",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql": {Data: []byte("up 1")},
+			},
+			want: []Migration{
+				{Version: 1, Name: "init", Up: "up 1"},
+			},
+		},
+		{
+			name: "invalid name",
+			fsys: fstest.MapFS{
+				"init.up.sql": {Data: []byte("up")},
+			},
+			wantErr: ErrInvalidFileName,
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql":  {Data: []byte("up")},
+				"0001_other.up.sql": {Data: []byte("up")},
+			},
+			wantErr: ErrDuplicateVersion,
+		},
+		{
+			name: "missing up",
+			fsys: fstest.MapFS{
+				"0001_init.down.sql": {Data: []byte("down")},
+			},
+			wantErr: ErrMissingUp,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.fsys)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	mgrs, err := Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mgrs) == 0 || mgrs[0].Version != 1 {
+		t.Fatalf("Embedded() = %v, want migrations starting at version 1", mgrs)
+	}
+	for i, m := range mgrs {
+		if m.Version != i+1 {
+			t.Errorf("Embedded() version %d at %d, want contiguous versions", m.Version, i)
+		}
+		if m.Down == "" {
+			t.Errorf("Embedded() %d_%s has no down migration", m.Version, m.Name)
+		}
+	}
+}
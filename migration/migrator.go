@@ -0,0 +1,192 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/log"
+)
+
+// lockKey is the postgres advisory lock key held while migrating
+// so that concurrently starting instances do not race each other
+const lockKey = 726712018
+
+// Table is the name of the table that records the applied migrations
+const Table = "schema_migrations"
+
+// Migrator applies and reverts migrations on a database
+type Migrator struct {
+	db   infra.TxDB
+	mgrs []Migration
+	lgr  log.Logger
+}
+
+// NewMigrator returns a new Migrator of mgrs on db
+// mgrs must be sorted by version as returned by Load
+func NewMigrator(db infra.TxDB, mgrs []Migration, lgr log.Logger) *Migrator {
+	return &Migrator{
+		db:   db,
+		mgrs: mgrs,
+		lgr:  lgr,
+	}
+}
+
+func (m *Migrator) println(v ...interface{}) {
+	if m.lgr != nil {
+		m.lgr.Println(v...)
+	}
+}
+
+// Up applies all pending migrations in order and returns the applied ones
+// every migration runs in its own transaction holding the advisory lock
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	done := []Migration{}
+	for {
+		var next *Migration
+		err := m.locked(ctx, func(tx infra.Tx, applied map[int]time.Time) error {
+			for _, mgr := range m.mgrs {
+				if _, ok := applied[mgr.Version]; !ok {
+					n := mgr
+					next = &n
+					break
+				}
+			}
+			if next == nil {
+				return nil
+			}
+
+			m.println("applying migration", next.Version, next.Name)
+			if err := tx.Exec(ctx, next.Up); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", next.Version, next.Name, err)
+			}
+			return tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %s ("version", "name") VALUES($1, $2)`, Table), next.Version, next.Name)
+		})
+		if err != nil {
+			return done, err
+		}
+		if next == nil {
+			return done, nil
+		}
+		done = append(done, *next)
+	}
+}
+
+// Down reverts the latest applied migration and returns it
+// it returns nil if there is no applied migration
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var last *Migration
+	err := m.locked(ctx, func(tx infra.Tx, applied map[int]time.Time) error {
+		ver := 0
+		for v := range applied {
+			if v > ver {
+				ver = v
+			}
+		}
+		if ver == 0 {
+			return nil
+		}
+		for _, mgr := range m.mgrs {
+			if mgr.Version == ver {
+				l := mgr
+				last = &l
+				break
+			}
+		}
+		if last == nil {
+			return fmt.Errorf("%w: %d", ErrUnknownVersion, ver)
+		}
+		if last.Down == "" {
+			return fmt.Errorf("%w: %d_%s", ErrIrreversible, last.Version, last.Name)
+		}
+
+		m.println("reverting migration", last.Version, last.Name)
+		if err := tx.Exec(ctx, last.Down); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", last.Version, last.Name, err)
+		}
+		return tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE "version"=$1`, Table), last.Version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return last, nil
+}
+
+// Status returns the known migrations with their applied state
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	sts := []Status{}
+	for _, mgr := range m.mgrs {
+		at, ok := applied[mgr.Version]
+		sts = append(sts, Status{
+			Migration: mgr,
+			Applied:   ok,
+			AppliedAt: at,
+		})
+	}
+	return sts, nil
+}
+
+// Pending returns the migrations that are not applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	sts, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mgrs := []Migration{}
+	for _, st := range sts {
+		if !st.Applied {
+			mgrs = append(mgrs, st.Migration)
+		}
+	}
+	return mgrs, nil
+}
+
+// locked calls fn in a transaction that holds the migration lock
+// with the versions applied at the time the lock is acquired
+func (m *Migrator) locked(ctx context.Context, fn func(tx infra.Tx, applied map[int]time.Time) error) error {
+	return infra.WithTx(ctx, m.db, func(tx infra.Tx) error {
+		if err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, lockKey); err != nil {
+			return err
+		}
+		applied, err := m.applied(ctx, tx)
+		if err != nil {
+			return err
+		}
+		return fn(tx, applied)
+	})
+}
+
+// applied returns the applied versions with their apply time
+// it creates the migration table if it does not exist
+func (m *Migrator) applied(ctx context.Context, db infra.DB) (map[int]time.Time, error) {
+	err := db.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`, Table))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, fmt.Sprintf(`SELECT "version", "applied_at" FROM %s ORDER BY "version"`, Table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var ver int
+		var at time.Time
+		if err := rows.Scan(&ver, &at); err != nil {
+			return nil, err
+		}
+		applied[ver] = at
+	}
+	return applied, nil
+}
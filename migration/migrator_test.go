@@ -0,0 +1,206 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/infra"
+)
+
+// fakeDB records the migration table in memory
+// and every statement executed through it
+type fakeDB struct {
+	applied []int
+	execs   []string
+	failOn  string
+}
+
+func (d *fakeDB) Exec(ctx context.Context, stmt string, args ...interface{}) error {
+	if d.failOn != "" && stmt == d.failOn {
+		return errors.New("exec failed")
+	}
+	d.execs = append(d.execs, stmt)
+	switch {
+	case strings.HasPrefix(stmt, "INSERT INTO "+Table):
+		d.applied = append(d.applied, args[0].(int))
+	case strings.HasPrefix(stmt, "DELETE FROM "+Table):
+		for i, v := range d.applied {
+			if v == args[0].(int) {
+				d.applied = append(d.applied[:i], d.applied[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (d *fakeDB) Query(ctx context.Context, stmt string, args ...interface{}) (infra.Row, error) {
+	return &fakeRows{vers: append([]int{}, d.applied...), i: -1}, nil
+}
+
+func (d *fakeDB) Begin(ctx context.Context) (infra.Tx, error) { return d, nil }
+func (d *fakeDB) Commit() error                               { return nil }
+func (d *fakeDB) Rollback() error                             { return nil }
+
+// sqls returns the executed migration statements
+func (d *fakeDB) sqls() []string {
+	sqls := []string{}
+	for _, s := range d.execs {
+		if !strings.Contains(s, Table) && !strings.Contains(s, "pg_advisory") {
+			sqls = append(sqls, s)
+		}
+	}
+	return sqls
+}
+
+type fakeRows struct {
+	vers []int
+	i    int
+}
+
+func (r *fakeRows) Next() bool {
+	r.i++
+	return r.i < len(r.vers)
+}
+
+func (r *fakeRows) Scan(v ...interface{}) error {
+	*v[0].(*int) = r.vers[r.i]
+	*v[1].(*time.Time) = time.Now()
+	return nil
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "init", Up: "up 1", Down: "down 1"},
+	{Version: 2, Name: "tags", Up: "up 2", Down: "down 2"},
+	{Version: 3, Name: "prices", Up: "up 3"},
+}
+
+func TestMigrator_Up(t *testing.T) {
+	tests := []struct {
+		name     string
+		applied  []int
+		failOn   string
+		wantDone []int
+		wantSQLs []string
+		wantErr  bool
+	}{
+		{
+			name:     "fresh",
+			wantDone: []int{1, 2, 3},
+			wantSQLs: []string{"up 1", "up 2", "up 3"},
+		},
+		{
+			name:     "behind",
+			applied:  []int{1},
+			wantDone: []int{2, 3},
+			wantSQLs: []string{"up 2", "up 3"},
+		},
+		{
+			name:     "current",
+			applied:  []int{1, 2, 3},
+			wantDone: []int{},
+			wantSQLs: []string{},
+		},
+		{
+			name:     "failing",
+			failOn:   "up 2",
+			wantDone: []int{1},
+			wantSQLs: []string{"up 1"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{applied: tt.applied, failOn: tt.failOn}
+			m := NewMigrator(db, testMigrations, nil)
+
+			done, err := m.Up(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Migrator.Up() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			vers := []int{}
+			for _, d := range done {
+				vers = append(vers, d.Version)
+			}
+			if !reflect.DeepEqual(vers, tt.wantDone) {
+				t.Errorf("Migrator.Up() = %v, want %v", vers, tt.wantDone)
+			}
+			if got := db.sqls(); !reflect.DeepEqual(got, tt.wantSQLs) {
+				t.Errorf("Migrator.Up() executed %v, want %v", got, tt.wantSQLs)
+			}
+		})
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	tests := []struct {
+		name    string
+		applied []int
+		want    int
+		wantErr error
+	}{
+		{
+			name:    "latest",
+			applied: []int{1, 2},
+			want:    2,
+		},
+		{
+			name: "empty",
+		},
+		{
+			name:    "irreversible",
+			applied: []int{1, 2, 3},
+			wantErr: ErrIrreversible,
+		},
+		{
+			name:    "unknown",
+			applied: []int{1, 4},
+			wantErr: ErrUnknownVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{applied: tt.applied}
+			m := NewMigrator(db, testMigrations, nil)
+
+			got, err := m.Down(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Migrator.Down() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			ver := 0
+			if got != nil {
+				ver = got.Version
+			}
+			if ver != tt.want {
+				t.Errorf("Migrator.Down() = %v, want %v", ver, tt.want)
+			}
+			for _, v := range db.applied {
+				if v == tt.want {
+					t.Errorf("Migrator.Down() left version %d applied", v)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrator_Pending(t *testing.T) {
+	db := &fakeDB{applied: []int{1}}
+	m := NewMigrator(db, testMigrations, nil)
+
+	pdg, err := m.Pending(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pdg) != 2 || pdg[0].Version != 2 || pdg[1].Version != 3 {
+		t.Errorf("Migrator.Pending() = %v, want versions [2 3]", pdg)
+	}
+}
@@ -1,4 +1,4 @@
-CREATE TABLE products (
+CREATE TABLE IF NOT EXISTS products (
 	id VARCHAR(40) NOT NULL PRIMARY KEY,
 	name VARCHAR(40) NOT NULL,
 	price BIGINT NOT NULL,
@@ -10,9 +10,9 @@ CREATE TABLE products (
 	deleted_at TIMESTAMP NOT NULL DEFAULT '1999-01-01 00:00:00'
 );
 
-CREATE TABLE ratings (
+CREATE TABLE IF NOT EXISTS ratings (
 	id VARCHAR(40) NOT NULL PRIMARY KEY,
 	product_id VARCHAR(40) NOT NULL,
 	value INT NOT NULL,
 	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-); 
+);
@@ -0,0 +1,23 @@
+ALTER TABLE products ADD COLUMN quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0);
+
+-- available is derived from quantity from now on, a product that was
+-- available keeps it with an opening quantity of one
+UPDATE products SET quantity = 1 WHERE available;
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+	id VARCHAR(40) NOT NULL PRIMARY KEY,
+	product_id VARCHAR(40) NOT NULL,
+	type VARCHAR(16) NOT NULL,
+	quantity INT NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	reference VARCHAR(255) NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS stock_movements_product_id_idx ON stock_movements (product_id, created_at);
+
+-- the opening quantities are recorded as adjustments so that the movements
+-- of a product sum up to its quantity
+INSERT INTO stock_movements (id, product_id, type, quantity, reason)
+SELECT md5(random()::text || id), id, 'adjustment', quantity, 'opening stock'
+FROM products WHERE quantity > 0;
@@ -0,0 +1,15 @@
+ALTER TABLE products ADD COLUMN reserved INT NOT NULL DEFAULT 0 CHECK (reserved >= 0);
+ALTER TABLE products ADD CONSTRAINT products_reserved_quantity_check CHECK (reserved <= quantity);
+
+CREATE TABLE IF NOT EXISTS reservations (
+	id VARCHAR(40) NOT NULL PRIMARY KEY,
+	product_id VARCHAR(40) NOT NULL,
+	quantity INT NOT NULL CHECK (quantity > 0),
+	status VARCHAR(16) NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS reservations_status_expires_at_idx ON reservations (status, expires_at);
+CREATE INDEX IF NOT EXISTS reservations_product_id_idx ON reservations (product_id);
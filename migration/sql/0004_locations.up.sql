@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS locations (
+	id VARCHAR(40) NOT NULL PRIMARY KEY,
+	name VARCHAR(80) NOT NULL,
+	address TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS stock_levels (
+	product_id VARCHAR(40) NOT NULL,
+	location_id VARCHAR(40) NOT NULL,
+	quantity INT NOT NULL CHECK (quantity >= 0),
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (product_id, location_id)
+);
+
+CREATE INDEX IF NOT EXISTS stock_levels_location_id_idx ON stock_levels (location_id, quantity);
+
+ALTER TABLE stock_movements ADD COLUMN location_id VARCHAR(40) NOT NULL DEFAULT '';
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS categories (
+	id VARCHAR(40) NOT NULL PRIMARY KEY,
+	name VARCHAR(80) NOT NULL,
+	parent_id VARCHAR(40) NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id);
+
+CREATE TABLE IF NOT EXISTS product_categories (
+	product_id VARCHAR(40) NOT NULL,
+	category_id VARCHAR(40) NOT NULL,
+	PRIMARY KEY (product_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS product_categories_category_id_idx ON product_categories (category_id);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS product_tags (
+	product_id VARCHAR(40) NOT NULL,
+	tag VARCHAR(40) NOT NULL,
+	PRIMARY KEY (product_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS product_tags_tag_idx ON product_tags (tag);
@@ -0,0 +1,6 @@
+ALTER TABLE products ADD COLUMN sku VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN barcode VARCHAR(13) NOT NULL DEFAULT '';
+
+-- the codes are optional and unique among the products not deleted
+CREATE UNIQUE INDEX IF NOT EXISTS products_sku_key ON products (sku) WHERE sku <> '' AND deleted = FALSE;
+CREATE UNIQUE INDEX IF NOT EXISTS products_barcode_key ON products (barcode) WHERE barcode <> '' AND deleted = FALSE;
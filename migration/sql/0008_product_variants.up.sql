@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS product_variants (
+	id VARCHAR(40) NOT NULL PRIMARY KEY,
+	product_id VARCHAR(40) NOT NULL,
+	options VARCHAR(255) NOT NULL,
+	sku VARCHAR(64) NOT NULL DEFAULT '',
+	price BIGINT NOT NULL DEFAULT 0 CHECK (price >= 0),
+	weight INT8 NOT NULL DEFAULT 0 CHECK (weight >= 0),
+	quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- options are encoded with the axes in order so equal options collide
+CREATE UNIQUE INDEX IF NOT EXISTS product_variants_options_key ON product_variants (product_id, options);
+CREATE UNIQUE INDEX IF NOT EXISTS product_variants_sku_key ON product_variants (sku) WHERE sku <> '';
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS price_changes (
+	id VARCHAR(40) NOT NULL PRIMARY KEY,
+	product_id VARCHAR(40) NOT NULL,
+	price BIGINT NOT NULL CHECK (price > 0),
+	status VARCHAR(16) NOT NULL,
+	effective_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS price_changes_product_id_idx ON price_changes (product_id, effective_at);
+CREATE INDEX IF NOT EXISTS price_changes_status_effective_at_idx ON price_changes (status, effective_at);
+
+-- the current prices are the first entries of the history
+INSERT INTO price_changes (id, product_id, price, status, effective_at)
+	SELECT id, id, price, 'applied', created_at FROM products WHERE price > 0;
@@ -0,0 +1,2 @@
+-- prices holds the prices in other currencies encoded like EUR:1099;GBP:899
+ALTER TABLE products ADD COLUMN prices TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS promotions (
+	id VARCHAR(40) NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	type VARCHAR(16) NOT NULL,
+	value BIGINT NOT NULL DEFAULT 0,
+	buy_quantity INTEGER NOT NULL DEFAULT 0,
+	get_quantity INTEGER NOT NULL DEFAULT 0,
+	product_ids TEXT NOT NULL DEFAULT '',
+	category_ids TEXT NOT NULL DEFAULT '',
+	tags TEXT NOT NULL DEFAULT '',
+	starts_at TIMESTAMPTZ NOT NULL,
+	ends_at TIMESTAMPTZ NOT NULL CHECK (ends_at > starts_at),
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS promotions_active_idx ON promotions (starts_at, ends_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS products_status_idx;
+ALTER TABLE products DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE products DROP COLUMN IF EXISTS published_at;
+ALTER TABLE products DROP COLUMN IF EXISTS status;
@@ -0,0 +1,9 @@
+-- status is the lifecycle state of a product, one of draft, published or archived
+ALTER TABLE products ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'draft';
+ALTER TABLE products ADD COLUMN published_at TIMESTAMP;
+ALTER TABLE products ADD COLUMN archived_at TIMESTAMP;
+
+-- the existing products are live
+UPDATE products SET status = 'published', published_at = created_at;
+
+CREATE INDEX IF NOT EXISTS products_status_idx ON products (status);
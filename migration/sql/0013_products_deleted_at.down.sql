@@ -0,0 +1 @@
+DROP INDEX IF EXISTS products_deleted_at_idx;
@@ -0,0 +1,2 @@
+-- the deleted products are listed and purged by their delete time
+CREATE INDEX IF NOT EXISTS products_deleted_at_idx ON products (deleted_at) WHERE deleted = TRUE;
@@ -0,0 +1,18 @@
+-- rating_summaries holds the ratings of every rated product aggregated on write
+-- stars_n is the number of ratings of value n
+CREATE TABLE IF NOT EXISTS rating_summaries (
+	product_id VARCHAR(40) NOT NULL PRIMARY KEY,
+	count INT NOT NULL DEFAULT 0,
+	sum INT NOT NULL DEFAULT 0,
+	stars_1 INT NOT NULL DEFAULT 0,
+	stars_2 INT NOT NULL DEFAULT 0,
+	stars_3 INT NOT NULL DEFAULT 0,
+	stars_4 INT NOT NULL DEFAULT 0,
+	stars_5 INT NOT NULL DEFAULT 0
+);
+
+INSERT INTO rating_summaries (product_id, count, sum, stars_1, stars_2, stars_3, stars_4, stars_5)
+SELECT product_id, COUNT(*), SUM(value),
+	COUNT(*) FILTER (WHERE value = 1), COUNT(*) FILTER (WHERE value = 2), COUNT(*) FILTER (WHERE value = 3),
+	COUNT(*) FILTER (WHERE value = 4), COUNT(*) FILTER (WHERE value = 5)
+FROM ratings GROUP BY product_id;
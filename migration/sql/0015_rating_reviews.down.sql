@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS ratings_product_created_idx;
+DROP INDEX IF EXISTS ratings_product_user_key;
+ALTER TABLE ratings DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE ratings DROP COLUMN IF EXISTS body;
+ALTER TABLE ratings DROP COLUMN IF EXISTS title;
+ALTER TABLE ratings DROP COLUMN IF EXISTS user_name;
+ALTER TABLE ratings DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,12 @@
+-- a rating is made by a user who rates a product once, with an optional review text
+-- the ratings made before have no user
+ALTER TABLE ratings ADD COLUMN user_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE ratings ADD COLUMN user_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE ratings ADD COLUMN title VARCHAR(120) NOT NULL DEFAULT '';
+ALTER TABLE ratings ADD COLUMN body TEXT NOT NULL DEFAULT '';
+ALTER TABLE ratings ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE ratings SET updated_at = created_at;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ratings_product_user_key ON ratings (product_id, user_id) WHERE user_id <> '';
+CREATE INDEX IF NOT EXISTS ratings_product_created_idx ON ratings (product_id, created_at);
@@ -0,0 +1,7 @@
+-- status is the moderation state of a rating, one of pending, approved or rejected
+-- reason is why a pending rating is flagged or a rejected one is rejected
+ALTER TABLE ratings ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'approved';
+ALTER TABLE ratings ADD COLUMN reason TEXT NOT NULL DEFAULT '';
+
+-- the moderation queue lists the pending ratings, the oldest first
+CREATE INDEX IF NOT EXISTS ratings_pending_idx ON ratings (created_at) WHERE status = 'pending';
@@ -0,0 +1 @@
+DELETE FROM stock_levels WHERE location_id = '';
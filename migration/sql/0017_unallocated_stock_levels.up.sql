@@ -0,0 +1,8 @@
+-- the units of a product not allocated to any location are kept in its
+-- stock level of the empty location so that the levels sum up to quantity
+INSERT INTO stock_levels (product_id, location_id, quantity)
+SELECT p.id, '', p.quantity - COALESCE(SUM(l.quantity), 0)
+FROM products p LEFT JOIN stock_levels l ON l.product_id = p.id
+GROUP BY p.id, p.quantity
+HAVING p.quantity > COALESCE(SUM(l.quantity), 0)
+ON CONFLICT (product_id, location_id) DO NOTHING;
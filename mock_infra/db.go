@@ -1,10 +1,11 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/msyrus/simple-product-inv/infra (interfaces: DB,Row)
+// Source: github.com/msyrus/simple-product-inv/infra (interfaces: DB,Row,Tx,TxDB)
 
 // Package mock_infra is a generated GoMock package.
 package mock_infra
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	infra "github.com/msyrus/simple-product-inv/infra"
 	reflect "reflect"
@@ -34,9 +35,9 @@ func (m *MockDB) EXPECT() *MockDBMockRecorder {
 }
 
 // Exec mocks base method
-func (m *MockDB) Exec(arg0 string, arg1 ...interface{}) error {
-	varargs := []interface{}{arg0}
-	for _, a := range arg1 {
+func (m *MockDB) Exec(arg0 context.Context, arg1 string, arg2 ...interface{}) error {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "Exec", varargs...)
@@ -45,15 +46,15 @@ func (m *MockDB) Exec(arg0 string, arg1 ...interface{}) error {
 }
 
 // Exec indicates an expected call of Exec
-func (mr *MockDBMockRecorder) Exec(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
-	varargs := append([]interface{}{arg0}, arg1...)
+func (mr *MockDBMockRecorder) Exec(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockDB)(nil).Exec), varargs...)
 }
 
 // Query mocks base method
-func (m *MockDB) Query(arg0 string, arg1 ...interface{}) (infra.Row, error) {
-	varargs := []interface{}{arg0}
-	for _, a := range arg1 {
+func (m *MockDB) Query(arg0 context.Context, arg1 string, arg2 ...interface{}) (infra.Row, error) {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "Query", varargs...)
@@ -63,8 +64,8 @@ func (m *MockDB) Query(arg0 string, arg1 ...interface{}) (infra.Row, error) {
 }
 
 // Query indicates an expected call of Query
-func (mr *MockDBMockRecorder) Query(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
-	varargs := append([]interface{}{arg0}, arg1...)
+func (mr *MockDBMockRecorder) Query(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockDB)(nil).Query), varargs...)
 }
 
@@ -130,3 +131,156 @@ func (m *MockRow) Scan(arg0 ...interface{}) error {
 func (mr *MockRowMockRecorder) Scan(arg0 ...interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockRow)(nil).Scan), arg0...)
 }
+
+// MockTx is a mock of Tx interface
+type MockTx struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxMockRecorder
+}
+
+// MockTxMockRecorder is the mock recorder for MockTx
+type MockTxMockRecorder struct {
+	mock *MockTx
+}
+
+// NewMockTx creates a new mock instance
+func NewMockTx(ctrl *gomock.Controller) *MockTx {
+	mock := &MockTx{ctrl: ctrl}
+	mock.recorder = &MockTxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTx) EXPECT() *MockTxMockRecorder {
+	return m.recorder
+}
+
+// Commit mocks base method
+func (m *MockTx) Commit() error {
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit
+func (mr *MockTxMockRecorder) Commit() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockTx)(nil).Commit))
+}
+
+// Exec mocks base method
+func (m *MockTx) Exec(arg0 context.Context, arg1 string, arg2 ...interface{}) error {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Exec", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Exec indicates an expected call of Exec
+func (mr *MockTxMockRecorder) Exec(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockTx)(nil).Exec), varargs...)
+}
+
+// Query mocks base method
+func (m *MockTx) Query(arg0 context.Context, arg1 string, arg2 ...interface{}) (infra.Row, error) {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Query", varargs...)
+	ret0, _ := ret[0].(infra.Row)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query
+func (mr *MockTxMockRecorder) Query(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockTx)(nil).Query), varargs...)
+}
+
+// Rollback mocks base method
+func (m *MockTx) Rollback() error {
+	ret := m.ctrl.Call(m, "Rollback")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback
+func (mr *MockTxMockRecorder) Rollback() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockTx)(nil).Rollback))
+}
+
+// MockTxDB is a mock of TxDB interface
+type MockTxDB struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxDBMockRecorder
+}
+
+// MockTxDBMockRecorder is the mock recorder for MockTxDB
+type MockTxDBMockRecorder struct {
+	mock *MockTxDB
+}
+
+// NewMockTxDB creates a new mock instance
+func NewMockTxDB(ctrl *gomock.Controller) *MockTxDB {
+	mock := &MockTxDB{ctrl: ctrl}
+	mock.recorder = &MockTxDBMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTxDB) EXPECT() *MockTxDBMockRecorder {
+	return m.recorder
+}
+
+// Begin mocks base method
+func (m *MockTxDB) Begin(arg0 context.Context) (infra.Tx, error) {
+	ret := m.ctrl.Call(m, "Begin", arg0)
+	ret0, _ := ret[0].(infra.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Begin indicates an expected call of Begin
+func (mr *MockTxDBMockRecorder) Begin(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockTxDB)(nil).Begin), arg0)
+}
+
+// Exec mocks base method
+func (m *MockTxDB) Exec(arg0 context.Context, arg1 string, arg2 ...interface{}) error {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Exec", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Exec indicates an expected call of Exec
+func (mr *MockTxDBMockRecorder) Exec(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockTxDB)(nil).Exec), varargs...)
+}
+
+// Query mocks base method
+func (m *MockTxDB) Query(arg0 context.Context, arg1 string, arg2 ...interface{}) (infra.Row, error) {
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Query", varargs...)
+	ret0, _ := ret[0].(infra.Row)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query
+func (mr *MockTxDBMockRecorder) Query(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockTxDB)(nil).Query), varargs...)
+}
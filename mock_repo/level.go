@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/msyrus/simple-product-inv/repo (interfaces: StockLevel)
+
+// Package mock_repo is a generated GoMock package.
+package mock_repo
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	repo "github.com/msyrus/simple-product-inv/repo"
+	reflect "reflect"
+)
+
+// MockStockLevel is a mock of StockLevel interface
+type MockStockLevel struct {
+	ctrl     *gomock.Controller
+	recorder *MockStockLevelMockRecorder
+}
+
+// MockStockLevelMockRecorder is the mock recorder for MockStockLevel
+type MockStockLevelMockRecorder struct {
+	mock *MockStockLevel
+}
+
+// NewMockStockLevel creates a new mock instance
+func NewMockStockLevel(ctrl *gomock.Controller) *MockStockLevel {
+	mock := &MockStockLevel{ctrl: ctrl}
+	mock.recorder = &MockStockLevelMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockStockLevel) EXPECT() *MockStockLevelMockRecorder {
+	return m.recorder
+}
+
+// AdjustLevel mocks base method
+func (m *MockStockLevel) AdjustLevel(arg0 context.Context, arg1, arg2 string, arg3 int) (int, error) {
+	ret := m.ctrl.Call(m, "AdjustLevel", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustLevel indicates an expected call of AdjustLevel
+func (mr *MockStockLevelMockRecorder) AdjustLevel(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustLevel", reflect.TypeOf((*MockStockLevel)(nil).AdjustLevel), arg0, arg1, arg2, arg3)
+}
+
+// RemoveProducts mocks base method
+func (m *MockStockLevel) RemoveProducts(arg0 context.Context, arg1 []string) error {
+	ret := m.ctrl.Call(m, "RemoveProducts", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveProducts indicates an expected call of RemoveProducts
+func (mr *MockStockLevelMockRecorder) RemoveProducts(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveProducts", reflect.TypeOf((*MockStockLevel)(nil).RemoveProducts), arg0, arg1)
+}
+
+// Search mocks base method
+func (m *MockStockLevel) Search(arg0 context.Context, arg1 repo.Query, arg2, arg3 int) ([]interface{}, error) {
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search
+func (mr *MockStockLevelMockRecorder) Search(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockStockLevel)(nil).Search), arg0, arg1, arg2, arg3)
+}
+
+// SearchCount mocks base method
+func (m *MockStockLevel) SearchCount(arg0 context.Context, arg1 repo.Query) (int, error) {
+	ret := m.ctrl.Call(m, "SearchCount", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchCount indicates an expected call of SearchCount
+func (mr *MockStockLevelMockRecorder) SearchCount(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCount", reflect.TypeOf((*MockStockLevel)(nil).SearchCount), arg0, arg1)
+}
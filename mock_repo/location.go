@@ -0,0 +1,98 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/msyrus/simple-product-inv/repo (interfaces: Location)
+
+// Package mock_repo is a generated GoMock package.
+package mock_repo
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockLocation is a mock of Location interface
+type MockLocation struct {
+	ctrl     *gomock.Controller
+	recorder *MockLocationMockRecorder
+}
+
+// MockLocationMockRecorder is the mock recorder for MockLocation
+type MockLocationMockRecorder struct {
+	mock *MockLocation
+}
+
+// NewMockLocation creates a new mock instance
+func NewMockLocation(ctrl *gomock.Controller) *MockLocation {
+	mock := &MockLocation{ctrl: ctrl}
+	mock.recorder = &MockLocationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockLocation) EXPECT() *MockLocationMockRecorder {
+	return m.recorder
+}
+
+// Count mocks base method
+func (m *MockLocation) Count(arg0 context.Context) (int, error) {
+	ret := m.ctrl.Call(m, "Count", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count
+func (mr *MockLocationMockRecorder) Count(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockLocation)(nil).Count), arg0)
+}
+
+// Create mocks base method
+func (m *MockLocation) Create(arg0 context.Context, arg1 interface{}) (string, error) {
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockLocationMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockLocation)(nil).Create), arg0, arg1)
+}
+
+// Fetch mocks base method
+func (m *MockLocation) Fetch(arg0 context.Context, arg1 string) (interface{}, error) {
+	ret := m.ctrl.Call(m, "Fetch", arg0, arg1)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Fetch indicates an expected call of Fetch
+func (mr *MockLocationMockRecorder) Fetch(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockLocation)(nil).Fetch), arg0, arg1)
+}
+
+// List mocks base method
+func (m *MockLocation) List(arg0 context.Context, arg1, arg2 int) ([]interface{}, error) {
+	ret := m.ctrl.Call(m, "List", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockLocationMockRecorder) List(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockLocation)(nil).List), arg0, arg1, arg2)
+}
+
+// Update mocks base method
+func (m *MockLocation) Update(arg0 context.Context, arg1 string, arg2 interface{}) error {
+	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update
+func (mr *MockLocationMockRecorder) Update(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockLocation)(nil).Update), arg0, arg1, arg2)
+}
@@ -5,9 +5,11 @@
 package mock_repo
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	repo "github.com/msyrus/simple-product-inv/repo"
 	reflect "reflect"
+	time "time"
 )
 
 // MockProduct is a mock of Product interface
@@ -33,104 +35,218 @@ func (m *MockProduct) EXPECT() *MockProductMockRecorder {
 	return m.recorder
 }
 
+// AdjustQuantity mocks base method
+func (m *MockProduct) AdjustQuantity(arg0 context.Context, arg1 string, arg2 int) (int, error) {
+	ret := m.ctrl.Call(m, "AdjustQuantity", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustQuantity indicates an expected call of AdjustQuantity
+func (mr *MockProductMockRecorder) AdjustQuantity(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustQuantity", reflect.TypeOf((*MockProduct)(nil).AdjustQuantity), arg0, arg1, arg2)
+}
+
+// AdjustReserved mocks base method
+func (m *MockProduct) AdjustReserved(arg0 context.Context, arg1 string, arg2 int) (int, error) {
+	ret := m.ctrl.Call(m, "AdjustReserved", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustReserved indicates an expected call of AdjustReserved
+func (mr *MockProductMockRecorder) AdjustReserved(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustReserved", reflect.TypeOf((*MockProduct)(nil).AdjustReserved), arg0, arg1, arg2)
+}
+
 // Count mocks base method
-func (m *MockProduct) Count() (int, error) {
-	ret := m.ctrl.Call(m, "Count")
+func (m *MockProduct) Count(arg0 context.Context) (int, error) {
+	ret := m.ctrl.Call(m, "Count", arg0)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Count indicates an expected call of Count
-func (mr *MockProductMockRecorder) Count() *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockProduct)(nil).Count))
+func (mr *MockProductMockRecorder) Count(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockProduct)(nil).Count), arg0)
+}
+
+// CountDeleted mocks base method
+func (m *MockProduct) CountDeleted(arg0 context.Context) (int, error) {
+	ret := m.ctrl.Call(m, "CountDeleted", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDeleted indicates an expected call of CountDeleted
+func (mr *MockProductMockRecorder) CountDeleted(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDeleted", reflect.TypeOf((*MockProduct)(nil).CountDeleted), arg0)
+}
+
+// CountTags mocks base method
+func (m *MockProduct) CountTags(arg0 context.Context) (map[string]int, error) {
+	ret := m.ctrl.Call(m, "CountTags", arg0)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTags indicates an expected call of CountTags
+func (mr *MockProductMockRecorder) CountTags(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTags", reflect.TypeOf((*MockProduct)(nil).CountTags), arg0)
 }
 
 // Create mocks base method
-func (m *MockProduct) Create(arg0 interface{}) (string, error) {
-	ret := m.ctrl.Call(m, "Create", arg0)
+func (m *MockProduct) Create(arg0 context.Context, arg1 interface{}) (string, error) {
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create
-func (mr *MockProductMockRecorder) Create(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockProduct)(nil).Create), arg0)
+func (mr *MockProductMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockProduct)(nil).Create), arg0, arg1)
 }
 
 // Delete mocks base method
-func (m *MockProduct) Delete(arg0 string) error {
-	ret := m.ctrl.Call(m, "Delete", arg0)
+func (m *MockProduct) Delete(arg0 context.Context, arg1 string) error {
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete
-func (mr *MockProductMockRecorder) Delete(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockProduct)(nil).Delete), arg0)
+func (mr *MockProductMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockProduct)(nil).Delete), arg0, arg1)
 }
 
 // Fetch mocks base method
-func (m *MockProduct) Fetch(arg0 string) (interface{}, error) {
-	ret := m.ctrl.Call(m, "Fetch", arg0)
+func (m *MockProduct) Fetch(arg0 context.Context, arg1 string) (interface{}, error) {
+	ret := m.ctrl.Call(m, "Fetch", arg0, arg1)
 	ret0, _ := ret[0].(interface{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Fetch indicates an expected call of Fetch
-func (mr *MockProductMockRecorder) Fetch(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockProduct)(nil).Fetch), arg0)
+func (mr *MockProductMockRecorder) Fetch(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockProduct)(nil).Fetch), arg0, arg1)
 }
 
 // List mocks base method
-func (m *MockProduct) List(arg0, arg1 int) ([]interface{}, error) {
-	ret := m.ctrl.Call(m, "List", arg0, arg1)
+func (m *MockProduct) List(arg0 context.Context, arg1, arg2 int) ([]interface{}, error) {
+	ret := m.ctrl.Call(m, "List", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]interface{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // List indicates an expected call of List
-func (mr *MockProductMockRecorder) List(arg0, arg1 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockProduct)(nil).List), arg0, arg1)
+func (mr *MockProductMockRecorder) List(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockProduct)(nil).List), arg0, arg1, arg2)
+}
+
+// ListDeleted mocks base method
+func (m *MockProduct) ListDeleted(arg0 context.Context, arg1, arg2 int) ([]interface{}, error) {
+	ret := m.ctrl.Call(m, "ListDeleted", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeleted indicates an expected call of ListDeleted
+func (mr *MockProductMockRecorder) ListDeleted(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeleted", reflect.TypeOf((*MockProduct)(nil).ListDeleted), arg0, arg1, arg2)
+}
+
+// Purge mocks base method
+func (m *MockProduct) Purge(arg0 context.Context, arg1 time.Time, arg2 int) ([]string, error) {
+	ret := m.ctrl.Call(m, "Purge", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge
+func (mr *MockProductMockRecorder) Purge(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockProduct)(nil).Purge), arg0, arg1, arg2)
+}
+
+// Restore mocks base method
+func (m *MockProduct) Restore(arg0 context.Context, arg1 string) error {
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore
+func (mr *MockProductMockRecorder) Restore(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockProduct)(nil).Restore), arg0, arg1)
 }
 
 // Search mocks base method
-func (m *MockProduct) Search(arg0 repo.Query, arg1, arg2 int) ([]interface{}, error) {
-	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2)
+func (m *MockProduct) Search(arg0 context.Context, arg1 repo.Query, arg2, arg3 int) ([]interface{}, error) {
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].([]interface{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Search indicates an expected call of Search
-func (mr *MockProductMockRecorder) Search(arg0, arg1, arg2 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockProduct)(nil).Search), arg0, arg1, arg2)
+func (mr *MockProductMockRecorder) Search(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockProduct)(nil).Search), arg0, arg1, arg2, arg3)
 }
 
 // SearchCount mocks base method
-func (m *MockProduct) SearchCount(arg0 repo.Query) (int, error) {
-	ret := m.ctrl.Call(m, "SearchCount", arg0)
+func (m *MockProduct) SearchCount(arg0 context.Context, arg1 repo.Query) (int, error) {
+	ret := m.ctrl.Call(m, "SearchCount", arg0, arg1)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SearchCount indicates an expected call of SearchCount
-func (mr *MockProductMockRecorder) SearchCount(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCount", reflect.TypeOf((*MockProduct)(nil).SearchCount), arg0)
+func (mr *MockProductMockRecorder) SearchCount(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCount", reflect.TypeOf((*MockProduct)(nil).SearchCount), arg0, arg1)
+}
+
+// SetPrice mocks base method
+func (m *MockProduct) SetPrice(arg0 context.Context, arg1 string, arg2 int) error {
+	ret := m.ctrl.Call(m, "SetPrice", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPrice indicates an expected call of SetPrice
+func (mr *MockProductMockRecorder) SetPrice(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPrice", reflect.TypeOf((*MockProduct)(nil).SetPrice), arg0, arg1, arg2)
+}
+
+// SetStatus mocks base method
+func (m *MockProduct) SetStatus(arg0 context.Context, arg1, arg2, arg3 string) error {
+	ret := m.ctrl.Call(m, "SetStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus
+func (mr *MockProductMockRecorder) SetStatus(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockProduct)(nil).SetStatus), arg0, arg1, arg2, arg3)
 }
 
 // Update mocks base method
-func (m *MockProduct) Update(arg0 string, arg1 interface{}) error {
-	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+func (m *MockProduct) Update(arg0 context.Context, arg1 string, arg2 interface{}) error {
+	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update
-func (mr *MockProductMockRecorder) Update(arg0, arg1 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProduct)(nil).Update), arg0, arg1)
+func (mr *MockProductMockRecorder) Update(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProduct)(nil).Update), arg0, arg1, arg2)
 }
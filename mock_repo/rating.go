@@ -5,7 +5,9 @@
 package mock_repo
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
+	model "github.com/msyrus/simple-product-inv/model"
 	repo "github.com/msyrus/simple-product-inv/repo"
 	reflect "reflect"
 )
@@ -34,27 +36,140 @@ func (m *MockRating) EXPECT() *MockRatingMockRecorder {
 }
 
 // Avg mocks base method
-func (m *MockRating) Avg(arg0 repo.Query, arg1 string) (float64, error) {
-	ret := m.ctrl.Call(m, "Avg", arg0, arg1)
+func (m *MockRating) Avg(arg0 context.Context, arg1 repo.Query, arg2 string) (float64, error) {
+	ret := m.ctrl.Call(m, "Avg", arg0, arg1, arg2)
 	ret0, _ := ret[0].(float64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Avg indicates an expected call of Avg
-func (mr *MockRatingMockRecorder) Avg(arg0, arg1 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Avg", reflect.TypeOf((*MockRating)(nil).Avg), arg0, arg1)
+func (mr *MockRatingMockRecorder) Avg(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Avg", reflect.TypeOf((*MockRating)(nil).Avg), arg0, arg1, arg2)
 }
 
 // Create mocks base method
-func (m *MockRating) Create(arg0 interface{}) (string, error) {
-	ret := m.ctrl.Call(m, "Create", arg0)
+func (m *MockRating) Create(arg0 context.Context, arg1 interface{}) (string, error) {
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create
-func (mr *MockRatingMockRecorder) Create(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRating)(nil).Create), arg0)
+func (mr *MockRatingMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRating)(nil).Create), arg0, arg1)
+}
+
+// Delete mocks base method
+func (m *MockRating) Delete(arg0 context.Context, arg1 string) error {
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockRatingMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRating)(nil).Delete), arg0, arg1)
+}
+
+// Fetch mocks base method
+func (m *MockRating) Fetch(arg0 context.Context, arg1 string) (interface{}, error) {
+	ret := m.ctrl.Call(m, "Fetch", arg0, arg1)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Fetch indicates an expected call of Fetch
+func (mr *MockRatingMockRecorder) Fetch(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockRating)(nil).Fetch), arg0, arg1)
+}
+
+// Moderate mocks base method
+func (m *MockRating) Moderate(arg0 context.Context, arg1, arg2, arg3, arg4 string) error {
+	ret := m.ctrl.Call(m, "Moderate", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Moderate indicates an expected call of Moderate
+func (mr *MockRatingMockRecorder) Moderate(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Moderate", reflect.TypeOf((*MockRating)(nil).Moderate), arg0, arg1, arg2, arg3, arg4)
+}
+
+// RebuildSummaries mocks base method
+func (m *MockRating) RebuildSummaries(arg0 context.Context) (int, error) {
+	ret := m.ctrl.Call(m, "RebuildSummaries", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RebuildSummaries indicates an expected call of RebuildSummaries
+func (mr *MockRatingMockRecorder) RebuildSummaries(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildSummaries", reflect.TypeOf((*MockRating)(nil).RebuildSummaries), arg0)
+}
+
+// RemoveProducts mocks base method
+func (m *MockRating) RemoveProducts(arg0 context.Context, arg1 []string) error {
+	ret := m.ctrl.Call(m, "RemoveProducts", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveProducts indicates an expected call of RemoveProducts
+func (mr *MockRatingMockRecorder) RemoveProducts(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveProducts", reflect.TypeOf((*MockRating)(nil).RemoveProducts), arg0, arg1)
+}
+
+// Search mocks base method
+func (m *MockRating) Search(arg0 context.Context, arg1 repo.Query, arg2, arg3 int) ([]interface{}, error) {
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search
+func (mr *MockRatingMockRecorder) Search(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockRating)(nil).Search), arg0, arg1, arg2, arg3)
+}
+
+// SearchCount mocks base method
+func (m *MockRating) SearchCount(arg0 context.Context, arg1 repo.Query) (int, error) {
+	ret := m.ctrl.Call(m, "SearchCount", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchCount indicates an expected call of SearchCount
+func (mr *MockRatingMockRecorder) SearchCount(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCount", reflect.TypeOf((*MockRating)(nil).SearchCount), arg0, arg1)
+}
+
+// Summaries mocks base method
+func (m *MockRating) Summaries(arg0 context.Context, arg1 []string) (map[string]model.RatingSummary, error) {
+	ret := m.ctrl.Call(m, "Summaries", arg0, arg1)
+	ret0, _ := ret[0].(map[string]model.RatingSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Summaries indicates an expected call of Summaries
+func (mr *MockRatingMockRecorder) Summaries(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Summaries", reflect.TypeOf((*MockRating)(nil).Summaries), arg0, arg1)
+}
+
+// Update mocks base method
+func (m *MockRating) Update(arg0 context.Context, arg1 string, arg2 interface{}) error {
+	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update
+func (mr *MockRatingMockRecorder) Update(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRating)(nil).Update), arg0, arg1, arg2)
 }
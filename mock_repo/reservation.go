@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/msyrus/simple-product-inv/repo (interfaces: Reservation)
+
+// Package mock_repo is a generated GoMock package.
+package mock_repo
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	repo "github.com/msyrus/simple-product-inv/repo"
+	reflect "reflect"
+)
+
+// MockReservation is a mock of Reservation interface
+type MockReservation struct {
+	ctrl     *gomock.Controller
+	recorder *MockReservationMockRecorder
+}
+
+// MockReservationMockRecorder is the mock recorder for MockReservation
+type MockReservationMockRecorder struct {
+	mock *MockReservation
+}
+
+// NewMockReservation creates a new mock instance
+func NewMockReservation(ctrl *gomock.Controller) *MockReservation {
+	mock := &MockReservation{ctrl: ctrl}
+	mock.recorder = &MockReservationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockReservation) EXPECT() *MockReservationMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method
+func (m *MockReservation) Create(arg0 context.Context, arg1 interface{}) (string, error) {
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockReservationMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockReservation)(nil).Create), arg0, arg1)
+}
+
+// Fetch mocks base method
+func (m *MockReservation) Fetch(arg0 context.Context, arg1 string) (interface{}, error) {
+	ret := m.ctrl.Call(m, "Fetch", arg0, arg1)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Fetch indicates an expected call of Fetch
+func (mr *MockReservationMockRecorder) Fetch(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockReservation)(nil).Fetch), arg0, arg1)
+}
+
+// RemoveProducts mocks base method
+func (m *MockReservation) RemoveProducts(arg0 context.Context, arg1 []string) error {
+	ret := m.ctrl.Call(m, "RemoveProducts", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveProducts indicates an expected call of RemoveProducts
+func (mr *MockReservationMockRecorder) RemoveProducts(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveProducts", reflect.TypeOf((*MockReservation)(nil).RemoveProducts), arg0, arg1)
+}
+
+// Search mocks base method
+func (m *MockReservation) Search(arg0 context.Context, arg1 repo.Query, arg2, arg3 int) ([]interface{}, error) {
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search
+func (mr *MockReservationMockRecorder) Search(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockReservation)(nil).Search), arg0, arg1, arg2, arg3)
+}
+
+// SearchCount mocks base method
+func (m *MockReservation) SearchCount(arg0 context.Context, arg1 repo.Query) (int, error) {
+	ret := m.ctrl.Call(m, "SearchCount", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchCount indicates an expected call of SearchCount
+func (mr *MockReservationMockRecorder) SearchCount(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCount", reflect.TypeOf((*MockReservation)(nil).SearchCount), arg0, arg1)
+}
+
+// SetStatus mocks base method
+func (m *MockReservation) SetStatus(arg0 context.Context, arg1, arg2, arg3 string) error {
+	ret := m.ctrl.Call(m, "SetStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus
+func (mr *MockReservationMockRecorder) SetStatus(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockReservation)(nil).SetStatus), arg0, arg1, arg2, arg3)
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/msyrus/simple-product-inv/repo (interfaces: Stock)
+
+// Package mock_repo is a generated GoMock package.
+package mock_repo
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	repo "github.com/msyrus/simple-product-inv/repo"
+	reflect "reflect"
+)
+
+// MockStock is a mock of Stock interface
+type MockStock struct {
+	ctrl     *gomock.Controller
+	recorder *MockStockMockRecorder
+}
+
+// MockStockMockRecorder is the mock recorder for MockStock
+type MockStockMockRecorder struct {
+	mock *MockStock
+}
+
+// NewMockStock creates a new mock instance
+func NewMockStock(ctrl *gomock.Controller) *MockStock {
+	mock := &MockStock{ctrl: ctrl}
+	mock.recorder = &MockStockMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockStock) EXPECT() *MockStockMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method
+func (m *MockStock) Create(arg0 context.Context, arg1 interface{}) (string, error) {
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockStockMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockStock)(nil).Create), arg0, arg1)
+}
+
+// RemoveProducts mocks base method
+func (m *MockStock) RemoveProducts(arg0 context.Context, arg1 []string) error {
+	ret := m.ctrl.Call(m, "RemoveProducts", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveProducts indicates an expected call of RemoveProducts
+func (mr *MockStockMockRecorder) RemoveProducts(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveProducts", reflect.TypeOf((*MockStock)(nil).RemoveProducts), arg0, arg1)
+}
+
+// Search mocks base method
+func (m *MockStock) Search(arg0 context.Context, arg1 repo.Query, arg2, arg3 int) ([]interface{}, error) {
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search
+func (mr *MockStockMockRecorder) Search(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockStock)(nil).Search), arg0, arg1, arg2, arg3)
+}
+
+// SearchCount mocks base method
+func (m *MockStock) SearchCount(arg0 context.Context, arg1 repo.Query) (int, error) {
+	ret := m.ctrl.Call(m, "SearchCount", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchCount indicates an expected call of SearchCount
+func (mr *MockStockMockRecorder) SearchCount(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCount", reflect.TypeOf((*MockStock)(nil).SearchCount), arg0, arg1)
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+)
+
+// Category holds the data of a product category
+// categories form a tree through ParentID, a root category has no parent
+type Category struct {
+	ID string
+
+	Name     string
+	ParentID string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Validate checks if the category is valid to store
+// it returns nil if there is no error
+// otherwise it will return ValidationError
+func (c *Category) Validate() error {
+	err := ValidationError{}
+	if c.ID == "" {
+		err.Add("ID", "is required")
+	}
+	if c.Name == "" {
+		err.Add("Name", "is empty")
+	}
+	if c.ID != "" && c.ParentID == c.ID {
+		err.Add("ParentID", "is same as ID")
+	}
+
+	if len(err) == 0 {
+		return nil
+	}
+	return err
+}
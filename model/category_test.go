@@ -0,0 +1,43 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCategory_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *Category
+		err  error
+	}{
+		{
+			c: &Category{},
+			err: ValidationError{
+				"ID":   []string{"is required"},
+				"Name": []string{"is empty"},
+			},
+		},
+		{
+			c: &Category{ID: "1", Name: "Fruits", ParentID: "1"},
+			err: ValidationError{
+				"ParentID": []string{"is same as ID"},
+			},
+		},
+		{
+			c:   &Category{ID: "1", Name: "Fruits"},
+			err: nil,
+		},
+		{
+			c:   &Category{ID: "2", Name: "Mango", ParentID: "1"},
+			err: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.Validate(); !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("Category.Validate() error = %#v, err %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+)
+
+// Location holds the data of a stock location such as a warehouse
+type Location struct {
+	ID string
+
+	Name    string
+	Address string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Validate checks if the location is valid to store
+// it returns nil if there is no error
+// otherwise it will return ValidationError
+func (l *Location) Validate() error {
+	err := ValidationError{}
+	if l.ID == "" {
+		err.Add("ID", "is required")
+	}
+	if l.Name == "" {
+		err.Add("Name", "is empty")
+	}
+
+	if len(err) == 0 {
+		return nil
+	}
+	return err
+}
+
+// StockLevel holds the on-hand quantity of a product at a location
+type StockLevel struct {
+	ProductID string
+	// LocationID is empty for the units not allocated to any location
+	LocationID string
+	Quantity   int
+
+	UpdatedAt time.Time
+}
+
+// Transfer holds a move of units of a product between two locations
+type Transfer struct {
+	// From is empty for a move of the units not allocated to any location
+	From      string
+	To        string
+	Quantity  int
+	Reference string
+}
+
+// Validate checks if the transfer is valid to apply
+// it returns nil if there is no error
+// otherwise it will return ValidationError
+func (t *Transfer) Validate() error {
+	err := ValidationError{}
+	if t.To == "" {
+		err.Add("To", "is empty")
+	}
+	if t.From != "" && t.From == t.To {
+		err.Add("To", "is same as From")
+	}
+	if t.Quantity < 1 {
+		err.Add("Quantity", "is invalid")
+	}
+
+	if len(err) == 0 {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocation_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		l    *Location
+		err  error
+	}{
+		{
+			l: &Location{},
+			err: ValidationError{
+				"ID":   []string{"is required"},
+				"Name": []string{"is empty"},
+			},
+		},
+		{
+			l:   &Location{ID: "1", Name: "Dhaka"},
+			err: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.l.Validate(); !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("Location.Validate() error = %#v, err %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestTransfer_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		t    *Transfer
+		err  error
+	}{
+		{
+			t: &Transfer{},
+			err: ValidationError{
+				"To":       []string{"is empty"},
+				"Quantity": []string{"is invalid"},
+			},
+		},
+		{
+			t: &Transfer{From: "1", To: "1", Quantity: 2},
+			err: ValidationError{
+				"To": []string{"is same as From"},
+			},
+		},
+		{
+			t:   &Transfer{To: "2", Quantity: 2},
+			err: nil,
+		},
+		{
+			t:   &Transfer{From: "1", To: "2", Quantity: 2},
+			err: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.t.Validate(); !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("Transfer.Validate() error = %#v, err %v", err, tt.err)
+			}
+		})
+	}
+}
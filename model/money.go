@@ -0,0 +1,202 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of the product prices unless configured otherwise
+const DefaultCurrency = "USD"
+
+// Money is an amount in the minor unit of an ISO 4217 currency, e.g. cents of USD
+type Money struct {
+	Amount   int
+	Currency string
+}
+
+// currencies maps the active ISO 4217 codes to the number of digits of their minor unit
+var currencies = map[string]int{
+	"AED": 2, "AFN": 2, "ALL": 2, "AMD": 2, "ANG": 2, "AOA": 2, "ARS": 2, "AUD": 2, "AWG": 2, "AZN": 2,
+	"BAM": 2, "BBD": 2, "BDT": 2, "BGN": 2, "BHD": 3, "BIF": 0, "BMD": 2, "BND": 2, "BOB": 2, "BRL": 2,
+	"BSD": 2, "BTN": 2, "BWP": 2, "BYN": 2, "BZD": 2, "CAD": 2, "CDF": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"COP": 2, "CRC": 2, "CUP": 2, "CVE": 2, "CZK": 2, "DJF": 0, "DKK": 2, "DOP": 2, "DZD": 2, "EGP": 2,
+	"ERN": 2, "ETB": 2, "EUR": 2, "FJD": 2, "FKP": 2, "GBP": 2, "GEL": 2, "GHS": 2, "GIP": 2, "GMD": 2,
+	"GNF": 0, "GTQ": 2, "GYD": 2, "HKD": 2, "HNL": 2, "HTG": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2,
+	"IQD": 3, "IRR": 2, "ISK": 0, "JMD": 2, "JOD": 3, "JPY": 0, "KES": 2, "KGS": 2, "KHR": 2, "KMF": 0,
+	"KPW": 2, "KRW": 0, "KWD": 3, "KYD": 2, "KZT": 2, "LAK": 2, "LBP": 2, "LKR": 2, "LRD": 2, "LSL": 2,
+	"LYD": 3, "MAD": 2, "MDL": 2, "MGA": 2, "MKD": 2, "MMK": 2, "MNT": 2, "MOP": 2, "MRU": 2, "MUR": 2,
+	"MVR": 2, "MWK": 2, "MXN": 2, "MYR": 2, "MZN": 2, "NAD": 2, "NGN": 2, "NIO": 2, "NOK": 2, "NPR": 2,
+	"NZD": 2, "OMR": 3, "PAB": 2, "PEN": 2, "PGK": 2, "PHP": 2, "PKR": 2, "PLN": 2, "PYG": 0, "QAR": 2,
+	"RON": 2, "RSD": 2, "RUB": 2, "RWF": 0, "SAR": 2, "SBD": 2, "SCR": 2, "SDG": 2, "SEK": 2, "SGD": 2,
+	"SHP": 2, "SLE": 2, "SOS": 2, "SRD": 2, "SSP": 2, "STN": 2, "SVC": 2, "SYP": 2, "SZL": 2, "THB": 2,
+	"TJS": 2, "TMT": 2, "TND": 3, "TOP": 2, "TRY": 2, "TTD": 2, "TWD": 2, "TZS": 2, "UAH": 2, "UGX": 0,
+	"USD": 2, "UYU": 2, "UZS": 2, "VES": 2, "VND": 0, "VUV": 0, "WST": 2, "XAF": 0, "XCD": 2, "XOF": 0,
+	"XPF": 0, "YER": 2, "ZAR": 2, "ZMW": 2, "ZWL": 2,
+}
+
+// ValidCurrency reports whether code is an active ISO 4217 currency code
+func ValidCurrency(code string) bool {
+	_, ok := currencies[code]
+	return ok
+}
+
+// validPrices adds the first invalid extra price of a product to err
+// every currency must be valid and used once with a positive amount,
+// the price in DefaultCurrency is the Price of the product and not an extra one
+func validPrices(prcs []Money, err ValidationError) {
+	seen := map[string]bool{}
+	for _, m := range prcs {
+		if !ValidCurrency(m.Currency) {
+			err.Add("Prices", "has invalid currency")
+			return
+		}
+		if m.Currency == DefaultCurrency {
+			err.Add("Prices", "has base currency")
+			return
+		}
+		if seen[m.Currency] {
+			err.Add("Prices", "has duplicate currency")
+			return
+		}
+		if m.Amount < 1 {
+			err.Add("Prices", "is invalid")
+			return
+		}
+		seen[m.Currency] = true
+	}
+}
+
+// EncodePrices encodes prcs as a stored string like EUR:1099;GBP:899
+// the prices are sorted by their currency
+func EncodePrices(prcs []Money) string {
+	ps := []string{}
+	for _, m := range prcs {
+		ps = append(ps, m.Currency+":"+strconv.Itoa(m.Amount))
+	}
+	sort.Strings(ps)
+	return strings.Join(ps, ";")
+}
+
+// DecodePrices decodes the prices encoded by EncodePrices
+func DecodePrices(s string) []Money {
+	prcs := []Money{}
+	if s == "" {
+		return prcs
+	}
+	for _, p := range strings.Split(s, ";") {
+		kv := strings.SplitN(p, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		amt, _ := strconv.Atoi(kv[1])
+		prcs = append(prcs, Money{Amount: amt, Currency: kv[0]})
+	}
+	return prcs
+}
+
+// ExchangeRates converts money between currencies through the Base currency
+// Rates maps a currency to its units per a unit of Base, e.g. EUR to 0.92 for USD
+type ExchangeRates struct {
+	Base  string
+	Rates map[string]float64
+}
+
+// Validate checks if the base and the rated currencies are valid
+// and every rate is positive
+func (r ExchangeRates) Validate() error {
+	err := ValidationError{}
+	if !ValidCurrency(r.Base) {
+		err.Add("Base", "is invalid")
+	}
+	for cur, rate := range r.Rates {
+		if !ValidCurrency(cur) || rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			err.Add("Rates", "is invalid")
+			break
+		}
+	}
+
+	if len(err) == 0 {
+		return nil
+	}
+	return err
+}
+
+// rate returns the units of cur per a unit of the base currency
+func (r ExchangeRates) rate(cur string) (float64, bool) {
+	if cur == r.Base {
+		return 1, true
+	}
+	rt, ok := r.Rates[cur]
+	return rt, ok && rt > 0
+}
+
+// Convert returns m in currency to rounded to its minor unit
+// it returns false if any of the currencies has no rate
+func (r ExchangeRates) Convert(m Money, to string) (Money, bool) {
+	if m.Currency == to {
+		return m, true
+	}
+	from, ok := r.rate(m.Currency)
+	if !ok {
+		return Money{}, false
+	}
+	rt, ok := r.rate(to)
+	if !ok {
+		return Money{}, false
+	}
+	// the minor units are scaled before dividing to keep exact halves exact
+	amt := float64(m.Amount) * rt * math.Pow10(currencies[to]) / (from * math.Pow10(currencies[m.Currency]))
+	return Money{
+		Amount:   int(math.Round(amt)),
+		Currency: to,
+	}, true
+}
+
+// PriceIn returns the price of pdt in currency cur
+// a price set for cur is used as is, otherwise the base price is converted by r
+// it returns false if the price can not be converted
+func (r ExchangeRates) PriceIn(pdt Product, cur string) (Money, bool) {
+	if cur != r.Base {
+		for _, m := range pdt.Prices {
+			if m.Currency == cur {
+				return m, true
+			}
+		}
+	}
+	return r.Convert(Money{Amount: pdt.Price, Currency: r.Base}, cur)
+}
+
+// PriceRangeIn returns the price range of the variants of pdt in currency cur
+// the inherited prices follow PriceIn and the overridden ones are converted by r
+// it returns nil if there is no variant and false if a price can not be converted
+func (r ExchangeRates) PriceRangeIn(pdt Product, vrts []Variant, cur string) (*PriceRange, bool) {
+	if len(vrts) == 0 {
+		return nil, true
+	}
+	base, ok := r.PriceIn(pdt, cur)
+	if !ok {
+		return nil, false
+	}
+	var rng *PriceRange
+	for _, vrt := range vrts {
+		prc := base
+		if vrt.Price != 0 {
+			if prc, ok = r.Convert(Money{Amount: vrt.Price, Currency: r.Base}, cur); !ok {
+				return nil, false
+			}
+		}
+		if rng == nil {
+			rng = &PriceRange{Min: prc.Amount, Max: prc.Amount}
+			continue
+		}
+		if prc.Amount < rng.Min {
+			rng.Min = prc.Amount
+		}
+		if prc.Amount > rng.Max {
+			rng.Max = prc.Amount
+		}
+	}
+	return rng, true
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodePrices(t *testing.T) {
+	tests := []struct {
+		name string
+		prcs []Money
+		want string
+	}{
+		{prcs: []Money{}, want: ""},
+		{prcs: []Money{{Amount: 1099, Currency: "EUR"}}, want: "EUR:1099"},
+		{prcs: []Money{{Amount: 899, Currency: "GBP"}, {Amount: 1099, Currency: "EUR"}}, want: "EUR:1099;GBP:899"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EncodePrices(tt.prcs)
+			if got != tt.want {
+				t.Errorf("EncodePrices() = %v, want %v", got, tt.want)
+			}
+			if prcs := DecodePrices(got); len(prcs) != len(tt.prcs) || EncodePrices(prcs) != got {
+				t.Errorf("DecodePrices() = %v, want %v", prcs, tt.prcs)
+			}
+		})
+	}
+}
+
+func TestExchangeRates_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		r    ExchangeRates
+		err  error
+	}{
+		{r: ExchangeRates{}, err: ValidationError{"Base": []string{"is invalid"}}},
+		{r: ExchangeRates{Base: "USD", Rates: map[string]float64{"EUR": 0}}, err: ValidationError{"Rates": []string{"is invalid"}}},
+		{r: ExchangeRates{Base: "USD", Rates: map[string]float64{"XXY": 1}}, err: ValidationError{"Rates": []string{"is invalid"}}},
+		{r: ExchangeRates{Base: "USD", Rates: map[string]float64{"EUR": 0.92}}, err: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.r.Validate(); !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("ExchangeRates.Validate() error = %#v, err %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestExchangeRates_PriceIn(t *testing.T) {
+	rates := ExchangeRates{Base: "USD", Rates: map[string]float64{"EUR": 0.92, "JPY": 150, "KWD": 0.31}}
+	pdt := Product{Price: 1999, Prices: []Money{{Amount: 1799, Currency: "GBP"}}}
+	tests := []struct {
+		name   string
+		cur    string
+		want   Money
+		wantOk bool
+	}{
+		{name: "base", cur: "USD", want: Money{Amount: 1999, Currency: "USD"}, wantOk: true},
+		{name: "set price", cur: "GBP", want: Money{Amount: 1799, Currency: "GBP"}, wantOk: true},
+		{name: "converted", cur: "EUR", want: Money{Amount: 1839, Currency: "EUR"}, wantOk: true},
+		{name: "no minor unit", cur: "JPY", want: Money{Amount: 2999, Currency: "JPY"}, wantOk: true},
+		{name: "three minor digits", cur: "KWD", want: Money{Amount: 6197, Currency: "KWD"}, wantOk: true},
+		{name: "no rate", cur: "CHF", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rates.PriceIn(pdt, tt.cur)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("ExchangeRates.PriceIn() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestExchangeRates_PriceRangeIn(t *testing.T) {
+	rates := ExchangeRates{Base: "USD", Rates: map[string]float64{"EUR": 0.5}}
+	pdt := Product{Price: 100, Prices: []Money{{Amount: 60, Currency: "EUR"}}}
+	tests := []struct {
+		name string
+		vrts []Variant
+		want *PriceRange
+	}{
+		{vrts: nil, want: nil},
+		{vrts: []Variant{{Price: 0}}, want: &PriceRange{Min: 60, Max: 60}},
+		{vrts: []Variant{{Price: 200}, {Price: 0}, {Price: 80}}, want: &PriceRange{Min: 40, Max: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := rates.PriceRangeIn(pdt, tt.vrts, "EUR"); !ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExchangeRates.PriceRangeIn() = %v, %v, want %v", got, ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+)
+
+// PriceStatus is the state of a product price change
+type PriceStatus string
+
+// Price statuses, a change made for now is applied right away
+// and a change made for later is scheduled until it is applied
+// a scheduled change of a deleted product is cancelled
+const (
+	PriceScheduled PriceStatus = "scheduled"
+	PriceApplied   PriceStatus = "applied"
+	PriceCancelled PriceStatus = "cancelled"
+)
+
+// PriceChange holds a price of a product and since when it is effective
+type PriceChange struct {
+	ID string
+
+	ProductID   string
+	Price       int
+	Status      PriceStatus
+	EffectiveAt time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Validate checks if the price change is valid to store
+// it returns nil if there is no error
+// otherwise it will return ValidationError
+func (p *PriceChange) Validate() error {
+	err := ValidationError{}
+	if p.ID == "" {
+		err.Add("ID", "is required")
+	}
+	if p.ProductID == "" {
+		err.Add("ProductID", "is empty")
+	}
+	if p.Price < 1 {
+		err.Add("Price", "is required")
+	}
+	switch p.Status {
+	case PriceScheduled, PriceApplied, PriceCancelled:
+	default:
+		err.Add("Status", "is invalid")
+	}
+	if p.EffectiveAt.IsZero() {
+		err.Add("EffectiveAt", "is required")
+	}
+
+	if len(err) == 0 {
+		return nil
+	}
+	return err
+}
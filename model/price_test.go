@@ -0,0 +1,37 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPriceChange_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *PriceChange
+		err  error
+	}{
+		{
+			p: &PriceChange{},
+			err: ValidationError{
+				"ID":          []string{"is required"},
+				"ProductID":   []string{"is empty"},
+				"Price":       []string{"is required"},
+				"Status":      []string{"is invalid"},
+				"EffectiveAt": []string{"is required"},
+			},
+		},
+		{
+			p:   &PriceChange{ID: "1", ProductID: "2", Price: 100, Status: PriceScheduled, EffectiveAt: time.Now()},
+			err: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.p.Validate(); !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("PriceChange.Validate() error = %#v, err %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -1,17 +1,82 @@
 package model
 
 import (
+	"regexp"
 	"time"
 )
 
+// MaxSKULength is the maximum length of a product SKU
+const MaxSKULength = 64
+
+var (
+	skuRegexp     = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	barcodeRegexp = regexp.MustCompile(`^[0-9]{12,13}$`)
+)
+
+// ProductStatus is the lifecycle state of a product
+type ProductStatus string
+
+// Product statuses, a product is staged as a draft, visible to the public
+// once published and withdrawn from the public when archived
+const (
+	ProductDraft     ProductStatus = "draft"
+	ProductPublished ProductStatus = "published"
+	ProductArchived  ProductStatus = "archived"
+)
+
+// productTransitions holds the statuses a product can move to from every status
+var productTransitions = map[ProductStatus][]ProductStatus{
+	ProductDraft:     {ProductPublished},
+	ProductPublished: {ProductDraft, ProductArchived},
+	ProductArchived:  {ProductPublished},
+}
+
+// Valid reports whether s is a known product status
+func (s ProductStatus) Valid() bool {
+	_, ok := productTransitions[s]
+	return ok
+}
+
+// CanTransition reports whether a product can move from s to status to
+func (s ProductStatus) CanTransition(to ProductStatus) bool {
+	for _, sts := range productTransitions[s] {
+		if sts == to {
+			return true
+		}
+	}
+	return false
+}
+
 // Product holds the data of a product
 type Product struct {
 	ID string
 
-	Name      string
-	Price     int
-	Weight    int
+	Name string
+	// Price is in the minor unit of the base currency
+	Price int
+	// Prices are the optional prices in other currencies, they take
+	// precedence over converting Price by the exchange rates
+	Prices []Money
+	Weight int
+	// SKU is the optional stock keeping unit, it is unique among the products
+	SKU string
+	// Barcode is the optional UPC-A or EAN-13 code, it is unique among the products
+	Barcode string
+	// Quantity is the on-hand stock, it is changed only by stock movements
+	Quantity int
+	// Reserved is the part of Quantity held by pending reservations
+	Reserved int
+	// Available is derived from Quantity exceeding Reserved
 	Available bool
+	// Tags are free-form labels of the product, nil leaves them unchanged on update
+	Tags []string
+
+	// Status is changed only by status transitions once the product is created
+	// an empty Status creates a draft
+	Status ProductStatus
+	// PublishedAt and ArchivedAt are the last times the product was published and archived
+	PublishedAt time.Time
+	ArchivedAt  time.Time
 
 	Deleted bool
 
@@ -34,12 +99,46 @@ func (r *Product) Validate() error {
 	if r.Price < 1 {
 		err.Add("Price", "is required")
 	}
+	validPrices(r.Prices, err)
 	if r.Weight < 1 {
 		err.Add("Weight", "is invalid")
 	}
+	if r.SKU != "" && (len(r.SKU) > MaxSKULength || !skuRegexp.MatchString(r.SKU)) {
+		err.Add("SKU", "is invalid")
+	}
+	if r.Barcode != "" {
+		if !barcodeRegexp.MatchString(r.Barcode) {
+			err.Add("Barcode", "is invalid")
+		} else if !validCheckDigit(r.Barcode) {
+			err.Add("Barcode", "has invalid check digit")
+		}
+	}
+	for _, tag := range r.Tags {
+		if !validTag(tag) {
+			err.Add("Tags", "is invalid")
+			break
+		}
+	}
+	if r.Status != "" && !r.Status.Valid() {
+		err.Add("Status", "is invalid")
+	}
 
 	if len(err) == 0 {
 		return nil
 	}
 	return err
 }
+
+// validCheckDigit verifies the last digit of the UPC-A or EAN-13 code
+// the other digits are weighted 3 and 1 alternately from the right
+func validCheckDigit(code string) bool {
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		d := int(code[i] - '0')
+		if (len(code)-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
@@ -52,6 +52,145 @@ func TestProduct_Validate(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			r: &Product{
+				ID:     "123",
+				Name:   "Test1",
+				Weight: 3,
+				Price:  100,
+				Tags:   []string{"summer", "a,b"},
+			},
+			err: ValidationError{
+				"Tags": []string{"is invalid"},
+			},
+		},
+		{
+			r: &Product{
+				ID:     "123",
+				Name:   "Test1",
+				Weight: 3,
+				Price:  100,
+				Status: "live",
+			},
+			err: ValidationError{
+				"Status": []string{"is invalid"},
+			},
+		},
+		{
+			r: &Product{
+				ID:     "123",
+				Name:   "Test1",
+				Weight: 3,
+				Price:  100,
+				Status: ProductArchived,
+			},
+			err: nil,
+		},
+		{
+			r: &Product{
+				ID:     "123",
+				Name:   "Test1",
+				Weight: 3,
+				Price:  100,
+				Tags:   []string{"summer", "clearance"},
+			},
+			err: nil,
+		},
+		{
+			r: &Product{
+				ID:     "123",
+				Name:   "Test1",
+				Weight: 3,
+				Price:  100,
+				Prices: []Money{{Amount: 90, Currency: "EUR"}, {Amount: 80, Currency: "EUR"}},
+			},
+			err: ValidationError{
+				"Prices": []string{"has duplicate currency"},
+			},
+		},
+		{
+			r: &Product{
+				ID:     "123",
+				Name:   "Test1",
+				Weight: 3,
+				Price:  100,
+				Prices: []Money{{Amount: 90, Currency: "eur"}},
+			},
+			err: ValidationError{
+				"Prices": []string{"has invalid currency"},
+			},
+		},
+		{
+			r: &Product{
+				ID:     "123",
+				Name:   "Test1",
+				Weight: 3,
+				Price:  100,
+				Prices: []Money{{Amount: 90, Currency: "EUR"}, {Amount: 95, Currency: DefaultCurrency}},
+			},
+			err: ValidationError{
+				"Prices": []string{"has base currency"},
+			},
+		},
+		{
+			r: &Product{
+				ID:     "123",
+				Name:   "Test1",
+				Weight: 3,
+				Price:  100,
+				Prices: []Money{{Amount: 90, Currency: "EUR"}, {Amount: 11000, Currency: "JPY"}},
+			},
+			err: nil,
+		},
+		{
+			r: &Product{
+				ID:      "123",
+				Name:    "Test1",
+				Weight:  3,
+				Price:   100,
+				SKU:     "-TS 01",
+				Barcode: "40063813",
+			},
+			err: ValidationError{
+				"SKU":     []string{"is invalid"},
+				"Barcode": []string{"is invalid"},
+			},
+		},
+		{
+			r: &Product{
+				ID:      "123",
+				Name:    "Test1",
+				Weight:  3,
+				Price:   100,
+				SKU:     "TS-01",
+				Barcode: "4006381333932",
+			},
+			err: ValidationError{
+				"Barcode": []string{"has invalid check digit"},
+			},
+		},
+		{
+			r: &Product{
+				ID:      "123",
+				Name:    "Test1",
+				Weight:  3,
+				Price:   100,
+				SKU:     "TS-01",
+				Barcode: "4006381333931",
+			},
+			err: nil,
+		},
+		{
+			r: &Product{
+				ID:      "123",
+				Name:    "Test1",
+				Weight:  3,
+				Price:   100,
+				SKU:     "ts_01.b",
+				Barcode: "036000291452",
+			},
+			err: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -61,3 +200,28 @@ func TestProduct_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestProductStatus_CanTransition(t *testing.T) {
+	tests := []struct {
+		from ProductStatus
+		to   ProductStatus
+		want bool
+	}{
+		{from: ProductDraft, to: ProductPublished, want: true},
+		{from: ProductDraft, to: ProductArchived, want: false},
+		{from: ProductDraft, to: ProductDraft, want: false},
+		{from: ProductPublished, to: ProductArchived, want: true},
+		{from: ProductPublished, to: ProductDraft, want: true},
+		{from: ProductArchived, to: ProductPublished, want: true},
+		{from: ProductArchived, to: ProductDraft, want: false},
+		{from: "live", to: ProductPublished, want: false},
+		{from: ProductDraft, to: "live", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"-"+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransition(tt.to); got != tt.want {
+				t.Errorf("ProductStatus.CanTransition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// PromotionType is the kind of discount a promotion gives
+type PromotionType string
+
+// Promotion types, a percentage promotion takes Value percent off the price,
+// a fixed one takes Value off in the minor unit of the base currency and
+// a buy x get y one gives GetQuantity units free for every BuyQuantity units bought
+const (
+	PromotionPercentage PromotionType = "percentage"
+	PromotionFixed      PromotionType = "fixed"
+	PromotionBuyXGetY   PromotionType = "buy_x_get_y"
+)
+
+// Promotion holds the data of a time-boxed discount
+// it targets the products of ProductIDs, the products of any of CategoryIDs
+// and the products tagged with any of Tags
+type Promotion struct {
+	ID string
+
+	Name  string
+	Type  PromotionType
+	Value int
+
+	BuyQuantity int
+	GetQuantity int
+
+	ProductIDs  []string
+	CategoryIDs []string
+	Tags        []string
+
+	// the promotion is active from StartsAt until EndsAt
+	StartsAt time.Time
+	EndsAt   time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Validate checks if the promotion is valid to store
+// it returns nil if there is no error
+// otherwise it will return ValidationError
+func (p *Promotion) Validate() error {
+	err := ValidationError{}
+	if p.ID == "" {
+		err.Add("ID", "is required")
+	}
+	if p.Name == "" {
+		err.Add("Name", "is empty")
+	}
+	switch p.Type {
+	case PromotionPercentage:
+		if p.Value < 1 || p.Value > 100 {
+			err.Add("Value", "is invalid")
+		}
+	case PromotionFixed:
+		if p.Value < 1 {
+			err.Add("Value", "is invalid")
+		}
+	case PromotionBuyXGetY:
+		if p.BuyQuantity < 1 {
+			err.Add("BuyQuantity", "is invalid")
+		}
+		if p.GetQuantity < 1 {
+			err.Add("GetQuantity", "is invalid")
+		}
+	default:
+		err.Add("Type", "is invalid")
+	}
+	if len(p.ProductIDs) == 0 && len(p.CategoryIDs) == 0 && len(p.Tags) == 0 {
+		err.Add("Targets", "is empty")
+	}
+	for _, id := range append(append([]string{}, p.ProductIDs...), p.CategoryIDs...) {
+		if id == "" || strings.Contains(id, ",") {
+			err.Add("Targets", "is invalid")
+			break
+		}
+	}
+	for _, tag := range p.Tags {
+		if !validTag(tag) {
+			err.Add("Tags", "is invalid")
+			break
+		}
+	}
+	if p.StartsAt.IsZero() {
+		err.Add("StartsAt", "is required")
+	}
+	if p.EndsAt.IsZero() {
+		err.Add("EndsAt", "is required")
+	} else if !p.EndsAt.After(p.StartsAt) {
+		err.Add("EndsAt", "is before StartsAt")
+	}
+
+	if len(err) == 0 {
+		return nil
+	}
+	return err
+}
+
+// Active reports whether the promotion is active at t
+func (p *Promotion) Active(t time.Time) bool {
+	return !t.Before(p.StartsAt) && t.Before(p.EndsAt)
+}
+
+// Targets reports whether the promotion targets pdt assigned to categories catIDs
+func (p *Promotion) Targets(pdt Product, catIDs []string) bool {
+	return containsAny(p.ProductIDs, []string{pdt.ID}) ||
+		containsAny(p.CategoryIDs, catIDs) ||
+		containsAny(p.Tags, pdt.Tags)
+}
+
+// Discount returns the discount of the promotion on a single unit of price
+// a fixed discount is converted by rates and never exceeds the price
+// a buy x get y promotion gives no discount on a single unit
+func (p *Promotion) Discount(price Money, rates ExchangeRates) int {
+	switch p.Type {
+	case PromotionPercentage:
+		return price.Amount * p.Value / 100
+	case PromotionFixed:
+		off, ok := rates.Convert(Money{Amount: p.Value, Currency: rates.Base}, price.Currency)
+		if !ok {
+			return 0
+		}
+		if off.Amount > price.Amount {
+			return price.Amount
+		}
+		return off.Amount
+	}
+	return 0
+}
+
+// Pricing holds the price of a product and its effective price
+// after the promotions of PromotionIDs are applied
+type Pricing struct {
+	Price        Money
+	Effective    Money
+	PromotionIDs []string
+}
+
+// ApplyPromotions returns the pricing of price with the promotions prms
+// promotions do not stack, the one with the highest discount is applied
+// and the buy x get y promotions are listed as they apply on checkout
+func ApplyPromotions(price Money, prms []Promotion, rates ExchangeRates) Pricing {
+	pr := Pricing{
+		Price:        price,
+		Effective:    price,
+		PromotionIDs: []string{},
+	}
+	best, bestID := 0, ""
+	for _, prm := range prms {
+		if prm.Type == PromotionBuyXGetY {
+			pr.PromotionIDs = append(pr.PromotionIDs, prm.ID)
+			continue
+		}
+		if off := prm.Discount(price, rates); off > best {
+			best, bestID = off, prm.ID
+		}
+	}
+	if bestID != "" {
+		pr.Effective.Amount -= best
+		pr.PromotionIDs = append([]string{bestID}, pr.PromotionIDs...)
+	}
+	return pr
+}
+
+// containsAny reports whether any of vals is in list
+func containsAny(list, vals []string) bool {
+	for _, l := range list {
+		for _, v := range vals {
+			if l == v {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPromotion_Validate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		p    *Promotion
+		err  error
+	}{
+		{
+			p: &Promotion{},
+			err: ValidationError{
+				"ID":       []string{"is required"},
+				"Name":     []string{"is empty"},
+				"Type":     []string{"is invalid"},
+				"Targets":  []string{"is empty"},
+				"StartsAt": []string{"is required"},
+				"EndsAt":   []string{"is required"},
+			},
+		},
+		{
+			p: &Promotion{ID: "1", Name: "Sale", Type: PromotionPercentage, Value: 120, Tags: []string{"a,b"}, StartsAt: now, EndsAt: now},
+			err: ValidationError{
+				"Value":  []string{"is invalid"},
+				"Tags":   []string{"is invalid"},
+				"EndsAt": []string{"is before StartsAt"},
+			},
+		},
+		{
+			p: &Promotion{ID: "1", Name: "Sale", Type: PromotionBuyXGetY, BuyQuantity: 2, ProductIDs: []string{""}, StartsAt: now, EndsAt: now.Add(time.Hour)},
+			err: ValidationError{
+				"GetQuantity": []string{"is invalid"},
+				"Targets":     []string{"is invalid"},
+			},
+		},
+		{
+			p:   &Promotion{ID: "1", Name: "Sale", Type: PromotionFixed, Value: 500, CategoryIDs: []string{"2"}, StartsAt: now, EndsAt: now.Add(time.Hour)},
+			err: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.p.Validate(); !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("Promotion.Validate() error = %#v, err %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPromotion_Targets(t *testing.T) {
+	pdt := Product{ID: "1", Tags: []string{"summer"}}
+	tests := []struct {
+		name   string
+		p      Promotion
+		catIDs []string
+		want   bool
+	}{
+		{name: "product", p: Promotion{ProductIDs: []string{"2", "1"}}, want: true},
+		{name: "category", p: Promotion{CategoryIDs: []string{"c1"}}, catIDs: []string{"c2", "c1"}, want: true},
+		{name: "tag", p: Promotion{Tags: []string{"summer"}}, want: true},
+		{name: "none", p: Promotion{ProductIDs: []string{"2"}, CategoryIDs: []string{"c1"}, Tags: []string{"winter"}}, catIDs: []string{"c2"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Targets(pdt, tt.catIDs); got != tt.want {
+				t.Errorf("Promotion.Targets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPromotions(t *testing.T) {
+	rates := ExchangeRates{Base: "USD", Rates: map[string]float64{"EUR": 0.5}}
+	pct := Promotion{ID: "pct", Type: PromotionPercentage, Value: 10}
+	fix := Promotion{ID: "fix", Type: PromotionFixed, Value: 300}
+	big := Promotion{ID: "big", Type: PromotionFixed, Value: 5000}
+	bxgy := Promotion{ID: "bxgy", Type: PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1}
+	tests := []struct {
+		name  string
+		price Money
+		prms  []Promotion
+		want  Pricing
+	}{
+		{
+			name:  "none",
+			price: Money{Amount: 1000, Currency: "USD"},
+			want:  Pricing{Price: Money{Amount: 1000, Currency: "USD"}, Effective: Money{Amount: 1000, Currency: "USD"}, PromotionIDs: []string{}},
+		},
+		{
+			name:  "best of",
+			price: Money{Amount: 1000, Currency: "USD"},
+			prms:  []Promotion{pct, fix, bxgy},
+			want:  Pricing{Price: Money{Amount: 1000, Currency: "USD"}, Effective: Money{Amount: 700, Currency: "USD"}, PromotionIDs: []string{"fix", "bxgy"}},
+		},
+		{
+			name:  "converted fixed",
+			price: Money{Amount: 1000, Currency: "EUR"},
+			prms:  []Promotion{fix},
+			want:  Pricing{Price: Money{Amount: 1000, Currency: "EUR"}, Effective: Money{Amount: 850, Currency: "EUR"}, PromotionIDs: []string{"fix"}},
+		},
+		{
+			name:  "free",
+			price: Money{Amount: 1000, Currency: "USD"},
+			prms:  []Promotion{big},
+			want:  Pricing{Price: Money{Amount: 1000, Currency: "USD"}, Effective: Money{Amount: 0, Currency: "USD"}, PromotionIDs: []string{"big"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyPromotions(tt.price, tt.prms, rates); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyPromotions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
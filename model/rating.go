@@ -1,17 +1,117 @@
 package model
 
 import (
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
-// Rating holds the data of a product rating
+// review text limits in characters
+const (
+	MaxReviewTitle = 120
+	MaxReviewBody  = 4000
+)
+
+// RatingStatus is the moderation state of a rating
+type RatingStatus string
+
+// Rating statuses, a rating with review text is pending until a moderator
+// approves or rejects it, only the approved ratings are public and averaged
+const (
+	RatingPending  RatingStatus = "pending"
+	RatingApproved RatingStatus = "approved"
+	RatingRejected RatingStatus = "rejected"
+)
+
+// ratingTransitions holds the statuses a rating can move to from every status
+var ratingTransitions = map[RatingStatus][]RatingStatus{
+	RatingPending:  {RatingApproved, RatingRejected},
+	RatingApproved: {RatingRejected},
+	RatingRejected: {RatingApproved},
+}
+
+// Valid reports whether s is a known rating status
+func (s RatingStatus) Valid() bool {
+	_, ok := ratingTransitions[s]
+	return ok
+}
+
+// CanTransition reports whether a rating can move from s to status to
+func (s RatingStatus) CanTransition(to RatingStatus) bool {
+	for _, sts := range ratingTransitions[s] {
+		if sts == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Rating holds the data of a product rating by a user
+// a user rates a product once, the optional title and body are the review text
 type Rating struct {
 	ID string
 
 	ProductID string
+	User      User
 	Value     int
+	Title     string
+	Body      string
+
+	// an empty Status creates an approved rating
+	Status RatingStatus
+	// Reason is why a pending rating is flagged or a rejected one is rejected
+	Reason string
 
 	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// HasReview reports whether the rating has review text
+func (r *Rating) HasReview() bool {
+	return r.Title != "" || r.Body != ""
+}
+
+// Update replaces the value and review text of r with the ones of rec
+func (r *Rating) Update(rec Rating) {
+	r.Value = rec.Value
+	r.Title = rec.Title
+	r.Body = rec.Body
+}
+
+// RatingSummary holds the aggregated ratings of a product
+// Histogram holds the number of ratings of every value, 1 star first
+type RatingSummary struct {
+	ProductID string
+	Count     int
+	Sum       int
+	Avg       float64
+	Histogram [5]int
+}
+
+// Add adds a rating of value to the summary
+func (s *RatingSummary) Add(value int) {
+	if value < 1 || value > 5 {
+		return
+	}
+	s.Count++
+	s.Sum += value
+	s.Histogram[value-1]++
+	s.Avg = float64(s.Sum) / float64(s.Count)
+}
+
+// Remove takes a rating of value out of the summary
+func (s *RatingSummary) Remove(value int) {
+	if value < 1 || value > 5 || s.Histogram[value-1] == 0 {
+		return
+	}
+	s.Count--
+	s.Sum -= value
+	s.Histogram[value-1]--
+	s.Avg = 0
+	if s.Count != 0 {
+		s.Avg = float64(s.Sum) / float64(s.Count)
+	}
 }
 
 // Validate checks if the rating is valid to store
@@ -25,12 +125,48 @@ func (r *Rating) Validate() error {
 	if r.ProductID == "" {
 		err.Add("ProductID", "is empty")
 	}
+	if r.User.ID == "" {
+		err.Add("User", "is required")
+	}
 	if r.Value < 1 || r.Value > 5 {
 		err.Add("Value", "is invalid")
 	}
+	if utf8.RuneCountInString(r.Title) > MaxReviewTitle {
+		err.Add("Title", "is too long")
+	}
+	if utf8.RuneCountInString(r.Body) > MaxReviewBody {
+		err.Add("Body", "is too long")
+	}
+	if r.Status != "" && !r.Status.Valid() {
+		err.Add("Status", "is invalid")
+	}
 
 	if len(err) == 0 {
 		return nil
 	}
 	return err
 }
+
+// BannedWords holds the words and phrases a review is flagged for
+// they match whole words of the review text regardless of case
+type BannedWords []string
+
+// Find returns the banned words found in texts in the order of b
+func (b BannedWords) Find(texts ...string) []string {
+	txt := " " + normalizeWords(strings.Join(texts, " ")) + " "
+	found := []string{}
+	for _, w := range b {
+		if nw := normalizeWords(w); nw != "" && strings.Contains(txt, " "+nw+" ") {
+			found = append(found, w)
+		}
+	}
+	return found
+}
+
+// normalizeWords returns the lower case words of s separated by single spaces
+func normalizeWords(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
+}
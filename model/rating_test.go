@@ -2,6 +2,7 @@ package model
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -16,32 +17,50 @@ func TestRating_Validate(t *testing.T) {
 			err: ValidationError{
 				"ID":        []string{"is required"},
 				"ProductID": []string{"is empty"},
+				"User":      []string{"is required"},
 				"Value":     []string{"is invalid"},
 			},
 		},
 		{
-			r: &Rating{ID: "123"},
+			r: &Rating{ID: "123", User: User{ID: "u1"}},
 			err: ValidationError{
 				"ProductID": []string{"is empty"},
 				"Value":     []string{"is invalid"},
 			},
 		},
 		{
-			r: &Rating{ID: "123", ProductID: "987", Value: 0},
+			r: &Rating{ID: "123", ProductID: "987", User: User{ID: "u1"}, Value: 0},
 			err: ValidationError{
 				"Value": []string{"is invalid"},
 			},
 		},
 		{
-			r: &Rating{ID: "123", ProductID: "987", Value: 6},
+			r: &Rating{ID: "123", ProductID: "987", User: User{ID: "u1"}, Value: 6},
 			err: ValidationError{
 				"Value": []string{"is invalid"},
 			},
 		},
 		{
-			r:   &Rating{ID: "123", ProductID: "987", Value: 3},
+			r:   &Rating{ID: "123", ProductID: "987", User: User{ID: "u1"}, Value: 3},
 			err: nil,
 		},
+		{
+			r:   &Rating{ID: "123", ProductID: "987", User: User{ID: "u1"}, Value: 3, Title: "Fits well", Body: "Warm enough for the winter", Status: RatingPending},
+			err: nil,
+		},
+		{
+			r: &Rating{ID: "123", ProductID: "987", User: User{ID: "u1"}, Value: 3, Status: "hidden"},
+			err: ValidationError{
+				"Status": []string{"is invalid"},
+			},
+		},
+		{
+			r: &Rating{ID: "123", ProductID: "987", User: User{ID: "u1"}, Value: 3, Title: strings.Repeat("é", MaxReviewTitle+1), Body: strings.Repeat("a", MaxReviewBody+1)},
+			err: ValidationError{
+				"Title": []string{"is too long"},
+				"Body":  []string{"is too long"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -51,3 +70,79 @@ func TestRating_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestRatingSummary_Add(t *testing.T) {
+	s := RatingSummary{ProductID: "1"}
+	for _, v := range []int{5, 4, 5, 0, 6} {
+		s.Add(v)
+	}
+	want := RatingSummary{ProductID: "1", Count: 3, Sum: 14, Avg: 14.0 / 3, Histogram: [5]int{0, 0, 0, 1, 2}}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("RatingSummary.Add() = %+v, want %+v", s, want)
+	}
+}
+
+func TestRatingSummary_Remove(t *testing.T) {
+	s := RatingSummary{ProductID: "1"}
+	for _, v := range []int{5, 4, 5} {
+		s.Add(v)
+	}
+	for _, v := range []int{5, 3, 0} {
+		s.Remove(v)
+	}
+	want := RatingSummary{ProductID: "1", Count: 2, Sum: 9, Avg: 4.5, Histogram: [5]int{0, 0, 0, 1, 1}}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("RatingSummary.Remove() = %+v, want %+v", s, want)
+	}
+
+	s.Remove(4)
+	s.Remove(5)
+	want = RatingSummary{ProductID: "1"}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("RatingSummary.Remove() all = %+v, want %+v", s, want)
+	}
+}
+
+func TestRatingStatus_CanTransition(t *testing.T) {
+	tests := []struct {
+		from, to RatingStatus
+		want     bool
+	}{
+		{from: RatingPending, to: RatingApproved, want: true},
+		{from: RatingPending, to: RatingRejected, want: true},
+		{from: RatingApproved, to: RatingRejected, want: true},
+		{from: RatingRejected, to: RatingApproved, want: true},
+		{from: RatingApproved, to: RatingPending},
+		{from: RatingApproved, to: RatingApproved},
+		{from: "hidden", to: RatingApproved},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransition(tt.to); got != tt.want {
+				t.Errorf("RatingStatus.CanTransition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBannedWords_Find(t *testing.T) {
+	b := BannedWords{"scam", "Rip Off", "  "}
+	tests := []struct {
+		name  string
+		texts []string
+		want  []string
+	}{
+		{name: "none", texts: []string{"Warm", "Warm enough"}, want: []string{}},
+		{name: "any case", texts: []string{"SCAM!", "fine"}, want: []string{"scam"}},
+		{name: "phrase across spaces", texts: []string{"", "a total rip-off,  really"}, want: []string{"Rip Off"}},
+		{name: "whole words only", texts: []string{"scampi", "ripoff"}, want: []string{}},
+		{name: "many", texts: []string{"rip off", "scam"}, want: []string{"scam", "Rip Off"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.Find(tt.texts...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BannedWords.Find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
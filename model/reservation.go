@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+)
+
+// ReservationStatus is the state of a stock reservation
+type ReservationStatus string
+
+// Reservation statuses, a reservation starts pending and
+// moves to exactly one of the others
+const (
+	ReservationPending   ReservationStatus = "pending"
+	ReservationConfirmed ReservationStatus = "confirmed"
+	ReservationCancelled ReservationStatus = "cancelled"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+// Reservation holds units of a product while a checkout completes
+type Reservation struct {
+	ID string
+
+	ProductID string
+	Quantity  int
+	Status    ReservationStatus
+	// ExpiresAt is when a pending reservation releases its units
+	ExpiresAt time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Expired checks if a pending reservation has passed its expiry at now
+func (r *Reservation) Expired(now time.Time) bool {
+	return r.Status == ReservationPending && !now.Before(r.ExpiresAt)
+}
+
+// Validate checks if the reservation is valid to store
+// it returns nil if there is no error
+// otherwise it will return ValidationError
+func (r *Reservation) Validate() error {
+	err := ValidationError{}
+	if r.ID == "" {
+		err.Add("ID", "is required")
+	}
+	if r.ProductID == "" {
+		err.Add("ProductID", "is empty")
+	}
+	if r.Quantity < 1 {
+		err.Add("Quantity", "is invalid")
+	}
+	switch r.Status {
+	case ReservationPending, ReservationConfirmed, ReservationCancelled, ReservationExpired:
+	default:
+		err.Add("Status", "is invalid")
+	}
+	if r.ExpiresAt.IsZero() {
+		err.Add("ExpiresAt", "is required")
+	}
+
+	if len(err) == 0 {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReservation_Expired(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		r    *Reservation
+		want bool
+	}{
+		{
+			r:    &Reservation{Status: ReservationPending, ExpiresAt: now.Add(time.Minute)},
+			want: false,
+		},
+		{
+			r:    &Reservation{Status: ReservationPending, ExpiresAt: now},
+			want: true,
+		},
+		{
+			r:    &Reservation{Status: ReservationConfirmed, ExpiresAt: now.Add(-time.Minute)},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Expired(now); got != tt.want {
+				t.Errorf("Reservation.Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReservation_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		r    *Reservation
+		err  error
+	}{
+		{
+			r: &Reservation{},
+			err: ValidationError{
+				"ID":        []string{"is required"},
+				"ProductID": []string{"is empty"},
+				"Quantity":  []string{"is invalid"},
+				"Status":    []string{"is invalid"},
+				"ExpiresAt": []string{"is required"},
+			},
+		},
+		{
+			r: &Reservation{ID: "1", ProductID: "2", Quantity: 0, Status: "held", ExpiresAt: time.Now()},
+			err: ValidationError{
+				"Quantity": []string{"is invalid"},
+				"Status":   []string{"is invalid"},
+			},
+		},
+		{
+			r:   &Reservation{ID: "1", ProductID: "2", Quantity: 3, Status: ReservationPending, ExpiresAt: time.Now()},
+			err: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.r.Validate(); !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("Reservation.Validate() error = %#v, err %v", err, tt.err)
+			}
+		})
+	}
+}
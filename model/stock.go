@@ -0,0 +1,97 @@
+package model
+
+import (
+	"time"
+)
+
+// MovementType is the kind of a stock movement
+type MovementType string
+
+// Stock movement types
+const (
+	MovementReceipt    MovementType = "receipt"
+	MovementSale       MovementType = "sale"
+	MovementAdjustment MovementType = "adjustment"
+	MovementReturn     MovementType = "return"
+	// MovementTransfer moves units between locations, it comes in
+	// pairs that cancel out on the quantity of the product
+	MovementTransfer MovementType = "transfer"
+)
+
+// StockMovement holds a single change of the on-hand quantity of a product
+type StockMovement struct {
+	ID string
+
+	ProductID string
+	// LocationID is the location the units moved at, it is optional
+	// except for transfers
+	LocationID string
+	Type       MovementType
+	// Quantity is the number of units moved, it is signed for adjustments
+	// and transfers and positive for every other type
+	Quantity  int
+	Reason    string
+	Reference string
+
+	CreatedAt time.Time
+}
+
+// Delta returns the change the movement makes to the on-hand quantity
+func (m *StockMovement) Delta() int {
+	if m.Type == MovementSale {
+		return -m.Quantity
+	}
+	return m.Quantity
+}
+
+// Validate checks if the stock movement is valid to store
+// it returns nil if there is no error
+// otherwise it will return ValidationError
+func (m *StockMovement) Validate() error {
+	err := ValidationError{}
+	if m.ID == "" {
+		err.Add("ID", "is required")
+	}
+	if m.ProductID == "" {
+		err.Add("ProductID", "is empty")
+	}
+	switch m.Type {
+	case MovementReceipt, MovementSale, MovementReturn:
+		if m.Quantity < 1 {
+			err.Add("Quantity", "is invalid")
+		}
+	case MovementAdjustment:
+		if m.Quantity == 0 {
+			err.Add("Quantity", "is invalid")
+		}
+		if m.Reason == "" {
+			err.Add("Reason", "is required")
+		}
+	case MovementTransfer:
+		if m.Quantity == 0 {
+			err.Add("Quantity", "is invalid")
+		}
+		// a transfer out may take the unallocated units, one in is located
+		if m.LocationID == "" && m.Quantity > 0 {
+			err.Add("LocationID", "is required")
+		}
+	default:
+		err.Add("Type", "is invalid")
+	}
+
+	if len(err) == 0 {
+		return nil
+	}
+	return err
+}
+
+// Stock holds the current on-hand quantity of a product
+type Stock struct {
+	ProductID string
+	Quantity  int
+	// Reserved is the part of Quantity held by pending reservations
+	Reserved  int
+	Available bool
+	// Levels are the quantities per location
+	Levels []StockLevel
+}
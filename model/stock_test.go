@@ -0,0 +1,97 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStockMovement_Delta(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *StockMovement
+		want int
+	}{
+		{
+			m:    &StockMovement{Type: MovementReceipt, Quantity: 5},
+			want: 5,
+		},
+		{
+			m:    &StockMovement{Type: MovementSale, Quantity: 5},
+			want: -5,
+		},
+		{
+			m:    &StockMovement{Type: MovementReturn, Quantity: 2},
+			want: 2,
+		},
+		{
+			m:    &StockMovement{Type: MovementAdjustment, Quantity: -3},
+			want: -3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Delta(); got != tt.want {
+				t.Errorf("StockMovement.Delta() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStockMovement_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *StockMovement
+		err  error
+	}{
+		{
+			m: &StockMovement{},
+			err: ValidationError{
+				"ID":        []string{"is required"},
+				"ProductID": []string{"is empty"},
+				"Type":      []string{"is invalid"},
+			},
+		},
+		{
+			m: &StockMovement{ID: "1", ProductID: "2", Type: MovementSale, Quantity: -1},
+			err: ValidationError{
+				"Quantity": []string{"is invalid"},
+			},
+		},
+		{
+			m: &StockMovement{ID: "1", ProductID: "2", Type: MovementAdjustment},
+			err: ValidationError{
+				"Quantity": []string{"is invalid"},
+				"Reason":   []string{"is required"},
+			},
+		},
+		{
+			m:   &StockMovement{ID: "1", ProductID: "2", Type: MovementAdjustment, Quantity: -1, Reason: "damaged"},
+			err: nil,
+		},
+		{
+			m:   &StockMovement{ID: "1", ProductID: "2", Type: MovementReceipt, Quantity: 10},
+			err: nil,
+		},
+		{
+			m: &StockMovement{ID: "1", ProductID: "2", Type: MovementTransfer, Quantity: 2},
+			err: ValidationError{
+				"LocationID": []string{"is required"},
+			},
+		},
+		{
+			m:   &StockMovement{ID: "1", ProductID: "2", Type: MovementTransfer, Quantity: -2},
+			err: nil,
+		},
+		{
+			m:   &StockMovement{ID: "1", ProductID: "2", LocationID: "3", Type: MovementTransfer, Quantity: -2},
+			err: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.m.Validate(); !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("StockMovement.Validate() error = %#v, err %v", err, tt.err)
+			}
+		})
+	}
+}
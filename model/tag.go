@@ -0,0 +1,40 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// MaxTagLength is the maximum length of a product tag
+const MaxTagLength = 40
+
+// TagCount holds the number of products a tag is used by
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// NormalizeTags returns tags trimmed and lower cased in ascending order
+// empty and repeated tags are dropped, it returns nil for nil tags
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	res := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		res = append(res, tag)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// validTag checks if tag fits in the tag column and can be listed comma separated
+func validTag(tag string) bool {
+	return tag != "" && len(tag) <= MaxTagLength && !strings.Contains(tag, ",")
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{tags: nil, want: nil},
+		{tags: []string{}, want: []string{}},
+		{tags: []string{" Summer", "clearance", "", "summer ", "  "}, want: []string{"clearance", "summer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeTags(tt.tags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeTags() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package model
 
 // User represents the user model
+// Name is the display name the user is shown by
 type User struct {
-	ID string
+	ID   string
+	Name string
 }
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxOptionLength is the maximum length of a variant option axis or value
+const MaxOptionLength = 40
+
+// Variant holds the data of a variant of a product such as a size and colour
+// Options maps the option axes to their values, e.g. size to M
+type Variant struct {
+	ID        string
+	ProductID string
+
+	Options map[string]string
+	SKU     string
+	// Price overrides the product price if it is not zero
+	Price int
+	// Weight overrides the product weight if it is not zero
+	Weight   int
+	Quantity int
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Validate checks if the variant is valid to store
+// it returns nil if there is no error
+// otherwise it will return ValidationError
+func (v *Variant) Validate() error {
+	err := ValidationError{}
+	if v.ID == "" {
+		err.Add("ID", "is required")
+	}
+	if v.ProductID == "" {
+		err.Add("ProductID", "is required")
+	}
+	if len(v.Options) == 0 {
+		err.Add("Options", "is empty")
+	}
+	for axis, val := range v.Options {
+		if !validOption(axis) || !validOption(val) {
+			err.Add("Options", "is invalid")
+			break
+		}
+	}
+	if v.SKU != "" && (len(v.SKU) > MaxSKULength || !skuRegexp.MatchString(v.SKU)) {
+		err.Add("SKU", "is invalid")
+	}
+	if v.Price < 0 {
+		err.Add("Price", "is invalid")
+	}
+	if v.Weight < 0 {
+		err.Add("Weight", "is invalid")
+	}
+	if v.Quantity < 0 {
+		err.Add("Quantity", "is invalid")
+	}
+
+	if len(err) == 0 {
+		return nil
+	}
+	return err
+}
+
+// EffectivePrice returns the price of the variant of product pdt
+func (v *Variant) EffectivePrice(pdt Product) int {
+	if v.Price != 0 {
+		return v.Price
+	}
+	return pdt.Price
+}
+
+// NormalizeOptions returns opts with trimmed values and trimmed lower cased axes
+// it returns nil for nil opts
+func NormalizeOptions(opts map[string]string) map[string]string {
+	if opts == nil {
+		return nil
+	}
+	res := map[string]string{}
+	for axis, val := range opts {
+		res[strings.ToLower(strings.TrimSpace(axis))] = strings.TrimSpace(val)
+	}
+	return res
+}
+
+// EncodeOptions returns opts as axis=value pairs joined by ; in ascending order of axis
+// equal options always have the same encoding
+func EncodeOptions(opts map[string]string) string {
+	axes := []string{}
+	for axis := range opts {
+		axes = append(axes, axis)
+	}
+	sort.Strings(axes)
+	pairs := []string{}
+	for _, axis := range axes {
+		pairs = append(pairs, axis+"="+opts[axis])
+	}
+	return strings.Join(pairs, ";")
+}
+
+// DecodeOptions returns the options encoded by EncodeOptions
+func DecodeOptions(s string) map[string]string {
+	opts := map[string]string{}
+	if s == "" {
+		return opts
+	}
+	for _, pair := range strings.Split(s, ";") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 {
+			opts[kv[0]] = kv[1]
+		}
+	}
+	return opts
+}
+
+// validOption checks if s fits in the options column and can be encoded
+func validOption(s string) bool {
+	return s != "" && len(s) <= MaxOptionLength && !strings.ContainsAny(s, "=;")
+}
+
+// PriceRange holds the lowest and highest price of the variants of a product
+type PriceRange struct {
+	Min int
+	Max int
+}
+
+// PriceRangeOf returns the price range of the variants vrts of pdt
+// it returns nil if there is no variant
+func PriceRangeOf(pdt Product, vrts []Variant) *PriceRange {
+	if len(vrts) == 0 {
+		return nil
+	}
+	rng := &PriceRange{Min: vrts[0].EffectivePrice(pdt), Max: vrts[0].EffectivePrice(pdt)}
+	for _, vrt := range vrts[1:] {
+		prc := vrt.EffectivePrice(pdt)
+		if prc < rng.Min {
+			rng.Min = prc
+		}
+		if prc > rng.Max {
+			rng.Max = prc
+		}
+	}
+	return rng
+}
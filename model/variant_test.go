@@ -0,0 +1,87 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVariant_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		v    *Variant
+		err  error
+	}{
+		{
+			v: &Variant{},
+			err: ValidationError{
+				"ID":        []string{"is required"},
+				"ProductID": []string{"is required"},
+				"Options":   []string{"is empty"},
+			},
+		},
+		{
+			v: &Variant{ID: "1", ProductID: "2", Options: map[string]string{"size": "M=L"}, SKU: "-1", Price: -1, Weight: -1, Quantity: -1},
+			err: ValidationError{
+				"Options":  []string{"is invalid"},
+				"SKU":      []string{"is invalid"},
+				"Price":    []string{"is invalid"},
+				"Weight":   []string{"is invalid"},
+				"Quantity": []string{"is invalid"},
+			},
+		},
+		{
+			v:   &Variant{ID: "1", ProductID: "2", Options: map[string]string{"size": "M", "colour": "red"}, SKU: "TS-01-M"},
+			err: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.v.Validate(); !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("Variant.Validate() error = %#v, err %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEncodeOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts map[string]string
+		want string
+	}{
+		{opts: map[string]string{}, want: ""},
+		{opts: map[string]string{"size": "M"}, want: "size=M"},
+		{opts: map[string]string{"size": "M", "colour": "red"}, want: "colour=red;size=M"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EncodeOptions(tt.opts)
+			if got != tt.want {
+				t.Errorf("EncodeOptions() = %v, want %v", got, tt.want)
+			}
+			if opts := DecodeOptions(got); !reflect.DeepEqual(opts, tt.opts) {
+				t.Errorf("DecodeOptions() = %v, want %v", opts, tt.opts)
+			}
+		})
+	}
+}
+
+func TestPriceRangeOf(t *testing.T) {
+	pdt := Product{ID: "1", Price: 100}
+	tests := []struct {
+		name string
+		vrts []Variant
+		want *PriceRange
+	}{
+		{vrts: nil, want: nil},
+		{vrts: []Variant{{Price: 0}}, want: &PriceRange{Min: 100, Max: 100}},
+		{vrts: []Variant{{Price: 120}, {Price: 0}, {Price: 80}}, want: &PriceRange{Min: 80, Max: 120}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PriceRangeOf(pdt, tt.vrts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PriceRangeOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/model"
+)
+
+// Category interface is the repo wrapper of product category
+// categories are searched by parent_id, an empty parent_id matches the root categories
+type Category interface {
+	Creator
+	Fetcher
+	Updater
+	Deleter
+	Lister
+	Counter
+	Searcher
+	CategoryAssigner
+	DescendantFinder
+	TreeLocker
+}
+
+// categoryColumns are the selected columns of a category in scan order
+const categoryColumns = `"id", "name", "parent_id", "created_at", "updated_at"`
+
+func scanCategory(row infra.Row) (model.Category, error) {
+	cat := model.Category{}
+	err := row.Scan(&cat.ID, &cat.Name, &cat.ParentID, &cat.CreatedAt, &cat.UpdatedAt)
+	return cat, err
+}
+
+// Curator is an implementation of Category
+type Curator struct {
+	table string
+	// assigns is the table of the product category assignments
+	assigns string
+	db      infra.DB
+}
+
+// NewCurator returns a new Curator with table name tab
+// the assignments are kept in the default product category table
+// it returns ErrInvalidTable if tab is not a valid sql identifier
+func NewCurator(tab string, db infra.DB) (*Curator, error) {
+	if !isIdent(tab) {
+		return nil, ErrInvalidTable
+	}
+	return &Curator{
+		table:   tab,
+		assigns: DefaultTables.ProductCategories,
+		db:      db,
+	}, nil
+}
+
+// Create creates a new category
+func (c *Curator) Create(ctx context.Context, v interface{}) (string, error) {
+	ctx = infra.WithOperation(ctx, "category.create")
+	cat, ok := v.(model.Category)
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+	cat.ID = uuid.NewV4().String()
+
+	if err := cat.Validate(); err != nil {
+		return "", err
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %s ("id", "name", "parent_id") VALUES($1, $2, $3)`, c.table)
+	if err := c.db.Exec(ctx, stmt, cat.ID, cat.Name, cat.ParentID); err != nil {
+		return "", err
+	}
+	return cat.ID, nil
+}
+
+// Fetch returns a model.Category finding by its id
+func (c *Curator) Fetch(ctx context.Context, id string) (interface{}, error) {
+	ctx = infra.WithOperation(ctx, "category.fetch")
+
+	row, err := c.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE "id"=$1`, categoryColumns, c.table), id)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	if !row.Next() {
+		return nil, nil
+	}
+	cat, err := scanCategory(row)
+	if err != nil {
+		return nil, err
+	}
+	return cat, nil
+}
+
+// Update updates a category
+func (c *Curator) Update(ctx context.Context, id string, v interface{}) error {
+	ctx = infra.WithOperation(ctx, "category.update")
+	cat, ok := v.(model.Category)
+	if !ok {
+		return ErrUnsupportedType
+	}
+	if err := cat.Validate(); err != nil {
+		return err
+	}
+
+	stmt := fmt.Sprintf(`UPDATE %s SET ("name", "parent_id", "updated_at") = ($1, $2, CURRENT_TIMESTAMP) WHERE "id"=$3`, c.table)
+	return c.db.Exec(ctx, stmt, cat.Name, cat.ParentID, id)
+}
+
+// Delete deletes a category with its product assignments
+func (c *Curator) Delete(ctx context.Context, id string) error {
+	ctx = infra.WithOperation(ctx, "category.delete")
+	if err := c.db.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE "category_id"=$1`, c.assigns), id); err != nil {
+		return err
+	}
+	return c.db.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE "id"=$1`, c.table), id)
+}
+
+// List lists categories by name
+func (c *Curator) List(ctx context.Context, skip, limit int) ([]interface{}, error) {
+	return c.Search(ctx, Query{}, skip, limit)
+}
+
+// Count counts the number of categories
+func (c *Curator) Count(ctx context.Context) (int, error) {
+	return c.SearchCount(ctx, Query{})
+}
+
+// Search returns the categories of query q ordered by name
+func (c *Curator) Search(ctx context.Context, q Query, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "category.search")
+	qstmt, vals := buildCategoryQuery(q)
+	str := fmt.Sprintf(`SELECT %s FROM %s`, categoryColumns, c.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+	str = str + fmt.Sprintf(` ORDER BY "name", "id" OFFSET $%d LIMIT $%d`, len(vals)+1, len(vals)+2)
+	vals = append(vals, skip, limit)
+
+	rows, err := c.db.Query(ctx, str, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cats := []interface{}{}
+	for rows.Next() {
+		cat, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		cats = append(cats, cat)
+	}
+	return cats, nil
+}
+
+// SearchCount returns number of categories that matches query
+func (c *Curator) SearchCount(ctx context.Context, q Query) (int, error) {
+	ctx = infra.WithOperation(ctx, "category.search_count")
+	qstmt, vals := buildCategoryQuery(q)
+	str := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, c.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+
+	rows, err := c.db.Query(ctx, str, vals...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Assign replaces the categories of a product with catIDs
+// repeated ids are assigned once
+func (c *Curator) Assign(ctx context.Context, pdtID string, catIDs []string) error {
+	ctx = infra.WithOperation(ctx, "category.assign")
+	if err := c.db.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE "product_id"=$1`, c.assigns), pdtID); err != nil {
+		return err
+	}
+
+	vals := []interface{}{pdtID}
+	phs := []string{}
+	seen := map[string]bool{}
+	for _, id := range catIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		vals = append(vals, id)
+		phs = append(phs, fmt.Sprintf("($1, $%d)", len(vals)))
+	}
+	if len(phs) == 0 {
+		return nil
+	}
+	stmt := fmt.Sprintf(`INSERT INTO %s ("product_id", "category_id") VALUES %s`, c.assigns, strings.Join(phs, ", "))
+	return c.db.Exec(ctx, stmt, vals...)
+}
+
+// Assigned returns the category ids of a product in ascending order
+func (c *Curator) Assigned(ctx context.Context, pdtID string) ([]string, error) {
+	ctx = infra.WithOperation(ctx, "category.assigned")
+
+	rows, err := c.db.Query(ctx, fmt.Sprintf(`SELECT "category_id" FROM %s WHERE "product_id"=$1 ORDER BY "category_id"`, c.assigns), pdtID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// AssignedAll returns the category ids of the products of pdtIDs in ascending order by product id
+// a product without categories is left out
+func (c *Curator) AssignedAll(ctx context.Context, pdtIDs []string) (map[string][]string, error) {
+	ctx = infra.WithOperation(ctx, "category.assigned_all")
+
+	ids := map[string][]string{}
+	if len(pdtIDs) == 0 {
+		return ids, nil
+	}
+	vals, phs := placeholders(pdtIDs)
+	stmt := fmt.Sprintf(`SELECT "product_id", "category_id" FROM %s WHERE "product_id" IN (%s) ORDER BY "product_id", "category_id"`, c.assigns, strings.Join(phs, ", "))
+	rows, err := c.db.Query(ctx, stmt, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pdtID, id string
+		if err := rows.Scan(&pdtID, &id); err != nil {
+			return nil, err
+		}
+		ids[pdtID] = append(ids[pdtID], id)
+	}
+	return ids, nil
+}
+
+// Descendants returns ids along with the ids of all of their descendant categories
+// every id is returned once in no particular order
+func (c *Curator) Descendants(ctx context.Context, ids []string) ([]string, error) {
+	ctx = infra.WithOperation(ctx, "category.descendants")
+
+	all := []string{}
+	if len(ids) == 0 {
+		return all, nil
+	}
+	vals, phs := placeholders(ids)
+	for i, ph := range phs {
+		phs[i] = fmt.Sprintf("(%s::TEXT)", ph)
+	}
+	stmt := fmt.Sprintf(`WITH RECURSIVE d("id") AS (
+			VALUES %[1]s
+			UNION
+			SELECT c."id"::TEXT FROM %[2]s c JOIN d ON c."parent_id" = d."id"
+		)
+		SELECT "id" FROM d`, strings.Join(phs, ", "), c.table)
+	rows, err := c.db.Query(ctx, stmt, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		all = append(all, id)
+	}
+	return all, nil
+}
+
+// LockTree takes a transaction level advisory lock on the category tree
+// so that concurrent parent changes can not create a cycle
+func (c *Curator) LockTree(ctx context.Context) error {
+	ctx = infra.WithOperation(ctx, "category.lock_tree")
+	return c.db.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, c.table)
+}
+
+func buildCategoryQuery(q Query) (string, []interface{}) {
+	str := ""
+	vals := []interface{}{}
+	if prt := q["parent_id"]; len(prt) != 0 {
+		vals = append(vals, fmt.Sprint(prt[0]))
+		str = fmt.Sprintf(`"parent_id" = $%d`, len(vals))
+	}
+	return str, vals
+}
@@ -0,0 +1,93 @@
+package repo_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/msyrus/simple-product-inv/infra/pgsql"
+	"github.com/msyrus/simple-product-inv/migration"
+	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/repo/repotest"
+)
+
+// postgresURIEnv names the env variable holding the uri of a scratch postgres
+// database, the conformance suites of the sql repos are skipped without it
+const postgresURIEnv = "REPO_TEST_POSTGRES_URI"
+
+// newTestDB returns a pgsql.DB bound to a new schema with the tables created
+// the schema is dropped when t finishes
+func newTestDB(t *testing.T) *pgsql.DB {
+	uri := os.Getenv(postgresURIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set", postgresURIEnv)
+	}
+
+	admin, err := sql.Open("postgres", uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("repotest_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	conn, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	})
+
+	db := pgsql.NewDB(conn, nil)
+	mgrs, err := migration.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migration.NewMigrator(db, mgrs, nil).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSQLRepos_Conformance(t *testing.T) {
+	repotest.RunSuites(t, func(t *testing.T) repo.Repos {
+		rps, err := repo.NewSQLRepos(newTestDB(t), repo.DefaultTables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rps
+	})
+}
+
+func TestSQLUnitOfWork_Conformance(t *testing.T) {
+	repotest.RunUnitOfWorkSuite(t, func(t *testing.T) (repo.UnitOfWork, repo.Repos) {
+		db := newTestDB(t)
+		uow, err := repo.NewSQLUnitOfWork(db, repo.DefaultTables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rps, err := repo.NewSQLRepos(db, repo.DefaultTables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return uow, rps
+	})
+}
@@ -4,3 +4,30 @@ import "errors"
 
 // ErrUnsupportedType is returned when unsupported struct type data is passed
 var ErrUnsupportedType = errors.New("repo: unsupported type")
+
+// ErrInvalidTable is returned when a table name is not a plain sql identifier
+var ErrInvalidTable = errors.New("repo: invalid table name")
+
+// ErrInvalidField is returned when a field name is not a plain sql identifier
+var ErrInvalidField = errors.New("repo: invalid field name")
+
+// ErrInsufficientQuantity is returned when a quantity would become negative
+var ErrInsufficientQuantity = errors.New("repo: insufficient quantity")
+
+// ErrDuplicateSKU is returned when a sku is already used by another product
+var ErrDuplicateSKU = errors.New("repo: duplicate sku")
+
+// ErrDuplicateBarcode is returned when a barcode is already used by another product
+var ErrDuplicateBarcode = errors.New("repo: duplicate barcode")
+
+// ErrDuplicateVariant is returned when a product already has a variant of the same options
+var ErrDuplicateVariant = errors.New("repo: duplicate variant")
+
+// ErrStaleStatus is returned when a status change finds the entry in another status
+var ErrStaleStatus = errors.New("repo: stale status")
+
+// ErrNotDeleted is returned when restoring an entry that is not deleted
+var ErrNotDeleted = errors.New("repo: entry not deleted")
+
+// ErrDuplicateRating is returned when a user has already rated the product
+var ErrDuplicateRating = errors.New("repo: duplicate rating")
@@ -0,0 +1,140 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/model"
+)
+
+// StockLevel interface is the repo wrapper of the per location stock levels
+// levels are searched by product_id and location_id
+type StockLevel interface {
+	LevelAdjuster
+	Searcher
+	ProductRemover
+}
+
+// Porter is an implementation of StockLevel
+type Porter struct {
+	table string
+	db    infra.DB
+}
+
+// NewPorter returns a new Porter with table name tab
+// it returns ErrInvalidTable if tab is not a valid sql identifier
+func NewPorter(tab string, db infra.DB) (*Porter, error) {
+	if !isIdent(tab) {
+		return nil, ErrInvalidTable
+	}
+	return &Porter{
+		table: tab,
+		db:    db,
+	}, nil
+}
+
+// AdjustLevel adds delta to the level of a product at a location and returns the new level
+// a level is created by its first positive adjustment, it returns
+// ErrInsufficientQuantity if the level would become negative
+func (p *Porter) AdjustLevel(ctx context.Context, pdtID, locID string, delta int) (int, error) {
+	ctx = infra.WithOperation(ctx, "stock_level.adjust")
+	stmt := fmt.Sprintf(`UPDATE %s SET ("quantity", "updated_at") = ("quantity" + $1, CURRENT_TIMESTAMP)
+		WHERE "product_id"=$2 AND "location_id"=$3 AND "quantity" + $1 >= 0 RETURNING "quantity"`, p.table)
+	if delta > 0 {
+		stmt = fmt.Sprintf(`INSERT INTO %[1]s ("product_id", "location_id", "quantity") VALUES($2, $3, $1)
+		ON CONFLICT ("product_id", "location_id") DO UPDATE SET ("quantity", "updated_at") = (%[1]s."quantity" + $1, CURRENT_TIMESTAMP)
+		RETURNING "quantity"`, p.table)
+	}
+
+	rows, err := p.db.Query(ctx, stmt, delta, pdtID, locID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, ErrInsufficientQuantity
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Search returns the stock levels of query q ordered by product and location
+func (p *Porter) Search(ctx context.Context, q Query, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "stock_level.search")
+	qstmt, vals := buildLevelQuery(q)
+	str := fmt.Sprintf(`SELECT "product_id", "location_id", "quantity", "updated_at" FROM %s`, p.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+	str = str + fmt.Sprintf(` ORDER BY "product_id", "location_id" OFFSET $%d LIMIT $%d`, len(vals)+1, len(vals)+2)
+	vals = append(vals, skip, limit)
+
+	rows, err := p.db.Query(ctx, str, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lvls := []interface{}{}
+	for rows.Next() {
+		lvl := model.StockLevel{}
+		if err := rows.Scan(&lvl.ProductID, &lvl.LocationID, &lvl.Quantity, &lvl.UpdatedAt); err != nil {
+			return nil, err
+		}
+		lvls = append(lvls, lvl)
+	}
+	return lvls, nil
+}
+
+// SearchCount returns number of stock levels that matches query
+func (p *Porter) SearchCount(ctx context.Context, q Query) (int, error) {
+	ctx = infra.WithOperation(ctx, "stock_level.search_count")
+	qstmt, vals := buildLevelQuery(q)
+	str := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, p.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+
+	rows, err := p.db.Query(ctx, str, vals...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func buildLevelQuery(q Query) (string, []interface{}) {
+	str := ""
+	vals := []interface{}{}
+	if pdtID := q["product_id"]; len(pdtID) != 0 {
+		vals = append(vals, fmt.Sprint(pdtID[0]))
+		str = fmt.Sprintf(`"product_id" = $%d`, len(vals))
+	}
+	if loc := q["location_id"]; len(loc) != 0 {
+		if len(vals) != 0 {
+			str = str + " AND "
+		}
+		vals = append(vals, fmt.Sprint(loc[0]))
+		str = str + fmt.Sprintf(`"location_id" = $%d`, len(vals))
+	}
+	return str, vals
+}
+
+// RemoveProducts permanently removes the stock levels of the products of pdtIDs
+func (p *Porter) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	ctx = infra.WithOperation(ctx, "stock_level.remove_products")
+	return removeProducts(ctx, p.db, p.table, pdtIDs)
+}
@@ -0,0 +1,142 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/model"
+)
+
+// Location interface is the repo wrapper of stock location
+type Location interface {
+	Creator
+	Fetcher
+	Updater
+	Lister
+	Counter
+}
+
+// locationColumns are the selected columns of a location in scan order
+const locationColumns = `"id", "name", "address", "created_at", "updated_at"`
+
+func scanLocation(row infra.Row) (model.Location, error) {
+	loc := model.Location{}
+	err := row.Scan(&loc.ID, &loc.Name, &loc.Address, &loc.CreatedAt, &loc.UpdatedAt)
+	return loc, err
+}
+
+// Scout is an implementation of Location
+type Scout struct {
+	table string
+	db    infra.DB
+}
+
+// NewScout returns a new Scout with table name tab
+// it returns ErrInvalidTable if tab is not a valid sql identifier
+func NewScout(tab string, db infra.DB) (*Scout, error) {
+	if !isIdent(tab) {
+		return nil, ErrInvalidTable
+	}
+	return &Scout{
+		table: tab,
+		db:    db,
+	}, nil
+}
+
+// Create creates a new location
+func (s *Scout) Create(ctx context.Context, v interface{}) (string, error) {
+	ctx = infra.WithOperation(ctx, "location.create")
+	loc, ok := v.(model.Location)
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+	loc.ID = uuid.NewV4().String()
+
+	if err := loc.Validate(); err != nil {
+		return "", err
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %s ("id", "name", "address") VALUES($1, $2, $3)`, s.table)
+	if err := s.db.Exec(ctx, stmt, loc.ID, loc.Name, loc.Address); err != nil {
+		return "", err
+	}
+	return loc.ID, nil
+}
+
+// Fetch returns a model.Location finding by its id
+func (s *Scout) Fetch(ctx context.Context, id string) (interface{}, error) {
+	ctx = infra.WithOperation(ctx, "location.fetch")
+
+	row, err := s.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE "id"=$1`, locationColumns, s.table), id)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	if !row.Next() {
+		return nil, nil
+	}
+	loc, err := scanLocation(row)
+	if err != nil {
+		return nil, err
+	}
+	return loc, nil
+}
+
+// Update updates a location
+func (s *Scout) Update(ctx context.Context, id string, v interface{}) error {
+	ctx = infra.WithOperation(ctx, "location.update")
+	loc, ok := v.(model.Location)
+	if !ok {
+		return ErrUnsupportedType
+	}
+	if err := loc.Validate(); err != nil {
+		return err
+	}
+
+	stmt := fmt.Sprintf(`UPDATE %s SET ("name", "address", "updated_at") = ($1, $2, CURRENT_TIMESTAMP) WHERE "id"=$3`, s.table)
+	return s.db.Exec(ctx, stmt, loc.Name, loc.Address, id)
+}
+
+// List lists locations by name
+func (s *Scout) List(ctx context.Context, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "location.list")
+
+	rows, err := s.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s ORDER BY "name", "id" OFFSET $1 LIMIT $2`, locationColumns, s.table), skip, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locs := []interface{}{}
+	for rows.Next() {
+		loc, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		locs = append(locs, loc)
+	}
+	return locs, nil
+}
+
+// Count counts the number of locations
+func (s *Scout) Count(ctx context.Context) (int, error) {
+	ctx = infra.WithOperation(ctx, "location.count")
+	rows, err := s.db.Query(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, s.table))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,271 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Curator is the in-memory implementation of repo.Category
+type Curator struct {
+	s  *Store
+	tx bool
+}
+
+// NewCurator returns a new Curator backed by s
+func NewCurator(s *Store) *Curator {
+	return &Curator{
+		s: s,
+	}
+}
+
+// Create creates a new category
+func (c *Curator) Create(ctx context.Context, v interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	cat, ok := v.(model.Category)
+	if !ok {
+		return "", repo.ErrUnsupportedType
+	}
+	cat.ID = uuid.NewV4().String()
+
+	if err := cat.Validate(); err != nil {
+		return "", err
+	}
+
+	defer c.s.write(c.tx)()
+
+	now := time.Now()
+	cat.CreatedAt = now
+	cat.UpdatedAt = now
+	c.s.cats[cat.ID] = cat
+	c.s.catOrder = append(c.s.catOrder, cat.ID)
+	return cat.ID, nil
+}
+
+// Fetch returns a model.Category finding by its id
+func (c *Curator) Fetch(ctx context.Context, id string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	cat, ok := c.s.cats[id]
+	if !ok {
+		return nil, nil
+	}
+	return cat, nil
+}
+
+// Update updates a category
+func (c *Curator) Update(ctx context.Context, id string, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	cat, ok := v.(model.Category)
+	if !ok {
+		return repo.ErrUnsupportedType
+	}
+	if err := cat.Validate(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	old, ok := c.s.cats[id]
+	if !ok {
+		return nil
+	}
+	old.Name = cat.Name
+	old.ParentID = cat.ParentID
+	old.UpdatedAt = time.Now()
+	c.s.cats[id] = old
+	return nil
+}
+
+// Delete deletes a category with its product assignments
+func (c *Curator) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	if _, ok := c.s.cats[id]; !ok {
+		return nil
+	}
+	delete(c.s.cats, id)
+	for i, cID := range c.s.catOrder {
+		if cID == id {
+			c.s.catOrder = append(c.s.catOrder[:i:i], c.s.catOrder[i+1:]...)
+			break
+		}
+	}
+	for k := range c.s.assigns {
+		if k.catID == id {
+			delete(c.s.assigns, k)
+		}
+	}
+	return nil
+}
+
+// List lists categories by name
+func (c *Curator) List(ctx context.Context, skip, limit int) ([]interface{}, error) {
+	return c.Search(ctx, repo.Query{}, skip, limit)
+}
+
+// Count counts the number of categories
+func (c *Curator) Count(ctx context.Context) (int, error) {
+	return c.SearchCount(ctx, repo.Query{})
+}
+
+// Search returns the categories of query q ordered by name
+func (c *Curator) Search(ctx context.Context, q repo.Query, skip, limit int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	cats := c.s.searchCategories(q)
+	from, to := page(len(cats), skip, limit)
+	res := []interface{}{}
+	for _, cat := range cats[from:to] {
+		res = append(res, cat)
+	}
+	return res, nil
+}
+
+// SearchCount returns number of categories that matches query
+func (c *Curator) SearchCount(ctx context.Context, q repo.Query) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	return len(c.s.searchCategories(q)), nil
+}
+
+// Assign replaces the categories of a product with catIDs
+func (c *Curator) Assign(ctx context.Context, pdtID string, catIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	for k := range c.s.assigns {
+		if k.pdtID == pdtID {
+			delete(c.s.assigns, k)
+		}
+	}
+	for _, id := range catIDs {
+		c.s.assigns[assignKey{pdtID, id}] = true
+	}
+	return nil
+}
+
+// Assigned returns the category ids of a product in ascending order
+func (c *Curator) Assigned(ctx context.Context, pdtID string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	ids := []string{}
+	for k := range c.s.assigns {
+		if k.pdtID == pdtID {
+			ids = append(ids, k.catID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// AssignedAll returns the category ids of the products of pdtIDs in ascending order by product id
+// a product without categories is left out
+func (c *Curator) AssignedAll(ctx context.Context, pdtIDs []string) (map[string][]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	want := map[string]bool{}
+	for _, id := range pdtIDs {
+		want[id] = true
+	}
+	ids := map[string][]string{}
+	for k := range c.s.assigns {
+		if want[k.pdtID] {
+			ids[k.pdtID] = append(ids[k.pdtID], k.catID)
+		}
+	}
+	for _, catIDs := range ids {
+		sort.Strings(catIDs)
+	}
+	return ids, nil
+}
+
+// Descendants returns ids along with the ids of all of their descendant categories
+func (c *Curator) Descendants(ctx context.Context, ids []string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	kids := map[string][]string{}
+	for _, id := range c.s.catOrder {
+		prt := c.s.cats[id].ParentID
+		kids[prt] = append(kids[prt], id)
+	}
+	all := []string{}
+	seen := map[string]bool{}
+	for len(ids) != 0 {
+		id := ids[0]
+		ids = ids[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		all = append(all, id)
+		ids = append(ids, kids[id]...)
+	}
+	return all, nil
+}
+
+// searchCategories returns the categories matching q ordered by name and id
+// s must be locked by the caller
+func (s *Store) searchCategories(q repo.Query) []model.Category {
+	cats := []model.Category{}
+	for _, id := range s.catOrder {
+		cat := s.cats[id]
+		if prt := q["parent_id"]; len(prt) != 0 && cat.ParentID != fmt.Sprint(prt[0]) {
+			continue
+		}
+		cats = append(cats, cat)
+	}
+	sort.SliceStable(cats, func(i, j int) bool {
+		if cats[i].Name != cats[j].Name {
+			return cats[i].Name < cats[j].Name
+		}
+		return cats[i].ID < cats[j].ID
+	})
+	return cats
+}
+
+// LockTree locks nothing, the transactions of the Store are serialized
+func (c *Curator) LockTree(ctx context.Context) error {
+	return ctx.Err()
+}
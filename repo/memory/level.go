@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Porter is the in-memory implementation of repo.StockLevel
+type Porter struct {
+	s  *Store
+	tx bool
+}
+
+// NewPorter returns a new Porter backed by s
+func NewPorter(s *Store) *Porter {
+	return &Porter{
+		s: s,
+	}
+}
+
+// AdjustLevel adds delta to the level of a product at a location and returns the new level
+// it returns repo.ErrInsufficientQuantity if the level would become negative
+func (p *Porter) AdjustLevel(ctx context.Context, pdtID, locID string, delta int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer p.s.write(p.tx)()
+
+	key := levelKey{pdtID, locID}
+	lvl, ok := p.s.levels[key]
+	if !ok && delta <= 0 || lvl.Quantity+delta < 0 {
+		return 0, repo.ErrInsufficientQuantity
+	}
+	lvl.ProductID = pdtID
+	lvl.LocationID = locID
+	lvl.Quantity += delta
+	lvl.UpdatedAt = time.Now()
+	p.s.levels[key] = lvl
+	return lvl.Quantity, nil
+}
+
+// Search returns the stock levels of query q ordered by product and location
+func (p *Porter) Search(ctx context.Context, q repo.Query, skip, limit int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer p.s.read(p.tx)()
+
+	lvls := p.s.searchLevels(q)
+	from, to := page(len(lvls), skip, limit)
+	res := []interface{}{}
+	for _, lvl := range lvls[from:to] {
+		res = append(res, lvl)
+	}
+	return res, nil
+}
+
+// SearchCount returns number of stock levels that matches query
+func (p *Porter) SearchCount(ctx context.Context, q repo.Query) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer p.s.read(p.tx)()
+
+	return len(p.s.searchLevels(q)), nil
+}
+
+// searchLevels returns the stock levels matching q ordered by product and location
+// s must be locked by the caller
+func (s *Store) searchLevels(q repo.Query) []model.StockLevel {
+	lvls := []model.StockLevel{}
+	for _, lvl := range s.levels {
+		if pdtID := q["product_id"]; len(pdtID) != 0 && lvl.ProductID != fmt.Sprint(pdtID[0]) {
+			continue
+		}
+		if loc := q["location_id"]; len(loc) != 0 && lvl.LocationID != fmt.Sprint(loc[0]) {
+			continue
+		}
+		lvls = append(lvls, lvl)
+	}
+	sort.Slice(lvls, func(i, j int) bool {
+		if lvls[i].ProductID != lvls[j].ProductID {
+			return lvls[i].ProductID < lvls[j].ProductID
+		}
+		return lvls[i].LocationID < lvls[j].LocationID
+	})
+	return lvls
+}
+
+// RemoveProducts permanently removes the stock levels of the products of pdtIDs
+func (p *Porter) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer p.s.write(p.tx)()
+
+	rm := map[string]bool{}
+	for _, id := range pdtIDs {
+		rm[id] = true
+	}
+	for k := range p.s.levels {
+		if rm[k.pdtID] {
+			delete(p.s.levels, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Scout is the in-memory implementation of repo.Location
+type Scout struct {
+	s  *Store
+	tx bool
+}
+
+// NewScout returns a new Scout backed by s
+func NewScout(s *Store) *Scout {
+	return &Scout{
+		s: s,
+	}
+}
+
+// Create creates a new location
+func (sc *Scout) Create(ctx context.Context, v interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	loc, ok := v.(model.Location)
+	if !ok {
+		return "", repo.ErrUnsupportedType
+	}
+	loc.ID = uuid.NewV4().String()
+
+	if err := loc.Validate(); err != nil {
+		return "", err
+	}
+
+	defer sc.s.write(sc.tx)()
+
+	now := time.Now()
+	loc.CreatedAt = now
+	loc.UpdatedAt = now
+	sc.s.locations[loc.ID] = loc
+	sc.s.locOrder = append(sc.s.locOrder, loc.ID)
+	return loc.ID, nil
+}
+
+// Fetch returns a model.Location finding by its id
+func (sc *Scout) Fetch(ctx context.Context, id string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer sc.s.read(sc.tx)()
+
+	loc, ok := sc.s.locations[id]
+	if !ok {
+		return nil, nil
+	}
+	return loc, nil
+}
+
+// Update updates a location
+func (sc *Scout) Update(ctx context.Context, id string, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	loc, ok := v.(model.Location)
+	if !ok {
+		return repo.ErrUnsupportedType
+	}
+	if err := loc.Validate(); err != nil {
+		return err
+	}
+
+	defer sc.s.write(sc.tx)()
+
+	old, ok := sc.s.locations[id]
+	if !ok {
+		return nil
+	}
+	old.Name = loc.Name
+	old.Address = loc.Address
+	old.UpdatedAt = time.Now()
+	sc.s.locations[id] = old
+	return nil
+}
+
+// List lists locations by name
+func (sc *Scout) List(ctx context.Context, skip, limit int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer sc.s.read(sc.tx)()
+
+	locs := []model.Location{}
+	for _, id := range sc.s.locOrder {
+		locs = append(locs, sc.s.locations[id])
+	}
+	sort.SliceStable(locs, func(i, j int) bool {
+		if locs[i].Name != locs[j].Name {
+			return locs[i].Name < locs[j].Name
+		}
+		return locs[i].ID < locs[j].ID
+	})
+
+	from, to := page(len(locs), skip, limit)
+	res := []interface{}{}
+	for _, loc := range locs[from:to] {
+		res = append(res, loc)
+	}
+	return res, nil
+}
+
+// Count counts the number of locations
+func (sc *Scout) Count(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer sc.s.read(sc.tx)()
+
+	return len(sc.s.locations), nil
+}
@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Appraiser is the in-memory implementation of repo.Price
+type Appraiser struct {
+	s  *Store
+	tx bool
+}
+
+// NewAppraiser returns a new Appraiser backed by s
+func NewAppraiser(s *Store) *Appraiser {
+	return &Appraiser{
+		s: s,
+	}
+}
+
+// Create creates a new price change
+func (a *Appraiser) Create(ctx context.Context, v interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	prc, ok := v.(model.PriceChange)
+	if !ok {
+		return "", repo.ErrUnsupportedType
+	}
+	prc.ID = uuid.NewV4().String()
+
+	if err := prc.Validate(); err != nil {
+		return "", err
+	}
+
+	defer a.s.write(a.tx)()
+
+	now := time.Now()
+	prc.CreatedAt = now
+	prc.UpdatedAt = now
+	a.s.prices[prc.ID] = prc
+	a.s.prcOrder = append(a.s.prcOrder, prc.ID)
+	return prc.ID, nil
+}
+
+// Fetch returns a model.PriceChange finding by its id
+func (a *Appraiser) Fetch(ctx context.Context, id string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer a.s.read(a.tx)()
+
+	prc, ok := a.s.prices[id]
+	if !ok {
+		return nil, nil
+	}
+	return prc, nil
+}
+
+// Search returns the price changes of query q ordered by their effective time
+func (a *Appraiser) Search(ctx context.Context, q repo.Query, skip, limit int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer a.s.read(a.tx)()
+
+	prcs := a.s.searchPrices(q)
+	from, to := page(len(prcs), skip, limit)
+	res := []interface{}{}
+	for _, prc := range prcs[from:to] {
+		res = append(res, prc)
+	}
+	return res, nil
+}
+
+// SearchCount returns number of price changes that matches query
+func (a *Appraiser) SearchCount(ctx context.Context, q repo.Query) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer a.s.read(a.tx)()
+
+	return len(a.s.searchPrices(q)), nil
+}
+
+// SetStatus changes the status of a price change from from to to
+// it returns repo.ErrStaleStatus if the price change is not in status from
+func (a *Appraiser) SetStatus(ctx context.Context, id string, from, to string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer a.s.write(a.tx)()
+
+	prc, ok := a.s.prices[id]
+	if !ok || string(prc.Status) != from {
+		return repo.ErrStaleStatus
+	}
+	prc.Status = model.PriceStatus(to)
+	prc.UpdatedAt = time.Now()
+	a.s.prices[id] = prc
+	return nil
+}
+
+// searchPrices returns the price changes matching q ordered by their effective time
+// s must be locked by the caller
+func (s *Store) searchPrices(q repo.Query) []model.PriceChange {
+	prcs := []model.PriceChange{}
+	for _, id := range s.prcOrder {
+		prc := s.prices[id]
+		if pdtID := q["product_id"]; len(pdtID) != 0 && prc.ProductID != fmt.Sprint(pdtID[0]) {
+			continue
+		}
+		if sts := q["status"]; len(sts) != 0 && string(prc.Status) != fmt.Sprint(sts[0]) {
+			continue
+		}
+		if eff := q["effective_before"]; len(eff) != 0 {
+			t, ok := eff[0].(time.Time)
+			if !ok || prc.EffectiveAt.After(t) {
+				continue
+			}
+		}
+		prcs = append(prcs, prc)
+	}
+	sort.SliceStable(prcs, func(i, j int) bool {
+		return prcs[i].EffectiveAt.Before(prcs[j].EffectiveAt)
+	})
+	return prcs
+}
+
+// RemoveProducts permanently removes the price changes of the products of pdtIDs
+func (a *Appraiser) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer a.s.write(a.tx)()
+
+	rm := map[string]bool{}
+	for _, id := range pdtIDs {
+		rm[id] = true
+	}
+	ids := []string{}
+	for _, id := range a.s.prcOrder {
+		if rm[a.s.prices[id].ProductID] {
+			delete(a.s.prices, id)
+			ids = append(ids, id)
+		}
+	}
+	a.s.prcOrder = without(a.s.prcOrder, ids)
+	return nil
+}
@@ -0,0 +1,534 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Chef is the in-memory implementation of repo.Product
+type Chef struct {
+	s  *Store
+	tx bool
+}
+
+// NewChef returns a new Chef backed by s
+func NewChef(s *Store) *Chef {
+	return &Chef{
+		s: s,
+	}
+}
+
+// Create a new product
+func (c *Chef) Create(ctx context.Context, v interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	pdt, ok := v.(model.Product)
+	if !ok {
+		return "", repo.ErrUnsupportedType
+	}
+	pdt.ID = uuid.NewV4().String()
+	pdt.Tags = model.NormalizeTags(pdt.Tags)
+	if pdt.Tags == nil {
+		pdt.Tags = []string{}
+	}
+	if pdt.Status == "" {
+		pdt.Status = model.ProductDraft
+	}
+
+	if err := pdt.Validate(); err != nil {
+		return "", err
+	}
+
+	defer c.s.write(c.tx)()
+
+	if err := c.s.checkCodes("", pdt); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	pdt.Prices = storedPrices(pdt.Prices)
+	pdt.Quantity = 0
+	pdt.Reserved = 0
+	pdt.Available = false
+	pdt.Deleted = false
+	pdt.PublishedAt = time.Time{}
+	pdt.ArchivedAt = time.Time{}
+	setTransitionTime(&pdt, now)
+	pdt.CreatedAt = now
+	pdt.UpdatedAt = now
+	pdt.DeletedAt = time.Time{}
+
+	c.s.products[pdt.ID] = pdt
+	c.s.pdtOrder = append(c.s.pdtOrder, pdt.ID)
+	return pdt.ID, nil
+}
+
+// Fetch returns a model.Product finding by its id
+func (c *Chef) Fetch(ctx context.Context, id string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	pdt, ok := c.s.products[id]
+	if !ok || pdt.Deleted {
+		return nil, nil
+	}
+	return pdt, nil
+}
+
+// Update updates a product
+// the tags are replaced only if they are not nil
+func (c *Chef) Update(ctx context.Context, id string, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	pdt, ok := v.(model.Product)
+	if !ok {
+		return repo.ErrUnsupportedType
+	}
+	pdt.Tags = model.NormalizeTags(pdt.Tags)
+	if err := pdt.Validate(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	old, ok := c.s.products[id]
+	if !ok || old.Deleted {
+		return nil
+	}
+	if err := c.s.checkCodes(id, pdt); err != nil {
+		return err
+	}
+	old.Name = pdt.Name
+	old.Price = pdt.Price
+	old.Prices = storedPrices(pdt.Prices)
+	old.Weight = pdt.Weight
+	old.SKU = pdt.SKU
+	old.Barcode = pdt.Barcode
+	if pdt.Tags != nil {
+		old.Tags = pdt.Tags
+	}
+	old.UpdatedAt = time.Now()
+	c.s.products[id] = old
+	return nil
+}
+
+// Delete deletes a product
+func (c *Chef) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	pdt, ok := c.s.products[id]
+	if !ok || pdt.Deleted {
+		return nil
+	}
+	pdt.Deleted = true
+	pdt.DeletedAt = time.Now()
+	c.s.products[id] = pdt
+	return nil
+}
+
+// ListDeleted lists the deleted products, the latest deleted first
+func (c *Chef) ListDeleted(ctx context.Context, skip, limit int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	pdts := c.s.deleted()
+	from, to := page(len(pdts), skip, limit)
+	res := []interface{}{}
+	for _, pdt := range pdts[from:to] {
+		res = append(res, pdt)
+	}
+	return res, nil
+}
+
+// CountDeleted counts the number of deleted products
+func (c *Chef) CountDeleted(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	return len(c.s.deleted()), nil
+}
+
+// Restore undeletes a deleted product
+// it returns repo.ErrNotDeleted if there is no deleted product of id and
+// repo.ErrDuplicateSKU or repo.ErrDuplicateBarcode if its codes are taken meanwhile
+func (c *Chef) Restore(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	pdt, ok := c.s.products[id]
+	if !ok || !pdt.Deleted {
+		return repo.ErrNotDeleted
+	}
+	if err := c.s.checkCodes(id, pdt); err != nil {
+		return err
+	}
+	pdt.Deleted = false
+	pdt.DeletedAt = time.Time{}
+	pdt.UpdatedAt = time.Now()
+	c.s.products[id] = pdt
+	return nil
+}
+
+// Purge permanently removes at most limit products deleted before t with their
+// category assignments and returns their ids
+func (c *Chef) Purge(ctx context.Context, t time.Time, limit int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.write(c.tx)()
+
+	pdts := c.s.deleted()
+	ids := []string{}
+	for i := len(pdts) - 1; i >= 0 && len(ids) < limit; i-- {
+		if !pdts[i].DeletedAt.Before(t) {
+			break
+		}
+		ids = append(ids, pdts[i].ID)
+	}
+	for _, id := range ids {
+		delete(c.s.products, id)
+		for k := range c.s.assigns {
+			if k.pdtID == id {
+				delete(c.s.assigns, k)
+			}
+		}
+	}
+	c.s.pdtOrder = without(c.s.pdtOrder, ids)
+	return ids, nil
+}
+
+// SetStatus changes the status of a product that is not deleted from from to to
+// it returns repo.ErrStaleStatus if the product is not in status from
+func (c *Chef) SetStatus(ctx context.Context, id string, from, to string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	pdt, ok := c.s.products[id]
+	if !ok || pdt.Deleted || string(pdt.Status) != from {
+		return repo.ErrStaleStatus
+	}
+	now := time.Now()
+	pdt.Status = model.ProductStatus(to)
+	setTransitionTime(&pdt, now)
+	pdt.UpdatedAt = now
+	c.s.products[id] = pdt
+	return nil
+}
+
+// SetPrice changes the price of a product that is not deleted
+func (c *Chef) SetPrice(ctx context.Context, id string, price int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if price < 1 {
+		return model.ValidationError{"Price": []string{"is required"}}
+	}
+
+	defer c.s.write(c.tx)()
+
+	pdt, ok := c.s.products[id]
+	if !ok || pdt.Deleted {
+		return nil
+	}
+	pdt.Price = price
+	pdt.UpdatedAt = time.Now()
+	c.s.products[id] = pdt
+	return nil
+}
+
+// setTransitionTime sets the publish or archive time of pdt to now
+// if it is published or archived
+func setTransitionTime(pdt *model.Product, now time.Time) {
+	switch pdt.Status {
+	case model.ProductPublished:
+		pdt.PublishedAt = now
+	case model.ProductArchived:
+		pdt.ArchivedAt = now
+	}
+}
+
+// List lists products
+func (c *Chef) List(ctx context.Context, skip, limit int) ([]interface{}, error) {
+	return c.Search(ctx, repo.Query{}, skip, limit)
+}
+
+// Count counts the number of products
+func (c *Chef) Count(ctx context.Context) (int, error) {
+	return c.SearchCount(ctx, repo.Query{})
+}
+
+// Search search products with query
+func (c *Chef) Search(ctx context.Context, q repo.Query, skip, limit int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	pdts := c.s.search(q)
+	from, to := page(len(pdts), skip, limit)
+	res := []interface{}{}
+	for _, pdt := range pdts[from:to] {
+		res = append(res, pdt)
+	}
+	return res, nil
+}
+
+// SearchCount returns number of products that matches query
+func (c *Chef) SearchCount(ctx context.Context, q repo.Query) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	return len(c.s.search(q)), nil
+}
+
+// CountTags returns the number of products that are not deleted of every tag
+func (c *Chef) CountTags(ctx context.Context) (map[string]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	cnts := map[string]int{}
+	for _, pdt := range c.s.products {
+		if pdt.Deleted {
+			continue
+		}
+		for _, tag := range pdt.Tags {
+			cnts[tag]++
+		}
+	}
+	return cnts, nil
+}
+
+// AdjustQuantity adds delta to the quantity of a product and returns the new quantity
+// it returns repo.ErrInsufficientQuantity if the quantity would drop below the reserved units
+func (c *Chef) AdjustQuantity(ctx context.Context, id string, delta int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer c.s.write(c.tx)()
+
+	pdt, ok := c.s.products[id]
+	if !ok || pdt.Deleted || pdt.Quantity+delta < pdt.Reserved {
+		return 0, repo.ErrInsufficientQuantity
+	}
+	pdt.Quantity += delta
+	pdt.Available = pdt.Quantity > pdt.Reserved
+	pdt.UpdatedAt = time.Now()
+	c.s.products[id] = pdt
+	return pdt.Quantity, nil
+}
+
+// AdjustReserved adds delta to the reserved units of a product and returns the new reserved units
+// it returns repo.ErrInsufficientQuantity if the reserved units would become negative
+// or exceed the quantity
+func (c *Chef) AdjustReserved(ctx context.Context, id string, delta int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer c.s.write(c.tx)()
+
+	pdt, ok := c.s.products[id]
+	if !ok || pdt.Deleted || pdt.Reserved+delta < 0 || pdt.Reserved+delta > pdt.Quantity {
+		return 0, repo.ErrInsufficientQuantity
+	}
+	pdt.Reserved += delta
+	pdt.Available = pdt.Quantity > pdt.Reserved
+	pdt.UpdatedAt = time.Now()
+	c.s.products[id] = pdt
+	return pdt.Reserved, nil
+}
+
+// search returns the products matching q in creation order unless q sorts by rating
+// it applies the same filters and order as the sql product query
+// s must be locked by the caller
+func (s *Store) search(q repo.Query) []model.Product {
+	pdts := []model.Product{}
+	for _, id := range s.pdtOrder {
+		pdt := s.products[id]
+		if pdt.Deleted || !matchProduct(pdt, q) || !s.stockedAt(pdt.ID, q) || !s.inCategory(pdt.ID, q) || !s.ratedAtLeast(pdt.ID, q) {
+			continue
+		}
+		pdts = append(pdts, pdt)
+	}
+	if srt := q["sort"]; len(srt) != 0 && (srt[0] == "rating" || srt[0] == "-rating") {
+		desc := srt[0] == "-rating"
+		sort.SliceStable(pdts, func(i, j int) bool {
+			ri, rj := s.ratSums[pdts[i].ID].Avg, s.ratSums[pdts[j].ID].Avg
+			if desc {
+				return ri > rj
+			}
+			return ri < rj
+		})
+	}
+	return pdts
+}
+
+// ratedAtLeast checks if the average rating of the product of pdtID is at least the min_rating of q
+// the products without ratings have the rating 0
+// s must be locked by the caller
+func (s *Store) ratedAtLeast(pdtID string, q repo.Query) bool {
+	rt := q["min_rating"]
+	if len(rt) == 0 {
+		return true
+	}
+	min, ok := toFloat(rt[0])
+	return ok && s.ratSums[pdtID].Avg >= min
+}
+
+// deleted returns the deleted products, the latest deleted first
+// s must be locked by the caller
+func (s *Store) deleted() []model.Product {
+	pdts := []model.Product{}
+	for _, id := range s.pdtOrder {
+		if pdt := s.products[id]; pdt.Deleted {
+			pdts = append(pdts, pdt)
+		}
+	}
+	sort.SliceStable(pdts, func(i, j int) bool {
+		return pdts[i].DeletedAt.After(pdts[j].DeletedAt)
+	})
+	return pdts
+}
+
+// stockedAt checks if the product of pdtID has stock at the location of q
+// s must be locked by the caller
+func (s *Store) stockedAt(pdtID string, q repo.Query) bool {
+	loc := q["location"]
+	if len(loc) == 0 {
+		return true
+	}
+	return s.levels[levelKey{pdtID, fmt.Sprint(loc[0])}].Quantity > 0
+}
+
+// inCategory checks if the product of pdtID is assigned to any category of q
+// s must be locked by the caller
+func (s *Store) inCategory(pdtID string, q repo.Query) bool {
+	cats := q["category"]
+	if len(cats) == 0 {
+		return true
+	}
+	for _, cat := range cats {
+		if s.assigns[assignKey{pdtID, fmt.Sprint(cat)}] {
+			return true
+		}
+	}
+	return false
+}
+
+func matchProduct(pdt model.Product, q repo.Query) bool {
+	if name := q["name"]; len(name) != 0 {
+		pat, ok := name[0].(string)
+		if !ok || !like(pat, pdt.Name) {
+			return false
+		}
+	}
+	if prc := q["price"]; len(prc) != 0 {
+		d, ok := toInt(prc[0])
+		if !ok || pdt.Price > d {
+			return false
+		}
+	}
+	if wgt := q["weight"]; len(wgt) != 0 {
+		d, ok := toInt(wgt[0])
+		if !ok || pdt.Weight > d {
+			return false
+		}
+	}
+	if avl := q["available"]; len(avl) != 0 {
+		b, ok := avl[0].(bool)
+		if !ok || pdt.Available != b {
+			return false
+		}
+	}
+	if sts := q["status"]; len(sts) != 0 && string(pdt.Status) != fmt.Sprint(sts[0]) {
+		return false
+	}
+	if sku := q["sku"]; len(sku) != 0 && pdt.SKU != fmt.Sprint(sku[0]) {
+		return false
+	}
+	if bc := q["barcode"]; len(bc) != 0 && pdt.Barcode != fmt.Sprint(bc[0]) {
+		return false
+	}
+	if tgs := q["tag"]; len(tgs) != 0 {
+		all := len(q["tag_match"]) != 0 && q["tag_match"][0] == "all"
+		n := 0
+		for _, tag := range tgs {
+			if hasTag(pdt, fmt.Sprint(tag)) {
+				n++
+			}
+		}
+		if n == 0 || all && n != len(tgs) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkCodes returns ErrDuplicateSKU or ErrDuplicateBarcode if a product
+// other than id which is not deleted uses the codes of pdt
+func (s *Store) checkCodes(id string, pdt model.Product) error {
+	for _, old := range s.products {
+		if old.ID == id || old.Deleted {
+			continue
+		}
+		if pdt.SKU != "" && old.SKU == pdt.SKU {
+			return repo.ErrDuplicateSKU
+		}
+		if pdt.Barcode != "" && old.Barcode == pdt.Barcode {
+			return repo.ErrDuplicateBarcode
+		}
+	}
+	return nil
+}
+
+func hasTag(pdt model.Product, tag string) bool {
+	for _, t := range pdt.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// storedPrices returns a copy of prcs as the sql repo stores them
+// so that the stored prices are never shared
+func storedPrices(prcs []model.Money) []model.Money {
+	return model.DecodePrices(model.EncodePrices(prcs))
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+func TestChef_Concurrent(t *testing.T) {
+	s := NewStore()
+	chf := NewChef(s)
+	ctx := context.Background()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := chf.Create(ctx, model.Product{Name: "Test", Price: 100, Weight: 1})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			chf.Update(ctx, id, model.Product{Name: "Test2", Price: 100, Weight: 1})
+			chf.Search(ctx, repo.Query{"name": {"Test%"}}, 0, 10)
+			s.Do(ctx, func(r repo.Repos) error {
+				_, err := r.Product.Fetch(ctx, id)
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+
+	if n, _ := chf.Count(ctx); n != 50 {
+		t.Errorf("Chef.Count() = %v, want %v", n, 50)
+	}
+}
+
+func TestChef_CancelledContext(t *testing.T) {
+	chf := NewChef(NewStore())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := chf.Create(ctx, model.Product{Name: "Test", Price: 100, Weight: 1}); err != context.Canceled {
+		t.Errorf("Chef.Create() error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := chf.Search(ctx, repo.Query{}, 0, 10); err != context.Canceled {
+		t.Errorf("Chef.Search() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestChef_SearchLocation(t *testing.T) {
+	s := NewStore()
+	rps := s.Repos()
+	ctx := context.Background()
+
+	ids := []string{}
+	for _, name := range []string{"Test1", "Test2", "Test3"} {
+		id, err := rps.Product.Create(ctx, model.Product{Name: name, Price: 100, Weight: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	rps.StockLevel.AdjustLevel(ctx, ids[0], "loc1", 2)
+	rps.StockLevel.AdjustLevel(ctx, ids[1], "loc1", 1)
+	rps.StockLevel.AdjustLevel(ctx, ids[1], "loc1", -1)
+	rps.StockLevel.AdjustLevel(ctx, ids[2], "loc2", 1)
+
+	res, err := rps.Product.Search(ctx, repo.Query{"location": {"loc1"}}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].(model.Product).ID != ids[0] {
+		t.Errorf("Chef.Search() = %v, want only %v", res, ids[0])
+	}
+}
+
+func TestChef_SearchCategory(t *testing.T) {
+	s := NewStore()
+	rps := s.Repos()
+	ctx := context.Background()
+
+	ids := []string{}
+	for _, name := range []string{"Test1", "Test2", "Test3"} {
+		id, err := rps.Product.Create(ctx, model.Product{Name: name, Price: 100, Weight: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	rps.Category.Assign(ctx, ids[0], []string{"cat1"})
+	rps.Category.Assign(ctx, ids[1], []string{"cat2", "cat3"})
+
+	res, err := rps.Product.Search(ctx, repo.Query{"category": {"cat1", "cat3"}}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0].(model.Product).ID != ids[0] || res[1].(model.Product).ID != ids[1] {
+		t.Errorf("Chef.Search() = %v, want %v", res, ids[:2])
+	}
+}
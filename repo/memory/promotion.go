@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Herald is the in-memory implementation of repo.Promotion
+type Herald struct {
+	s  *Store
+	tx bool
+}
+
+// NewHerald returns a new Herald backed by s
+func NewHerald(s *Store) *Herald {
+	return &Herald{
+		s: s,
+	}
+}
+
+// Create creates a new promotion
+func (h *Herald) Create(ctx context.Context, v interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	prm, ok := v.(model.Promotion)
+	if !ok {
+		return "", repo.ErrUnsupportedType
+	}
+	prm.ID = uuid.NewV4().String()
+	prm.Tags = model.NormalizeTags(prm.Tags)
+
+	if err := prm.Validate(); err != nil {
+		return "", err
+	}
+
+	defer h.s.write(h.tx)()
+
+	now := time.Now()
+	prm.CreatedAt = now
+	prm.UpdatedAt = now
+	h.s.prms[prm.ID] = copyPromotion(prm)
+	h.s.prmOrder = append(h.s.prmOrder, prm.ID)
+	return prm.ID, nil
+}
+
+// Fetch returns a model.Promotion finding by its id
+func (h *Herald) Fetch(ctx context.Context, id string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer h.s.read(h.tx)()
+
+	prm, ok := h.s.prms[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyPromotion(prm), nil
+}
+
+// Update updates a promotion
+func (h *Herald) Update(ctx context.Context, id string, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	prm, ok := v.(model.Promotion)
+	if !ok {
+		return repo.ErrUnsupportedType
+	}
+	prm.Tags = model.NormalizeTags(prm.Tags)
+	if err := prm.Validate(); err != nil {
+		return err
+	}
+
+	defer h.s.write(h.tx)()
+
+	old, ok := h.s.prms[id]
+	if !ok {
+		return nil
+	}
+	prm.ID = id
+	prm.CreatedAt = old.CreatedAt
+	prm.UpdatedAt = time.Now()
+	h.s.prms[id] = copyPromotion(prm)
+	return nil
+}
+
+// Delete deletes a promotion
+func (h *Herald) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer h.s.write(h.tx)()
+
+	if _, ok := h.s.prms[id]; !ok {
+		return nil
+	}
+	delete(h.s.prms, id)
+	for i, pID := range h.s.prmOrder {
+		if pID == id {
+			h.s.prmOrder = append(h.s.prmOrder[:i:i], h.s.prmOrder[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Search returns the promotions of query q in creation order
+func (h *Herald) Search(ctx context.Context, q repo.Query, skip, limit int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer h.s.read(h.tx)()
+
+	prms := h.s.searchPromotions(q)
+	from, to := page(len(prms), skip, limit)
+	res := []interface{}{}
+	for _, prm := range prms[from:to] {
+		res = append(res, copyPromotion(prm))
+	}
+	return res, nil
+}
+
+// SearchCount returns number of promotions that matches query
+func (h *Herald) SearchCount(ctx context.Context, q repo.Query) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer h.s.read(h.tx)()
+
+	return len(h.s.searchPromotions(q)), nil
+}
+
+// searchPromotions returns the promotions matching q in creation order
+// s must be locked by the caller
+func (s *Store) searchPromotions(q repo.Query) []model.Promotion {
+	prms := []model.Promotion{}
+	for _, id := range s.prmOrder {
+		prm := s.prms[id]
+		if at := q["active_at"]; len(at) != 0 {
+			t, ok := at[0].(time.Time)
+			if !ok || !prm.Active(t) {
+				continue
+			}
+		}
+		prms = append(prms, prm)
+	}
+	return prms
+}
+
+// copyPromotion returns prm with copies of its targets
+// so that the stored targets are never shared
+func copyPromotion(prm model.Promotion) model.Promotion {
+	prm.ProductIDs = append([]string{}, prm.ProductIDs...)
+	prm.CategoryIDs = append([]string{}, prm.CategoryIDs...)
+	prm.Tags = append([]string{}, prm.Tags...)
+	return prm
+}
+
+// RemoveProducts drops the products of pdtIDs from the targets of every promotion
+// the promotions are kept even if they target nothing else
+func (h *Herald) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer h.s.write(h.tx)()
+
+	rm := map[string]bool{}
+	for _, id := range pdtIDs {
+		rm[id] = true
+	}
+	for id, prm := range h.s.prms {
+		ids := []string{}
+		for _, pdtID := range prm.ProductIDs {
+			if !rm[pdtID] {
+				ids = append(ids, pdtID)
+			}
+		}
+		if len(ids) != len(prm.ProductIDs) {
+			prm.ProductIDs = ids
+			h.s.prms[id] = prm
+		}
+	}
+	return nil
+}
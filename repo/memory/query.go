@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"regexp"
+	"strings"
+)
+
+// like reports whether s matches the sql LIKE pattern
+// % matches any sequence, _ matches a single character
+// and backslash escapes the next character
+func like(pattern, s string) bool {
+	b := strings.Builder{}
+	b.WriteString("^")
+	esc := false
+	for _, r := range pattern {
+		switch {
+		case esc:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			esc = false
+		case r == '\\':
+			esc = true
+		case r == '%':
+			b.WriteString("(?s:.*)")
+		case r == '_':
+			b.WriteString("(?s:.)")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()).MatchString(s)
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	}
+	if d, ok := toInt(v); ok {
+		return float64(d), true
+	}
+	return 0, false
+}
+
+// page returns the skip and limit bounded part of n items
+func page(n, skip, limit int) (int, int) {
+	if skip < 0 {
+		skip = 0
+	}
+	if skip > n {
+		skip = n
+	}
+	end := n
+	if limit >= 0 && skip+limit < n {
+		end = skip + limit
+	}
+	return skip, end
+}
+
+// without returns the ids of order that are not in ids keeping their order
+func without(order, ids []string) []string {
+	rm := map[string]bool{}
+	for _, id := range ids {
+		rm[id] = true
+	}
+	res := []string{}
+	for _, id := range order {
+		if !rm[id] {
+			res = append(res, id)
+		}
+	}
+	return res
+}
@@ -0,0 +1,34 @@
+package memory
+
+import "testing"
+
+func Test_like(t *testing.T) {
+	type args struct {
+		pattern string
+		s       string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{args: args{pattern: "Test", s: "Test"}, want: true},
+		{args: args{pattern: "Test", s: "test"}, want: false},
+		{args: args{pattern: "Test", s: "Test1"}, want: false},
+		{args: args{pattern: "Te%", s: "Test1"}, want: true},
+		{args: args{pattern: "%st%", s: "Test1"}, want: true},
+		{args: args{pattern: "T_st", s: "Test"}, want: true},
+		{args: args{pattern: "T_st", s: "Tst"}, want: false},
+		{args: args{pattern: `100\%`, s: "100%"}, want: true},
+		{args: args{pattern: `100\%`, s: "1000"}, want: false},
+		{args: args{pattern: "a.c", s: "abc"}, want: false},
+		{args: args{pattern: "O'Brien", s: "O'Brien"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := like(tt.args.pattern, tt.args.s); got != tt.want {
+				t.Errorf("like(%q, %q) = %v, want %v", tt.args.pattern, tt.args.s, got, tt.want)
+			}
+		})
+	}
+}
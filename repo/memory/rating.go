@@ -0,0 +1,312 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// ErrUnknownField is returned when aggregating a field that ratings do not have
+var ErrUnknownField = errors.New("memory: unknown field")
+
+// Critic is the in-memory implementation of repo.Rating
+type Critic struct {
+	s  *Store
+	tx bool
+}
+
+// NewCritic returns a new Critic backed by s
+func NewCritic(s *Store) *Critic {
+	return &Critic{
+		s: s,
+	}
+}
+
+// Create creates a new rating in Critic
+// it returns repo.ErrDuplicateRating if the user has already rated the product
+func (c *Critic) Create(ctx context.Context, v interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	rat, ok := v.(model.Rating)
+	if !ok {
+		return "", repo.ErrUnsupportedType
+	}
+	rat.ID = uuid.NewV4().String()
+	if rat.Status == "" {
+		rat.Status = model.RatingApproved
+	}
+
+	if err := rat.Validate(); err != nil {
+		return "", err
+	}
+
+	defer c.s.write(c.tx)()
+
+	if len(c.s.searchRatings(repo.Query{"product_id": {rat.ProductID}, "user_id": {rat.User.ID}})) != 0 {
+		return "", repo.ErrDuplicateRating
+	}
+
+	now := time.Now()
+	rat.CreatedAt = now
+	rat.UpdatedAt = now
+	c.s.ratings[rat.ID] = rat
+	c.s.ratOrder = append(c.s.ratOrder, rat.ID)
+	c.s.summarize(model.Rating{}, rat)
+	return rat.ID, nil
+}
+
+// summarize replaces rating old with rat in the summary of the product of rat
+// only the approved ratings are counted, every rated product has a summary
+// s must be locked by the caller
+func (s *Store) summarize(old, rat model.Rating) {
+	sum := s.ratSums[rat.ProductID]
+	sum.ProductID = rat.ProductID
+	if old.Status == model.RatingApproved {
+		sum.Remove(old.Value)
+	}
+	if rat.Status == model.RatingApproved {
+		sum.Add(rat.Value)
+	}
+	s.ratSums[rat.ProductID] = sum
+}
+
+// Fetch returns a model.Rating finding by its id
+func (c *Critic) Fetch(ctx context.Context, id string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	rat, ok := c.s.ratings[id]
+	if !ok {
+		return nil, nil
+	}
+	return rat, nil
+}
+
+// Update updates the value, review text, status and user name of a rating
+// its product and user are never changed, the summary of the product is kept in sync
+func (c *Critic) Update(ctx context.Context, id string, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rat, ok := v.(model.Rating)
+	if !ok {
+		return repo.ErrUnsupportedType
+	}
+	rat.ID = id
+	if rat.Status == "" {
+		rat.Status = model.RatingApproved
+	}
+	if err := rat.Validate(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	old, ok := c.s.ratings[id]
+	if !ok {
+		return nil
+	}
+	upd := old
+	upd.Update(rat)
+	upd.User.Name = rat.User.Name
+	upd.Status = rat.Status
+	upd.Reason = rat.Reason
+	upd.UpdatedAt = time.Now()
+	c.s.summarize(old, upd)
+	c.s.ratings[id] = upd
+	return nil
+}
+
+// Moderate changes the status of a rating from from to to with the reason
+// the summary of its product is kept in sync
+// it returns repo.ErrStaleStatus if the rating is not in status from
+func (c *Critic) Moderate(ctx context.Context, id string, from, to string, reason string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !model.RatingStatus(to).Valid() {
+		return model.ValidationError{"Status": []string{"is invalid"}}
+	}
+
+	defer c.s.write(c.tx)()
+
+	old, ok := c.s.ratings[id]
+	if !ok || string(old.Status) != from {
+		return repo.ErrStaleStatus
+	}
+	upd := old
+	upd.Status = model.RatingStatus(to)
+	upd.Reason = reason
+	c.s.summarize(old, upd)
+	c.s.ratings[id] = upd
+	return nil
+}
+
+// Delete deletes a rating and takes it out of the summary of its product
+func (c *Critic) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	rat, ok := c.s.ratings[id]
+	if !ok {
+		return nil
+	}
+	c.s.summarize(rat, model.Rating{ProductID: rat.ProductID})
+
+	delete(c.s.ratings, id)
+	c.s.ratOrder = without(c.s.ratOrder, []string{id})
+	return nil
+}
+
+// Search returns the ratings of query q, the latest first unless sorted by created_at
+func (c *Critic) Search(ctx context.Context, q repo.Query, skip, limit int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	rats := c.s.searchRatings(q)
+	from, to := page(len(rats), skip, limit)
+	res := []interface{}{}
+	for _, rat := range rats[from:to] {
+		res = append(res, rat)
+	}
+	return res, nil
+}
+
+// SearchCount returns number of ratings that matches query
+func (c *Critic) SearchCount(ctx context.Context, q repo.Query) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	return len(c.s.searchRatings(q)), nil
+}
+
+// searchRatings returns the ratings matching q, the latest first unless sorted by created_at
+// s must be locked by the caller
+func (s *Store) searchRatings(q repo.Query) []model.Rating {
+	oldest := false
+	if srt := q["sort"]; len(srt) != 0 && fmt.Sprint(srt[0]) == "created_at" {
+		oldest = true
+	}
+	rats := []model.Rating{}
+	for i := range s.ratOrder {
+		if !oldest {
+			i = len(s.ratOrder) - 1 - i
+		}
+		rat := s.ratings[s.ratOrder[i]]
+		if pdtID := q["product_id"]; len(pdtID) != 0 && rat.ProductID != fmt.Sprint(pdtID[0]) {
+			continue
+		}
+		if usrID := q["user_id"]; len(usrID) != 0 && rat.User.ID != fmt.Sprint(usrID[0]) {
+			continue
+		}
+		if sts := q["status"]; len(sts) != 0 && string(rat.Status) != fmt.Sprint(sts[0]) {
+			continue
+		}
+		rats = append(rats, rat)
+	}
+	return rats
+}
+
+// Avg returns the aggregated average value of the approved ratings selected by query
+func (c *Critic) Avg(ctx context.Context, q repo.Query, field string) (float64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if field != "value" {
+		return 0, ErrUnknownField
+	}
+
+	defer c.s.read(c.tx)()
+
+	sum, n := 0, 0
+	for _, id := range c.s.ratOrder {
+		rat := c.s.ratings[id]
+		if pdtID := q["product_id"]; len(pdtID) != 0 && rat.ProductID != pdtID[0] {
+			continue
+		}
+		if rat.Status != model.RatingApproved {
+			continue
+		}
+		sum += rat.Value
+		n++
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return float64(sum) / float64(n), nil
+}
+
+// Summaries returns the stored rating summaries of the products of pdtIDs by product id
+func (c *Critic) Summaries(ctx context.Context, pdtIDs []string) (map[string]model.RatingSummary, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	sums := map[string]model.RatingSummary{}
+	for _, id := range pdtIDs {
+		if sum, ok := c.s.ratSums[id]; ok {
+			sums[id] = sum
+		}
+	}
+	return sums, nil
+}
+
+// RebuildSummaries recomputes the rating summaries from the approved ratings and returns their number
+func (c *Critic) RebuildSummaries(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer c.s.write(c.tx)()
+
+	c.s.ratSums = map[string]model.RatingSummary{}
+	for _, id := range c.s.ratOrder {
+		c.s.summarize(model.Rating{}, c.s.ratings[id])
+	}
+	return len(c.s.ratSums), nil
+}
+
+// RemoveProducts permanently removes the ratings and summaries of the products of pdtIDs
+func (c *Critic) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	rm := map[string]bool{}
+	for _, id := range pdtIDs {
+		rm[id] = true
+		delete(c.s.ratSums, id)
+	}
+	ids := []string{}
+	for _, id := range c.s.ratOrder {
+		if rm[c.s.ratings[id].ProductID] {
+			delete(c.s.ratings, id)
+			ids = append(ids, id)
+		}
+	}
+	c.s.ratOrder = without(c.s.ratOrder, ids)
+	return nil
+}
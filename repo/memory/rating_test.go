@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+)
+
+func TestCritic_RebuildSummaries(t *testing.T) {
+	s := NewStore()
+	c := NewCritic(s)
+	ctx := context.Background()
+	if _, err := c.Create(ctx, model.Rating{ProductID: "1", User: model.User{ID: "u1"}, Value: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	// drifted summaries are replaced and orphan ones are dropped
+	s.ratSums["1"] = model.RatingSummary{ProductID: "1", Count: 7}
+	s.ratSums["2"] = model.RatingSummary{ProductID: "2", Count: 1}
+
+	if n, err := c.RebuildSummaries(ctx); err != nil || n != 1 {
+		t.Fatalf("RebuildSummaries() = %v, %v, want 1", n, err)
+	}
+	want := map[string]model.RatingSummary{
+		"1": {ProductID: "1", Count: 1, Sum: 3, Avg: 3, Histogram: [5]int{0, 0, 1, 0, 0}},
+	}
+	if !reflect.DeepEqual(s.ratSums, want) {
+		t.Errorf("RebuildSummaries() summaries = %v, want %v", s.ratSums, want)
+	}
+}
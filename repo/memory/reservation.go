@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Clerk is the in-memory implementation of repo.Reservation
+type Clerk struct {
+	s  *Store
+	tx bool
+}
+
+// NewClerk returns a new Clerk backed by s
+func NewClerk(s *Store) *Clerk {
+	return &Clerk{
+		s: s,
+	}
+}
+
+// Create creates a new reservation
+func (c *Clerk) Create(ctx context.Context, v interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	rsv, ok := v.(model.Reservation)
+	if !ok {
+		return "", repo.ErrUnsupportedType
+	}
+	rsv.ID = uuid.NewV4().String()
+
+	if err := rsv.Validate(); err != nil {
+		return "", err
+	}
+
+	defer c.s.write(c.tx)()
+
+	now := time.Now()
+	rsv.CreatedAt = now
+	rsv.UpdatedAt = now
+	c.s.rsvs[rsv.ID] = rsv
+	c.s.rsvOrder = append(c.s.rsvOrder, rsv.ID)
+	return rsv.ID, nil
+}
+
+// Fetch returns a model.Reservation finding by its id
+func (c *Clerk) Fetch(ctx context.Context, id string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	rsv, ok := c.s.rsvs[id]
+	if !ok {
+		return nil, nil
+	}
+	return rsv, nil
+}
+
+// Search returns the reservations of query q ordered by their expiry
+func (c *Clerk) Search(ctx context.Context, q repo.Query, skip, limit int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	rsvs := c.s.searchReservations(q)
+	from, to := page(len(rsvs), skip, limit)
+	res := []interface{}{}
+	for _, rsv := range rsvs[from:to] {
+		res = append(res, rsv)
+	}
+	return res, nil
+}
+
+// SearchCount returns number of reservations that matches query
+func (c *Clerk) SearchCount(ctx context.Context, q repo.Query) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer c.s.read(c.tx)()
+
+	return len(c.s.searchReservations(q)), nil
+}
+
+// SetStatus changes the status of a reservation from from to to
+// it returns repo.ErrStaleStatus if the reservation is not in status from
+func (c *Clerk) SetStatus(ctx context.Context, id string, from, to string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	rsv, ok := c.s.rsvs[id]
+	if !ok || string(rsv.Status) != from {
+		return repo.ErrStaleStatus
+	}
+	rsv.Status = model.ReservationStatus(to)
+	rsv.UpdatedAt = time.Now()
+	c.s.rsvs[id] = rsv
+	return nil
+}
+
+// searchReservations returns the reservations matching q ordered by their expiry
+// s must be locked by the caller
+func (s *Store) searchReservations(q repo.Query) []model.Reservation {
+	rsvs := []model.Reservation{}
+	for _, id := range s.rsvOrder {
+		rsv := s.rsvs[id]
+		if pdtID := q["product_id"]; len(pdtID) != 0 && rsv.ProductID != pdtID[0] {
+			continue
+		}
+		if sts := q["status"]; len(sts) != 0 && string(rsv.Status) != fmt.Sprint(sts[0]) {
+			continue
+		}
+		if exp := q["expires_before"]; len(exp) != 0 {
+			t, ok := exp[0].(time.Time)
+			if !ok || rsv.ExpiresAt.After(t) {
+				continue
+			}
+		}
+		rsvs = append(rsvs, rsv)
+	}
+	sort.SliceStable(rsvs, func(i, j int) bool {
+		return rsvs[i].ExpiresAt.Before(rsvs[j].ExpiresAt)
+	})
+	return rsvs
+}
+
+// RemoveProducts permanently removes the reservations of the products of pdtIDs
+func (c *Clerk) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer c.s.write(c.tx)()
+
+	rm := map[string]bool{}
+	for _, id := range pdtIDs {
+		rm[id] = true
+	}
+	ids := []string{}
+	for _, id := range c.s.rsvOrder {
+		if rm[c.s.rsvs[id].ProductID] {
+			delete(c.s.rsvs, id)
+			ids = append(ids, id)
+		}
+	}
+	c.s.rsvOrder = without(c.s.rsvOrder, ids)
+	return nil
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Keeper is the in-memory implementation of repo.Stock
+type Keeper struct {
+	s  *Store
+	tx bool
+}
+
+// NewKeeper returns a new Keeper backed by s
+func NewKeeper(s *Store) *Keeper {
+	return &Keeper{
+		s: s,
+	}
+}
+
+// Create appends a new stock movement to the ledger
+func (k *Keeper) Create(ctx context.Context, v interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	mvt, ok := v.(model.StockMovement)
+	if !ok {
+		return "", repo.ErrUnsupportedType
+	}
+	mvt.ID = uuid.NewV4().String()
+
+	if err := mvt.Validate(); err != nil {
+		return "", err
+	}
+
+	defer k.s.write(k.tx)()
+
+	mvt.CreatedAt = time.Now()
+	k.s.movements[mvt.ID] = mvt
+	k.s.mvtOrder = append(k.s.mvtOrder, mvt.ID)
+	return mvt.ID, nil
+}
+
+// Search returns the stock movements of query q latest first
+func (k *Keeper) Search(ctx context.Context, q repo.Query, skip, limit int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer k.s.read(k.tx)()
+
+	mvts := k.s.searchMovements(q)
+	from, to := page(len(mvts), skip, limit)
+	res := []interface{}{}
+	for _, mvt := range mvts[from:to] {
+		res = append(res, mvt)
+	}
+	return res, nil
+}
+
+// SearchCount returns number of stock movements that matches query
+func (k *Keeper) SearchCount(ctx context.Context, q repo.Query) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer k.s.read(k.tx)()
+
+	return len(k.s.searchMovements(q)), nil
+}
+
+// searchMovements returns the movements matching q latest first
+// s must be locked by the caller
+func (s *Store) searchMovements(q repo.Query) []model.StockMovement {
+	mvts := []model.StockMovement{}
+	for i := len(s.mvtOrder) - 1; i >= 0; i-- {
+		mvt := s.movements[s.mvtOrder[i]]
+		if pdtID := q["product_id"]; len(pdtID) != 0 && mvt.ProductID != pdtID[0] {
+			continue
+		}
+		if loc := q["location_id"]; len(loc) != 0 && mvt.LocationID != loc[0] {
+			continue
+		}
+		if typ := q["type"]; len(typ) != 0 && string(mvt.Type) != typ[0] {
+			continue
+		}
+		mvts = append(mvts, mvt)
+	}
+	return mvts
+}
+
+// RemoveProducts permanently removes the stock movements of the products of pdtIDs
+func (k *Keeper) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer k.s.write(k.tx)()
+
+	rm := map[string]bool{}
+	for _, id := range pdtIDs {
+		rm[id] = true
+	}
+	ids := []string{}
+	for _, id := range k.s.mvtOrder {
+		if rm[k.s.movements[id].ProductID] {
+			delete(k.s.movements, id)
+			ids = append(ids, id)
+		}
+	}
+	k.s.mvtOrder = without(k.s.mvtOrder, ids)
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package memory implements the repo interfaces on top of in-memory maps
+// it is meant for development and tests where no database is available
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Store holds the in-memory data shared by the memory repos
+// it is safe for concurrent use
+type Store struct {
+	// txMu serializes transactions and reads and writes made outside of them
+	txMu sync.Mutex
+	// mu guards data
+	mu sync.RWMutex
+
+	*data
+}
+
+// data holds the records of every memory repo
+// the order slices keep the ids in creation order
+type data struct {
+	products  map[string]model.Product
+	pdtOrder  []string
+	ratings   map[string]model.Rating
+	ratOrder  []string
+	ratSums   map[string]model.RatingSummary
+	movements map[string]model.StockMovement
+	mvtOrder  []string
+	rsvs      map[string]model.Reservation
+	rsvOrder  []string
+	locations map[string]model.Location
+	locOrder  []string
+	levels    map[levelKey]model.StockLevel
+	cats      map[string]model.Category
+	catOrder  []string
+	assigns   map[assignKey]bool
+	vrts      map[string]model.Variant
+	vrtOrder  []string
+	prices    map[string]model.PriceChange
+	prcOrder  []string
+	prms      map[string]model.Promotion
+	prmOrder  []string
+}
+
+// levelKey is the key of a stock level in data
+type levelKey struct {
+	pdtID string
+	locID string
+}
+
+// assignKey is the key of a product category assignment in data
+type assignKey struct {
+	pdtID string
+	catID string
+}
+
+// NewStore returns a new empty Store
+func NewStore() *Store {
+	return &Store{
+		data: &data{
+			products:  map[string]model.Product{},
+			ratings:   map[string]model.Rating{},
+			ratSums:   map[string]model.RatingSummary{},
+			movements: map[string]model.StockMovement{},
+			rsvs:      map[string]model.Reservation{},
+			locations: map[string]model.Location{},
+			levels:    map[levelKey]model.StockLevel{},
+			cats:      map[string]model.Category{},
+			assigns:   map[assignKey]bool{},
+			vrts:      map[string]model.Variant{},
+			prices:    map[string]model.PriceChange{},
+			prms:      map[string]model.Promotion{},
+		},
+	}
+}
+
+// Repos returns the memory Repos backed by s
+func (s *Store) Repos() repo.Repos {
+	return s.repos(false)
+}
+
+func (s *Store) repos(inTx bool) repo.Repos {
+	return repo.Repos{
+		Product:     &Chef{s: s, tx: inTx},
+		Rating:      &Critic{s: s, tx: inTx},
+		Stock:       &Keeper{s: s, tx: inTx},
+		Reservation: &Clerk{s: s, tx: inTx},
+		Location:    &Scout{s: s, tx: inTx},
+		StockLevel:  &Porter{s: s, tx: inTx},
+		Category:    &Curator{s: s, tx: inTx},
+		Variant:     &Tailor{s: s, tx: inTx},
+		Price:       &Appraiser{s: s, tx: inTx},
+		Promotion:   &Herald{s: s, tx: inTx},
+	}
+}
+
+// Do runs fn with Repos backed by s, it implements repo.UnitOfWork
+// transactions are serialized and every change made by fn
+// is reverted if fn returns an error or panics
+func (s *Store) Do(ctx context.Context, fn func(r repo.Repos) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	snap := s.snapshot()
+	defer func() {
+		if p := recover(); p != nil {
+			s.restore(snap)
+			panic(p)
+		}
+	}()
+
+	if err := fn(s.repos(true)); err != nil {
+		s.restore(snap)
+		return err
+	}
+	return nil
+}
+
+// write locks s for writing and returns the unlock func
+// writes outside of a transaction wait for the running transaction
+// so that a rollback never discards them
+func (s *Store) write(inTx bool) func() {
+	if !inTx {
+		s.txMu.Lock()
+	}
+	s.mu.Lock()
+	return func() {
+		s.mu.Unlock()
+		if !inTx {
+			s.txMu.Unlock()
+		}
+	}
+}
+
+// read locks s for reading and returns the unlock func
+// reads outside of a transaction wait for the running transaction
+// so that they never see its uncommitted changes
+func (s *Store) read(inTx bool) func() {
+	if !inTx {
+		s.txMu.Lock()
+	}
+	s.mu.RLock()
+	return func() {
+		s.mu.RUnlock()
+		if !inTx {
+			s.txMu.Unlock()
+		}
+	}
+}
+
+// snapshot returns a copy of the data of s
+// it is taken by the running transaction
+func (s *Store) snapshot() *data {
+	defer s.read(true)()
+	return s.data.clone()
+}
+
+// restore replaces the data of s with snap
+func (s *Store) restore(snap *data) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = snap
+}
+
+// clone returns a copy of d, the records are copied by value
+func (d *data) clone() *data {
+	c := &data{
+		products:  make(map[string]model.Product, len(d.products)),
+		pdtOrder:  append([]string(nil), d.pdtOrder...),
+		ratings:   make(map[string]model.Rating, len(d.ratings)),
+		ratOrder:  append([]string(nil), d.ratOrder...),
+		ratSums:   make(map[string]model.RatingSummary, len(d.ratSums)),
+		movements: make(map[string]model.StockMovement, len(d.movements)),
+		mvtOrder:  append([]string(nil), d.mvtOrder...),
+		rsvs:      make(map[string]model.Reservation, len(d.rsvs)),
+		rsvOrder:  append([]string(nil), d.rsvOrder...),
+		locations: make(map[string]model.Location, len(d.locations)),
+		locOrder:  append([]string(nil), d.locOrder...),
+		levels:    make(map[levelKey]model.StockLevel, len(d.levels)),
+		cats:      make(map[string]model.Category, len(d.cats)),
+		catOrder:  append([]string(nil), d.catOrder...),
+		assigns:   make(map[assignKey]bool, len(d.assigns)),
+		vrts:      make(map[string]model.Variant, len(d.vrts)),
+		vrtOrder:  append([]string(nil), d.vrtOrder...),
+		prices:    make(map[string]model.PriceChange, len(d.prices)),
+		prcOrder:  append([]string(nil), d.prcOrder...),
+		prms:      make(map[string]model.Promotion, len(d.prms)),
+		prmOrder:  append([]string(nil), d.prmOrder...),
+	}
+	for k, v := range d.products {
+		c.products[k] = v
+	}
+	for k, v := range d.ratings {
+		c.ratings[k] = v
+	}
+	for k, v := range d.ratSums {
+		c.ratSums[k] = v
+	}
+	for k, v := range d.movements {
+		c.movements[k] = v
+	}
+	for k, v := range d.rsvs {
+		c.rsvs[k] = v
+	}
+	for k, v := range d.locations {
+		c.locations[k] = v
+	}
+	for k, v := range d.levels {
+		c.levels[k] = v
+	}
+	for k, v := range d.cats {
+		c.cats[k] = v
+	}
+	for k, v := range d.assigns {
+		c.assigns[k] = v
+	}
+	for k, v := range d.vrts {
+		c.vrts[k] = v
+	}
+	for k, v := range d.prices {
+		c.prices[k] = v
+	}
+	for k, v := range d.prms {
+		c.prms[k] = v
+	}
+	return c
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/repo/repotest"
+)
+
+func TestStore_Do(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	errFn := errors.New("fn failed")
+
+	tests := []struct {
+		name      string
+		fn        func(r repo.Repos) error
+		wantErr   error
+		wantCount int
+	}{
+		{
+			fn: func(r repo.Repos) error {
+				_, err := r.Product.Create(ctx, model.Product{Name: "Test", Price: 100, Weight: 1})
+				return err
+			},
+			wantErr:   nil,
+			wantCount: 1,
+		},
+		{
+			fn: func(r repo.Repos) error {
+				if _, err := r.Product.Create(ctx, model.Product{Name: "Test", Price: 100, Weight: 1}); err != nil {
+					return err
+				}
+				return errFn
+			},
+			wantErr:   errFn,
+			wantCount: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Do(ctx, tt.fn); err != tt.wantErr {
+				t.Errorf("Store.Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n, _ := s.Repos().Product.Count(ctx); n != tt.wantCount {
+				t.Errorf("Store.Do() count = %v, want %v", n, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestStore_DoPanic(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+
+	func() {
+		defer func() {
+			if p := recover(); p == nil {
+				t.Errorf("Store.Do() did not re-panic")
+			}
+		}()
+		s.Do(ctx, func(r repo.Repos) error {
+			r.Rating.Create(ctx, model.Rating{ProductID: "1", User: model.User{ID: "u1"}, Value: 3})
+			panic("fn panicked")
+		})
+	}()
+
+	if avg, _ := s.Repos().Rating.Avg(ctx, repo.Query{}, "value"); avg != 0 {
+		t.Errorf("Store.Do() avg after panic = %v, want %v", avg, 0)
+	}
+}
+
+func TestStore_Conformance(t *testing.T) {
+	repotest.RunSuites(t, func(t *testing.T) repo.Repos {
+		return NewStore().Repos()
+	})
+	repotest.RunUnitOfWorkSuite(t, func(t *testing.T) (repo.UnitOfWork, repo.Repos) {
+		s := NewStore()
+		return s, s.Repos()
+	})
+}
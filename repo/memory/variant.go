@@ -0,0 +1,221 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Tailor is the in-memory implementation of repo.Variant
+type Tailor struct {
+	s  *Store
+	tx bool
+}
+
+// NewTailor returns a new Tailor backed by s
+func NewTailor(s *Store) *Tailor {
+	return &Tailor{
+		s: s,
+	}
+}
+
+// Create creates a new variant
+func (t *Tailor) Create(ctx context.Context, v interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	vrt, ok := v.(model.Variant)
+	if !ok {
+		return "", repo.ErrUnsupportedType
+	}
+	vrt.ID = uuid.NewV4().String()
+	vrt.Options = model.NormalizeOptions(vrt.Options)
+
+	if err := vrt.Validate(); err != nil {
+		return "", err
+	}
+
+	defer t.s.write(t.tx)()
+
+	if err := t.s.checkVariant(vrt); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	vrt.CreatedAt = now
+	vrt.UpdatedAt = now
+	t.s.vrts[vrt.ID] = vrt
+	t.s.vrtOrder = append(t.s.vrtOrder, vrt.ID)
+	return vrt.ID, nil
+}
+
+// Fetch returns a model.Variant finding by its id
+func (t *Tailor) Fetch(ctx context.Context, id string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer t.s.read(t.tx)()
+
+	vrt, ok := t.s.vrts[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyVariant(vrt), nil
+}
+
+// Update updates a variant, its product is never changed
+func (t *Tailor) Update(ctx context.Context, id string, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	vrt, ok := v.(model.Variant)
+	if !ok {
+		return repo.ErrUnsupportedType
+	}
+	vrt.Options = model.NormalizeOptions(vrt.Options)
+	if err := vrt.Validate(); err != nil {
+		return err
+	}
+
+	defer t.s.write(t.tx)()
+
+	old, ok := t.s.vrts[id]
+	if !ok {
+		return nil
+	}
+	vrt.ID = id
+	vrt.ProductID = old.ProductID
+	if err := t.s.checkVariant(vrt); err != nil {
+		return err
+	}
+	old.Options = vrt.Options
+	old.SKU = vrt.SKU
+	old.Price = vrt.Price
+	old.Weight = vrt.Weight
+	old.Quantity = vrt.Quantity
+	old.UpdatedAt = time.Now()
+	t.s.vrts[id] = old
+	return nil
+}
+
+// Delete deletes a variant
+func (t *Tailor) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer t.s.write(t.tx)()
+
+	if _, ok := t.s.vrts[id]; !ok {
+		return nil
+	}
+	delete(t.s.vrts, id)
+	for i, vID := range t.s.vrtOrder {
+		if vID == id {
+			t.s.vrtOrder = append(t.s.vrtOrder[:i:i], t.s.vrtOrder[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Search returns the variants of query q in creation order
+func (t *Tailor) Search(ctx context.Context, q repo.Query, skip, limit int) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer t.s.read(t.tx)()
+
+	vrts := t.s.searchVariants(q)
+	from, to := page(len(vrts), skip, limit)
+	res := []interface{}{}
+	for _, vrt := range vrts[from:to] {
+		res = append(res, copyVariant(vrt))
+	}
+	return res, nil
+}
+
+// SearchCount returns number of variants that matches query
+func (t *Tailor) SearchCount(ctx context.Context, q repo.Query) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer t.s.read(t.tx)()
+
+	return len(t.s.searchVariants(q)), nil
+}
+
+// searchVariants returns the variants matching q in creation order
+// s must be locked by the caller
+func (s *Store) searchVariants(q repo.Query) []model.Variant {
+	vrts := []model.Variant{}
+	for _, id := range s.vrtOrder {
+		vrt := s.vrts[id]
+		if pdtID := q["product_id"]; len(pdtID) != 0 && vrt.ProductID != fmt.Sprint(pdtID[0]) {
+			continue
+		}
+		vrts = append(vrts, vrt)
+	}
+	return vrts
+}
+
+// checkVariant returns ErrDuplicateVariant if another variant of the product
+// has the options of vrt or ErrDuplicateSKU if another variant uses its sku
+// s must be locked by the caller
+func (s *Store) checkVariant(vrt model.Variant) error {
+	opts := model.EncodeOptions(vrt.Options)
+	for _, old := range s.vrts {
+		if old.ID == vrt.ID {
+			continue
+		}
+		if old.ProductID == vrt.ProductID && model.EncodeOptions(old.Options) == opts {
+			return repo.ErrDuplicateVariant
+		}
+		if vrt.SKU != "" && old.SKU == vrt.SKU {
+			return repo.ErrDuplicateSKU
+		}
+	}
+	return nil
+}
+
+// copyVariant returns vrt with a copy of its options
+// so that the stored options are never shared
+func copyVariant(vrt model.Variant) model.Variant {
+	opts := map[string]string{}
+	for axis, val := range vrt.Options {
+		opts[axis] = val
+	}
+	vrt.Options = opts
+	return vrt
+}
+
+// RemoveProducts permanently removes the variants of the products of pdtIDs
+func (t *Tailor) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer t.s.write(t.tx)()
+
+	rm := map[string]bool{}
+	for _, id := range pdtIDs {
+		rm[id] = true
+	}
+	ids := []string{}
+	for _, id := range t.s.vrtOrder {
+		if rm[t.s.vrts[id].ProductID] {
+			delete(t.s.vrts, id)
+			ids = append(ids, id)
+		}
+	}
+	t.s.vrtOrder = without(t.s.vrtOrder, ids)
+	return nil
+}
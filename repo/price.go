@@ -0,0 +1,189 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/model"
+)
+
+// Price interface is the repo wrapper of product price change
+// price changes are searched by product_id, status and effective_before
+type Price interface {
+	Creator
+	Fetcher
+	Searcher
+	StatusSetter
+	ProductRemover
+}
+
+// priceColumns are the selected columns of a price change in scan order
+const priceColumns = `"id", "product_id", "price", "status", "effective_at", "created_at", "updated_at"`
+
+func scanPrice(row infra.Row) (model.PriceChange, error) {
+	prc := model.PriceChange{}
+	var sts string
+	err := row.Scan(&prc.ID, &prc.ProductID, &prc.Price, &sts, &prc.EffectiveAt, &prc.CreatedAt, &prc.UpdatedAt)
+	prc.Status = model.PriceStatus(sts)
+	return prc, err
+}
+
+// Appraiser is an implementation of Price
+type Appraiser struct {
+	table string
+	db    infra.DB
+}
+
+// NewAppraiser returns a new Appraiser with table name tab
+// it returns ErrInvalidTable if tab is not a valid sql identifier
+func NewAppraiser(tab string, db infra.DB) (*Appraiser, error) {
+	if !isIdent(tab) {
+		return nil, ErrInvalidTable
+	}
+	return &Appraiser{
+		table: tab,
+		db:    db,
+	}, nil
+}
+
+// Create creates a new price change
+func (a *Appraiser) Create(ctx context.Context, v interface{}) (string, error) {
+	ctx = infra.WithOperation(ctx, "price.create")
+	prc, ok := v.(model.PriceChange)
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+	prc.ID = uuid.NewV4().String()
+
+	if err := prc.Validate(); err != nil {
+		return "", err
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %s ("id", "product_id", "price", "status", "effective_at") VALUES($1, $2, $3, $4, $5)`, a.table)
+	err := a.db.Exec(ctx, stmt, prc.ID, prc.ProductID, prc.Price, string(prc.Status), prc.EffectiveAt)
+	if err != nil {
+		return "", err
+	}
+	return prc.ID, nil
+}
+
+// Fetch returns a model.PriceChange finding by its id
+func (a *Appraiser) Fetch(ctx context.Context, id string) (interface{}, error) {
+	ctx = infra.WithOperation(ctx, "price.fetch")
+
+	row, err := a.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE "id"=$1`, priceColumns, a.table), id)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	if !row.Next() {
+		return nil, nil
+	}
+	prc, err := scanPrice(row)
+	if err != nil {
+		return nil, err
+	}
+	return prc, nil
+}
+
+// Search returns the price changes of query q ordered by their effective time
+func (a *Appraiser) Search(ctx context.Context, q Query, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "price.search")
+	qstmt, vals := buildPriceQuery(q)
+	str := fmt.Sprintf(`SELECT %s FROM %s`, priceColumns, a.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+	str = str + fmt.Sprintf(` ORDER BY "effective_at", "created_at", "id" OFFSET $%d LIMIT $%d`, len(vals)+1, len(vals)+2)
+	vals = append(vals, skip, limit)
+
+	rows, err := a.db.Query(ctx, str, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prcs := []interface{}{}
+	for rows.Next() {
+		prc, err := scanPrice(rows)
+		if err != nil {
+			return nil, err
+		}
+		prcs = append(prcs, prc)
+	}
+	return prcs, nil
+}
+
+// SearchCount returns number of price changes that matches query
+func (a *Appraiser) SearchCount(ctx context.Context, q Query) (int, error) {
+	ctx = infra.WithOperation(ctx, "price.search_count")
+	qstmt, vals := buildPriceQuery(q)
+	str := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, a.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+
+	rows, err := a.db.Query(ctx, str, vals...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// SetStatus changes the status of a price change from from to to
+// it returns ErrStaleStatus if the price change is not in status from
+// so that a scheduled price is applied only once
+func (a *Appraiser) SetStatus(ctx context.Context, id string, from, to string) error {
+	ctx = infra.WithOperation(ctx, "price.set_status")
+	stmt := fmt.Sprintf(`UPDATE %s SET ("status", "updated_at") = ($1, CURRENT_TIMESTAMP)
+		WHERE "id"=$2 AND "status"=$3 RETURNING "id"`, a.table)
+
+	rows, err := a.db.Query(ctx, stmt, to, id, from)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return ErrStaleStatus
+	}
+	return nil
+}
+
+func buildPriceQuery(q Query) (string, []interface{}) {
+	conds := []string{}
+	vals := []interface{}{}
+	if pdtID := q["product_id"]; len(pdtID) != 0 {
+		vals = append(vals, fmt.Sprint(pdtID[0]))
+		conds = append(conds, fmt.Sprintf(`"product_id" = $%d`, len(vals)))
+	}
+	if sts := q["status"]; len(sts) != 0 {
+		vals = append(vals, fmt.Sprint(sts[0]))
+		conds = append(conds, fmt.Sprintf(`"status" = $%d`, len(vals)))
+	}
+	if eff := q["effective_before"]; len(eff) != 0 {
+		vals = append(vals, eff[0])
+		conds = append(conds, fmt.Sprintf(`"effective_at" <= $%d`, len(vals)))
+	}
+	return strings.Join(conds, " AND "), vals
+}
+
+// RemoveProducts permanently removes the price changes of the products of pdtIDs
+func (a *Appraiser) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	ctx = infra.WithOperation(ctx, "price.remove_products")
+	return removeProducts(ctx, a.db, a.table, pdtIDs)
+}
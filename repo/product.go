@@ -1,7 +1,10 @@
 package repo
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/satori/go.uuid"
 
@@ -10,6 +13,9 @@ import (
 )
 
 // Product interface is the repo wrapper of product
+// products are searched by name, price, weight, available, status, location, category, tag, sku, barcode and min_rating
+// tag matches any of its values unless tag_match is "all"
+// the searched products are ordered by creation unless sort is "rating" or "-rating"
 type Product interface {
 	Creator
 	Fetcher
@@ -18,48 +24,109 @@ type Product interface {
 	Lister
 	Counter
 	Searcher
+	QuantityAdjuster
+	TagCounter
+	StatusSetter
+	PriceSetter
+	Restorer
+	Purger
+}
+
+// productColumns are the selected columns of a product in scan order
+// the prices are stored encoded by model.EncodePrices
+// the transition times are NULL until the first transition and scan as zero times
+const productColumns = `"id", "name", "price", "prices", "weight", "sku", "barcode", "quantity", "reserved", "available", "status", ` +
+	`COALESCE("published_at", '0001-01-01'), COALESCE("archived_at", '0001-01-01'), "deleted", "created_at", "updated_at", "deleted_at"`
+
+// columns returns the selected columns of a product in scan order
+// the tags are aggregated as a comma separated list after productColumns
+func (c *Chef) columns() string {
+	return fmt.Sprintf(`%s, COALESCE((SELECT string_agg("tag", ',' ORDER BY "tag") FROM %s WHERE "product_id" = %s."id"), '')`,
+		productColumns, c.tags, c.table)
+}
+
+func scanProduct(row infra.Row) (model.Product, error) {
+	pdt := model.Product{}
+	tags, prcs, sts := "", "", ""
+	err := row.Scan(&pdt.ID, &pdt.Name, &pdt.Price, &prcs, &pdt.Weight, &pdt.SKU, &pdt.Barcode,
+		&pdt.Quantity, &pdt.Reserved, &pdt.Available, &sts, &pdt.PublishedAt, &pdt.ArchivedAt,
+		&pdt.Deleted, &pdt.CreatedAt, &pdt.UpdatedAt, &pdt.DeletedAt, &tags)
+	pdt.Prices = model.DecodePrices(prcs)
+	pdt.Status = model.ProductStatus(sts)
+	pdt.Tags = []string{}
+	if tags != "" {
+		pdt.Tags = strings.Split(tags, ",")
+	}
+	return pdt, err
 }
 
 // Chef is an implementation of Product interface
 type Chef struct {
 	table string
-	db    infra.DB
+	// levels is the stock level table the location query is matched against
+	levels string
+	// assigns is the product category table the category query is matched against
+	assigns string
+	// tags is the product tag table
+	tags string
+	// ratings is the rating summary table the rating query and sort use
+	ratings string
+	db      infra.DB
 }
 
 // NewChef returns new Chef with table name tab
-func NewChef(tab string, db infra.DB) *Chef {
-	return &Chef{
-		table: tab,
-		db:    db,
+// the tags and the location, category and rating queries use the default tables
+// it returns ErrInvalidTable if tab is not a valid sql identifier
+func NewChef(tab string, db infra.DB) (*Chef, error) {
+	if !isIdent(tab) {
+		return nil, ErrInvalidTable
 	}
+	return &Chef{
+		table:   tab,
+		levels:  DefaultTables.StockLevels,
+		assigns: DefaultTables.ProductCategories,
+		tags:    DefaultTables.ProductTags,
+		ratings: DefaultTables.RatingSummaries,
+		db:      db,
+	}, nil
 }
 
 // Create a new product
-func (c *Chef) Create(v interface{}) (string, error) {
+func (c *Chef) Create(ctx context.Context, v interface{}) (string, error) {
+	ctx = infra.WithOperation(ctx, "product.create")
 	pdt, ok := v.(model.Product)
 	if !ok {
 		return "", ErrUnsupportedType
 	}
 	pdt.ID = uuid.NewV4().String()
+	pdt.Tags = model.NormalizeTags(pdt.Tags)
+	if pdt.Status == "" {
+		pdt.Status = model.ProductDraft
+	}
 
 	if err := pdt.Validate(); err != nil {
 		return "", err
 	}
 
-	err := c.db.Exec(fmt.Sprintf(`INSERT INTO %s ("id", "name", "price", "weight", "available") VALUES('%s', '%s', %d, %d, %t)`,
-		c.table, pdt.ID, pdt.Name, pdt.Price, pdt.Weight, pdt.Available,
-	))
+	// a product starts without stock, it is received by stock movements
+	err := c.db.Exec(ctx, fmt.Sprintf(`INSERT INTO %s ("id", "name", "price", "prices", "weight", "sku", "barcode", "status", "published_at", "archived_at", "quantity", "available")
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, %s, %s, 0, FALSE)`, c.table, transitionTime(8, model.ProductPublished, "NULL"), transitionTime(8, model.ProductArchived, "NULL")),
+		pdt.ID, pdt.Name, pdt.Price, model.EncodePrices(pdt.Prices), pdt.Weight, pdt.SKU, pdt.Barcode, string(pdt.Status),
+	)
 	if err != nil {
+		return "", duplicateCode(err)
+	}
+	if err := c.insertTags(ctx, pdt.ID, pdt.Tags); err != nil {
 		return "", err
 	}
 	return pdt.ID, nil
 }
 
 // Fetch returns a model.Product finding by its id
-func (c *Chef) Fetch(id string) (interface{}, error) {
-	pdt := model.Product{}
+func (c *Chef) Fetch(ctx context.Context, id string) (interface{}, error) {
+	ctx = infra.WithOperation(ctx, "product.fetch")
 
-	row, err := c.db.Query(fmt.Sprintf(`SELECT * FROM %s WHERE "id"='%s' AND "deleted"=FALSE`, c.table, id))
+	row, err := c.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE "id"=$1 AND "deleted"=FALSE`, c.columns(), c.table), id)
 	if err != nil {
 		return nil, err
 	}
@@ -68,8 +135,7 @@ func (c *Chef) Fetch(id string) (interface{}, error) {
 	if !row.Next() {
 		return nil, nil
 	}
-	err = row.Scan(&pdt.ID, &pdt.Name, &pdt.Price, &pdt.Weight, &pdt.Available,
-		&pdt.Deleted, &pdt.CreatedAt, &pdt.UpdatedAt, &pdt.DeletedAt)
+	pdt, err := scanProduct(row)
 	if err != nil {
 		return nil, err
 	}
@@ -77,40 +143,211 @@ func (c *Chef) Fetch(id string) (interface{}, error) {
 }
 
 // Update updates a product
-func (c *Chef) Update(id string, v interface{}) error {
+// the tags are replaced only if they are not nil
+func (c *Chef) Update(ctx context.Context, id string, v interface{}) error {
+	ctx = infra.WithOperation(ctx, "product.update")
 	pdt, ok := v.(model.Product)
 	if !ok {
 		return ErrUnsupportedType
 	}
+	pdt.Tags = model.NormalizeTags(pdt.Tags)
 	if err := pdt.Validate(); err != nil {
 		return err
 	}
 
-	stmt := fmt.Sprintf(`UPDATE %s SET ("name", "price", "weight", "available", "updated_at") = ('%s', %d, %d, %t, CURRENT_TIMESTAMP)
-		WHERE "id"='%s' AND "deleted"=FALSE`, c.table, pdt.Name, pdt.Price, pdt.Weight, pdt.Available, id)
+	stmt := fmt.Sprintf(`UPDATE %s SET ("name", "price", "prices", "weight", "sku", "barcode", "updated_at") = ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+		WHERE "id"=$7 AND "deleted"=FALSE`, c.table)
+
+	if err := c.db.Exec(ctx, stmt, pdt.Name, pdt.Price, model.EncodePrices(pdt.Prices), pdt.Weight, pdt.SKU, pdt.Barcode, id); err != nil {
+		return duplicateCode(err)
+	}
+	if pdt.Tags == nil {
+		return nil
+	}
+	if err := c.db.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE "product_id"=$1`, c.tags), id); err != nil {
+		return err
+	}
+	return c.insertTags(ctx, id, pdt.Tags)
+}
+
+// SetPrice changes the price of a product that is not deleted
+func (c *Chef) SetPrice(ctx context.Context, id string, price int) error {
+	ctx = infra.WithOperation(ctx, "product.set_price")
+	if price < 1 {
+		return model.ValidationError{"Price": []string{"is required"}}
+	}
+	stmt := fmt.Sprintf(`UPDATE %s SET ("price", "updated_at") = ($1, CURRENT_TIMESTAMP) WHERE "id"=$2 AND "deleted"=FALSE`, c.table)
+	return c.db.Exec(ctx, stmt, price, id)
+}
+
+// duplicateCode maps the unique violations of the product codes
+// to ErrDuplicateSKU and ErrDuplicateBarcode
+func duplicateCode(err error) error {
+	uerr, ok := err.(infra.UniqueViolationError)
+	if !ok {
+		return err
+	}
+	switch uerr.Constraint {
+	case "products_sku_key":
+		return ErrDuplicateSKU
+	case "products_barcode_key":
+		return ErrDuplicateBarcode
+	}
+	return err
+}
 
-	return c.db.Exec(stmt)
+// insertTags adds the normalized tags to the product of id
+func (c *Chef) insertTags(ctx context.Context, id string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	vals := []interface{}{id}
+	phs := []string{}
+	for _, tag := range tags {
+		vals = append(vals, tag)
+		phs = append(phs, fmt.Sprintf("($1, $%d)", len(vals)))
+	}
+	stmt := fmt.Sprintf(`INSERT INTO %s ("product_id", "tag") VALUES %s`, c.tags, strings.Join(phs, ", "))
+	return c.db.Exec(ctx, stmt, vals...)
 }
 
 // Delete deletes a product
-func (c *Chef) Delete(id string) error {
-	return c.db.Exec(fmt.Sprintf(`UPDATE %s SET ("deleted", "deleted_at") = (TRUE, CURRENT_TIMESTAMP) WHERE "id"='%s' AND "deleted"=FALSE`, c.table, id))
+func (c *Chef) Delete(ctx context.Context, id string) error {
+	ctx = infra.WithOperation(ctx, "product.delete")
+	return c.db.Exec(ctx, fmt.Sprintf(`UPDATE %s SET ("deleted", "deleted_at") = (TRUE, CURRENT_TIMESTAMP) WHERE "id"=$1 AND "deleted"=FALSE`, c.table), id)
+}
+
+// ListDeleted lists the deleted products, the latest deleted first
+func (c *Chef) ListDeleted(ctx context.Context, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "product.list_deleted")
+	pdts := []interface{}{}
+
+	rows, err := c.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE "deleted"=TRUE ORDER BY "deleted_at" DESC, "id" OFFSET $1 LIMIT $2`, c.columns(), c.table), skip, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		pdt, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		pdts = append(pdts, pdt)
+	}
+	return pdts, nil
+}
+
+// CountDeleted counts the number of deleted products
+func (c *Chef) CountDeleted(ctx context.Context) (int, error) {
+	ctx = infra.WithOperation(ctx, "product.count_deleted")
+	rows, err := c.db.Query(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE "deleted"=TRUE`, c.table))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Restore undeletes a deleted product
+// it returns ErrNotDeleted if there is no deleted product of id and
+// ErrDuplicateSKU or ErrDuplicateBarcode if its codes are taken meanwhile
+func (c *Chef) Restore(ctx context.Context, id string) error {
+	ctx = infra.WithOperation(ctx, "product.restore")
+	stmt := fmt.Sprintf(`UPDATE %s SET ("deleted", "deleted_at", "updated_at") = (FALSE, DEFAULT, CURRENT_TIMESTAMP)
+		WHERE "id"=$1 AND "deleted"=TRUE RETURNING "id"`, c.table)
+
+	rows, err := c.db.Query(ctx, stmt, id)
+	if err != nil {
+		return duplicateCode(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return ErrNotDeleted
+	}
+	return nil
+}
+
+// Purge permanently removes at most limit products deleted before t with their tags
+// and category assignments and returns their ids
+// deleted_at has no time zone so t is converted to the session time zone it is stored in
+func (c *Chef) Purge(ctx context.Context, t time.Time, limit int) ([]string, error) {
+	ctx = infra.WithOperation(ctx, "product.purge")
+	stmt := fmt.Sprintf(`WITH p AS (
+			DELETE FROM %[1]s WHERE "id" IN (SELECT "id" FROM %[1]s WHERE "deleted"=TRUE AND "deleted_at" < $1::TIMESTAMPTZ::TIMESTAMP ORDER BY "deleted_at" LIMIT $2) RETURNING "id"
+		), t AS (
+			DELETE FROM %[2]s WHERE "product_id" IN (SELECT "id" FROM p)
+		), a AS (
+			DELETE FROM %[3]s WHERE "product_id" IN (SELECT "id" FROM p)
+		)
+		SELECT "id" FROM p`, c.table, c.tags, c.assigns)
+
+	rows, err := c.db.Query(ctx, stmt, t, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// SetStatus changes the status of a product that is not deleted from from to to
+// the publish or archive time is set when to is published or archived
+// it returns ErrStaleStatus if the product is not in status from
+func (c *Chef) SetStatus(ctx context.Context, id string, from, to string) error {
+	ctx = infra.WithOperation(ctx, "product.set_status")
+	stmt := fmt.Sprintf(`UPDATE %s SET ("status", "published_at", "archived_at", "updated_at") = ($1, %s, %s, CURRENT_TIMESTAMP)
+		WHERE "id"=$2 AND "status"=$3 AND "deleted"=FALSE RETURNING "id"`,
+		c.table, transitionTime(1, model.ProductPublished, `"published_at"`), transitionTime(1, model.ProductArchived, `"archived_at"`))
+
+	rows, err := c.db.Query(ctx, stmt, to, id, from)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return ErrStaleStatus
+	}
+	return nil
+}
+
+// transitionTime returns the sql expression of the time of a transition to status sts
+// it is the current time if the status placeholder $n is sts and expression els otherwise
+func transitionTime(n int, sts model.ProductStatus, els string) string {
+	return fmt.Sprintf(`CASE WHEN $%d = '%s' THEN CURRENT_TIMESTAMP ELSE %s END`, n, sts, els)
 }
 
 // List lists products
-func (c *Chef) List(skip, limit int) ([]interface{}, error) {
+func (c *Chef) List(ctx context.Context, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "product.list")
 	pdts := []interface{}{}
 
-	rows, err := c.db.Query(fmt.Sprintf(`SELECT * FROM %s WHERE "deleted"=FALSE ORDER BY "created_at" OFFSET %d LIMIT %d`, c.table, skip, limit))
+	rows, err := c.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE "deleted"=FALSE ORDER BY "created_at" OFFSET $1 LIMIT $2`, c.columns(), c.table), skip, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		pdt := model.Product{}
-		err = rows.Scan(&pdt.ID, &pdt.Name, &pdt.Price, &pdt.Weight, &pdt.Available,
-			&pdt.Deleted, &pdt.CreatedAt, &pdt.UpdatedAt, &pdt.DeletedAt)
+		pdt, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -121,8 +358,9 @@ func (c *Chef) List(skip, limit int) ([]interface{}, error) {
 }
 
 // Count counts the number of products
-func (c *Chef) Count() (int, error) {
-	rows, err := c.db.Query(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE "deleted"=FALSE`, c.table))
+func (c *Chef) Count(ctx context.Context) (int, error) {
+	ctx = infra.WithOperation(ctx, "product.count")
+	rows, err := c.db.Query(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE "deleted"=FALSE`, c.table))
 	if err != nil {
 		return 0, err
 	}
@@ -140,24 +378,24 @@ func (c *Chef) Count() (int, error) {
 }
 
 // Search search products with query
-func (c *Chef) Search(q Query, skip, limit int) ([]interface{}, error) {
-	qstmt, vals := buildProductQuery(q)
-	str := fmt.Sprintf(`SELECT * FROM %s WHERE "deleted"=FALSE `, c.table)
+func (c *Chef) Search(ctx context.Context, q Query, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "product.search")
+	qstmt, vals := buildProductQuery(q, c.levels, c.assigns, c.tags)
+	str := fmt.Sprintf(`SELECT %s FROM %s WHERE "deleted"=FALSE `, c.columns(), c.from(q))
 	if len(vals) != 0 {
 		str = str + " AND " + qstmt
 	}
-	str = str + fmt.Sprintf(` ORDER BY "created_at" OFFSET %d LIMIT %d`, skip, limit)
+	str = str + fmt.Sprintf(` ORDER BY %s OFFSET $%d LIMIT $%d`, productOrder(q), len(vals)+1, len(vals)+2)
+	vals = append(vals, skip, limit)
 
-	rows, err := c.db.Query(str, vals...)
+	rows, err := c.db.Query(ctx, str, vals...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	pdts := []interface{}{}
 	for rows.Next() {
-		pdt := model.Product{}
-		err = rows.Scan(&pdt.ID, &pdt.Name, &pdt.Price, &pdt.Weight, &pdt.Available,
-			&pdt.Deleted, &pdt.CreatedAt, &pdt.UpdatedAt, &pdt.DeletedAt)
+		pdt, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -167,14 +405,15 @@ func (c *Chef) Search(q Query, skip, limit int) ([]interface{}, error) {
 }
 
 // SearchCount returns number of products that matches query
-func (c *Chef) SearchCount(q Query) (int, error) {
-	qstmt, vals := buildProductQuery(q)
-	str := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE "deleted"=FALSE `, c.table)
+func (c *Chef) SearchCount(ctx context.Context, q Query) (int, error) {
+	ctx = infra.WithOperation(ctx, "product.search_count")
+	qstmt, vals := buildProductQuery(q, c.levels, c.assigns, c.tags)
+	str := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE "deleted"=FALSE `, c.from(q))
 	if len(vals) != 0 {
 		str = str + " AND " + qstmt
 	}
 
-	rows, err := c.db.Query(str, vals...)
+	rows, err := c.db.Query(ctx, str, vals...)
 	if err != nil {
 		return 0, err
 	}
@@ -192,7 +431,105 @@ func (c *Chef) SearchCount(q Query) (int, error) {
 	return n, nil
 }
 
-func buildProductQuery(q Query) (string, []interface{}) {
+// productRating is the average rating of a product in the rating summary joined as rs
+// the products without ratings have the rating 0
+const productRating = `COALESCE(rs."sum"::FLOAT / NULLIF(rs."count", 0), 0)`
+
+// from returns the from clause of the products searched by q
+// the rating summaries are joined as rs if q filters or sorts by rating
+func (c *Chef) from(q Query) string {
+	if len(q["min_rating"]) == 0 && !ratingSort(q) {
+		return c.table
+	}
+	return fmt.Sprintf(`%s LEFT JOIN %s rs ON rs."product_id" = %s."id"`, c.table, c.ratings, c.table)
+}
+
+// ratingSort checks if q sorts the products by rating
+func ratingSort(q Query) bool {
+	srt := q["sort"]
+	return len(srt) != 0 && (srt[0] == "rating" || srt[0] == "-rating")
+}
+
+// productOrder returns the order by clause of the products searched by q
+// the products of the same rating stay in creation order
+func productOrder(q Query) string {
+	if !ratingSort(q) {
+		return `"created_at"`
+	}
+	if q["sort"][0] == "-rating" {
+		return productRating + ` DESC, "created_at"`
+	}
+	return productRating + `, "created_at"`
+}
+
+// CountTags returns the number of products that are not deleted of every tag
+func (c *Chef) CountTags(ctx context.Context) (map[string]int, error) {
+	ctx = infra.WithOperation(ctx, "product.count_tags")
+	stmt := fmt.Sprintf(`SELECT t."tag", COUNT(*) FROM %s t JOIN %s p ON p."id" = t."product_id"
+		WHERE p."deleted"=FALSE GROUP BY t."tag"`, c.tags, c.table)
+
+	rows, err := c.db.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cnts := map[string]int{}
+	for rows.Next() {
+		var tag string
+		var n int
+		if err := rows.Scan(&tag, &n); err != nil {
+			return nil, err
+		}
+		cnts[tag] = n
+	}
+	return cnts, nil
+}
+
+// AdjustQuantity adds delta to the quantity of a product and returns the new quantity
+// Available is kept in sync with the quantity exceeding the reserved units
+// it returns ErrInsufficientQuantity if the quantity would drop below the reserved units
+func (c *Chef) AdjustQuantity(ctx context.Context, id string, delta int) (int, error) {
+	ctx = infra.WithOperation(ctx, "product.adjust_quantity")
+	stmt := fmt.Sprintf(`UPDATE %s SET ("quantity", "available", "updated_at") = ("quantity" + $1, "quantity" + $1 > "reserved", CURRENT_TIMESTAMP)
+		WHERE "id"=$2 AND "deleted"=FALSE AND "quantity" + $1 >= "reserved" RETURNING "quantity"`, c.table)
+	return c.adjust(ctx, stmt, id, delta)
+}
+
+// AdjustReserved adds delta to the reserved units of a product and returns the new reserved units
+// it returns ErrInsufficientQuantity if the reserved units would become negative
+// or exceed the quantity
+func (c *Chef) AdjustReserved(ctx context.Context, id string, delta int) (int, error) {
+	ctx = infra.WithOperation(ctx, "product.adjust_reserved")
+	stmt := fmt.Sprintf(`UPDATE %s SET ("reserved", "available", "updated_at") = ("reserved" + $1, "quantity" > "reserved" + $1, CURRENT_TIMESTAMP)
+		WHERE "id"=$2 AND "deleted"=FALSE AND "reserved" + $1 >= 0 AND "reserved" + $1 <= "quantity" RETURNING "reserved"`, c.table)
+	return c.adjust(ctx, stmt, id, delta)
+}
+
+// adjust runs the conditional update stmt with delta and id
+// the update is a single statement so concurrent adjustments never
+// read a stale value, no returned row means the condition failed
+func (c *Chef) adjust(ctx context.Context, stmt, id string, delta int) (int, error) {
+	rows, err := c.db.Query(ctx, stmt, delta, id)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, ErrInsufficientQuantity
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// buildProductQuery returns the where clause of q and its args
+// location matches the products with stock at it in stock level table levels
+// min_rating needs the rating summaries joined as rs
+func buildProductQuery(q Query, levels, assigns, tags string) (string, []interface{}) {
 	str := ""
 	vals := []interface{}{}
 	cnt := 0
@@ -225,5 +562,75 @@ func buildProductQuery(q Query) (string, []interface{}) {
 		str = str + fmt.Sprintf(`"available" = $%d`, cnt)
 		vals = append(vals, avl[0])
 	}
+	if sts := q["status"]; len(sts) != 0 {
+		if cnt != 0 {
+			str = str + " AND "
+		}
+		cnt++
+		str = str + fmt.Sprintf(`"status" = $%d`, cnt)
+		vals = append(vals, fmt.Sprint(sts[0]))
+	}
+	if sku := q["sku"]; len(sku) != 0 {
+		if cnt != 0 {
+			str = str + " AND "
+		}
+		cnt++
+		str = str + fmt.Sprintf(`"sku" = $%d`, cnt)
+		vals = append(vals, fmt.Sprint(sku[0]))
+	}
+	if bc := q["barcode"]; len(bc) != 0 {
+		if cnt != 0 {
+			str = str + " AND "
+		}
+		cnt++
+		str = str + fmt.Sprintf(`"barcode" = $%d`, cnt)
+		vals = append(vals, fmt.Sprint(bc[0]))
+	}
+	if rt := q["min_rating"]; len(rt) != 0 {
+		if cnt != 0 {
+			str = str + " AND "
+		}
+		cnt++
+		str = str + fmt.Sprintf(`%s >= $%d`, productRating, cnt)
+		vals = append(vals, rt[0])
+	}
+	if loc := q["location"]; len(loc) != 0 {
+		if cnt != 0 {
+			str = str + " AND "
+		}
+		cnt++
+		str = str + fmt.Sprintf(`"id" IN (SELECT "product_id" FROM %s WHERE "location_id" = $%d AND "quantity" > 0)`, levels, cnt)
+		vals = append(vals, fmt.Sprint(loc[0]))
+	}
+	if cats := q["category"]; len(cats) != 0 {
+		if cnt != 0 {
+			str = str + " AND "
+		}
+		phs := []string{}
+		for _, cat := range cats {
+			cnt++
+			phs = append(phs, fmt.Sprintf("$%d", cnt))
+			vals = append(vals, fmt.Sprint(cat))
+		}
+		str = str + fmt.Sprintf(`"id" IN (SELECT "product_id" FROM %s WHERE "category_id" IN (%s))`, assigns, strings.Join(phs, ", "))
+	}
+	if tgs := q["tag"]; len(tgs) != 0 {
+		if cnt != 0 {
+			str = str + " AND "
+		}
+		phs := []string{}
+		for _, tag := range tgs {
+			cnt++
+			phs = append(phs, fmt.Sprintf("$%d", cnt))
+			vals = append(vals, fmt.Sprint(tag))
+		}
+		str = str + fmt.Sprintf(`"id" IN (SELECT "product_id" FROM %s WHERE "tag" IN (%s)`, tags, strings.Join(phs, ", "))
+		if mt := q["tag_match"]; len(mt) != 0 && mt[0] == "all" {
+			cnt++
+			str = str + fmt.Sprintf(` GROUP BY "product_id" HAVING COUNT(*) = $%d`, cnt)
+			vals = append(vals, len(tgs))
+		}
+		str = str + ")"
+	}
 	return str, vals
 }
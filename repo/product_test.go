@@ -1,10 +1,12 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/msyrus/simple-product-inv/infra"
@@ -23,9 +25,10 @@ func TestNewChef(t *testing.T) {
 		db  infra.DB
 	}
 	tests := []struct {
-		name string
-		args args
-		want *Chef
+		name    string
+		args    args
+		want    *Chef
+		wantErr bool
 	}{
 		{
 			args: args{
@@ -33,14 +36,47 @@ func TestNewChef(t *testing.T) {
 				db:  db,
 			},
 			want: &Chef{
-				table: "test",
-				db:    db,
+				table:   "test",
+				levels:  "stock_levels",
+				assigns: "product_categories",
+				tags:    "product_tags",
+				ratings: "rating_summaries",
+				db:      db,
+			},
+		},
+		{
+			args: args{
+				tab: "test; DROP TABLE test",
+				db:  db,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			args: args{
+				tab: `"test"`,
+				db:  db,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			args: args{
+				tab: "",
+				db:  db,
 			},
+			want:    nil,
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewChef(tt.args.tab, tt.args.db); !reflect.DeepEqual(got, tt.want) {
+			got, err := NewChef(tt.args.tab, tt.args.db)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewChef() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewChef() = %v, want %v", got, tt.want)
 			}
 		})
@@ -52,13 +88,13 @@ func TestChef_Create(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	db := mock_infra.NewMockDB(mockCtrl)
-	chf := NewChef("test", db)
+	chf, _ := NewChef("test", db)
 
 	pdt := model.Product{ID: "1", Name: "Test", Price: 100, Weight: 1, Available: false}
 
 	gomock.InOrder(
-		db.EXPECT().Exec(gomock.Any()).Return(nil),
-		db.EXPECT().Exec(gomock.Any()).Return(sql.ErrConnDone),
+		db.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), pdt.Name, pdt.Price, "", pdt.Weight, pdt.SKU, pdt.Barcode, "draft").Return(nil),
+		db.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), pdt.Name, pdt.Price, "", pdt.Weight, pdt.SKU, pdt.Barcode, "draft").Return(sql.ErrConnDone),
 	)
 
 	type args struct {
@@ -106,7 +142,7 @@ func TestChef_Create(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.c.Create(tt.args.v)
+			got, err := tt.c.Create(context.Background(), tt.args.v)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Chef.Create() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -124,11 +160,11 @@ func TestChef_Fetch(t *testing.T) {
 
 	db := mock_infra.NewMockDB(mockCtrl)
 	row := mock_infra.NewMockRow(mockCtrl)
-	chf := NewChef("test", db)
+	chf, _ := NewChef("test", db)
 
 	pdt := model.Product{ID: "1", Name: "Test", Price: 100, Weight: 1, Available: false}
 
-	db.EXPECT().Query(fmt.Sprintf(`SELECT * FROM %s WHERE "id"='%s' AND "deleted"=FALSE`, chf.table, pdt.ID)).Return(row, nil)
+	db.EXPECT().Query(gomock.Any(), fmt.Sprintf(`SELECT %s FROM %s WHERE "id"=$1 AND "deleted"=FALSE`, chf.columns(), chf.table), pdt.ID).Return(row, nil)
 	row.EXPECT().Next().Return(true)
 	row.EXPECT().Scan(gomock.Any()).Return(nil)
 	row.EXPECT().Close().Return(nil)
@@ -148,13 +184,13 @@ func TestChef_Fetch(t *testing.T) {
 			args: args{
 				id: "1",
 			},
-			want:    model.Product{},
+			want:    model.Product{Prices: []model.Money{}, Tags: []string{}},
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.c.Fetch(tt.args.id)
+			got, err := tt.c.Fetch(context.Background(), tt.args.id)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Chef.Fetch() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -171,15 +207,15 @@ func TestChef_Update(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	db := mock_infra.NewMockDB(mockCtrl)
-	chf := NewChef("test", db)
+	chf, _ := NewChef("test", db)
 
 	pdt := model.Product{ID: "1", Name: "Test", Price: 100, Weight: 1, Available: false}
 
+	stmt := fmt.Sprintf(`UPDATE %s SET ("name", "price", "prices", "weight", "sku", "barcode", "updated_at") = ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+		WHERE "id"=$7 AND "deleted"=FALSE`, chf.table)
 	gomock.InOrder(
-		db.EXPECT().Exec(fmt.Sprintf(`UPDATE %s SET ("name", "price", "weight", "available", "updated_at") = ('%s', %d, %d, %t, CURRENT_TIMESTAMP)
-		WHERE "id"='unavailable_id' AND "deleted"=FALSE`, chf.table, pdt.Name, pdt.Price, pdt.Weight, pdt.Available)).Return(nil),
-		db.EXPECT().Exec(fmt.Sprintf(`UPDATE %s SET ("name", "price", "weight", "available", "updated_at") = ('%s', %d, %d, %t, CURRENT_TIMESTAMP)
-		WHERE "id"='%s' AND "deleted"=FALSE`, chf.table, pdt.Name, pdt.Price, pdt.Weight, pdt.Available, pdt.ID)).Return(nil),
+		db.EXPECT().Exec(gomock.Any(), stmt, pdt.Name, pdt.Price, "", pdt.Weight, pdt.SKU, pdt.Barcode, "unavailable_id").Return(nil),
+		db.EXPECT().Exec(gomock.Any(), stmt, pdt.Name, pdt.Price, "", pdt.Weight, pdt.SKU, pdt.Barcode, pdt.ID).Return(nil),
 	)
 
 	type args struct {
@@ -227,7 +263,7 @@ func TestChef_Update(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.c.Update(tt.args.id, tt.args.v); (err != nil) != tt.wantErr {
+			if err := tt.c.Update(context.Background(), tt.args.id, tt.args.v); (err != nil) != tt.wantErr {
 				t.Errorf("Chef.Update() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -239,13 +275,14 @@ func TestChef_Delete(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	db := mock_infra.NewMockDB(mockCtrl)
-	chf := NewChef("test", db)
+	chf, _ := NewChef("test", db)
 
 	pdt := model.Product{ID: "1", Name: "Test", Price: 100, Weight: 1, Available: false}
 
+	stmt := fmt.Sprintf(`UPDATE %s SET ("deleted", "deleted_at") = (TRUE, CURRENT_TIMESTAMP) WHERE "id"=$1 AND "deleted"=FALSE`, chf.table)
 	gomock.InOrder(
-		db.EXPECT().Exec(fmt.Sprintf(`UPDATE %s SET ("deleted", "deleted_at") = (TRUE, CURRENT_TIMESTAMP) WHERE "id"='%s' AND "deleted"=FALSE`, chf.table, "unavailable_id")).Return(nil),
-		db.EXPECT().Exec(fmt.Sprintf(`UPDATE %s SET ("deleted", "deleted_at") = (TRUE, CURRENT_TIMESTAMP) WHERE "id"='%s' AND "deleted"=FALSE`, chf.table, pdt.ID)).Return(nil),
+		db.EXPECT().Exec(gomock.Any(), stmt, "unavailable_id").Return(nil),
+		db.EXPECT().Exec(gomock.Any(), stmt, pdt.ID).Return(nil),
 	)
 
 	type args struct {
@@ -274,13 +311,119 @@ func TestChef_Delete(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.c.Delete(tt.args.id); (err != nil) != tt.wantErr {
+			if err := tt.c.Delete(context.Background(), tt.args.id); (err != nil) != tt.wantErr {
 				t.Errorf("Chef.Delete() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+func TestChef_Restore(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db := mock_infra.NewMockDB(mockCtrl)
+	row := mock_infra.NewMockRow(mockCtrl)
+	chf, _ := NewChef("test", db)
+
+	stmt := `UPDATE test SET ("deleted", "deleted_at", "updated_at") = (FALSE, DEFAULT, CURRENT_TIMESTAMP)
+		WHERE "id"=$1 AND "deleted"=TRUE RETURNING "id"`
+	gomock.InOrder(
+		db.EXPECT().Query(gomock.Any(), stmt, "1").Return(row, nil),
+		row.EXPECT().Next().Return(true),
+		row.EXPECT().Close().Return(nil),
+		db.EXPECT().Query(gomock.Any(), stmt, "2").Return(row, nil),
+		row.EXPECT().Next().Return(false),
+		row.EXPECT().Close().Return(nil),
+		db.EXPECT().Query(gomock.Any(), stmt, "3").Return(nil, infra.UniqueViolationError{Constraint: "products_sku_key"}),
+	)
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{name: "deleted", id: "1"},
+		{name: "not deleted", id: "2", wantErr: ErrNotDeleted},
+		{name: "sku taken", id: "3", wantErr: ErrDuplicateSKU},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := chf.Restore(context.Background(), tt.id); err != tt.wantErr {
+				t.Errorf("Chef.Restore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChef_SetPrice(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db := mock_infra.NewMockDB(mockCtrl)
+	chf, _ := NewChef("test", db)
+
+	db.EXPECT().Exec(gomock.Any(), `UPDATE test SET ("price", "updated_at") = ($1, CURRENT_TIMESTAMP) WHERE "id"=$2 AND "deleted"=FALSE`, 1200, "1").Return(nil)
+
+	if err := chf.SetPrice(context.Background(), "1", 1200); err != nil {
+		t.Errorf("Chef.SetPrice() error = %v", err)
+	}
+	if _, ok := chf.SetPrice(context.Background(), "1", 0).(model.ValidationError); !ok {
+		t.Errorf("Chef.SetPrice() zero price error, want model.ValidationError")
+	}
+}
+
+func TestChef_Purge(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db := mock_infra.NewMockDB(mockCtrl)
+	row := mock_infra.NewMockRow(mockCtrl)
+	chf, _ := NewChef("test", db)
+	before := time.Now()
+
+	stmt := `WITH p AS (
+			DELETE FROM test WHERE "id" IN (SELECT "id" FROM test WHERE "deleted"=TRUE AND "deleted_at" < $1::TIMESTAMPTZ::TIMESTAMP ORDER BY "deleted_at" LIMIT $2) RETURNING "id"
+		), t AS (
+			DELETE FROM product_tags WHERE "product_id" IN (SELECT "id" FROM p)
+		), a AS (
+			DELETE FROM product_categories WHERE "product_id" IN (SELECT "id" FROM p)
+		)
+		SELECT "id" FROM p`
+	gomock.InOrder(
+		db.EXPECT().Query(gomock.Any(), stmt, before, 2).Return(row, nil),
+		row.EXPECT().Next().Return(true),
+		row.EXPECT().Scan(gomock.Any()).SetArg(0, "1").Return(nil),
+		row.EXPECT().Next().Return(true),
+		row.EXPECT().Scan(gomock.Any()).SetArg(0, "2").Return(nil),
+		row.EXPECT().Next().Return(false),
+		row.EXPECT().Close().Return(nil),
+		db.EXPECT().Query(gomock.Any(), stmt, before, 2).Return(nil, sql.ErrConnDone),
+	)
+
+	if got, err := chf.Purge(context.Background(), before, 2); err != nil || !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("Chef.Purge() = %v, %v, want [1 2]", got, err)
+	}
+	if _, err := chf.Purge(context.Background(), before, 2); err != sql.ErrConnDone {
+		t.Errorf("Chef.Purge() error = %v, want %v", err, sql.ErrConnDone)
+	}
+}
+
+func Test_removeProducts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db := mock_infra.NewMockDB(mockCtrl)
+	db.EXPECT().Exec(gomock.Any(), `DELETE FROM test WHERE "product_id" IN ($1, $2)`, "1", `2' OR '1'='1`).Return(nil)
+
+	if err := removeProducts(context.Background(), db, "test", []string{"1", `2' OR '1'='1`}); err != nil {
+		t.Errorf("removeProducts() error = %v", err)
+	}
+	if err := removeProducts(context.Background(), db, "test", nil); err != nil {
+		t.Errorf("removeProducts() no products error = %v", err)
+	}
+}
+
 func TestChef_List(t *testing.T) {
 	type args struct {
 		skip  int
@@ -297,7 +440,7 @@ func TestChef_List(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.c.List(tt.args.skip, tt.args.limit)
+			got, err := tt.c.List(context.Background(), tt.args.skip, tt.args.limit)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Chef.List() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -320,7 +463,7 @@ func TestChef_Count(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.c.Count()
+			got, err := tt.c.Count(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Chef.Count() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -332,6 +475,26 @@ func TestChef_Count(t *testing.T) {
 	}
 }
 
+func Test_productOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		q    Query
+		want string
+	}{
+		{name: "creation", q: Query{}, want: `"created_at"`},
+		{name: "unknown sort", q: Query{"sort": {"name"}}, want: `"created_at"`},
+		{name: "rating", q: Query{"sort": {"rating"}}, want: `COALESCE(rs."sum"::FLOAT / NULLIF(rs."count", 0), 0), "created_at"`},
+		{name: "rating desc", q: Query{"sort": {"-rating"}}, want: `COALESCE(rs."sum"::FLOAT / NULLIF(rs."count", 0), 0) DESC, "created_at"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := productOrder(tt.q); got != tt.want {
+				t.Errorf("productOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestChef_Search(t *testing.T) {
 	type args struct {
 		q     Query
@@ -349,7 +512,7 @@ func TestChef_Search(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.c.Search(tt.args.q, tt.args.skip, tt.args.limit)
+			got, err := tt.c.Search(context.Background(), tt.args.q, tt.args.skip, tt.args.limit)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Chef.Search() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -376,7 +539,7 @@ func TestChef_SearchCount(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.c.SearchCount(tt.args.q)
+			got, err := tt.c.SearchCount(context.Background(), tt.args.q)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Chef.SearchCount() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -398,11 +561,50 @@ func Test_buildProductQuery(t *testing.T) {
 		want  string
 		want1 []interface{}
 	}{
-		// TODO: Add test cases.
+		{
+			args:  args{q: Query{}},
+			want:  "",
+			want1: []interface{}{},
+		},
+		{
+			args:  args{q: Query{"name": {"Test%"}, "available": {true}}},
+			want:  `"name" LIKE $1 AND "available" = $2`,
+			want1: []interface{}{"Test%", true},
+		},
+		{
+			args:  args{q: Query{"price": {100}, "location": {"loc1"}}},
+			want:  `"price" <= $1 AND "id" IN (SELECT "product_id" FROM stock_levels WHERE "location_id" = $2 AND "quantity" > 0)`,
+			want1: []interface{}{100, "loc1"},
+		},
+		{
+			args:  args{q: Query{"weight": {2}, "category": {"cat1", "cat2"}}},
+			want:  `"weight" <= $1 AND "id" IN (SELECT "product_id" FROM product_categories WHERE "category_id" IN ($2, $3))`,
+			want1: []interface{}{2, "cat1", "cat2"},
+		},
+		{
+			args:  args{q: Query{"tag": {"summer", "sale"}}},
+			want:  `"id" IN (SELECT "product_id" FROM product_tags WHERE "tag" IN ($1, $2))`,
+			want1: []interface{}{"summer", "sale"},
+		},
+		{
+			args:  args{q: Query{"name": {"Test%"}, "tag": {"summer", "sale"}, "tag_match": {"all"}}},
+			want:  `"name" LIKE $1 AND "id" IN (SELECT "product_id" FROM product_tags WHERE "tag" IN ($2, $3) GROUP BY "product_id" HAVING COUNT(*) = $4)`,
+			want1: []interface{}{"Test%", "summer", "sale", 2},
+		},
+		{
+			args:  args{q: Query{"sku": {"TS-01"}, "barcode": {"4006381333931"}}},
+			want:  `"sku" = $1 AND "barcode" = $2`,
+			want1: []interface{}{"TS-01", "4006381333931"},
+		},
+		{
+			args:  args{q: Query{"price": {500}, "min_rating": {4.0}, "sort": {"-rating"}}},
+			want:  `"price" <= $1 AND COALESCE(rs."sum"::FLOAT / NULLIF(rs."count", 0), 0) >= $2`,
+			want1: []interface{}{500, 4.0},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1 := buildProductQuery(tt.args.q)
+			got, got1 := buildProductQuery(tt.args.q, "stock_levels", "product_categories", "product_tags")
 			if got != tt.want {
 				t.Errorf("buildProductQuery() got = %v, want %v", got, tt.want)
 			}
@@ -412,3 +614,99 @@ func Test_buildProductQuery(t *testing.T) {
 		})
 	}
 }
+
+func Test_duplicateCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{err: nil, want: nil},
+		{err: sql.ErrConnDone, want: sql.ErrConnDone},
+		{err: infra.UniqueViolationError{Constraint: "products_sku_key"}, want: ErrDuplicateSKU},
+		{err: infra.UniqueViolationError{Constraint: "products_barcode_key"}, want: ErrDuplicateBarcode},
+		{err: infra.UniqueViolationError{Constraint: "products_pkey"}, want: infra.UniqueViolationError{Constraint: "products_pkey"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := duplicateCode(tt.err); got != tt.want {
+				t.Errorf("duplicateCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChef_HostileInput(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db := mock_infra.NewMockDB(mockCtrl)
+	row := mock_infra.NewMockRow(mockCtrl)
+	chf, _ := NewChef("test", db)
+
+	names := []string{
+		`O'Brien`,
+		`'); DROP TABLE test; --`,
+		`Robert"); DELETE FROM test WHERE ("1"="1`,
+		`\'; SELECT pg_sleep(10); --`,
+	}
+	ids := []string{
+		`1' OR '1'='1`,
+		`1'; UPDATE test SET "price"=1; --`,
+		`$1`,
+	}
+
+	for _, name := range names {
+		pdt := model.Product{Name: name, Price: 100, Weight: 1, Available: true}
+		db.EXPECT().Exec(gomock.Any(),
+			`INSERT INTO test ("id", "name", "price", "prices", "weight", "sku", "barcode", "status", "published_at", "archived_at", "quantity", "available")
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, CASE WHEN $8 = 'published' THEN CURRENT_TIMESTAMP ELSE NULL END, CASE WHEN $8 = 'archived' THEN CURRENT_TIMESTAMP ELSE NULL END, 0, FALSE)`,
+			gomock.Any(), name, pdt.Price, "", pdt.Weight, pdt.SKU, pdt.Barcode, "draft",
+		).Return(nil)
+		if _, err := chf.Create(context.Background(), pdt); err != nil {
+			t.Errorf("Chef.Create() name = %q, error = %v", name, err)
+		}
+
+		db.EXPECT().Query(gomock.Any(),
+			`SELECT `+chf.columns()+` FROM test WHERE "deleted"=FALSE  AND "name" LIKE $1 ORDER BY "created_at" OFFSET $2 LIMIT $3`,
+			name, 0, 10,
+		).Return(row, nil)
+		row.EXPECT().Next().Return(false)
+		row.EXPECT().Close().Return(nil)
+		if _, err := chf.Search(context.Background(), Query{"name": []interface{}{name}}, 0, 10); err != nil {
+			t.Errorf("Chef.Search() name = %q, error = %v", name, err)
+		}
+	}
+
+	for _, id := range ids {
+		db.EXPECT().Query(gomock.Any(), `SELECT `+chf.columns()+` FROM test WHERE "id"=$1 AND "deleted"=FALSE`, id).Return(row, nil)
+		row.EXPECT().Next().Return(false)
+		row.EXPECT().Close().Return(nil)
+		if got, err := chf.Fetch(context.Background(), id); err != nil || got != nil {
+			t.Errorf("Chef.Fetch() id = %q, got = %v, error = %v", id, got, err)
+		}
+
+		pdt := model.Product{ID: id, Name: names[0], Price: 100, Weight: 1}
+		db.EXPECT().Exec(gomock.Any(),
+			`UPDATE test SET ("name", "price", "prices", "weight", "sku", "barcode", "updated_at") = ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+		WHERE "id"=$7 AND "deleted"=FALSE`,
+			pdt.Name, pdt.Price, "", pdt.Weight, pdt.SKU, pdt.Barcode, id,
+		).Return(nil)
+		if err := chf.Update(context.Background(), id, pdt); err != nil {
+			t.Errorf("Chef.Update() id = %q, error = %v", id, err)
+		}
+
+		pdt.Tags = []string{`x'); drop table product_tags; --`}
+		db.EXPECT().Exec(gomock.Any(), gomock.Any(), pdt.Name, pdt.Price, "", pdt.Weight, pdt.SKU, pdt.Barcode, id).Return(nil)
+		db.EXPECT().Exec(gomock.Any(), `DELETE FROM product_tags WHERE "product_id"=$1`, id).Return(nil)
+		db.EXPECT().Exec(gomock.Any(), `INSERT INTO product_tags ("product_id", "tag") VALUES ($1, $2)`, id, pdt.Tags[0]).Return(nil)
+		if err := chf.Update(context.Background(), id, pdt); err != nil {
+			t.Errorf("Chef.Update() id = %q tags = %q, error = %v", id, pdt.Tags, err)
+		}
+
+		db.EXPECT().Exec(gomock.Any(), `UPDATE test SET ("deleted", "deleted_at") = (TRUE, CURRENT_TIMESTAMP) WHERE "id"=$1 AND "deleted"=FALSE`, id).Return(nil)
+		if err := chf.Delete(context.Background(), id); err != nil {
+			t.Errorf("Chef.Delete() id = %q, error = %v", id, err)
+		}
+	}
+}
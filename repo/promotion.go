@@ -0,0 +1,213 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/model"
+)
+
+// Promotion interface is the repo wrapper of promotion
+// promotions are searched by active_at, the time they are active at
+// removing products drops them from the targets of the promotions
+type Promotion interface {
+	Creator
+	Fetcher
+	Updater
+	Deleter
+	Searcher
+	ProductRemover
+}
+
+// promotionColumns are the selected columns of a promotion in scan order
+// the targets are stored as comma separated lists
+const promotionColumns = `"id", "name", "type", "value", "buy_quantity", "get_quantity", "product_ids", "category_ids", "tags", "starts_at", "ends_at", "created_at", "updated_at"`
+
+func scanPromotion(row infra.Row) (model.Promotion, error) {
+	prm := model.Promotion{}
+	var typ, pdtIDs, catIDs, tags string
+	err := row.Scan(&prm.ID, &prm.Name, &typ, &prm.Value, &prm.BuyQuantity, &prm.GetQuantity,
+		&pdtIDs, &catIDs, &tags, &prm.StartsAt, &prm.EndsAt, &prm.CreatedAt, &prm.UpdatedAt)
+	prm.Type = model.PromotionType(typ)
+	prm.ProductIDs = splitList(pdtIDs)
+	prm.CategoryIDs = splitList(catIDs)
+	prm.Tags = splitList(tags)
+	return prm, err
+}
+
+// splitList splits the comma separated list s, it returns no element for an empty s
+func splitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// Herald is an implementation of Promotion
+type Herald struct {
+	table string
+	db    infra.DB
+}
+
+// NewHerald returns a new Herald with table name tab
+// it returns ErrInvalidTable if tab is not a valid sql identifier
+func NewHerald(tab string, db infra.DB) (*Herald, error) {
+	if !isIdent(tab) {
+		return nil, ErrInvalidTable
+	}
+	return &Herald{
+		table: tab,
+		db:    db,
+	}, nil
+}
+
+// Create creates a new promotion
+func (h *Herald) Create(ctx context.Context, v interface{}) (string, error) {
+	ctx = infra.WithOperation(ctx, "promotion.create")
+	prm, ok := v.(model.Promotion)
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+	prm.ID = uuid.NewV4().String()
+	prm.Tags = model.NormalizeTags(prm.Tags)
+
+	if err := prm.Validate(); err != nil {
+		return "", err
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %s ("id", "name", "type", "value", "buy_quantity", "get_quantity", "product_ids", "category_ids", "tags", "starts_at", "ends_at")
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`, h.table)
+	err := h.db.Exec(ctx, stmt, prm.ID, prm.Name, string(prm.Type), prm.Value, prm.BuyQuantity, prm.GetQuantity,
+		strings.Join(prm.ProductIDs, ","), strings.Join(prm.CategoryIDs, ","), strings.Join(prm.Tags, ","), prm.StartsAt, prm.EndsAt)
+	if err != nil {
+		return "", err
+	}
+	return prm.ID, nil
+}
+
+// Fetch returns a model.Promotion finding by its id
+func (h *Herald) Fetch(ctx context.Context, id string) (interface{}, error) {
+	ctx = infra.WithOperation(ctx, "promotion.fetch")
+
+	row, err := h.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE "id"=$1`, promotionColumns, h.table), id)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	if !row.Next() {
+		return nil, nil
+	}
+	prm, err := scanPromotion(row)
+	if err != nil {
+		return nil, err
+	}
+	return prm, nil
+}
+
+// Update updates a promotion
+func (h *Herald) Update(ctx context.Context, id string, v interface{}) error {
+	ctx = infra.WithOperation(ctx, "promotion.update")
+	prm, ok := v.(model.Promotion)
+	if !ok {
+		return ErrUnsupportedType
+	}
+	prm.Tags = model.NormalizeTags(prm.Tags)
+	if err := prm.Validate(); err != nil {
+		return err
+	}
+
+	stmt := fmt.Sprintf(`UPDATE %s SET ("name", "type", "value", "buy_quantity", "get_quantity", "product_ids", "category_ids", "tags", "starts_at", "ends_at", "updated_at")
+		= ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, CURRENT_TIMESTAMP) WHERE "id"=$11`, h.table)
+	return h.db.Exec(ctx, stmt, prm.Name, string(prm.Type), prm.Value, prm.BuyQuantity, prm.GetQuantity,
+		strings.Join(prm.ProductIDs, ","), strings.Join(prm.CategoryIDs, ","), strings.Join(prm.Tags, ","), prm.StartsAt, prm.EndsAt, id)
+}
+
+// Delete deletes a promotion
+func (h *Herald) Delete(ctx context.Context, id string) error {
+	ctx = infra.WithOperation(ctx, "promotion.delete")
+	return h.db.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE "id"=$1`, h.table), id)
+}
+
+// Search returns the promotions of query q in creation order
+func (h *Herald) Search(ctx context.Context, q Query, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "promotion.search")
+	qstmt, vals := buildPromotionQuery(q)
+	str := fmt.Sprintf(`SELECT %s FROM %s`, promotionColumns, h.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+	str = str + fmt.Sprintf(` ORDER BY "created_at", "id" OFFSET $%d LIMIT $%d`, len(vals)+1, len(vals)+2)
+	vals = append(vals, skip, limit)
+
+	rows, err := h.db.Query(ctx, str, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prms := []interface{}{}
+	for rows.Next() {
+		prm, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		prms = append(prms, prm)
+	}
+	return prms, nil
+}
+
+// SearchCount returns number of promotions that matches query
+func (h *Herald) SearchCount(ctx context.Context, q Query) (int, error) {
+	ctx = infra.WithOperation(ctx, "promotion.search_count")
+	qstmt, vals := buildPromotionQuery(q)
+	str := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, h.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+
+	rows, err := h.db.Query(ctx, str, vals...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// RemoveProducts drops the products of pdtIDs from the targets of every promotion
+// the promotions are kept even if they target nothing else
+func (h *Herald) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	ctx = infra.WithOperation(ctx, "promotion.remove_products")
+	if len(pdtIDs) == 0 {
+		return nil
+	}
+	vals, phs := placeholders(pdtIDs)
+	stmt := fmt.Sprintf(`UPDATE %[1]s SET "product_ids" = array_to_string(ARRAY(
+			SELECT u."id" FROM unnest(string_to_array("product_ids", ',')) WITH ORDINALITY AS u("id", "n")
+			WHERE u."id" NOT IN (%[2]s) ORDER BY u."n"
+		), ',')
+		WHERE string_to_array("product_ids", ',') && ARRAY[%[2]s]::TEXT[]`, h.table, strings.Join(phs, ", "))
+	return h.db.Exec(ctx, stmt, vals...)
+}
+
+func buildPromotionQuery(q Query) (string, []interface{}) {
+	str := ""
+	vals := []interface{}{}
+	if at := q["active_at"]; len(at) != 0 {
+		vals = append(vals, at[0])
+		str = fmt.Sprintf(`"starts_at" <= $%d AND "ends_at" > $%d`, len(vals), len(vals))
+	}
+	return str, vals
+}
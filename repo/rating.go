@@ -1,8 +1,10 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/msyrus/simple-product-inv/infra"
 	"github.com/msyrus/simple-product-inv/model"
@@ -10,57 +12,321 @@ import (
 )
 
 // Rating interface is the repo wrapper of rating
+// ratings are searched by product_id, user_id and status, the latest first
+// unless sort is created_at for the oldest first
+// only the approved ratings are averaged and summarized
 type Rating interface {
 	Creator
+	Fetcher
+	Updater
+	Deleter
+	Searcher
+	Moderator
 	AvgAggrigator
+	ProductRemover
+	Summarizer
+}
+
+// Summarizer interface holds the necessery dependencies to keep the rating summaries of products
+// Summaries returns the rating summaries of the products of pdtIDs by product id
+// in a single lookup, the products never rated are omitted
+// RebuildSummaries recomputes every summary from the ratings and returns their number
+type Summarizer interface {
+	Summaries(ctx context.Context, pdtIDs []string) (map[string]model.RatingSummary, error)
+	RebuildSummaries(ctx context.Context) (int, error)
+}
+
+// ratingColumns are the selected columns of a rating in scan order
+const ratingColumns = `"id", "product_id", "user_id", "user_name", "value", "title", "body", "status", "reason", "created_at", "updated_at"`
+
+func scanRating(row infra.Row) (model.Rating, error) {
+	rat := model.Rating{}
+	sts := ""
+	err := row.Scan(&rat.ID, &rat.ProductID, &rat.User.ID, &rat.User.Name, &rat.Value, &rat.Title, &rat.Body, &sts, &rat.Reason, &rat.CreatedAt, &rat.UpdatedAt)
+	rat.Status = model.RatingStatus(sts)
+	return rat, err
+}
+
+// approvedCond is the sql condition of an approved rating
+var approvedCond = fmt.Sprintf(`"status" = '%s'`, model.RatingApproved)
+
+// approvedRating is the sql expression of 1 for an approved rating and 0 otherwise
+var approvedRating = "(" + approvedCond + ")::INT"
+
+// summaryColumns are the selected columns of a rating summary in scan order
+const summaryColumns = `"product_id", "count", "sum", "stars_1", "stars_2", "stars_3", "stars_4", "stars_5"`
+
+func scanSummary(row infra.Row) (model.RatingSummary, error) {
+	sum := model.RatingSummary{}
+	h := &sum.Histogram
+	err := row.Scan(&sum.ProductID, &sum.Count, &sum.Sum, &h[0], &h[1], &h[2], &h[3], &h[4])
+	if sum.Count != 0 {
+		sum.Avg = float64(sum.Sum) / float64(sum.Count)
+	}
+	return sum, err
+}
+
+// starCounts returns the sql expressions of the histogram counts
+// of the star values 1 to 5 formatted into tmpl
+func starCounts(tmpl string) string {
+	cnts := []string{}
+	for i := 1; i <= 5; i++ {
+		cnts = append(cnts, fmt.Sprintf(tmpl, i))
+	}
+	return strings.Join(cnts, ", ")
 }
 
 // Critic is an implementation of Rating
 type Critic struct {
 	table string
-	db    infra.DB
+	// summaries is the rating summary table kept in sync with table
+	summaries string
+	db        infra.DB
 }
 
 // NewCritic returns a new Critic with table name tab
-func NewCritic(tab string, db infra.DB) *Critic {
-	return &Critic{
-		table: tab,
-		db:    db,
+// the summaries use the default table
+// it returns ErrInvalidTable if tab is not a valid sql identifier
+func NewCritic(tab string, db infra.DB) (*Critic, error) {
+	if !isIdent(tab) {
+		return nil, ErrInvalidTable
 	}
+	return &Critic{
+		table:     tab,
+		summaries: DefaultTables.RatingSummaries,
+		db:        db,
+	}, nil
 }
 
 // Create creates a new rating in Critic
-func (c *Critic) Create(v interface{}) (string, error) {
+// it returns ErrDuplicateRating if the user has already rated the product
+func (c *Critic) Create(ctx context.Context, v interface{}) (string, error) {
+	ctx = infra.WithOperation(ctx, "rating.create")
 	rat, ok := v.(model.Rating)
 	if !ok {
 		return "", ErrUnsupportedType
 	}
 	rat.ID = uuid.NewV4().String()
+	if rat.Status == "" {
+		rat.Status = model.RatingApproved
+	}
 
 	if err := rat.Validate(); err != nil {
 		return "", err
 	}
 
-	stmt := fmt.Sprintf(`INSERT INTO %s ("id", "product_id", "value") VALUES('%s', '%s', %d)`,
-		c.table, rat.ID, rat.ProductID, rat.Value,
-	)
-	err := c.db.Exec(stmt)
+	// the rating and its summary are written by a single statement
+	// so that the summary never misses a rating, every rated product
+	// has a summary counting its approved ratings
+	stmt := fmt.Sprintf(`WITH r AS (
+			INSERT INTO %s ("id", "product_id", "user_id", "user_name", "value", "title", "body", "status", "reason") VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING "product_id", "value", %s AS "approved"
+		)
+		INSERT INTO %s AS s (%s) SELECT "product_id", "approved", "value" * "approved", %s FROM r
+		ON CONFLICT ("product_id") DO UPDATE SET ("count", "sum", "stars_1", "stars_2", "stars_3", "stars_4", "stars_5") =
+			(s."count" + EXCLUDED."count", s."sum" + EXCLUDED."sum", s."stars_1" + EXCLUDED."stars_1", s."stars_2" + EXCLUDED."stars_2",
+			s."stars_3" + EXCLUDED."stars_3", s."stars_4" + EXCLUDED."stars_4", s."stars_5" + EXCLUDED."stars_5")`,
+		c.table, approvedRating, c.summaries, summaryColumns, starCounts(`("value" = %d)::INT * "approved"`))
+	err := c.db.Exec(ctx, stmt, rat.ID, rat.ProductID, rat.User.ID, rat.User.Name, rat.Value, rat.Title, rat.Body, string(rat.Status), rat.Reason)
 	if err != nil {
-		return "", err
+		return "", duplicateRating(err)
 	}
 	return rat.ID, nil
 }
 
-// Avg returns the aggregated average rating value selected by query
-func (c *Critic) Avg(q Query, field string) (float64, error) {
-	stmt := fmt.Sprintf(`SELECT AVG("%s") FROM %s`, field, c.table)
+// Fetch returns a model.Rating finding by its id
+func (c *Critic) Fetch(ctx context.Context, id string) (interface{}, error) {
+	ctx = infra.WithOperation(ctx, "rating.fetch")
+
+	row, err := c.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE "id"=$1`, ratingColumns, c.table), id)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	if !row.Next() {
+		return nil, nil
+	}
+	rat, err := scanRating(row)
+	if err != nil {
+		return nil, err
+	}
+	return rat, nil
+}
+
+// Update updates the value, review text, status and user name of a rating
+// its product and user are never changed, the summary of the product is kept in sync
+func (c *Critic) Update(ctx context.Context, id string, v interface{}) error {
+	ctx = infra.WithOperation(ctx, "rating.update")
+	rat, ok := v.(model.Rating)
+	if !ok {
+		return ErrUnsupportedType
+	}
+	rat.ID = id
+	if rat.Status == "" {
+		rat.Status = model.RatingApproved
+	}
+	if err := rat.Validate(); err != nil {
+		return err
+	}
+
+	// o reads the replaced rating as the statement sees the ratings before r updates them
+	stmt := fmt.Sprintf(`WITH o AS (
+			SELECT "product_id", "value", %[3]s AS "approved" FROM %[1]s WHERE "id"=$7 FOR UPDATE
+		), r AS (
+			UPDATE %[1]s SET ("user_name", "value", "title", "body", "status", "reason", "updated_at") = ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+			WHERE "id"=$7 RETURNING "value", %[3]s AS "approved"
+		)
+		UPDATE %[2]s AS s SET ("count", "sum", %[4]s) =
+			(s."count" - o."approved" + r."approved", s."sum" - o."value" * o."approved" + r."value" * r."approved", %[5]s)
+		FROM o, r WHERE s."product_id" = o."product_id"`,
+		c.table, c.summaries, approvedRating, starCounts(`"stars_%d"`),
+		starCounts(`s."stars_%[1]d" - (o."value" = %[1]d)::INT * o."approved" + (r."value" = %[1]d)::INT * r."approved"`))
+	return c.db.Exec(ctx, stmt, rat.User.Name, rat.Value, rat.Title, rat.Body, string(rat.Status), rat.Reason, id)
+}
+
+// Moderate changes the status of a rating from from to to with the reason
+// the summary of its product is kept in sync
+// it returns ErrStaleStatus if the rating is not in status from
+func (c *Critic) Moderate(ctx context.Context, id string, from, to string, reason string) error {
+	ctx = infra.WithOperation(ctx, "rating.moderate")
+	if !model.RatingStatus(to).Valid() {
+		return model.ValidationError{"Status": []string{"is invalid"}}
+	}
+
+	stmt := fmt.Sprintf(`WITH o AS (
+			SELECT "product_id", "value", %[3]s AS "approved" FROM %[1]s WHERE "id"=$3 AND "status"=$4 FOR UPDATE
+		), r AS (
+			UPDATE %[1]s SET ("status", "reason") = ($1, $2) WHERE "id"=$3 AND "status"=$4 RETURNING "id", %[3]s AS "approved"
+		), s AS (
+			UPDATE %[2]s AS s SET ("count", "sum", %[4]s) =
+				(s."count" - o."approved" + r."approved", s."sum" + o."value" * (r."approved" - o."approved"), %[5]s)
+			FROM o, r WHERE s."product_id" = o."product_id"
+		)
+		SELECT "id" FROM r`,
+		c.table, c.summaries, approvedRating, starCounts(`"stars_%d"`),
+		starCounts(`s."stars_%[1]d" + (o."value" = %[1]d)::INT * (r."approved" - o."approved")`))
+
+	rows, err := c.db.Query(ctx, stmt, to, reason, id, from)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return ErrStaleStatus
+	}
+	return nil
+}
+
+// Delete deletes a rating and takes it out of the summary of its product
+func (c *Critic) Delete(ctx context.Context, id string) error {
+	ctx = infra.WithOperation(ctx, "rating.delete")
+	stmt := fmt.Sprintf(`WITH r AS (
+			DELETE FROM %s WHERE "id"=$1 RETURNING "product_id", "value", %s AS "approved"
+		)
+		UPDATE %s AS s SET ("count", "sum", %s) = (s."count" - r."approved", s."sum" - r."value" * r."approved", %s)
+		FROM r WHERE s."product_id" = r."product_id"`,
+		c.table, approvedRating, c.summaries, starCounts(`"stars_%d"`), starCounts(`s."stars_%[1]d" - (r."value" = %[1]d)::INT * r."approved"`))
+	return c.db.Exec(ctx, stmt, id)
+}
+
+// Search returns the ratings of query q, the latest first unless sorted by created_at
+func (c *Critic) Search(ctx context.Context, q Query, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "rating.search")
+	qstmt, vals := buildRatingQuery(q)
+	str := fmt.Sprintf(`SELECT %s FROM %s`, ratingColumns, c.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+	str = str + fmt.Sprintf(` ORDER BY %s OFFSET $%d LIMIT $%d`, ratingOrder(q), len(vals)+1, len(vals)+2)
+	vals = append(vals, skip, limit)
+
+	rows, err := c.db.Query(ctx, str, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rats := []interface{}{}
+	for rows.Next() {
+		rat, err := scanRating(rows)
+		if err != nil {
+			return nil, err
+		}
+		rats = append(rats, rat)
+	}
+	return rats, nil
+}
+
+// SearchCount returns number of ratings that matches query
+func (c *Critic) SearchCount(ctx context.Context, q Query) (int, error) {
+	ctx = infra.WithOperation(ctx, "rating.search_count")
+	qstmt, vals := buildRatingQuery(q)
+	str := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, c.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+
+	rows, err := c.db.Query(ctx, str, vals...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// duplicateRating maps the unique violation of the ratings of a user to ErrDuplicateRating
+func duplicateRating(err error) error {
+	uerr, ok := err.(infra.UniqueViolationError)
+	if ok && uerr.Constraint == "ratings_product_user_key" {
+		return ErrDuplicateRating
+	}
+	return err
+}
+
+// ratingOrder returns the sql order of the ratings of query q
+func ratingOrder(q Query) string {
+	if srt := q["sort"]; len(srt) != 0 && fmt.Sprint(srt[0]) == "created_at" {
+		return `"created_at", "id"`
+	}
+	return `"created_at" DESC, "id"`
+}
+
+func buildRatingQuery(q Query) (string, []interface{}) {
+	conds := []string{}
+	vals := []interface{}{}
+	for _, key := range []string{"product_id", "user_id", "status"} {
+		if v := q[key]; len(v) != 0 {
+			vals = append(vals, fmt.Sprint(v[0]))
+			conds = append(conds, fmt.Sprintf(`"%s" = $%d`, key, len(vals)))
+		}
+	}
+	return strings.Join(conds, " AND "), vals
+}
+
+// Avg returns the aggregated average value of the approved ratings selected by query
+func (c *Critic) Avg(ctx context.Context, q Query, field string) (float64, error) {
+	ctx = infra.WithOperation(ctx, "rating.avg")
+	if !isIdent(field) {
+		return 0, ErrInvalidField
+	}
+	stmt := fmt.Sprintf(`SELECT AVG("%s") FROM %s WHERE %s`, field, c.table, approvedCond)
 	vals := []interface{}{}
 	if pdtID := q["product_id"]; len(pdtID) != 0 {
 		vals = append(vals, pdtID[0])
-		stmt = stmt + ` WHERE "product_id" = $1`
+		stmt = stmt + ` AND "product_id" = $1`
 	}
 
-	rows, err := c.db.Query(stmt, vals...)
+	rows, err := c.db.Query(ctx, stmt, vals...)
 	if err != nil {
 		return 0, err
 	}
@@ -78,3 +344,90 @@ func (c *Critic) Avg(q Query, field string) (float64, error) {
 	}
 	return f.Float64, nil
 }
+
+// Summaries returns the stored rating summaries of the products of pdtIDs by product id
+func (c *Critic) Summaries(ctx context.Context, pdtIDs []string) (map[string]model.RatingSummary, error) {
+	ctx = infra.WithOperation(ctx, "rating.summaries")
+	sums := map[string]model.RatingSummary{}
+	if len(pdtIDs) == 0 {
+		return sums, nil
+	}
+	vals, phs := placeholders(pdtIDs)
+	stmt := fmt.Sprintf(`SELECT %s FROM %s WHERE "product_id" IN (%s)`, summaryColumns, c.summaries, strings.Join(phs, ", "))
+
+	rows, err := c.db.Query(ctx, stmt, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sum, err := scanSummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		sums[sum.ProductID] = sum
+	}
+	return sums, nil
+}
+
+// RebuildSummaries recomputes the rating summaries from the approved ratings and returns their number
+// it runs more than one statement so it should run in a unit of work
+func (c *Critic) RebuildSummaries(ctx context.Context) (int, error) {
+	ctx = infra.WithOperation(ctx, "rating.rebuild_summaries")
+	err := c.db.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE "product_id" NOT IN (SELECT "product_id" FROM %s)`, c.summaries, c.table))
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %[1]s AS s (%[2]s)
+		SELECT "product_id", COUNT(*) FILTER (WHERE %[5]s), COALESCE(SUM("value") FILTER (WHERE %[5]s), 0), %[3]s FROM %[4]s GROUP BY "product_id"
+		ON CONFLICT ("product_id") DO UPDATE SET ("count", "sum", "stars_1", "stars_2", "stars_3", "stars_4", "stars_5") =
+			(EXCLUDED."count", EXCLUDED."sum", EXCLUDED."stars_1", EXCLUDED."stars_2", EXCLUDED."stars_3", EXCLUDED."stars_4", EXCLUDED."stars_5")
+		RETURNING "product_id"`,
+		c.summaries, summaryColumns, starCounts(`COUNT(*) FILTER (WHERE "value" = %d AND `+approvedCond+`)`), c.table, approvedCond)
+	rows, err := c.db.Query(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	return n, nil
+}
+
+// placeholders returns ids as args with their placeholders $1 to $n
+func placeholders(ids []string) ([]interface{}, []string) {
+	vals := []interface{}{}
+	phs := []string{}
+	for _, id := range ids {
+		vals = append(vals, id)
+		phs = append(phs, fmt.Sprintf("$%d", len(vals)))
+	}
+	return vals, phs
+}
+
+// removeProducts deletes the rows of table tab of the products of pdtIDs
+func removeProducts(ctx context.Context, db infra.DB, tab string, pdtIDs []string) error {
+	if len(pdtIDs) == 0 {
+		return nil
+	}
+	vals, phs := placeholders(pdtIDs)
+	stmt := fmt.Sprintf(`DELETE FROM %s WHERE "product_id" IN (%s)`, tab, strings.Join(phs, ", "))
+	return db.Exec(ctx, stmt, vals...)
+}
+
+// RemoveProducts permanently removes the ratings and summaries of the products of pdtIDs
+func (c *Critic) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	ctx = infra.WithOperation(ctx, "rating.remove_products")
+	if len(pdtIDs) == 0 {
+		return nil
+	}
+	vals, phs := placeholders(pdtIDs)
+	stmt := fmt.Sprintf(`WITH s AS (DELETE FROM %[1]s WHERE "product_id" IN (%[3]s))
+		DELETE FROM %[2]s WHERE "product_id" IN (%[3]s)`, c.summaries, c.table, strings.Join(phs, ", "))
+	return c.db.Exec(ctx, stmt, vals...)
+}
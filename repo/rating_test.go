@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
@@ -24,21 +25,35 @@ func TestNewCritic(t *testing.T) {
 		db  infra.DB
 	}
 	tests := []struct {
-		name string
-		args args
-		want *Critic
+		name    string
+		args    args
+		want    *Critic
+		wantErr bool
 	}{
 		{
 			args: args{
 				db:  db,
 				tab: "test",
 			},
-			want: &Critic{table: "test", db: db},
+			want: &Critic{table: "test", summaries: "rating_summaries", db: db},
+		},
+		{
+			args: args{
+				db:  db,
+				tab: "test WHERE 1=1",
+			},
+			want:    nil,
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewCritic(tt.args.tab, tt.args.db); !reflect.DeepEqual(got, tt.want) {
+			got, err := NewCritic(tt.args.tab, tt.args.db)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewCritic() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewCritic() = %v, want %v", got, tt.want)
 			}
 		})
@@ -50,13 +65,15 @@ func TestCritic_Create(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	db := mock_infra.NewMockDB(mockCtrl)
-	ctc := NewCritic("test", db)
+	ctc, _ := NewCritic("test", db)
 
-	rat := model.Rating{ID: "1", ProductID: "111", Value: 3, CreatedAt: time.Time{}}
+	rat := model.Rating{ID: "1", ProductID: "111", User: model.User{ID: "u1", Name: "Jo"}, Value: 3, Title: "Warm", CreatedAt: time.Time{}}
 
 	gomock.InOrder(
-		db.EXPECT().Exec(gomock.Any()).Return(nil),
-		db.EXPECT().Exec(gomock.Any()).Return(sql.ErrConnDone),
+		db.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), rat.ProductID, rat.User.ID, rat.User.Name, rat.Value, rat.Title, rat.Body, "approved", "").Return(nil),
+		db.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), rat.ProductID, rat.User.ID, rat.User.Name, rat.Value, rat.Title, rat.Body, "approved", "").Return(sql.ErrConnDone),
+		db.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), rat.ProductID, rat.User.ID, rat.User.Name, rat.Value, rat.Title, rat.Body, "approved", "").
+			Return(infra.UniqueViolationError{Constraint: "ratings_product_user_key"}),
 	)
 
 	type args struct {
@@ -101,14 +118,26 @@ func TestCritic_Create(t *testing.T) {
 			want:    rat.ID == "",
 			wantErr: true,
 		},
+		{
+			name: "rated by the user",
+			c:    ctc,
+			args: args{
+				v: rat,
+			},
+			want:    false,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.c.Create(tt.args.v)
+			got, err := tt.c.Create(context.Background(), tt.args.v)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Critic.Create() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.name == "rated by the user" && err != ErrDuplicateRating {
+				t.Errorf("Critic.Create() error = %v, want %v", err, ErrDuplicateRating)
+			}
 			if (got != "") != tt.want {
 				t.Errorf("Critic.Create() = %v, want %v", got, tt.want)
 			}
@@ -121,7 +150,7 @@ func TestCritic_Avg(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	db := mock_infra.NewMockDB(mockCtrl)
-	ctc := NewCritic("test", db)
+	ctc, _ := NewCritic("test", db)
 
 	rat := model.Rating{ID: "1", ProductID: "111", Value: 3, CreatedAt: time.Time{}}
 
@@ -131,7 +160,7 @@ func TestCritic_Avg(t *testing.T) {
 	row.EXPECT().Scan(gomock.AssignableToTypeOf(&f)).Return(nil)
 	row.EXPECT().Close().Return(nil)
 
-	db.EXPECT().Query(fmt.Sprintf(`SELECT AVG("value") FROM %s WHERE "product_id" = $1`, ctc.table), rat.ID).Return(row, nil)
+	db.EXPECT().Query(gomock.Any(), fmt.Sprintf(`SELECT AVG("value") FROM %s WHERE "status" = 'approved' AND "product_id" = $1`, ctc.table), rat.ID).Return(row, nil)
 
 	type args struct {
 		q     Query
@@ -156,7 +185,7 @@ func TestCritic_Avg(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.c.Avg(tt.args.q, tt.args.field)
+			got, err := tt.c.Avg(context.Background(), tt.args.q, tt.args.field)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Critic.Avg() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -167,3 +196,151 @@ func TestCritic_Avg(t *testing.T) {
 		})
 	}
 }
+
+func TestCritic_HostileInput(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db := mock_infra.NewMockDB(mockCtrl)
+	row := mock_infra.NewMockRow(mockCtrl)
+	ctc, _ := NewCritic("test", db)
+
+	ids := []string{
+		`1' OR '1'='1`,
+		`1'); DROP TABLE test; --`,
+	}
+	for _, id := range ids {
+		db.EXPECT().Exec(gomock.Any(), `WITH r AS (
+			INSERT INTO test ("id", "product_id", "user_id", "user_name", "value", "title", "body", "status", "reason") VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING "product_id", "value", ("status" = 'approved')::INT AS "approved"
+		)
+		INSERT INTO rating_summaries AS s ("product_id", "count", "sum", "stars_1", "stars_2", "stars_3", "stars_4", "stars_5") `+
+			`SELECT "product_id", "approved", "value" * "approved", ("value" = 1)::INT * "approved", ("value" = 2)::INT * "approved", `+
+			`("value" = 3)::INT * "approved", ("value" = 4)::INT * "approved", ("value" = 5)::INT * "approved" FROM r
+		ON CONFLICT ("product_id") DO UPDATE SET ("count", "sum", "stars_1", "stars_2", "stars_3", "stars_4", "stars_5") =
+			(s."count" + EXCLUDED."count", s."sum" + EXCLUDED."sum", s."stars_1" + EXCLUDED."stars_1", s."stars_2" + EXCLUDED."stars_2",
+			s."stars_3" + EXCLUDED."stars_3", s."stars_4" + EXCLUDED."stars_4", s."stars_5" + EXCLUDED."stars_5")`,
+			gomock.Any(), id, id, id, 3, id, id, "pending", id).Return(nil)
+		hostile := model.Rating{ProductID: id, User: model.User{ID: id, Name: id}, Value: 3, Title: id, Body: id, Status: model.RatingPending, Reason: id}
+		if _, err := ctc.Create(context.Background(), hostile); err != nil {
+			t.Errorf("Critic.Create() product id = %q, error = %v", id, err)
+		}
+
+		db.EXPECT().Exec(gomock.Any(), `WITH o AS (
+			SELECT "product_id", "value", ("status" = 'approved')::INT AS "approved" FROM test WHERE "id"=$7 FOR UPDATE
+		), r AS (
+			UPDATE test SET ("user_name", "value", "title", "body", "status", "reason", "updated_at") = ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+			WHERE "id"=$7 RETURNING "value", ("status" = 'approved')::INT AS "approved"
+		)
+		UPDATE rating_summaries AS s SET ("count", "sum", "stars_1", "stars_2", "stars_3", "stars_4", "stars_5") =
+			(s."count" - o."approved" + r."approved", s."sum" - o."value" * o."approved" + r."value" * r."approved", `+
+			`s."stars_1" - (o."value" = 1)::INT * o."approved" + (r."value" = 1)::INT * r."approved", `+
+			`s."stars_2" - (o."value" = 2)::INT * o."approved" + (r."value" = 2)::INT * r."approved", `+
+			`s."stars_3" - (o."value" = 3)::INT * o."approved" + (r."value" = 3)::INT * r."approved", `+
+			`s."stars_4" - (o."value" = 4)::INT * o."approved" + (r."value" = 4)::INT * r."approved", `+
+			`s."stars_5" - (o."value" = 5)::INT * o."approved" + (r."value" = 5)::INT * r."approved")
+		FROM o, r WHERE s."product_id" = o."product_id"`,
+			id, 3, id, id, "pending", id, id).Return(nil)
+		if err := ctc.Update(context.Background(), id, hostile); err != nil {
+			t.Errorf("Critic.Update() id = %q, error = %v", id, err)
+		}
+
+		db.EXPECT().Exec(gomock.Any(), `WITH r AS (
+			DELETE FROM test WHERE "id"=$1 RETURNING "product_id", "value", ("status" = 'approved')::INT AS "approved"
+		)
+		UPDATE rating_summaries AS s SET ("count", "sum", "stars_1", "stars_2", "stars_3", "stars_4", "stars_5") = `+
+			`(s."count" - r."approved", s."sum" - r."value" * r."approved", `+
+			`s."stars_1" - (r."value" = 1)::INT * r."approved", s."stars_2" - (r."value" = 2)::INT * r."approved", `+
+			`s."stars_3" - (r."value" = 3)::INT * r."approved", s."stars_4" - (r."value" = 4)::INT * r."approved", `+
+			`s."stars_5" - (r."value" = 5)::INT * r."approved")
+		FROM r WHERE s."product_id" = r."product_id"`, id).Return(nil)
+		if err := ctc.Delete(context.Background(), id); err != nil {
+			t.Errorf("Critic.Delete() id = %q, error = %v", id, err)
+		}
+
+		db.EXPECT().Query(gomock.Any(), `WITH o AS (
+			SELECT "product_id", "value", ("status" = 'approved')::INT AS "approved" FROM test WHERE "id"=$3 AND "status"=$4 FOR UPDATE
+		), r AS (
+			UPDATE test SET ("status", "reason") = ($1, $2) WHERE "id"=$3 AND "status"=$4 RETURNING "id", ("status" = 'approved')::INT AS "approved"
+		), s AS (
+			UPDATE rating_summaries AS s SET ("count", "sum", "stars_1", "stars_2", "stars_3", "stars_4", "stars_5") =
+				(s."count" - o."approved" + r."approved", s."sum" + o."value" * (r."approved" - o."approved"), `+
+			`s."stars_1" + (o."value" = 1)::INT * (r."approved" - o."approved"), s."stars_2" + (o."value" = 2)::INT * (r."approved" - o."approved"), `+
+			`s."stars_3" + (o."value" = 3)::INT * (r."approved" - o."approved"), s."stars_4" + (o."value" = 4)::INT * (r."approved" - o."approved"), `+
+			`s."stars_5" + (o."value" = 5)::INT * (r."approved" - o."approved"))
+			FROM o, r WHERE s."product_id" = o."product_id"
+		)
+		SELECT "id" FROM r`, "rejected", id, id, "pending").Return(row, nil)
+		row.EXPECT().Next().Return(false)
+		row.EXPECT().Close().Return(nil)
+		if err := ctc.Moderate(context.Background(), id, "pending", "rejected", id); err != ErrStaleStatus {
+			t.Errorf("Critic.Moderate() id = %q, error = %v, want %v", id, err, ErrStaleStatus)
+		}
+
+		db.EXPECT().Query(gomock.Any(), `SELECT COUNT(*) FROM test WHERE "product_id" = $1 AND "user_id" = $2 AND "status" = $3`, id, id, id).Return(row, nil)
+		row.EXPECT().Next().Return(false)
+		row.EXPECT().Close().Return(nil)
+		if _, err := ctc.SearchCount(context.Background(), Query{"product_id": {id}, "user_id": {id}, "status": {id}}); err != nil {
+			t.Errorf("Critic.SearchCount() product id = %q, error = %v", id, err)
+		}
+
+		db.EXPECT().Query(gomock.Any(), `SELECT AVG("value") FROM test WHERE "status" = 'approved' AND "product_id" = $1`, id).Return(row, nil)
+		row.EXPECT().Next().Return(false)
+		row.EXPECT().Close().Return(nil)
+		if _, err := ctc.Avg(context.Background(), Query{"product_id": []interface{}{id}}, "value"); err != nil {
+			t.Errorf("Critic.Avg() product id = %q, error = %v", id, err)
+		}
+	}
+
+	fields := []string{
+		`value") FROM test; DROP TABLE test; --`,
+		`value"`,
+		"",
+	}
+	for _, f := range fields {
+		if _, err := ctc.Avg(context.Background(), Query{}, f); err != ErrInvalidField {
+			t.Errorf("Critic.Avg() field = %q, error = %v, want %v", f, err, ErrInvalidField)
+		}
+	}
+}
+
+func Test_buildRatingQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		q     Query
+		want  string
+		want1 []interface{}
+	}{
+		{
+			q:     Query{},
+			want:  "",
+			want1: []interface{}{},
+		},
+		{
+			q:     Query{"product_id": {"1"}},
+			want:  `"product_id" = $1`,
+			want1: []interface{}{"1"},
+		},
+		{
+			q:     Query{"user_id": {"u1"}, "product_id": {"1"}, "value": {5}},
+			want:  `"product_id" = $1 AND "user_id" = $2`,
+			want1: []interface{}{"1", "u1"},
+		},
+		{
+			q:     Query{"status": {"pending"}, "sort": {"created_at"}},
+			want:  `"status" = $1`,
+			want1: []interface{}{"pending"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1 := buildRatingQuery(tt.q)
+			if got != tt.want {
+				t.Errorf("buildRatingQuery() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(got1, tt.want1) {
+				t.Errorf("buildRatingQuery() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
@@ -1,5 +1,11 @@
 package repo
 
+import (
+	"context"
+	"regexp"
+	"time"
+)
+
 // Query represents the query object
 type Query map[string][]interface{}
 
@@ -11,47 +17,138 @@ func (q Query) Add(key string, val interface{}) {
 // Creator interface holds the necessery dependencies to create a entry in repo
 // Create takes a model and returns its id on success
 type Creator interface {
-	Create(v interface{}) (string, error)
+	Create(ctx context.Context, v interface{}) (string, error)
 }
 
 // Fetcher interface holds the necessery dependencies to Fetch a entry from repo
 // Fetch takes an id and returns the model on success
 type Fetcher interface {
-	Fetch(id string) (interface{}, error)
+	Fetch(ctx context.Context, id string) (interface{}, error)
 }
 
 // Updater interface holds the necessery dependencies to Update a entry in repo
 // Update takes an id and model, it select the entry by id and replace its fields
 // with the new model except the id
 type Updater interface {
-	Update(id string, v interface{}) error
+	Update(ctx context.Context, id string, v interface{}) error
 }
 
 // Deleter interface holds the necessery dependencies to delete a entry in repo
 // Delete takes an id and delete the entry
 type Deleter interface {
-	Delete(id string) error
+	Delete(ctx context.Context, id string) error
 }
 
 // Searcher interface holds the necessery dependencies to search and count entries
 // with the matching query parameters
 type Searcher interface {
-	Search(q Query, skip, limit int) ([]interface{}, error)
-	SearchCount(q Query) (int, error)
+	Search(ctx context.Context, q Query, skip, limit int) ([]interface{}, error)
+	SearchCount(ctx context.Context, q Query) (int, error)
 }
 
 // Lister interface holds the necessery dependencies to list paginated entries
 type Lister interface {
-	List(skip, limit int) ([]interface{}, error)
+	List(ctx context.Context, skip, limit int) ([]interface{}, error)
 }
 
 // Counter interface holds the necessery dependencies to count the entries in repo
 type Counter interface {
-	Count() (int, error)
+	Count(ctx context.Context) (int, error)
 }
 
 // AvgAggrigator interface holds the necessery dependencies to aggregate
 // average value of the field with matching query q
 type AvgAggrigator interface {
-	Avg(q Query, field string) (float64, error)
+	Avg(ctx context.Context, q Query, field string) (float64, error)
+}
+
+// QuantityAdjuster interface holds the necessery dependencies to adjust the quantity of a entry
+// AdjustQuantity adds delta to the quantity of the entry by id and returns the new quantity
+// AdjustReserved adds delta to the reserved part of the quantity and returns the new reserved
+type QuantityAdjuster interface {
+	AdjustQuantity(ctx context.Context, id string, delta int) (int, error)
+	AdjustReserved(ctx context.Context, id string, delta int) (int, error)
+}
+
+// TagCounter interface holds the necessery dependencies to count the usage of tags
+// CountTags returns the number of entries tagged with every tag
+type TagCounter interface {
+	CountTags(ctx context.Context) (map[string]int, error)
+}
+
+// LevelAdjuster interface holds the necessery dependencies to adjust the level of a product at a location
+// AdjustLevel adds delta to the level of product pdtID at location locID and returns the new level
+type LevelAdjuster interface {
+	AdjustLevel(ctx context.Context, pdtID, locID string, delta int) (int, error)
+}
+
+// CategoryAssigner interface holds the necessery dependencies to assign categories to a product
+// Assign replaces the categories of product pdtID with catIDs
+// Assigned returns the category ids of product pdtID
+// AssignedAll returns the category ids of the products of pdtIDs by product id
+type CategoryAssigner interface {
+	Assign(ctx context.Context, pdtID string, catIDs []string) error
+	Assigned(ctx context.Context, pdtID string) ([]string, error)
+	AssignedAll(ctx context.Context, pdtIDs []string) (map[string][]string, error)
+}
+
+// PriceSetter interface holds the necessery dependencies to change the price of a entry
+// SetPrice changes the price of the entry by id leaving its other fields as they are
+type PriceSetter interface {
+	SetPrice(ctx context.Context, id string, price int) error
+}
+
+// DescendantFinder interface holds the necessery dependencies to find the descendants of tree entries
+// Descendants returns ids along with the ids of all of their descendant entries
+type DescendantFinder interface {
+	Descendants(ctx context.Context, ids []string) ([]string, error)
+}
+
+// TreeLocker interface holds the necessery dependencies to lock a tree of entries
+// LockTree serializes the changes of the tree until the transaction of ctx ends
+type TreeLocker interface {
+	LockTree(ctx context.Context) error
+}
+
+// StatusSetter interface holds the necessery dependencies to change the status of a entry
+// SetStatus changes the status of the entry by id to to only if it is from
+type StatusSetter interface {
+	SetStatus(ctx context.Context, id string, from, to string) error
+}
+
+// Moderator interface holds the necessery dependencies to moderate the entries in repo
+// Moderate changes the status of the entry by id from from to to with the reason of the change
+// it returns ErrStaleStatus if the entry is not in status from
+type Moderator interface {
+	Moderate(ctx context.Context, id string, from, to string, reason string) error
+}
+
+// Restorer interface holds the necessery dependencies to list and restore deleted entries
+// ListDeleted lists the deleted entries, the latest deleted first
+// Restore undeletes the entry by id, it returns ErrNotDeleted if it is not a deleted entry
+type Restorer interface {
+	ListDeleted(ctx context.Context, skip, limit int) ([]interface{}, error)
+	CountDeleted(ctx context.Context) (int, error)
+	Restore(ctx context.Context, id string) error
+}
+
+// Purger interface holds the necessery dependencies to permanently remove deleted entries
+// Purge removes at most limit entries deleted before t and returns their ids
+type Purger interface {
+	Purge(ctx context.Context, t time.Time, limit int) ([]string, error)
+}
+
+// ProductRemover interface holds the necessery dependencies to remove the entries of products
+// RemoveProducts permanently removes every entry of the products of pdtIDs
+type ProductRemover interface {
+	RemoveProducts(ctx context.Context, pdtIDs []string) error
+}
+
+var identRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// isIdent checks if s is safe to be used as a sql identifier
+// identifiers can not be passed as placeholder args so they
+// are restricted to letters, digits and underscore
+func isIdent(s string) bool {
+	return identRegexp.MatchString(s)
 }
@@ -0,0 +1,271 @@
+package repotest
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// CategoryFactory returns a new and empty repo.Category on every call
+type CategoryFactory func(t *testing.T) repo.Category
+
+// RunCategorySuite runs the repo.Category conformance suite
+// against the repos returned by newRepo
+func RunCategorySuite(t *testing.T, newRepo CategoryFactory) {
+	t.Run("CreateFetch", func(t *testing.T) { testCategoryCreateFetch(t, newRepo(t)) })
+	t.Run("Update", func(t *testing.T) { testCategoryUpdate(t, newRepo(t)) })
+	t.Run("Search", func(t *testing.T) { testCategorySearch(t, newRepo(t)) })
+	t.Run("Assign", func(t *testing.T) { testCategoryAssign(t, newRepo(t)) })
+	t.Run("Descendants", func(t *testing.T) { testCategoryDescendants(t, newRepo(t)) })
+	t.Run("LockTree", func(t *testing.T) { testCategoryLockTree(t, newRepo(t)) })
+	t.Run("Delete", func(t *testing.T) { testCategoryDelete(t, newRepo(t)) })
+}
+
+func fetchCategory(t *testing.T, r repo.Category, id string) *model.Category {
+	t.Helper()
+	v, err := r.Fetch(ctx, id)
+	if err != nil {
+		t.Fatalf("Fetch(%q) error = %v", id, err)
+	}
+	if v == nil {
+		return nil
+	}
+	cat, ok := v.(model.Category)
+	if !ok {
+		t.Fatalf("Fetch(%q) = %T, want model.Category", id, v)
+	}
+	return &cat
+}
+
+func categoryIDs(t *testing.T, vs []interface{}) []string {
+	t.Helper()
+	ids := []string{}
+	for _, v := range vs {
+		cat, ok := v.(model.Category)
+		if !ok {
+			t.Fatalf("got %T, want model.Category", v)
+		}
+		ids = append(ids, cat.ID)
+	}
+	return ids
+}
+
+func createCategories(t *testing.T, r repo.Category, cats ...model.Category) []string {
+	t.Helper()
+	ids := []string{}
+	for _, cat := range cats {
+		id, err := r.Create(ctx, cat)
+		if err != nil {
+			t.Fatalf("Create(%#v) error = %v", cat, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func testCategoryCreateFetch(t *testing.T, r repo.Category) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		wantErr bool
+	}{
+		{v: struct{}{}, wantErr: true},
+		{v: model.Category{}, wantErr: true},
+		{v: model.Category{ID: "ignored", Name: "Fruits", ParentID: "root"}, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := r.Create(ctx, tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if id == "" || id == "ignored" {
+				t.Fatalf("Create() id = %q, want a new generated id", id)
+			}
+			cat := fetchCategory(t, r, id)
+			if cat == nil || cat.Name != "Fruits" || cat.ParentID != "root" || cat.CreatedAt.IsZero() {
+				t.Errorf("Fetch() = %#v", cat)
+			}
+		})
+	}
+
+	if cat := fetchCategory(t, r, "unavailable_id"); cat != nil {
+		t.Errorf("Fetch() = %#v, want nil", cat)
+	}
+}
+
+func testCategoryUpdate(t *testing.T, r repo.Category) {
+	ids := createCategories(t, r, model.Category{Name: "Fruits"}, model.Category{Name: "Mango"})
+
+	if err := r.Update(ctx, ids[1], model.Category{ID: ids[1], Name: "Mango", ParentID: ids[1]}); err == nil {
+		t.Errorf("Update() with itself as parent error = nil")
+	}
+	if err := r.Update(ctx, ids[1], model.Category{ID: ids[1], Name: "Mangoes", ParentID: ids[0]}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	cat := fetchCategory(t, r, ids[1])
+	if cat == nil || cat.Name != "Mangoes" || cat.ParentID != ids[0] {
+		t.Errorf("Fetch() after Update() = %#v", cat)
+	}
+}
+
+func testCategorySearch(t *testing.T, r repo.Category) {
+	ids := createCategories(t, r, model.Category{Name: "Fruits"}, model.Category{Name: "Drinks"})
+	kids := createCategories(t, r,
+		model.Category{Name: "Mango", ParentID: ids[0]},
+		model.Category{Name: "Apple", ParentID: ids[0]},
+		model.Category{Name: "Juice", ParentID: ids[1]},
+	)
+
+	tests := []struct {
+		name string
+		q    repo.Query
+		want []string
+	}{
+		{name: "all", q: repo.Query{}, want: []string{kids[1], ids[1], ids[0], kids[2], kids[0]}},
+		{name: "roots", q: repo.Query{"parent_id": {""}}, want: []string{ids[1], ids[0]}},
+		{name: "children", q: repo.Query{"parent_id": {ids[0]}}, want: []string{kids[1], kids[0]}},
+		{name: "injection", q: repo.Query{"parent_id": {"' OR '1'='1"}}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Search(ctx, tt.q, 0, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			assertIDs(t, "Search()", categoryIDs(t, res), tt.want)
+
+			n, err := r.SearchCount(ctx, tt.q)
+			if err != nil || n != len(tt.want) {
+				t.Errorf("SearchCount() = %v, %v, want %v", n, err, len(tt.want))
+			}
+		})
+	}
+
+	res, err := r.List(ctx, 1, 2)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	assertIDs(t, "List() paged", categoryIDs(t, res), []string{ids[1], ids[0]})
+	if n, err := r.Count(ctx); err != nil || n != 5 {
+		t.Errorf("Count() = %v, %v, want 5", n, err)
+	}
+}
+
+func testCategoryAssign(t *testing.T, r repo.Category) {
+	if err := r.Assign(ctx, "p1", []string{"c2", "c1", "c2"}); err != nil {
+		t.Fatalf("Assign() error = %v", err)
+	}
+	if err := r.Assign(ctx, "p2", []string{"c1"}); err != nil {
+		t.Fatalf("Assign() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		pdtID  string
+		assign []string
+		want   []string
+	}{
+		{name: "assigned", pdtID: "p1", want: []string{"c1", "c2"}},
+		{name: "replace", pdtID: "p1", assign: []string{"c3"}, want: []string{"c3"}},
+		{name: "clear", pdtID: "p1", assign: []string{}, want: []string{}},
+		{name: "untouched", pdtID: "p2", want: []string{"c1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.assign != nil {
+				if err := r.Assign(ctx, tt.pdtID, tt.assign); err != nil {
+					t.Fatalf("Assign() error = %v", err)
+				}
+			}
+			got, err := r.Assigned(ctx, tt.pdtID)
+			if err != nil {
+				t.Fatalf("Assigned() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Assigned() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := r.Assign(ctx, "p3", []string{"c2", "c1"}); err != nil {
+		t.Fatalf("Assign() error = %v", err)
+	}
+	want := map[string][]string{"p2": {"c1"}, "p3": {"c1", "c2"}}
+	if got, err := r.AssignedAll(ctx, []string{"p1", "p2", "p3", "p4"}); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("AssignedAll() = %v, %v, want %v", got, err, want)
+	}
+	if got, err := r.AssignedAll(ctx, nil); err != nil || len(got) != 0 {
+		t.Errorf("AssignedAll() no products = %v, %v, want empty", got, err)
+	}
+}
+
+func testCategoryDescendants(t *testing.T, r repo.Category) {
+	roots := createCategories(t, r, model.Category{Name: "Food"}, model.Category{Name: "Drinks"})
+	food, drink := roots[0], roots[1]
+	fruit := createCategories(t, r, model.Category{Name: "Fruits", ParentID: food})[0]
+	mango := createCategories(t, r, model.Category{Name: "Mangoes", ParentID: fruit})[0]
+	tea := createCategories(t, r, model.Category{Name: "Tea", ParentID: drink})[0]
+
+	tests := []struct {
+		name string
+		ids  []string
+		want []string
+	}{
+		{name: "tree", ids: []string{food}, want: []string{food, fruit, mango}},
+		{name: "leaf", ids: []string{mango}, want: []string{mango}},
+		{name: "overlapping", ids: []string{fruit, food, drink}, want: []string{food, fruit, mango, drink, tea}},
+		{name: "unknown", ids: []string{"unavailable_id"}, want: []string{"unavailable_id"}},
+		{name: "none", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Descendants(ctx, tt.ids)
+			if err != nil {
+				t.Fatalf("Descendants() error = %v", err)
+			}
+			sort.Strings(got)
+			want := append([]string{}, tt.want...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Descendants() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func testCategoryDelete(t *testing.T, r repo.Category) {
+	ids := createCategories(t, r, model.Category{Name: "Fruits"}, model.Category{Name: "Drinks"})
+	if err := r.Assign(ctx, "p1", ids); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Delete(ctx, ids[0]); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := r.Delete(ctx, "unavailable_id"); err != nil {
+		t.Errorf("Delete() of unavailable category error = %v", err)
+	}
+
+	if cat := fetchCategory(t, r, ids[0]); cat != nil {
+		t.Errorf("Fetch() after Delete() = %#v, want nil", cat)
+	}
+	got, err := r.Assigned(ctx, "p1")
+	if err != nil || !reflect.DeepEqual(got, []string{ids[1]}) {
+		t.Errorf("Assigned() after Delete() = %v, %v, want %v", got, err, []string{ids[1]})
+	}
+}
+
+func testCategoryLockTree(t *testing.T, r repo.Category) {
+	if err := r.LockTree(ctx); err != nil {
+		t.Errorf("LockTree() error = %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package repotest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// LocationFactory returns a new and empty repo.Location on every call
+type LocationFactory func(t *testing.T) repo.Location
+
+// RunLocationSuite runs the repo.Location conformance suite
+// against the repos returned by newRepo
+func RunLocationSuite(t *testing.T, newRepo LocationFactory) {
+	t.Run("CreateFetch", func(t *testing.T) { testLocationCreateFetch(t, newRepo(t)) })
+	t.Run("Update", func(t *testing.T) { testLocationUpdate(t, newRepo(t)) })
+	t.Run("ListCount", func(t *testing.T) { testLocationListCount(t, newRepo(t)) })
+}
+
+// StockLevelFactory returns a new and empty repo.StockLevel on every call
+type StockLevelFactory func(t *testing.T) repo.StockLevel
+
+// RunStockLevelSuite runs the repo.StockLevel conformance suite
+// against the repos returned by newRepo
+func RunStockLevelSuite(t *testing.T, newRepo StockLevelFactory) {
+	t.Run("AdjustLevel", func(t *testing.T) { testStockLevelAdjust(t, newRepo(t)) })
+	t.Run("Search", func(t *testing.T) { testStockLevelSearch(t, newRepo(t)) })
+}
+
+func fetchLocation(t *testing.T, r repo.Location, id string) *model.Location {
+	t.Helper()
+	v, err := r.Fetch(ctx, id)
+	if err != nil {
+		t.Fatalf("Fetch(%q) error = %v", id, err)
+	}
+	if v == nil {
+		return nil
+	}
+	loc, ok := v.(model.Location)
+	if !ok {
+		t.Fatalf("Fetch(%q) = %T, want model.Location", id, v)
+	}
+	return &loc
+}
+
+func locationIDs(t *testing.T, vs []interface{}) []string {
+	t.Helper()
+	ids := []string{}
+	for _, v := range vs {
+		loc, ok := v.(model.Location)
+		if !ok {
+			t.Fatalf("got %T, want model.Location", v)
+		}
+		ids = append(ids, loc.ID)
+	}
+	return ids
+}
+
+func testLocationCreateFetch(t *testing.T, r repo.Location) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		wantErr bool
+	}{
+		{v: struct{}{}, wantErr: true},
+		{v: model.Location{}, wantErr: true},
+		{v: model.Location{ID: "ignored", Name: "Dhaka", Address: "Tejgaon"}, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := r.Create(ctx, tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if id == "" || id == "ignored" {
+				t.Fatalf("Create() id = %q, want a new generated id", id)
+			}
+			loc := fetchLocation(t, r, id)
+			if loc == nil || loc.Name != "Dhaka" || loc.Address != "Tejgaon" || loc.CreatedAt.IsZero() {
+				t.Errorf("Fetch() = %#v", loc)
+			}
+		})
+	}
+
+	if loc := fetchLocation(t, r, "unavailable_id"); loc != nil {
+		t.Errorf("Fetch() = %#v, want nil", loc)
+	}
+}
+
+func testLocationUpdate(t *testing.T, r repo.Location) {
+	id, err := r.Create(ctx, model.Location{Name: "Dhaka"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Update(ctx, id, model.Location{ID: id}); err == nil {
+		t.Errorf("Update() with invalid location error = nil")
+	}
+	if err := r.Update(ctx, id, model.Location{ID: id, Name: "Chittagong", Address: "Agrabad"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := r.Update(ctx, "unavailable_id", model.Location{ID: "unavailable_id", Name: "Sylhet"}); err != nil {
+		t.Errorf("Update() of unavailable location error = %v", err)
+	}
+
+	loc := fetchLocation(t, r, id)
+	if loc == nil || loc.Name != "Chittagong" || loc.Address != "Agrabad" {
+		t.Errorf("Fetch() after Update() = %#v", loc)
+	}
+}
+
+func testLocationListCount(t *testing.T, r repo.Location) {
+	ids := []string{}
+	for _, name := range []string{"Sylhet", "Chittagong", "Dhaka"} {
+		id, err := r.Create(ctx, model.Location{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	res, err := r.List(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	assertIDs(t, "List()", locationIDs(t, res), []string{ids[1], ids[2], ids[0]})
+
+	res, err = r.List(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("List() paged error = %v", err)
+	}
+	assertIDs(t, "List() paged", locationIDs(t, res), []string{ids[2]})
+
+	n, err := r.Count(ctx)
+	if err != nil || n != 3 {
+		t.Errorf("Count() = %v, %v, want 3", n, err)
+	}
+}
+
+func testStockLevelAdjust(t *testing.T, r repo.StockLevel) {
+	tests := []struct {
+		name    string
+		loc     string
+		delta   int
+		want    int
+		wantErr error
+	}{
+		{name: "take unstocked", loc: "1", delta: -1, wantErr: repo.ErrInsufficientQuantity},
+		{name: "receive", loc: "1", delta: 5, want: 5},
+		{name: "receive more", loc: "1", delta: 2, want: 7},
+		{name: "overdraw", loc: "1", delta: -8, wantErr: repo.ErrInsufficientQuantity},
+		{name: "take", loc: "1", delta: -7, want: 0},
+		{name: "other location", loc: "2", delta: 3, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := r.AdjustLevel(ctx, "p1", tt.loc, tt.delta)
+			if err != tt.wantErr {
+				t.Fatalf("AdjustLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && n != tt.want {
+				t.Errorf("AdjustLevel() = %v, want %v", n, tt.want)
+			}
+		})
+	}
+
+	res, err := r.Search(ctx, repo.Query{"product_id": {"p1"}}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("Search() = %v, want 2 levels", res)
+	}
+	for i, want := range []int{0, 3} {
+		if lvl := res[i].(model.StockLevel); lvl.Quantity != want || lvl.UpdatedAt.IsZero() {
+			t.Errorf("Search()[%d] = %#v, want quantity %d", i, lvl, want)
+		}
+	}
+}
+
+func testStockLevelSearch(t *testing.T, r repo.StockLevel) {
+	for _, lvl := range []model.StockLevel{
+		{ProductID: "p2", LocationID: "1", Quantity: 1},
+		{ProductID: "p1", LocationID: "2", Quantity: 2},
+		{ProductID: "p1", LocationID: "1", Quantity: 3},
+	} {
+		if _, err := r.AdjustLevel(ctx, lvl.ProductID, lvl.LocationID, lvl.Quantity); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		q    repo.Query
+		want []int
+	}{
+		{name: "all", q: repo.Query{}, want: []int{3, 2, 1}},
+		{name: "product", q: repo.Query{"product_id": {"p1"}}, want: []int{3, 2}},
+		{name: "location", q: repo.Query{"location_id": {"1"}}, want: []int{3, 1}},
+		{name: "product and location", q: repo.Query{"product_id": {"p2"}, "location_id": {"1"}}, want: []int{1}},
+		{name: "injection", q: repo.Query{"product_id": {"p1' OR '1'='1"}}, want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Search(ctx, tt.q, 0, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			got := []int{}
+			for _, v := range res {
+				got = append(got, v.(model.StockLevel).Quantity)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search() quantities = %v, want %v", got, tt.want)
+			}
+
+			n, err := r.SearchCount(ctx, tt.q)
+			if err != nil || n != len(tt.want) {
+				t.Errorf("SearchCount() = %v, %v, want %v", n, err, len(tt.want))
+			}
+		})
+	}
+}
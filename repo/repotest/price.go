@@ -0,0 +1,182 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// PriceFactory returns a new and empty repo.Price on every call
+type PriceFactory func(t *testing.T) repo.Price
+
+// RunPriceSuite runs the repo.Price conformance suite
+// against the repos returned by newRepo
+func RunPriceSuite(t *testing.T, newRepo PriceFactory) {
+	t.Run("CreateFetch", func(t *testing.T) { testPriceCreateFetch(t, newRepo(t)) })
+	t.Run("Search", func(t *testing.T) { testPriceSearch(t, newRepo(t)) })
+	t.Run("SetStatus", func(t *testing.T) { testPriceSetStatus(t, newRepo(t)) })
+}
+
+func priceIDs(t *testing.T, vs []interface{}) []string {
+	t.Helper()
+	ids := []string{}
+	for _, v := range vs {
+		prc, ok := v.(model.PriceChange)
+		if !ok {
+			t.Fatalf("got %T, want model.PriceChange", v)
+		}
+		ids = append(ids, prc.ID)
+	}
+	return ids
+}
+
+func fetchPrice(t *testing.T, r repo.Price, id string) *model.PriceChange {
+	t.Helper()
+	v, err := r.Fetch(ctx, id)
+	if err != nil {
+		t.Fatalf("Fetch(%q) error = %v", id, err)
+	}
+	if v == nil {
+		return nil
+	}
+	prc, ok := v.(model.PriceChange)
+	if !ok {
+		t.Fatalf("Fetch(%q) = %T, want model.PriceChange", id, v)
+	}
+	return &prc
+}
+
+func testPriceCreateFetch(t *testing.T, r repo.Price) {
+	eff := time.Now().Add(time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name    string
+		v       interface{}
+		wantErr bool
+	}{
+		{v: struct{}{}, wantErr: true},
+		{v: model.PriceChange{}, wantErr: true},
+		{v: model.PriceChange{ProductID: "1", Price: 0, Status: model.PriceScheduled, EffectiveAt: eff}, wantErr: true},
+		{v: model.PriceChange{ProductID: "1", Price: 120, Status: "pending", EffectiveAt: eff}, wantErr: true},
+		{v: model.PriceChange{ID: "ignored", ProductID: "1", Price: 120, Status: model.PriceScheduled, EffectiveAt: eff}, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := r.Create(ctx, tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if id == "" || id == "ignored" {
+				t.Fatalf("Create() id = %q, want a new generated id", id)
+			}
+
+			prc := fetchPrice(t, r, id)
+			if prc == nil {
+				t.Fatalf("Fetch(%q) = nil, want the created price change", id)
+			}
+			if prc.ProductID != "1" || prc.Price != 120 || prc.Status != model.PriceScheduled ||
+				!prc.EffectiveAt.Equal(eff) || prc.CreatedAt.IsZero() {
+				t.Errorf("Fetch() = %#v", prc)
+			}
+		})
+	}
+
+	if prc := fetchPrice(t, r, "unavailable_id"); prc != nil {
+		t.Errorf("Fetch() = %#v, want nil", prc)
+	}
+}
+
+func testPriceSearch(t *testing.T, r repo.Price) {
+	now := time.Now().Truncate(time.Second)
+	prcs := []model.PriceChange{
+		{ProductID: "1", Price: 100, Status: model.PriceScheduled, EffectiveAt: now.Add(3 * time.Minute)},
+		{ProductID: "2", Price: 100, Status: model.PriceScheduled, EffectiveAt: now.Add(-time.Minute)},
+		{ProductID: "1", Price: 100, Status: model.PriceScheduled, EffectiveAt: now.Add(-2 * time.Minute)},
+		{ProductID: "1", Price: 100, Status: model.PriceApplied, EffectiveAt: now.Add(-3 * time.Minute)},
+	}
+	ids := []string{}
+	for _, prc := range prcs {
+		id, err := r.Create(ctx, prc)
+		if err != nil {
+			t.Fatalf("Create(%#v) error = %v", prc, err)
+		}
+		ids = append(ids, id)
+	}
+
+	tests := []struct {
+		name string
+		q    repo.Query
+		want []string
+	}{
+		{name: "all by effective time", q: repo.Query{}, want: []string{ids[3], ids[2], ids[1], ids[0]}},
+		{name: "product", q: repo.Query{"product_id": {"1"}}, want: []string{ids[3], ids[2], ids[0]}},
+		{name: "due scheduled", q: repo.Query{"status": {"scheduled"}, "effective_before": {now}}, want: []string{ids[2], ids[1]}},
+		{name: "unknown product", q: repo.Query{"product_id": {"3"}}, want: nil},
+		{name: "injection", q: repo.Query{"status": {"scheduled' OR '1'='1"}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Search(ctx, tt.q, 0, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			assertIDs(t, "Search()", priceIDs(t, res), tt.want)
+
+			n, err := r.SearchCount(ctx, tt.q)
+			if err != nil {
+				t.Fatalf("SearchCount() error = %v", err)
+			}
+			if n != len(tt.want) {
+				t.Errorf("SearchCount() = %v, want %v", n, len(tt.want))
+			}
+
+			if len(tt.want) < 2 {
+				return
+			}
+			res, err = r.Search(ctx, tt.q, 1, 1)
+			if err != nil {
+				t.Fatalf("Search() paged error = %v", err)
+			}
+			assertIDs(t, "Search() paged", priceIDs(t, res), tt.want[1:2])
+		})
+	}
+}
+
+func testPriceSetStatus(t *testing.T, r repo.Price) {
+	id, err := r.Create(ctx, model.PriceChange{ProductID: "1", Price: 100, Status: model.PriceScheduled, EffectiveAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		from, to model.PriceStatus
+		want     model.PriceStatus
+		wantErr  error
+	}{
+		{name: "apply", id: id, from: model.PriceScheduled, to: model.PriceApplied, want: model.PriceApplied},
+		{name: "stale", id: id, from: model.PriceScheduled, to: model.PriceCancelled, want: model.PriceApplied, wantErr: repo.ErrStaleStatus},
+		{name: "unavailable", id: "unavailable_id", from: model.PriceScheduled, to: model.PriceCancelled, wantErr: repo.ErrStaleStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.SetStatus(ctx, tt.id, string(tt.from), string(tt.to))
+			if err != tt.wantErr {
+				t.Fatalf("SetStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			prc := fetchPrice(t, r, tt.id)
+			if prc == nil {
+				return
+			}
+			if prc.Status != tt.want {
+				t.Errorf("Fetch() after SetStatus() status = %v, want %v", prc.Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,844 @@
+package repotest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// ProductFactory returns a new and empty repo.Product on every call
+type ProductFactory func(t *testing.T) repo.Product
+
+// ProductRatingFactory returns a new and empty repo.Product with the repo.Rating
+// of the same storage on every call
+type ProductRatingFactory func(t *testing.T) (repo.Product, repo.Rating)
+
+// RunProductSuite runs the repo.Product conformance suite
+// against the repos returned by newRepo
+func RunProductSuite(t *testing.T, newRepo ProductFactory) {
+	t.Run("Create", func(t *testing.T) { testProductCreate(t, newRepo(t)) })
+	t.Run("Fetch", func(t *testing.T) { testProductFetch(t, newRepo(t)) })
+	t.Run("Update", func(t *testing.T) { testProductUpdate(t, newRepo(t)) })
+	t.Run("Delete", func(t *testing.T) { testProductDelete(t, newRepo(t)) })
+	t.Run("ListCount", func(t *testing.T) { testProductListCount(t, newRepo(t)) })
+	t.Run("Search", func(t *testing.T) { testProductSearch(t, newRepo(t)) })
+	t.Run("AdjustQuantity", func(t *testing.T) { testProductAdjustQuantity(t, newRepo(t)) })
+	t.Run("AdjustReserved", func(t *testing.T) { testProductAdjustReserved(t, newRepo(t)) })
+	t.Run("Tags", func(t *testing.T) { testProductTags(t, newRepo(t)) })
+	t.Run("Codes", func(t *testing.T) { testProductCodes(t, newRepo(t)) })
+	t.Run("Prices", func(t *testing.T) { testProductPrices(t, newRepo(t)) })
+	t.Run("Status", func(t *testing.T) { testProductStatus(t, newRepo(t)) })
+	t.Run("Restore", func(t *testing.T) { testProductRestore(t, newRepo(t)) })
+	t.Run("Purge", func(t *testing.T) { testProductPurge(t, newRepo(t)) })
+}
+
+// createProducts creates pdts and receives their Quantity as stock
+func createProducts(t *testing.T, r repo.Product, pdts ...model.Product) []string {
+	t.Helper()
+	ids := []string{}
+	for _, pdt := range pdts {
+		tick()
+		id, err := r.Create(ctx, pdt)
+		if err != nil {
+			t.Fatalf("Create(%#v) error = %v", pdt, err)
+		}
+		if pdt.Quantity != 0 {
+			if _, err := r.AdjustQuantity(ctx, id, pdt.Quantity); err != nil {
+				t.Fatalf("AdjustQuantity(%q, %d) error = %v", id, pdt.Quantity, err)
+			}
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func fetchProduct(t *testing.T, r repo.Product, id string) *model.Product {
+	t.Helper()
+	v, err := r.Fetch(ctx, id)
+	if err != nil {
+		t.Fatalf("Fetch(%q) error = %v", id, err)
+	}
+	if v == nil {
+		return nil
+	}
+	pdt, ok := v.(model.Product)
+	if !ok {
+		t.Fatalf("Fetch(%q) = %T, want model.Product", id, v)
+	}
+	return &pdt
+}
+
+func productIDs(t *testing.T, vs []interface{}) []string {
+	t.Helper()
+	ids := []string{}
+	for _, v := range vs {
+		pdt, ok := v.(model.Product)
+		if !ok {
+			t.Fatalf("got %T, want model.Product", v)
+		}
+		ids = append(ids, pdt.ID)
+	}
+	return ids
+}
+
+func testProductCreate(t *testing.T, r repo.Product) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		wantErr bool
+	}{
+		{v: struct{}{}, wantErr: true},
+		{v: model.Product{}, wantErr: true},
+		{v: model.Product{Name: "Test", Price: 0, Weight: 1}, wantErr: true},
+		{v: model.Product{Name: "Test", Price: 1, Weight: 0}, wantErr: true},
+		{v: model.Product{Name: "Test", Price: 1, Weight: 1}, wantErr: false},
+		{v: model.Product{ID: "ignored", Name: "O'Brien", Price: 1, Weight: 1}, wantErr: false},
+		{v: model.Product{Name: "Stocked", Price: 1, Weight: 1, Quantity: 5, Available: true}, wantErr: false},
+	}
+	ids := map[string]bool{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := r.Create(ctx, tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if id == "" || id == "ignored" || ids[id] {
+				t.Errorf("Create() id = %q, want a new generated id", id)
+			}
+			ids[id] = true
+
+			if pdt := fetchProduct(t, r, id); pdt == nil || pdt.Quantity != 0 || pdt.Available {
+				t.Errorf("Create() stocked product %#v, want no stock", pdt)
+			}
+		})
+	}
+	if n, _ := r.Count(ctx); n != len(ids) {
+		t.Errorf("Count() = %v, want %v", n, len(ids))
+	}
+}
+
+func testProductFetch(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r, model.Product{Name: "Test", Price: 100, Weight: 2, Quantity: 3})
+
+	pdt := fetchProduct(t, r, ids[0])
+	if pdt == nil {
+		t.Fatalf("Fetch() = nil, want product")
+	}
+	if pdt.ID != ids[0] || pdt.Name != "Test" || pdt.Price != 100 || pdt.Weight != 2 || pdt.Quantity != 3 || !pdt.Available || pdt.Deleted {
+		t.Errorf("Fetch() = %#v", pdt)
+	}
+	if pdt.CreatedAt.IsZero() || pdt.UpdatedAt.IsZero() {
+		t.Errorf("Fetch() timestamps not set %#v", pdt)
+	}
+
+	if pdt := fetchProduct(t, r, "unavailable_id"); pdt != nil {
+		t.Errorf("Fetch() unavailable = %#v, want nil", pdt)
+	}
+}
+
+func testProductUpdate(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Test1", Price: 100, Weight: 1, Quantity: 2},
+		model.Product{Name: "Test2", Price: 200, Weight: 2},
+	)
+
+	if err := r.Update(ctx, ids[0], struct{}{}); err == nil {
+		t.Errorf("Update() unsupported type error = nil")
+	}
+	if err := r.Update(ctx, ids[0], model.Product{ID: ids[0]}); err == nil {
+		t.Errorf("Update() invalid product error = nil")
+	}
+
+	upd := model.Product{ID: "no_effect", Name: "Updated", Price: 300, Weight: 3, Quantity: 9, Available: false}
+	if err := r.Update(ctx, ids[0], upd); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	pdt := fetchProduct(t, r, ids[0])
+	if pdt == nil || pdt.ID != ids[0] || pdt.Name != upd.Name || pdt.Price != upd.Price || pdt.Weight != upd.Weight {
+		t.Errorf("Fetch() after Update() = %#v", pdt)
+	}
+	if pdt != nil && (pdt.Quantity != 2 || !pdt.Available) {
+		t.Errorf("Update() changed stock %#v, want quantity 2", pdt)
+	}
+	if other := fetchProduct(t, r, ids[1]); other == nil || other.Name != "Test2" {
+		t.Errorf("Update() changed other product %#v", other)
+	}
+	if pdt := fetchProduct(t, r, "no_effect"); pdt != nil {
+		t.Errorf("Update() created product with body id %#v", pdt)
+	}
+
+	if err := r.Update(ctx, "unavailable_id", upd); err != nil {
+		t.Errorf("Update() unavailable error = %v", err)
+	}
+}
+
+func testProductDelete(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Test1", Price: 100, Weight: 1, Quantity: 1},
+		model.Product{Name: "Test2", Price: 100, Weight: 1, Quantity: 1},
+	)
+
+	if err := r.Delete(ctx, ids[0]); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if pdt := fetchProduct(t, r, ids[0]); pdt != nil {
+		t.Errorf("Fetch() after Delete() = %#v, want nil", pdt)
+	}
+
+	res, err := r.List(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIDs(t, "List() after Delete()", productIDs(t, res), ids[1:])
+	if n, _ := r.Count(ctx); n != 1 {
+		t.Errorf("Count() after Delete() = %v, want 1", n)
+	}
+
+	q := repo.Query{"name": {"Test%"}, "available": {true}}
+	res, err = r.Search(ctx, q, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIDs(t, "Search() after Delete()", productIDs(t, res), ids[1:])
+	if n, _ := r.SearchCount(ctx, q); n != 1 {
+		t.Errorf("SearchCount() after Delete() = %v, want 1", n)
+	}
+
+	if err := r.Update(ctx, ids[0], model.Product{ID: ids[0], Name: "Revived", Price: 1, Weight: 1}); err != nil {
+		t.Errorf("Update() deleted error = %v", err)
+	}
+	if pdt := fetchProduct(t, r, ids[0]); pdt != nil {
+		t.Errorf("Update() revived deleted product %#v", pdt)
+	}
+
+	if err := r.Delete(ctx, ids[0]); err != nil {
+		t.Errorf("Delete() twice error = %v", err)
+	}
+	if err := r.Delete(ctx, "unavailable_id"); err != nil {
+		t.Errorf("Delete() unavailable error = %v", err)
+	}
+}
+
+func testProductListCount(t *testing.T, r repo.Product) {
+	if n, err := r.Count(ctx); n != 0 || err != nil {
+		t.Errorf("Count() empty = %v, %v, want 0, nil", n, err)
+	}
+	if res, err := r.List(ctx, 0, 10); len(res) != 0 || err != nil {
+		t.Errorf("List() empty = %v, %v, want [], nil", res, err)
+	}
+
+	ids := createProducts(t, r,
+		model.Product{Name: "Test1", Price: 100, Weight: 1},
+		model.Product{Name: "Test2", Price: 100, Weight: 1},
+		model.Product{Name: "Test3", Price: 100, Weight: 1},
+		model.Product{Name: "Test4", Price: 100, Weight: 1},
+		model.Product{Name: "Test5", Price: 100, Weight: 1},
+	)
+
+	if n, err := r.Count(ctx); n != len(ids) || err != nil {
+		t.Errorf("Count() = %v, %v, want %v, nil", n, err, len(ids))
+	}
+
+	tests := []struct {
+		name  string
+		skip  int
+		limit int
+		want  []string
+	}{
+		{skip: 0, limit: 10, want: ids},
+		{skip: 0, limit: 5, want: ids},
+		{skip: 0, limit: 2, want: ids[:2]},
+		{skip: 2, limit: 2, want: ids[2:4]},
+		{skip: 4, limit: 2, want: ids[4:]},
+		{skip: 5, limit: 2, want: nil},
+		{skip: 10, limit: 2, want: nil},
+		{skip: 0, limit: 0, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.List(ctx, tt.skip, tt.limit)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			assertIDs(t, "List()", productIDs(t, res), tt.want)
+
+			res, err = r.Search(ctx, repo.Query{}, tt.skip, tt.limit)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			assertIDs(t, "Search() empty query", productIDs(t, res), tt.want)
+		})
+	}
+}
+
+func testProductSearch(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Apple", Price: 100, Weight: 1, Quantity: 1},
+		model.Product{Name: "Banana", Price: 200, Weight: 2},
+		model.Product{Name: "Apricot", Price: 300, Weight: 3, Quantity: 7},
+		model.Product{Name: "O'Brien", Price: 400, Weight: 4},
+	)
+
+	tests := []struct {
+		name string
+		q    repo.Query
+		want []string
+	}{
+		{name: "empty", q: repo.Query{}, want: ids},
+		{name: "name exact", q: repo.Query{"name": {"Banana"}}, want: ids[1:2]},
+		{name: "name case sensitive", q: repo.Query{"name": {"banana"}}, want: nil},
+		{name: "name prefix", q: repo.Query{"name": {"Ap%"}}, want: []string{ids[0], ids[2]}},
+		{name: "name single char", q: repo.Query{"name": {"Appl_"}}, want: ids[:1]},
+		{name: "name quote", q: repo.Query{"name": {"O'Brien"}}, want: ids[3:]},
+		{name: "name injection", q: repo.Query{"name": {"' OR '1'='1"}}, want: nil},
+		{name: "price bound inclusive", q: repo.Query{"price": {200}}, want: ids[:2]},
+		{name: "price below all", q: repo.Query{"price": {99}}, want: nil},
+		{name: "weight", q: repo.Query{"weight": {3}}, want: ids[:3]},
+		{name: "available", q: repo.Query{"available": {true}}, want: []string{ids[0], ids[2]}},
+		{name: "unavailable", q: repo.Query{"available": {false}}, want: []string{ids[1], ids[3]}},
+		{name: "name and available", q: repo.Query{"name": {"%a%"}, "available": {false}}, want: ids[1:2]},
+		{name: "price and weight", q: repo.Query{"price": {300}, "weight": {2}}, want: ids[:2]},
+		{name: "all filters", q: repo.Query{"name": {"A%"}, "price": {300}, "weight": {3}, "available": {true}}, want: []string{ids[0], ids[2]}},
+		{name: "no match", q: repo.Query{"name": {"A%"}, "available": {false}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Search(ctx, tt.q, 0, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			assertIDs(t, "Search()", productIDs(t, res), tt.want)
+
+			n, err := r.SearchCount(ctx, tt.q)
+			if err != nil {
+				t.Fatalf("SearchCount() error = %v", err)
+			}
+			if n != len(tt.want) {
+				t.Errorf("SearchCount() = %v, want %v", n, len(tt.want))
+			}
+
+			if len(tt.want) < 2 {
+				return
+			}
+			res, err = r.Search(ctx, tt.q, 1, 1)
+			if err != nil {
+				t.Fatalf("Search() paged error = %v", err)
+			}
+			assertIDs(t, "Search() paged", productIDs(t, res), tt.want[1:2])
+		})
+	}
+}
+
+func testProductAdjustQuantity(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Test1", Price: 100, Weight: 1},
+		model.Product{Name: "Test2", Price: 100, Weight: 1},
+	)
+	if err := r.Delete(ctx, ids[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		id        string
+		delta     int
+		want      int
+		wantAvail bool
+		wantErr   error
+	}{
+		{name: "receive", id: ids[0], delta: 5, want: 5, wantAvail: true},
+		{name: "take", id: ids[0], delta: -2, want: 3, wantAvail: true},
+		{name: "overdraw", id: ids[0], delta: -4, want: 3, wantAvail: true, wantErr: repo.ErrInsufficientQuantity},
+		{name: "empty", id: ids[0], delta: -3, want: 0, wantAvail: false},
+		{name: "deleted", id: ids[1], delta: 1, wantErr: repo.ErrInsufficientQuantity},
+		{name: "unavailable", id: "unavailable_id", delta: 1, wantErr: repo.ErrInsufficientQuantity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := r.AdjustQuantity(ctx, tt.id, tt.delta)
+			if err != tt.wantErr {
+				t.Fatalf("AdjustQuantity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && n != tt.want {
+				t.Errorf("AdjustQuantity() = %v, want %v", n, tt.want)
+			}
+
+			pdt := fetchProduct(t, r, tt.id)
+			if pdt == nil {
+				return
+			}
+			if pdt.Quantity != tt.want || pdt.Available != tt.wantAvail {
+				t.Errorf("Fetch() after AdjustQuantity() quantity = %v available = %v, want %v %v", pdt.Quantity, pdt.Available, tt.want, tt.wantAvail)
+			}
+		})
+	}
+}
+
+func testProductAdjustReserved(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Test1", Price: 100, Weight: 1, Quantity: 5},
+	)
+
+	tests := []struct {
+		name      string
+		quantity  int
+		reserved  int
+		wantAvail bool
+		wantErr   error
+		adjust    func() (int, error)
+	}{
+		{
+			name: "reserve", quantity: 5, reserved: 3, wantAvail: true,
+			adjust: func() (int, error) { return r.AdjustReserved(ctx, ids[0], 3) },
+		},
+		{
+			name: "overbook", quantity: 5, reserved: 3, wantAvail: true, wantErr: repo.ErrInsufficientQuantity,
+			adjust: func() (int, error) { return r.AdjustReserved(ctx, ids[0], 3) },
+		},
+		{
+			name: "take reserved", quantity: 5, reserved: 3, wantAvail: true, wantErr: repo.ErrInsufficientQuantity,
+			adjust: func() (int, error) { return r.AdjustQuantity(ctx, ids[0], -3) },
+		},
+		{
+			name: "take unreserved", quantity: 3, reserved: 3, wantAvail: false,
+			adjust: func() (int, error) { return r.AdjustQuantity(ctx, ids[0], -2) },
+		},
+		{
+			name: "over release", quantity: 3, reserved: 3, wantAvail: false, wantErr: repo.ErrInsufficientQuantity,
+			adjust: func() (int, error) { return r.AdjustReserved(ctx, ids[0], -4) },
+		},
+		{
+			name: "release", quantity: 3, reserved: 1, wantAvail: true,
+			adjust: func() (int, error) { return r.AdjustReserved(ctx, ids[0], -2) },
+		},
+		{
+			name: "unavailable", quantity: 3, reserved: 1, wantAvail: true, wantErr: repo.ErrInsufficientQuantity,
+			adjust: func() (int, error) { return r.AdjustReserved(ctx, "unavailable_id", 1) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.adjust(); err != tt.wantErr {
+				t.Fatalf("adjust error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			pdt := fetchProduct(t, r, ids[0])
+			if pdt.Quantity != tt.quantity || pdt.Reserved != tt.reserved || pdt.Available != tt.wantAvail {
+				t.Errorf("Fetch() quantity = %v reserved = %v available = %v, want %v %v %v",
+					pdt.Quantity, pdt.Reserved, pdt.Available, tt.quantity, tt.reserved, tt.wantAvail)
+			}
+		})
+	}
+}
+
+func testProductTags(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Hat", Price: 100, Weight: 1, Tags: []string{"Summer", "clearance", "summer"}},
+		model.Product{Name: "Scarf", Price: 100, Weight: 1, Tags: []string{"winter", "clearance"}},
+		model.Product{Name: "Shorts", Price: 100, Weight: 1, Tags: []string{"summer"}},
+		model.Product{Name: "Boots", Price: 100, Weight: 1},
+	)
+
+	if pdt := fetchProduct(t, r, ids[0]); pdt == nil || !reflect.DeepEqual(pdt.Tags, []string{"clearance", "summer"}) {
+		t.Errorf("Fetch() tags = %#v, want normalized tags", pdt)
+	}
+	if pdt := fetchProduct(t, r, ids[3]); pdt == nil || len(pdt.Tags) != 0 {
+		t.Errorf("Fetch() untagged = %#v, want no tags", pdt)
+	}
+	if _, err := r.Create(ctx, model.Product{Name: "Bad", Price: 100, Weight: 1, Tags: []string{"a,b"}}); err == nil {
+		t.Errorf("Create() with invalid tag error = nil")
+	}
+
+	tests := []struct {
+		name string
+		q    repo.Query
+		want []string
+	}{
+		{name: "tag", q: repo.Query{"tag": {"summer"}}, want: []string{ids[0], ids[2]}},
+		{name: "any", q: repo.Query{"tag": {"winter", "summer"}}, want: ids[:3]},
+		{name: "all", q: repo.Query{"tag": {"clearance", "summer"}, "tag_match": {"all"}}, want: ids[:1]},
+		{name: "all unknown", q: repo.Query{"tag": {"summer", "unknown"}, "tag_match": {"all"}}, want: nil},
+		{name: "tag and name", q: repo.Query{"tag": {"clearance"}, "name": {"S%"}}, want: ids[1:2]},
+		{name: "injection", q: repo.Query{"tag": {"' OR '1'='1"}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Search(ctx, tt.q, 0, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			assertIDs(t, "Search()", productIDs(t, res), tt.want)
+
+			n, err := r.SearchCount(ctx, tt.q)
+			if err != nil || n != len(tt.want) {
+				t.Errorf("SearchCount() = %v, %v, want %v", n, err, len(tt.want))
+			}
+		})
+	}
+
+	pdt := fetchProduct(t, r, ids[1])
+	pdt.Tags = nil
+	if err := r.Update(ctx, ids[1], *pdt); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := fetchProduct(t, r, ids[1]); got == nil || !reflect.DeepEqual(got.Tags, []string{"clearance", "winter"}) {
+		t.Errorf("Update() without tags changed tags %#v", got)
+	}
+	pdt.Tags = []string{"Spring"}
+	if err := r.Update(ctx, ids[1], *pdt); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := fetchProduct(t, r, ids[1]); got == nil || !reflect.DeepEqual(got.Tags, []string{"spring"}) {
+		t.Errorf("Update() tags = %#v, want [spring]", got)
+	}
+
+	if err := r.Delete(ctx, ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	cnts, err := r.CountTags(ctx)
+	if err != nil {
+		t.Fatalf("CountTags() error = %v", err)
+	}
+	want := map[string]int{"clearance": 1, "summer": 1, "spring": 1}
+	if !reflect.DeepEqual(cnts, want) {
+		t.Errorf("CountTags() = %v, want %v", cnts, want)
+	}
+}
+
+func testProductCodes(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Hat", Price: 100, Weight: 1, SKU: "HAT-1", Barcode: "4006381333931"},
+		model.Product{Name: "Scarf", Price: 100, Weight: 1, SKU: "SCARF-1"},
+		model.Product{Name: "Boots", Price: 100, Weight: 1},
+		model.Product{Name: "Shorts", Price: 100, Weight: 1},
+	)
+
+	if pdt := fetchProduct(t, r, ids[0]); pdt == nil || pdt.SKU != "HAT-1" || pdt.Barcode != "4006381333931" {
+		t.Errorf("Fetch() codes = %#v", pdt)
+	}
+
+	tests := []struct {
+		name    string
+		pdt     model.Product
+		wantErr error
+	}{
+		{name: "duplicate sku", pdt: model.Product{Name: "Cap", Price: 100, Weight: 1, SKU: "HAT-1"}, wantErr: repo.ErrDuplicateSKU},
+		{name: "duplicate barcode", pdt: model.Product{Name: "Cap", Price: 100, Weight: 1, Barcode: "4006381333931"}, wantErr: repo.ErrDuplicateBarcode},
+		{name: "unique", pdt: model.Product{Name: "Cap", Price: 100, Weight: 1, SKU: "CAP-1", Barcode: "036000291452"}, wantErr: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := r.Create(ctx, tt.pdt); err != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	pdt := fetchProduct(t, r, ids[2])
+	pdt.SKU = "SCARF-1"
+	if err := r.Update(ctx, ids[2], *pdt); err != repo.ErrDuplicateSKU {
+		t.Errorf("Update() to a used sku error = %v, want %v", err, repo.ErrDuplicateSKU)
+	}
+	pdt = fetchProduct(t, r, ids[0])
+	pdt.Name = "Sun Hat"
+	if err := r.Update(ctx, ids[0], *pdt); err != nil {
+		t.Errorf("Update() keeping own codes error = %v", err)
+	}
+
+	res, err := r.Search(ctx, repo.Query{"sku": {"SCARF-1"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	assertIDs(t, "Search() sku", productIDs(t, res), ids[1:2])
+	res, err = r.Search(ctx, repo.Query{"barcode": {"4006381333931"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	assertIDs(t, "Search() barcode", productIDs(t, res), ids[:1])
+
+	if err := r.Delete(ctx, ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Create(ctx, model.Product{Name: "Scarf", Price: 100, Weight: 1, SKU: "SCARF-1"}); err != nil {
+		t.Errorf("Create() with sku of a deleted product error = %v", err)
+	}
+}
+
+func testProductPrices(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Hat", Price: 1000, Weight: 1, Prices: []model.Money{{Amount: 899, Currency: "GBP"}, {Amount: 1099, Currency: "EUR"}}},
+	)
+	if _, err := r.Create(ctx, model.Product{Name: "Cap", Price: 100, Weight: 1, Prices: []model.Money{{Amount: 90, Currency: "EU"}}}); err == nil {
+		t.Errorf("Create() invalid currency error = nil")
+	}
+
+	want := []model.Money{{Amount: 1099, Currency: "EUR"}, {Amount: 899, Currency: "GBP"}}
+	pdt := fetchProduct(t, r, ids[0])
+	if pdt == nil || !reflect.DeepEqual(pdt.Prices, want) {
+		t.Fatalf("Fetch() prices = %#v, want %v", pdt, want)
+	}
+
+	pdt.Prices = []model.Money{{Amount: 120000, Currency: "JPY"}}
+	if err := r.Update(ctx, ids[0], *pdt); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if pdt := fetchProduct(t, r, ids[0]); pdt == nil || !reflect.DeepEqual(pdt.Prices, []model.Money{{Amount: 120000, Currency: "JPY"}}) {
+		t.Errorf("Fetch() after Update() prices = %#v", pdt)
+	}
+
+	pdt.Prices = nil
+	pdt.Tags = []string{"winter"}
+	if err := r.Update(ctx, ids[0], *pdt); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if pdt := fetchProduct(t, r, ids[0]); pdt == nil || len(pdt.Prices) != 0 {
+		t.Errorf("Fetch() after clearing prices = %#v", pdt)
+	}
+
+	// SetPrice changes the price only
+	if err := r.SetPrice(ctx, ids[0], 1200); err != nil {
+		t.Fatalf("SetPrice() error = %v", err)
+	}
+	if got := fetchProduct(t, r, ids[0]); got == nil || got.Price != 1200 || got.Name != "Hat" || !reflect.DeepEqual(got.Tags, []string{"winter"}) {
+		t.Errorf("Fetch() after SetPrice() = %#v, want price 1200 and the other fields kept", got)
+	}
+	if err := r.SetPrice(ctx, ids[0], 0); err == nil {
+		t.Errorf("SetPrice() zero price error = nil")
+	}
+	if err := r.SetPrice(ctx, "unavailable_id", 1200); err != nil {
+		t.Errorf("SetPrice() unknown product error = %v", err)
+	}
+}
+
+func testProductStatus(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Hat", Price: 100, Weight: 1},
+		model.Product{Name: "Cap", Price: 100, Weight: 1, Status: model.ProductPublished},
+	)
+	if _, err := r.Create(ctx, model.Product{Name: "Mug", Price: 100, Weight: 1, Status: "live"}); err == nil {
+		t.Errorf("Create() invalid status error = nil")
+	}
+
+	hat := fetchProduct(t, r, ids[0])
+	if hat == nil || hat.Status != model.ProductDraft || !hat.PublishedAt.IsZero() || !hat.ArchivedAt.IsZero() {
+		t.Fatalf("Fetch() = %#v, want a draft never published", hat)
+	}
+	if pub := fetchProduct(t, r, ids[1]); pub == nil || pub.Status != model.ProductPublished || pub.PublishedAt.IsZero() {
+		t.Fatalf("Fetch() = %#v, want a published product with its publish time", pub)
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		from, to model.ProductStatus
+		wantErr  error
+	}{
+		{name: "stale", id: ids[0], from: model.ProductPublished, to: model.ProductArchived, wantErr: repo.ErrStaleStatus},
+		{name: "unknown", id: "unavailable_id", from: model.ProductDraft, to: model.ProductPublished, wantErr: repo.ErrStaleStatus},
+		{name: "publish", id: ids[0], from: model.ProductDraft, to: model.ProductPublished},
+		{name: "archive", id: ids[0], from: model.ProductPublished, to: model.ProductArchived},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.SetStatus(ctx, tt.id, string(tt.from), string(tt.to)); err != tt.wantErr {
+				t.Errorf("SetStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	hat = fetchProduct(t, r, ids[0])
+	if hat == nil || hat.Status != model.ProductArchived || hat.PublishedAt.IsZero() || hat.ArchivedAt.IsZero() {
+		t.Errorf("Fetch() after SetStatus() = %#v, want archived with its transition times", hat)
+	}
+
+	hat.Name = "Hat 2"
+	hat.Status = model.ProductDraft
+	if err := r.Update(ctx, ids[0], *hat); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if hat := fetchProduct(t, r, ids[0]); hat == nil || hat.Status != model.ProductArchived {
+		t.Errorf("Fetch() after Update() = %#v, want the status unchanged", hat)
+	}
+
+	q := repo.Query{"status": {string(model.ProductPublished)}}
+	res, err := r.Search(ctx, q, 0, 10)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	assertIDs(t, "Search()", productIDs(t, res), []string{ids[1]})
+	if n, err := r.SearchCount(ctx, q); err != nil || n != 1 {
+		t.Errorf("SearchCount() = %v, %v, want 1", n, err)
+	}
+
+	if err := r.Delete(ctx, ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetStatus(ctx, ids[1], string(model.ProductPublished), string(model.ProductArchived)); err != repo.ErrStaleStatus {
+		t.Errorf("SetStatus() of a deleted product error = %v, want %v", err, repo.ErrStaleStatus)
+	}
+}
+
+func testProductRestore(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Hat", Price: 100, Weight: 1, SKU: "HAT-1"},
+		model.Product{Name: "Cap", Price: 100, Weight: 1},
+		model.Product{Name: "Mug", Price: 100, Weight: 1},
+	)
+	for _, id := range ids[:2] {
+		if err := r.Delete(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := r.ListDeleted(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ListDeleted() error = %v", err)
+	}
+	assertIDs(t, "ListDeleted()", productIDs(t, res), []string{ids[1], ids[0]})
+	if n, err := r.CountDeleted(ctx); err != nil || n != 2 {
+		t.Errorf("CountDeleted() = %v, %v, want 2", n, err)
+	}
+	if pdt := res[1].(model.Product); !pdt.Deleted || pdt.DeletedAt.IsZero() {
+		t.Errorf("ListDeleted() = %#v, want deleted with its delete time", pdt)
+	}
+
+	if _, err := r.Create(ctx, model.Product{Name: "Hat 2", Price: 100, Weight: 1, SKU: "HAT-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{name: "sku taken", id: ids[0], wantErr: repo.ErrDuplicateSKU},
+		{name: "not deleted", id: ids[2], wantErr: repo.ErrNotDeleted},
+		{name: "unknown", id: "unavailable_id", wantErr: repo.ErrNotDeleted},
+		{name: "restore", id: ids[1]},
+		{name: "twice", id: ids[1], wantErr: repo.ErrNotDeleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Restore(ctx, tt.id); err != tt.wantErr {
+				t.Errorf("Restore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if pdt := fetchProduct(t, r, ids[1]); pdt == nil || pdt.Deleted {
+		t.Errorf("Fetch() after Restore() = %#v, want the product", pdt)
+	}
+	if pdt := fetchProduct(t, r, ids[0]); pdt != nil {
+		t.Errorf("Fetch() after failed Restore() = %#v, want nil", pdt)
+	}
+	if n, err := r.CountDeleted(ctx); err != nil || n != 1 {
+		t.Errorf("CountDeleted() after Restore() = %v, %v, want 1", n, err)
+	}
+}
+
+func testProductPurge(t *testing.T, r repo.Product) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Hat", Price: 100, Weight: 1},
+		model.Product{Name: "Cap", Price: 100, Weight: 1},
+		model.Product{Name: "Mug", Price: 100, Weight: 1},
+	)
+	before := time.Now().Add(-time.Hour)
+	for _, id := range ids[:2] {
+		if err := r.Delete(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, err := r.Purge(ctx, before, 10); err != nil || len(got) != 0 {
+		t.Errorf("Purge() before the deletes = %v, %v, want none", got, err)
+	}
+
+	after := time.Now().Add(time.Hour)
+	got, err := r.Purge(ctx, after, 1)
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	assertIDs(t, "Purge() limited", got, ids[:1])
+	got, err = r.Purge(ctx, after, 10)
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	assertIDs(t, "Purge()", got, ids[1:2])
+
+	if n, err := r.CountDeleted(ctx); err != nil || n != 0 {
+		t.Errorf("CountDeleted() after Purge() = %v, %v, want 0", n, err)
+	}
+	if err := r.Restore(ctx, ids[0]); err != repo.ErrNotDeleted {
+		t.Errorf("Restore() purged error = %v, want %v", err, repo.ErrNotDeleted)
+	}
+	res, err := r.List(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIDs(t, "List() after Purge()", productIDs(t, res), ids[2:])
+}
+
+// RunProductRatingSuite runs the rating queries of the repo.Product conformance suite
+// against the repos returned by newRepos
+func RunProductRatingSuite(t *testing.T, newRepos ProductRatingFactory) {
+	t.Run("SearchRating", func(t *testing.T) {
+		r, rat := newRepos(t)
+		testProductSearchRating(t, r, rat)
+	})
+}
+
+func testProductSearchRating(t *testing.T, r repo.Product, rat repo.Rating) {
+	ids := createProducts(t, r,
+		model.Product{Name: "Apple", Price: 100, Weight: 1},
+		model.Product{Name: "Banana", Price: 200, Weight: 1},
+		model.Product{Name: "Apricot", Price: 300, Weight: 1},
+		model.Product{Name: "Cherry", Price: 400, Weight: 1},
+	)
+	// Apple 4.5, Banana unrated, Apricot 3, Cherry 4.5
+	for i, vals := range [][]int{{4, 5}, nil, {3}, {5, 4}} {
+		for j, v := range vals {
+			if _, err := rat.Create(ctx, model.Rating{ProductID: ids[i], User: model.User{ID: fmt.Sprint("u", j)}, Value: v}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name string
+		q    repo.Query
+		want []string
+	}{
+		{name: "min rating", q: repo.Query{"min_rating": {4.0}}, want: []string{ids[0], ids[3]}},
+		{name: "min rating fraction", q: repo.Query{"min_rating": {4.5}}, want: []string{ids[0], ids[3]}},
+		{name: "min rating above all", q: repo.Query{"min_rating": {4.6}}, want: nil},
+		{name: "min rating zero", q: repo.Query{"min_rating": {0.0}}, want: ids},
+		{name: "min rating and price", q: repo.Query{"min_rating": {3.0}, "price": {300}}, want: []string{ids[0], ids[2]}},
+		{name: "best rated first", q: repo.Query{"sort": {"-rating"}}, want: []string{ids[0], ids[3], ids[2], ids[1]}},
+		{name: "worst rated first", q: repo.Query{"sort": {"rating"}}, want: []string{ids[1], ids[2], ids[0], ids[3]}},
+		{name: "best rated under a price", q: repo.Query{"sort": {"-rating"}, "min_rating": {3.0}, "price": {350}}, want: []string{ids[0], ids[2]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Search(ctx, tt.q, 0, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			assertIDs(t, "Search()", productIDs(t, res), tt.want)
+
+			n, err := r.SearchCount(ctx, tt.q)
+			if err != nil {
+				t.Fatalf("SearchCount() error = %v", err)
+			}
+			if n != len(tt.want) {
+				t.Errorf("SearchCount() = %v, want %v", n, len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package repotest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// PromotionFactory returns a new and empty repo.Promotion on every call
+type PromotionFactory func(t *testing.T) repo.Promotion
+
+// RunPromotionSuite runs the repo.Promotion conformance suite
+// against the repos returned by newRepo
+func RunPromotionSuite(t *testing.T, newRepo PromotionFactory) {
+	t.Run("CreateFetch", func(t *testing.T) { testPromotionCreateFetch(t, newRepo(t)) })
+	t.Run("Update", func(t *testing.T) { testPromotionUpdate(t, newRepo(t)) })
+	t.Run("SearchDelete", func(t *testing.T) { testPromotionSearchDelete(t, newRepo(t)) })
+}
+
+var prmStart = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newPromotion(name string, from, to time.Time) model.Promotion {
+	return model.Promotion{
+		Name:       name,
+		Type:       model.PromotionPercentage,
+		Value:      10,
+		ProductIDs: []string{"p1"},
+		StartsAt:   from,
+		EndsAt:     to,
+	}
+}
+
+func fetchPromotion(t *testing.T, r repo.Promotion, id string) *model.Promotion {
+	t.Helper()
+	v, err := r.Fetch(ctx, id)
+	if err != nil {
+		t.Fatalf("Fetch(%q) error = %v", id, err)
+	}
+	if v == nil {
+		return nil
+	}
+	prm, ok := v.(model.Promotion)
+	if !ok {
+		t.Fatalf("Fetch(%q) = %T, want model.Promotion", id, v)
+	}
+	return &prm
+}
+
+func promotionIDs(t *testing.T, vs []interface{}) []string {
+	t.Helper()
+	ids := []string{}
+	for _, v := range vs {
+		prm, ok := v.(model.Promotion)
+		if !ok {
+			t.Fatalf("got %T, want model.Promotion", v)
+		}
+		ids = append(ids, prm.ID)
+	}
+	return ids
+}
+
+func createPromotions(t *testing.T, r repo.Promotion, prms ...model.Promotion) []string {
+	t.Helper()
+	ids := []string{}
+	for _, prm := range prms {
+		tick()
+		id, err := r.Create(ctx, prm)
+		if err != nil {
+			t.Fatalf("Create(%#v) error = %v", prm, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func testPromotionCreateFetch(t *testing.T, r repo.Promotion) {
+	if _, err := r.Create(ctx, struct{}{}); err != repo.ErrUnsupportedType {
+		t.Errorf("Create() error = %v, want %v", err, repo.ErrUnsupportedType)
+	}
+	if _, err := r.Create(ctx, newPromotion("", prmStart, prmStart.Add(time.Hour))); err == nil {
+		t.Errorf("Create() without name error = nil")
+	}
+
+	prm := model.Promotion{
+		ID:          "ignored",
+		Name:        "Summer",
+		Type:        model.PromotionBuyXGetY,
+		BuyQuantity: 2,
+		GetQuantity: 1,
+		ProductIDs:  []string{"p1", "p2"},
+		CategoryIDs: []string{"c1"},
+		Tags:        []string{" Sale ", "new"},
+		StartsAt:    prmStart,
+		EndsAt:      prmStart.Add(24 * time.Hour),
+	}
+	id, err := r.Create(ctx, prm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == "" || id == "ignored" {
+		t.Fatalf("Create() id = %q, want a new generated id", id)
+	}
+	got := fetchPromotion(t, r, id)
+	if got == nil || got.Name != "Summer" || got.Type != model.PromotionBuyXGetY || got.BuyQuantity != 2 || got.GetQuantity != 1 ||
+		!got.StartsAt.Equal(prm.StartsAt) || !got.EndsAt.Equal(prm.EndsAt) || got.CreatedAt.IsZero() {
+		t.Fatalf("Fetch() = %#v", got)
+	}
+	if !reflect.DeepEqual(got.ProductIDs, prm.ProductIDs) || !reflect.DeepEqual(got.CategoryIDs, prm.CategoryIDs) || !reflect.DeepEqual(got.Tags, []string{"new", "sale"}) {
+		t.Errorf("Fetch() targets = %v, %v, %v", got.ProductIDs, got.CategoryIDs, got.Tags)
+	}
+	if got := fetchPromotion(t, r, "unavailable_id"); got != nil {
+		t.Errorf("Fetch() = %#v, want nil", got)
+	}
+}
+
+func testPromotionUpdate(t *testing.T, r repo.Promotion) {
+	ids := createPromotions(t, r, newPromotion("Summer", prmStart, prmStart.Add(time.Hour)))
+
+	prm := fetchPromotion(t, r, ids[0])
+	prm.Type = model.PromotionFixed
+	prm.Value = 0
+	if err := r.Update(ctx, ids[0], *prm); err == nil {
+		t.Errorf("Update() with invalid value error = nil")
+	}
+	prm.Name = "Winter"
+	prm.Value = 500
+	prm.ProductIDs = []string{}
+	prm.Tags = []string{"sale"}
+	if err := r.Update(ctx, ids[0], *prm); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	got := fetchPromotion(t, r, ids[0])
+	if got == nil || got.Name != "Winter" || got.Type != model.PromotionFixed || got.Value != 500 ||
+		len(got.ProductIDs) != 0 || !reflect.DeepEqual(got.Tags, []string{"sale"}) {
+		t.Errorf("Fetch() after Update() = %#v", got)
+	}
+}
+
+func testPromotionSearchDelete(t *testing.T, r repo.Promotion) {
+	ids := createPromotions(t, r,
+		newPromotion("past", prmStart, prmStart.Add(time.Hour)),
+		newPromotion("current", prmStart.Add(time.Hour), prmStart.Add(3*time.Hour)),
+		newPromotion("long", prmStart, prmStart.Add(4*time.Hour)),
+	)
+
+	tests := []struct {
+		name string
+		q    repo.Query
+		want []string
+	}{
+		{name: "all", q: repo.Query{}, want: ids},
+		{name: "active", q: repo.Query{"active_at": {prmStart.Add(2 * time.Hour)}}, want: []string{ids[1], ids[2]}},
+		{name: "start is inclusive", q: repo.Query{"active_at": {prmStart}}, want: []string{ids[0], ids[2]}},
+		{name: "end is exclusive", q: repo.Query{"active_at": {prmStart.Add(4 * time.Hour)}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Search(ctx, tt.q, 0, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			assertIDs(t, "Search()", promotionIDs(t, res), tt.want)
+
+			n, err := r.SearchCount(ctx, tt.q)
+			if err != nil || n != len(tt.want) {
+				t.Errorf("SearchCount() = %v, %v, want %v", n, err, len(tt.want))
+			}
+		})
+	}
+
+	if err := r.Delete(ctx, ids[0]); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if prm := fetchPromotion(t, r, ids[0]); prm != nil {
+		t.Errorf("Fetch() after Delete() = %#v, want nil", prm)
+	}
+}
@@ -0,0 +1,392 @@
+package repotest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// RatingFactory returns a new and empty repo.Rating on every call
+type RatingFactory func(t *testing.T) repo.Rating
+
+// RunRatingSuite runs the repo.Rating conformance suite
+// against the repos returned by newRepo
+func RunRatingSuite(t *testing.T, newRepo RatingFactory) {
+	t.Run("Create", func(t *testing.T) { testRatingCreate(t, newRepo(t)) })
+	t.Run("Avg", func(t *testing.T) { testRatingAvg(t, newRepo(t)) })
+	t.Run("RemoveProducts", func(t *testing.T) { testRatingRemoveProducts(t, newRepo(t)) })
+	t.Run("Summaries", func(t *testing.T) { testRatingSummaries(t, newRepo(t)) })
+	t.Run("UpdateDelete", func(t *testing.T) { testRatingUpdateDelete(t, newRepo(t)) })
+	t.Run("Search", func(t *testing.T) { testRatingSearch(t, newRepo(t)) })
+	t.Run("Moderate", func(t *testing.T) { testRatingModerate(t, newRepo(t)) })
+}
+
+func testRatingCreate(t *testing.T, r repo.Rating) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		wantErr bool
+	}{
+		{v: struct{}{}, wantErr: true},
+		{v: model.Rating{}, wantErr: true},
+		{v: model.Rating{ProductID: "1", User: model.User{ID: "u1"}, Value: 0}, wantErr: true},
+		{v: model.Rating{ProductID: "1", User: model.User{ID: "u1"}, Value: 6}, wantErr: true},
+		{v: model.Rating{ProductID: "1", Value: 1}, wantErr: true},
+		{v: model.Rating{ProductID: "1", User: model.User{ID: "u1"}, Value: 1}, wantErr: false},
+		{v: model.Rating{ID: "ignored", ProductID: "1", User: model.User{ID: "u2"}, Value: 5, Title: "Warm"}, wantErr: false},
+		{name: "rated by the user", v: model.Rating{ProductID: "1", User: model.User{ID: "u1"}, Value: 2}, wantErr: true},
+		{v: model.Rating{ProductID: "2", User: model.User{ID: "u1"}, Value: 2}, wantErr: false},
+	}
+	ids := map[string]bool{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := r.Create(ctx, tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.name == "rated by the user" && err != repo.ErrDuplicateRating {
+				t.Errorf("Create() error = %v, want %v", err, repo.ErrDuplicateRating)
+			}
+			if tt.wantErr {
+				return
+			}
+			if id == "" || id == "ignored" || ids[id] {
+				t.Errorf("Create() id = %q, want a new generated id", id)
+			}
+			ids[id] = true
+		})
+	}
+}
+
+func testRatingAvg(t *testing.T, r repo.Rating) {
+	byPdt := func(id string) repo.Query {
+		return repo.Query{"product_id": {id}}
+	}
+
+	if avg, err := r.Avg(ctx, byPdt("1"), "value"); avg != 0 || err != nil {
+		t.Errorf("Avg() empty = %v, %v, want 0, nil", avg, err)
+	}
+
+	for _, rat := range []model.Rating{
+		{ProductID: "1", User: model.User{ID: "u1"}, Value: 1},
+		{ProductID: "1", User: model.User{ID: "u2"}, Value: 4},
+		{ProductID: "2", User: model.User{ID: "u3"}, Value: 5},
+		{ProductID: "1' OR '1'='1", User: model.User{ID: "u4"}, Value: 2},
+	} {
+		if _, err := r.Create(ctx, rat); err != nil {
+			t.Fatalf("Create(%#v) error = %v", rat, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		q       repo.Query
+		field   string
+		want    float64
+		wantErr bool
+	}{
+		{name: "product", q: byPdt("1"), field: "value", want: 2.5},
+		{name: "other product", q: byPdt("2"), field: "value", want: 5},
+		{name: "unrated product", q: byPdt("3"), field: "value", want: 0},
+		{name: "hostile id", q: byPdt("1' OR '1'='1"), field: "value", want: 2},
+		{name: "all", q: repo.Query{}, field: "value", want: 3},
+		{name: "hostile field", q: byPdt("1"), field: `value") FROM ratings; --`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Avg(ctx, tt.q, tt.field)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Avg() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Avg() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testRatingRemoveProducts(t *testing.T, r repo.Rating) {
+	for _, rat := range []model.Rating{
+		{ProductID: "1", User: model.User{ID: "u5"}, Value: 1},
+		{ProductID: "2", User: model.User{ID: "u6"}, Value: 4},
+		{ProductID: "3", User: model.User{ID: "u7"}, Value: 5},
+	} {
+		if _, err := r.Create(ctx, rat); err != nil {
+			t.Fatalf("Create(%#v) error = %v", rat, err)
+		}
+	}
+
+	if err := r.RemoveProducts(ctx, nil); err != nil {
+		t.Errorf("RemoveProducts() none error = %v", err)
+	}
+	if err := r.RemoveProducts(ctx, []string{"1", "3"}); err != nil {
+		t.Fatalf("RemoveProducts() error = %v", err)
+	}
+
+	for id, want := range map[string]float64{"1": 0, "2": 4, "3": 0} {
+		if avg, err := r.Avg(ctx, repo.Query{"product_id": {id}}, "value"); avg != want || err != nil {
+			t.Errorf("Avg() of %s after RemoveProducts() = %v, %v, want %v", id, avg, err, want)
+		}
+	}
+}
+
+func testRatingSummaries(t *testing.T, r repo.Rating) {
+	for _, rat := range []model.Rating{
+		{ProductID: "1", User: model.User{ID: "u8"}, Value: 1},
+		{ProductID: "1", User: model.User{ID: "u9"}, Value: 4},
+		{ProductID: "2", User: model.User{ID: "u10"}, Value: 5},
+		{ProductID: "3", User: model.User{ID: "u11"}, Value: 2},
+	} {
+		if _, err := r.Create(ctx, rat); err != nil {
+			t.Fatalf("Create(%#v) error = %v", rat, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		pdtIDs []string
+		want   map[string]model.RatingSummary
+	}{
+		{name: "none", want: map[string]model.RatingSummary{}},
+		{name: "unrated", pdtIDs: []string{"4"}, want: map[string]model.RatingSummary{}},
+		{
+			name:   "many",
+			pdtIDs: []string{"1", "2", "4"},
+			want: map[string]model.RatingSummary{
+				"1": {ProductID: "1", Count: 2, Sum: 5, Avg: 2.5, Histogram: [5]int{1, 0, 0, 1, 0}},
+				"2": {ProductID: "2", Count: 1, Sum: 5, Avg: 5, Histogram: [5]int{0, 0, 0, 0, 1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Summaries(ctx, tt.pdtIDs)
+			if err != nil {
+				t.Fatalf("Summaries() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Summaries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if n, err := r.RebuildSummaries(ctx); err != nil || n != 3 {
+		t.Errorf("RebuildSummaries() = %v, %v, want 3", n, err)
+	}
+	got, err := r.Summaries(ctx, []string{"1", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, tests[2].want) {
+		t.Errorf("Summaries() after RebuildSummaries() = %v, want %v", got, tests[2].want)
+	}
+
+	if err := r.RemoveProducts(ctx, []string{"1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.Summaries(ctx, []string{"1"}); err != nil || len(got) != 0 {
+		t.Errorf("Summaries() after RemoveProducts() = %v, %v, want none", got, err)
+	}
+}
+
+// fetchRating returns the rating id of r or fails t
+func fetchRating(t *testing.T, r repo.Rating, id string) model.Rating {
+	t.Helper()
+	v, err := r.Fetch(ctx, id)
+	if err != nil {
+		t.Fatalf("Fetch(%q) error = %v", id, err)
+	}
+	rat, ok := v.(model.Rating)
+	if !ok {
+		t.Fatalf("Fetch(%q) = %#v, want a model.Rating", id, v)
+	}
+	return rat
+}
+
+func testRatingUpdateDelete(t *testing.T, r repo.Rating) {
+	if v, err := r.Fetch(ctx, "missing"); v != nil || err != nil {
+		t.Errorf("Fetch() missing = %v, %v, want nil, nil", v, err)
+	}
+
+	ids := []string{}
+	for _, rat := range []model.Rating{
+		{ProductID: "1", User: model.User{ID: "u1", Name: "Jo"}, Value: 2},
+		{ProductID: "1", User: model.User{ID: "u2"}, Value: 5},
+	} {
+		id, err := r.Create(ctx, rat)
+		if err != nil {
+			t.Fatalf("Create(%#v) error = %v", rat, err)
+		}
+		ids = append(ids, id)
+	}
+
+	got := fetchRating(t, r, ids[0])
+	if got.ProductID != "1" || got.User != (model.User{ID: "u1", Name: "Jo"}) || got.Value != 2 || got.CreatedAt.IsZero() {
+		t.Errorf("Fetch() = %+v, want the created rating", got)
+	}
+
+	rec := model.Rating{ProductID: "1", User: model.User{ID: "u1", Name: "Joe"}, Value: 4, Title: "Warm", Body: "Warm enough"}
+	if err := r.Update(ctx, ids[0], rec); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := r.Update(ctx, ids[0], model.Rating{ProductID: "1", User: model.User{ID: "u1"}, Value: 6}); err == nil {
+		t.Errorf("Update() invalid value error = nil, want error")
+	}
+	upd := fetchRating(t, r, ids[0])
+	if upd.Value != 4 || upd.Title != "Warm" || upd.Body != "Warm enough" || upd.User.Name != "Joe" || upd.UpdatedAt.Before(got.UpdatedAt) {
+		t.Errorf("Fetch() after Update() = %+v, want the updated rating", upd)
+	}
+
+	want := model.RatingSummary{ProductID: "1", Count: 2, Sum: 9, Avg: 4.5, Histogram: [5]int{0, 0, 0, 1, 1}}
+	if sums, err := r.Summaries(ctx, []string{"1"}); err != nil || !reflect.DeepEqual(sums["1"], want) {
+		t.Errorf("Summaries() after Update() = %v, %v, want %v", sums["1"], err, want)
+	}
+
+	if err := r.Delete(ctx, ids[1]); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := r.Delete(ctx, ids[1]); err != nil {
+		t.Errorf("Delete() deleted error = %v", err)
+	}
+	if v, err := r.Fetch(ctx, ids[1]); v != nil || err != nil {
+		t.Errorf("Fetch() deleted = %v, %v, want nil, nil", v, err)
+	}
+	want = model.RatingSummary{ProductID: "1", Count: 1, Sum: 4, Avg: 4, Histogram: [5]int{0, 0, 0, 1, 0}}
+	if sums, err := r.Summaries(ctx, []string{"1"}); err != nil || !reflect.DeepEqual(sums["1"], want) {
+		t.Errorf("Summaries() after Delete() = %v, %v, want %v", sums["1"], err, want)
+	}
+
+	// the user rates the product again once the rating is deleted
+	if _, err := r.Create(ctx, model.Rating{ProductID: "1", User: model.User{ID: "u2"}, Value: 1}); err != nil {
+		t.Errorf("Create() after Delete() error = %v", err)
+	}
+}
+
+func testRatingSearch(t *testing.T, r repo.Rating) {
+	ids := []string{}
+	for _, rat := range []model.Rating{
+		{ProductID: "1", User: model.User{ID: "u1"}, Value: 2},
+		{ProductID: "2", User: model.User{ID: "u1"}, Value: 3},
+		{ProductID: "1", User: model.User{ID: "u2"}, Value: 5, Status: model.RatingPending},
+		{ProductID: "1", User: model.User{ID: "u3"}, Value: 4, Status: model.RatingPending},
+	} {
+		id, err := r.Create(ctx, rat)
+		if err != nil {
+			t.Fatalf("Create(%#v) error = %v", rat, err)
+		}
+		ids = append(ids, id)
+		// the ratings are listed by their create time
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tests := []struct {
+		name        string
+		q           repo.Query
+		skip, limit int
+		want        []string
+		wantCount   int
+	}{
+		{name: "product latest first", q: repo.Query{"product_id": {"1"}}, limit: 10, want: []string{ids[3], ids[2], ids[0]}, wantCount: 3},
+		{name: "page", q: repo.Query{"product_id": {"1"}}, skip: 1, limit: 1, want: []string{ids[2]}, wantCount: 3},
+		{name: "user", q: repo.Query{"user_id": {"u1"}}, limit: 10, want: []string{ids[1], ids[0]}, wantCount: 2},
+		{name: "product of user", q: repo.Query{"product_id": {"1"}, "user_id": {"u2"}}, limit: 10, want: []string{ids[2]}, wantCount: 1},
+		{name: "pending oldest first", q: repo.Query{"status": {"pending"}, "sort": {"created_at"}}, limit: 10, want: []string{ids[2], ids[3]}, wantCount: 2},
+		{name: "approved of product", q: repo.Query{"product_id": {"1"}, "status": {"approved"}}, limit: 10, want: []string{ids[0]}, wantCount: 1},
+		{name: "hostile", q: repo.Query{"product_id": {"1' OR '1'='1"}}, limit: 10, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Search(ctx, tt.q, tt.skip, tt.limit)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			got := []string{}
+			for _, v := range res {
+				got = append(got, v.(model.Rating).ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search() = %v, want %v", got, tt.want)
+			}
+			if n, err := r.SearchCount(ctx, tt.q); n != tt.wantCount || err != nil {
+				t.Errorf("SearchCount() = %v, %v, want %v", n, err, tt.wantCount)
+			}
+		})
+	}
+}
+
+func testRatingModerate(t *testing.T, r repo.Rating) {
+	ids := []string{}
+	for _, rat := range []model.Rating{
+		{ProductID: "1", User: model.User{ID: "u1"}, Value: 2},
+		{ProductID: "1", User: model.User{ID: "u2"}, Value: 5, Title: "Hot", Status: model.RatingPending},
+		{ProductID: "2", User: model.User{ID: "u1"}, Value: 4, Title: "Cold", Status: model.RatingPending},
+	} {
+		id, err := r.Create(ctx, rat)
+		if err != nil {
+			t.Fatalf("Create(%#v) error = %v", rat, err)
+		}
+		ids = append(ids, id)
+	}
+
+	// the pending ratings are left out of the summaries and averages
+	want := map[string]model.RatingSummary{
+		"1": {ProductID: "1", Count: 1, Sum: 2, Avg: 2, Histogram: [5]int{0, 1, 0, 0, 0}},
+		"2": {ProductID: "2"},
+	}
+	if sums, err := r.Summaries(ctx, []string{"1", "2"}); err != nil || !reflect.DeepEqual(sums, want) {
+		t.Errorf("Summaries() with pending = %v, %v, want %v", sums, err, want)
+	}
+	if avg, err := r.Avg(ctx, repo.Query{"product_id": {"1"}}, "value"); err != nil || avg != 2 {
+		t.Errorf("Avg() with pending = %v, %v, want 2", avg, err)
+	}
+
+	if err := r.Moderate(ctx, ids[1], "pending", "approved", ""); err != nil {
+		t.Fatalf("Moderate() approve error = %v", err)
+	}
+	if err := r.Moderate(ctx, ids[2], "pending", "rejected", "spam"); err != nil {
+		t.Fatalf("Moderate() reject error = %v", err)
+	}
+	if rat := fetchRating(t, r, ids[2]); rat.Status != model.RatingRejected || rat.Reason != "spam" {
+		t.Errorf("Fetch() after Moderate() = %+v, want rejected for spam", rat)
+	}
+	want = map[string]model.RatingSummary{
+		"1": {ProductID: "1", Count: 2, Sum: 7, Avg: 3.5, Histogram: [5]int{0, 1, 0, 0, 1}},
+		"2": {ProductID: "2"},
+	}
+	if sums, err := r.Summaries(ctx, []string{"1", "2"}); err != nil || !reflect.DeepEqual(sums, want) {
+		t.Errorf("Summaries() after Moderate() = %v, %v, want %v", sums, err, want)
+	}
+
+	// a rating moderated concurrently is no more in the expected status
+	if err := r.Moderate(ctx, ids[1], "pending", "rejected", "late"); err != repo.ErrStaleStatus {
+		t.Errorf("Moderate() stale error = %v, want %v", err, repo.ErrStaleStatus)
+	}
+	if err := r.Moderate(ctx, "missing", "pending", "approved", ""); err != repo.ErrStaleStatus {
+		t.Errorf("Moderate() missing error = %v, want %v", err, repo.ErrStaleStatus)
+	}
+	if err := r.Moderate(ctx, ids[1], "approved", "hidden", ""); err == nil {
+		t.Errorf("Moderate() invalid status error = nil, want error")
+	}
+
+	if err := r.Moderate(ctx, ids[1], "approved", "rejected", "rude"); err != nil {
+		t.Fatalf("Moderate() reject approved error = %v", err)
+	}
+	if err := r.Delete(ctx, ids[1]); err != nil {
+		t.Fatalf("Delete() rejected error = %v", err)
+	}
+	if n, err := r.RebuildSummaries(ctx); err != nil || n != 2 {
+		t.Errorf("RebuildSummaries() = %v, %v, want 2", n, err)
+	}
+	want = map[string]model.RatingSummary{
+		"1": {ProductID: "1", Count: 1, Sum: 2, Avg: 2, Histogram: [5]int{0, 1, 0, 0, 0}},
+		"2": {ProductID: "2"},
+	}
+	if sums, err := r.Summaries(ctx, []string{"1", "2"}); err != nil || !reflect.DeepEqual(sums, want) {
+		t.Errorf("Summaries() after RebuildSummaries() = %v, %v, want %v", sums, err, want)
+	}
+}
@@ -0,0 +1,99 @@
+package repotest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// RunRemoveProductsSuite runs the repo.ProductRemover conformance suite
+// against every remover of the repos returned by newRepos
+func RunRemoveProductsSuite(t *testing.T, newRepos ReposFactory) {
+	t.Run("RemoveProducts", func(t *testing.T) { testRemoveProducts(t, newRepos(t)) })
+}
+
+// seedProductEntries creates an entry of product pdtID in every product dependent repo of r
+func seedProductEntries(t *testing.T, r repo.Repos, pdtID string) {
+	t.Helper()
+	vals := []struct {
+		c repo.Creator
+		v interface{}
+	}{
+		{r.Rating, model.Rating{ProductID: pdtID, User: model.User{ID: "u1"}, Value: 4}},
+		{r.Stock, model.StockMovement{ProductID: pdtID, Type: model.MovementReceipt, Quantity: 3}},
+		{r.Reservation, model.Reservation{ProductID: pdtID, Quantity: 1, Status: model.ReservationPending, ExpiresAt: time.Now().Add(time.Hour)}},
+		{r.Variant, model.Variant{ProductID: pdtID, Options: map[string]string{"size": "M"}}},
+		{r.Price, model.PriceChange{ProductID: pdtID, Price: 120, Status: model.PriceScheduled, EffectiveAt: time.Now().Add(time.Hour)}},
+	}
+	for _, v := range vals {
+		if _, err := v.c.Create(ctx, v.v); err != nil {
+			t.Fatalf("Create(%#v) error = %v", v.v, err)
+		}
+	}
+	if _, err := r.StockLevel.AdjustLevel(ctx, pdtID, "loc1", 3); err != nil {
+		t.Fatalf("AdjustLevel(%q) error = %v", pdtID, err)
+	}
+}
+
+// countProductEntries returns the number of entries of product pdtID by product dependent repo of r
+func countProductEntries(t *testing.T, r repo.Repos, pdtID string) map[string]int {
+	t.Helper()
+	q := repo.Query{"product_id": {pdtID}}
+	cnts := map[string]int{}
+	for name, s := range map[string]repo.Searcher{
+		"Rating":      r.Rating,
+		"Stock":       r.Stock,
+		"Reservation": r.Reservation,
+		"StockLevel":  r.StockLevel,
+		"Variant":     r.Variant,
+		"Price":       r.Price,
+	} {
+		n, err := s.SearchCount(ctx, q)
+		if err != nil {
+			t.Fatalf("%s.SearchCount(%v) error = %v", name, q, err)
+		}
+		cnts[name] = n
+	}
+	return cnts
+}
+
+func testRemoveProducts(t *testing.T, r repo.Repos) {
+	seedProductEntries(t, r, "1")
+	seedProductEntries(t, r, "2")
+	prm := newPromotion("Summer", prmStart, prmStart.Add(time.Hour))
+	prm.ProductIDs = []string{"2", "1", "3"}
+	prmID, err := r.Promotion.Create(ctx, prm)
+	if err != nil {
+		t.Fatalf("Create(%#v) error = %v", prm, err)
+	}
+
+	for _, rm := range []repo.ProductRemover{r.Rating, r.Stock, r.Reservation, r.StockLevel, r.Variant, r.Price, r.Promotion} {
+		if err := rm.RemoveProducts(ctx, []string{"1"}); err != nil {
+			t.Fatalf("%T.RemoveProducts() error = %v", rm, err)
+		}
+		if err := rm.RemoveProducts(ctx, nil); err != nil {
+			t.Errorf("%T.RemoveProducts() no products error = %v", rm, err)
+		}
+	}
+
+	// no entry of a removed product survives and the others are kept
+	for name, n := range countProductEntries(t, r, "1") {
+		if n != 0 {
+			t.Errorf("%s entries of the removed product = %v, want 0", name, n)
+		}
+	}
+	for name, n := range countProductEntries(t, r, "2") {
+		if n != 1 {
+			t.Errorf("%s entries of the kept product = %v, want 1", name, n)
+		}
+	}
+	if sums, err := r.Rating.Summaries(ctx, []string{"1", "2"}); err != nil || len(sums) != 1 || sums["2"].Count != 1 {
+		t.Errorf("Summaries() after RemoveProducts() = %v, %v, want only the kept product", sums, err)
+	}
+	if got := fetchPromotion(t, r.Promotion, prmID); got == nil || !reflect.DeepEqual(got.ProductIDs, []string{"2", "3"}) {
+		t.Errorf("Promotion after RemoveProducts() = %+v, want the kept products targeted in order", got)
+	}
+}
@@ -0,0 +1,92 @@
+// Package repotest provides backend agnostic conformance suites for the repo
+// interfaces, any implementation can be validated with a single call
+//
+//	func TestChef(t *testing.T) {
+//		repotest.RunProductSuite(t, func(t *testing.T) repo.Product {
+//			return NewChef(NewStore())
+//		})
+//	}
+//
+// a backend implementing every repo runs all the suites with RunSuites
+// and its repo.UnitOfWork is validated with RunUnitOfWorkSuite
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// ReposFactory returns new and empty repo.Repos of a single storage on every call
+type ReposFactory func(t *testing.T) repo.Repos
+
+// RunSuites runs every conformance suite against the repos returned by newRepos
+func RunSuites(t *testing.T, newRepos ReposFactory) {
+	suites := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{name: "Product", run: func(t *testing.T) {
+			RunProductSuite(t, func(t *testing.T) repo.Product { return newRepos(t).Product })
+		}},
+		{name: "ProductRating", run: func(t *testing.T) {
+			RunProductRatingSuite(t, func(t *testing.T) (repo.Product, repo.Rating) {
+				r := newRepos(t)
+				return r.Product, r.Rating
+			})
+		}},
+		{name: "Rating", run: func(t *testing.T) {
+			RunRatingSuite(t, func(t *testing.T) repo.Rating { return newRepos(t).Rating })
+		}},
+		{name: "Stock", run: func(t *testing.T) {
+			RunStockSuite(t, func(t *testing.T) repo.Stock { return newRepos(t).Stock })
+		}},
+		{name: "Reservation", run: func(t *testing.T) {
+			RunReservationSuite(t, func(t *testing.T) repo.Reservation { return newRepos(t).Reservation })
+		}},
+		{name: "Location", run: func(t *testing.T) {
+			RunLocationSuite(t, func(t *testing.T) repo.Location { return newRepos(t).Location })
+		}},
+		{name: "StockLevel", run: func(t *testing.T) {
+			RunStockLevelSuite(t, func(t *testing.T) repo.StockLevel { return newRepos(t).StockLevel })
+		}},
+		{name: "Category", run: func(t *testing.T) {
+			RunCategorySuite(t, func(t *testing.T) repo.Category { return newRepos(t).Category })
+		}},
+		{name: "Variant", run: func(t *testing.T) {
+			RunVariantSuite(t, func(t *testing.T) repo.Variant { return newRepos(t).Variant })
+		}},
+		{name: "Price", run: func(t *testing.T) {
+			RunPriceSuite(t, func(t *testing.T) repo.Price { return newRepos(t).Price })
+		}},
+		{name: "Promotion", run: func(t *testing.T) {
+			RunPromotionSuite(t, func(t *testing.T) repo.Promotion { return newRepos(t).Promotion })
+		}},
+		{name: "RemoveProducts", run: func(t *testing.T) { RunRemoveProductsSuite(t, newRepos) }},
+	}
+	for _, s := range suites {
+		t.Run(s.name, s.run)
+	}
+}
+
+// ctx is the context used by the suites
+var ctx = context.Background()
+
+// tick waits long enough for the next entry to get a later creation time
+// as the backends order entries by their creation timestamp
+func tick() {
+	time.Sleep(2 * time.Millisecond)
+}
+
+func assertIDs(t *testing.T, fn string, got, want []string) {
+	t.Helper()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s = %v, want %v", fn, got, want)
+	}
+}
@@ -0,0 +1,182 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// ReservationFactory returns a new and empty repo.Reservation on every call
+type ReservationFactory func(t *testing.T) repo.Reservation
+
+// RunReservationSuite runs the repo.Reservation conformance suite
+// against the repos returned by newRepo
+func RunReservationSuite(t *testing.T, newRepo ReservationFactory) {
+	t.Run("CreateFetch", func(t *testing.T) { testReservationCreateFetch(t, newRepo(t)) })
+	t.Run("Search", func(t *testing.T) { testReservationSearch(t, newRepo(t)) })
+	t.Run("SetStatus", func(t *testing.T) { testReservationSetStatus(t, newRepo(t)) })
+}
+
+func reservationIDs(t *testing.T, vs []interface{}) []string {
+	t.Helper()
+	ids := []string{}
+	for _, v := range vs {
+		rsv, ok := v.(model.Reservation)
+		if !ok {
+			t.Fatalf("got %T, want model.Reservation", v)
+		}
+		ids = append(ids, rsv.ID)
+	}
+	return ids
+}
+
+func fetchReservation(t *testing.T, r repo.Reservation, id string) *model.Reservation {
+	t.Helper()
+	v, err := r.Fetch(ctx, id)
+	if err != nil {
+		t.Fatalf("Fetch(%q) error = %v", id, err)
+	}
+	if v == nil {
+		return nil
+	}
+	rsv, ok := v.(model.Reservation)
+	if !ok {
+		t.Fatalf("Fetch(%q) = %T, want model.Reservation", id, v)
+	}
+	return &rsv
+}
+
+func testReservationCreateFetch(t *testing.T, r repo.Reservation) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name    string
+		v       interface{}
+		wantErr bool
+	}{
+		{v: struct{}{}, wantErr: true},
+		{v: model.Reservation{}, wantErr: true},
+		{v: model.Reservation{ProductID: "1", Quantity: 0, Status: model.ReservationPending, ExpiresAt: exp}, wantErr: true},
+		{v: model.Reservation{ProductID: "1", Quantity: 2, Status: "held", ExpiresAt: exp}, wantErr: true},
+		{v: model.Reservation{ID: "ignored", ProductID: "1", Quantity: 2, Status: model.ReservationPending, ExpiresAt: exp}, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := r.Create(ctx, tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if id == "" || id == "ignored" {
+				t.Fatalf("Create() id = %q, want a new generated id", id)
+			}
+
+			rsv := fetchReservation(t, r, id)
+			if rsv == nil {
+				t.Fatalf("Fetch(%q) = nil, want the created reservation", id)
+			}
+			if rsv.ProductID != "1" || rsv.Quantity != 2 || rsv.Status != model.ReservationPending ||
+				!rsv.ExpiresAt.Equal(exp) || rsv.CreatedAt.IsZero() {
+				t.Errorf("Fetch() = %#v", rsv)
+			}
+		})
+	}
+
+	if rsv := fetchReservation(t, r, "unavailable_id"); rsv != nil {
+		t.Errorf("Fetch() = %#v, want nil", rsv)
+	}
+}
+
+func testReservationSearch(t *testing.T, r repo.Reservation) {
+	now := time.Now().Truncate(time.Second)
+	rsvs := []model.Reservation{
+		{ProductID: "1", Quantity: 1, Status: model.ReservationPending, ExpiresAt: now.Add(3 * time.Minute)},
+		{ProductID: "2", Quantity: 1, Status: model.ReservationPending, ExpiresAt: now.Add(-time.Minute)},
+		{ProductID: "1", Quantity: 1, Status: model.ReservationPending, ExpiresAt: now.Add(-2 * time.Minute)},
+		{ProductID: "1", Quantity: 1, Status: model.ReservationConfirmed, ExpiresAt: now.Add(-3 * time.Minute)},
+	}
+	ids := []string{}
+	for _, rsv := range rsvs {
+		id, err := r.Create(ctx, rsv)
+		if err != nil {
+			t.Fatalf("Create(%#v) error = %v", rsv, err)
+		}
+		ids = append(ids, id)
+	}
+
+	tests := []struct {
+		name string
+		q    repo.Query
+		want []string
+	}{
+		{name: "all by expiry", q: repo.Query{}, want: []string{ids[3], ids[2], ids[1], ids[0]}},
+		{name: "product", q: repo.Query{"product_id": {"1"}}, want: []string{ids[3], ids[2], ids[0]}},
+		{name: "expired pending", q: repo.Query{"status": {"pending"}, "expires_before": {now}}, want: []string{ids[2], ids[1]}},
+		{name: "unknown product", q: repo.Query{"product_id": {"3"}}, want: nil},
+		{name: "injection", q: repo.Query{"status": {"pending' OR '1'='1"}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Search(ctx, tt.q, 0, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			assertIDs(t, "Search()", reservationIDs(t, res), tt.want)
+
+			n, err := r.SearchCount(ctx, tt.q)
+			if err != nil {
+				t.Fatalf("SearchCount() error = %v", err)
+			}
+			if n != len(tt.want) {
+				t.Errorf("SearchCount() = %v, want %v", n, len(tt.want))
+			}
+
+			if len(tt.want) < 2 {
+				return
+			}
+			res, err = r.Search(ctx, tt.q, 1, 1)
+			if err != nil {
+				t.Fatalf("Search() paged error = %v", err)
+			}
+			assertIDs(t, "Search() paged", reservationIDs(t, res), tt.want[1:2])
+		})
+	}
+}
+
+func testReservationSetStatus(t *testing.T, r repo.Reservation) {
+	id, err := r.Create(ctx, model.Reservation{ProductID: "1", Quantity: 1, Status: model.ReservationPending, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		from, to model.ReservationStatus
+		want     model.ReservationStatus
+		wantErr  error
+	}{
+		{name: "confirm", id: id, from: model.ReservationPending, to: model.ReservationConfirmed, want: model.ReservationConfirmed},
+		{name: "stale", id: id, from: model.ReservationPending, to: model.ReservationCancelled, want: model.ReservationConfirmed, wantErr: repo.ErrStaleStatus},
+		{name: "unavailable", id: "unavailable_id", from: model.ReservationPending, to: model.ReservationCancelled, wantErr: repo.ErrStaleStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.SetStatus(ctx, tt.id, string(tt.from), string(tt.to))
+			if err != tt.wantErr {
+				t.Fatalf("SetStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			rsv := fetchReservation(t, r, tt.id)
+			if rsv == nil {
+				return
+			}
+			if rsv.Status != tt.want {
+				t.Errorf("Fetch() after SetStatus() status = %v, want %v", rsv.Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// StockFactory returns a new and empty repo.Stock on every call
+type StockFactory func(t *testing.T) repo.Stock
+
+// RunStockSuite runs the repo.Stock conformance suite
+// against the repos returned by newRepo
+func RunStockSuite(t *testing.T, newRepo StockFactory) {
+	t.Run("Create", func(t *testing.T) { testStockCreate(t, newRepo(t)) })
+	t.Run("Search", func(t *testing.T) { testStockSearch(t, newRepo(t)) })
+}
+
+func movementIDs(t *testing.T, vs []interface{}) []string {
+	t.Helper()
+	ids := []string{}
+	for _, v := range vs {
+		mvt, ok := v.(model.StockMovement)
+		if !ok {
+			t.Fatalf("got %T, want model.StockMovement", v)
+		}
+		ids = append(ids, mvt.ID)
+	}
+	return ids
+}
+
+func testStockCreate(t *testing.T, r repo.Stock) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		wantErr bool
+	}{
+		{v: struct{}{}, wantErr: true},
+		{v: model.StockMovement{}, wantErr: true},
+		{v: model.StockMovement{ProductID: "1", Type: "gift", Quantity: 1}, wantErr: true},
+		{v: model.StockMovement{ProductID: "1", Type: model.MovementSale, Quantity: 0}, wantErr: true},
+		{v: model.StockMovement{ProductID: "1", Type: model.MovementAdjustment, Quantity: -1}, wantErr: true},
+		{v: model.StockMovement{ProductID: "1", Type: model.MovementReceipt, Quantity: 10, Reference: "PO-1"}, wantErr: false},
+		{v: model.StockMovement{ID: "ignored", ProductID: "1", Type: model.MovementAdjustment, Quantity: -1, Reason: "broken"}, wantErr: false},
+	}
+	ids := map[string]bool{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := r.Create(ctx, tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if id == "" || id == "ignored" || ids[id] {
+				t.Errorf("Create() id = %q, want a new generated id", id)
+			}
+			ids[id] = true
+		})
+	}
+
+	res, err := r.Search(ctx, repo.Query{"product_id": {"1"}}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != len(ids) {
+		t.Fatalf("Search() = %v, want %d movements", res, len(ids))
+	}
+	for _, v := range res {
+		mvt := v.(model.StockMovement)
+		if mvt.Type != model.MovementReceipt {
+			continue
+		}
+		if mvt.ProductID != "1" || mvt.Quantity != 10 || mvt.Reference != "PO-1" || mvt.CreatedAt.IsZero() {
+			t.Errorf("Search() stored movement = %#v", mvt)
+		}
+	}
+}
+
+func testStockSearch(t *testing.T, r repo.Stock) {
+	mvts := []model.StockMovement{
+		{ProductID: "1", Type: model.MovementReceipt, Quantity: 10},
+		{ProductID: "2", Type: model.MovementReceipt, Quantity: 5},
+		{ProductID: "1", Type: model.MovementSale, Quantity: 3},
+		{ProductID: "1", Type: model.MovementReturn, Quantity: 1},
+		{ProductID: "1", Type: model.MovementSale, Quantity: 2},
+	}
+	ids := []string{}
+	for _, mvt := range mvts {
+		tick()
+		id, err := r.Create(ctx, mvt)
+		if err != nil {
+			t.Fatalf("Create(%#v) error = %v", mvt, err)
+		}
+		ids = append(ids, id)
+	}
+
+	tests := []struct {
+		name string
+		q    repo.Query
+		want []string
+	}{
+		{name: "all latest first", q: repo.Query{}, want: []string{ids[4], ids[3], ids[2], ids[1], ids[0]}},
+		{name: "product", q: repo.Query{"product_id": {"1"}}, want: []string{ids[4], ids[3], ids[2], ids[0]}},
+		{name: "product and type", q: repo.Query{"product_id": {"1"}, "type": {"sale"}}, want: []string{ids[4], ids[2]}},
+		{name: "unknown product", q: repo.Query{"product_id": {"3"}}, want: nil},
+		{name: "injection", q: repo.Query{"product_id": {"1' OR '1'='1"}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Search(ctx, tt.q, 0, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			assertIDs(t, "Search()", movementIDs(t, res), tt.want)
+
+			n, err := r.SearchCount(ctx, tt.q)
+			if err != nil {
+				t.Fatalf("SearchCount() error = %v", err)
+			}
+			if n != len(tt.want) {
+				t.Errorf("SearchCount() = %v, want %v", n, len(tt.want))
+			}
+
+			if len(tt.want) < 2 {
+				return
+			}
+			res, err = r.Search(ctx, tt.q, 1, 1)
+			if err != nil {
+				t.Fatalf("Search() paged error = %v", err)
+			}
+			assertIDs(t, "Search() paged", movementIDs(t, res), tt.want[1:2])
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// UnitOfWorkFactory returns a new repo.UnitOfWork and the repo.Repos
+// of its empty storage on every call
+type UnitOfWorkFactory func(t *testing.T) (repo.UnitOfWork, repo.Repos)
+
+// RunUnitOfWorkSuite runs the repo.UnitOfWork conformance suite against the
+// units of work returned by newUoW
+func RunUnitOfWorkSuite(t *testing.T, newUoW UnitOfWorkFactory) {
+	t.Run("Commit", func(t *testing.T) {
+		u, r := newUoW(t)
+		testUnitOfWorkCommit(t, u, r)
+	})
+	t.Run("ReadDuringRollback", func(t *testing.T) {
+		u, r := newUoW(t)
+		testUnitOfWorkReadDuringRollback(t, u, r)
+	})
+}
+
+func testUnitOfWorkCommit(t *testing.T, u repo.UnitOfWork, r repo.Repos) {
+	err := u.Do(ctx, func(tx repo.Repos) error {
+		_, err := tx.Product.Create(ctx, model.Product{Name: "Shirt", Price: 100, Weight: 1})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if n, err := r.Product.Count(ctx); n != 1 || err != nil {
+		t.Errorf("Count() after Do() = %v, %v, want 1", n, err)
+	}
+}
+
+func testUnitOfWorkReadDuringRollback(t *testing.T, u repo.UnitOfWork, r repo.Repos) {
+	errFn := errors.New("fn failed")
+	got := make(chan int, 1)
+	err := u.Do(ctx, func(tx repo.Repos) error {
+		if _, err := tx.Product.Create(ctx, model.Product{Name: "Shirt", Price: 100, Weight: 1}); err != nil {
+			return err
+		}
+		// the read runs while the transaction is open and must not see its changes
+		go func() {
+			n, err := r.Product.Count(ctx)
+			if err != nil {
+				t.Errorf("Count() during Do() error = %v", err)
+			}
+			got <- n
+		}()
+		time.Sleep(10 * time.Millisecond)
+		return errFn
+	})
+	if err != errFn {
+		t.Fatalf("Do() error = %v, want %v", err, errFn)
+	}
+	if n := <-got; n != 0 {
+		t.Errorf("Count() during a failing Do() = %v, want 0", n)
+	}
+	if n, err := r.Product.Count(ctx); n != 0 || err != nil {
+		t.Errorf("Count() after a failing Do() = %v, %v, want 0", n, err)
+	}
+}
@@ -0,0 +1,176 @@
+package repotest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// VariantFactory returns a new and empty repo.Variant on every call
+type VariantFactory func(t *testing.T) repo.Variant
+
+// RunVariantSuite runs the repo.Variant conformance suite
+// against the repos returned by newRepo
+func RunVariantSuite(t *testing.T, newRepo VariantFactory) {
+	t.Run("CreateFetch", func(t *testing.T) { testVariantCreateFetch(t, newRepo(t)) })
+	t.Run("Update", func(t *testing.T) { testVariantUpdate(t, newRepo(t)) })
+	t.Run("SearchDelete", func(t *testing.T) { testVariantSearchDelete(t, newRepo(t)) })
+}
+
+func fetchVariant(t *testing.T, r repo.Variant, id string) *model.Variant {
+	t.Helper()
+	v, err := r.Fetch(ctx, id)
+	if err != nil {
+		t.Fatalf("Fetch(%q) error = %v", id, err)
+	}
+	if v == nil {
+		return nil
+	}
+	vrt, ok := v.(model.Variant)
+	if !ok {
+		t.Fatalf("Fetch(%q) = %T, want model.Variant", id, v)
+	}
+	return &vrt
+}
+
+func variantIDs(t *testing.T, vs []interface{}) []string {
+	t.Helper()
+	ids := []string{}
+	for _, v := range vs {
+		vrt, ok := v.(model.Variant)
+		if !ok {
+			t.Fatalf("got %T, want model.Variant", v)
+		}
+		ids = append(ids, vrt.ID)
+	}
+	return ids
+}
+
+func createVariants(t *testing.T, r repo.Variant, vrts ...model.Variant) []string {
+	t.Helper()
+	ids := []string{}
+	for _, vrt := range vrts {
+		tick()
+		id, err := r.Create(ctx, vrt)
+		if err != nil {
+			t.Fatalf("Create(%#v) error = %v", vrt, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func testVariantCreateFetch(t *testing.T, r repo.Variant) {
+	createVariants(t, r, model.Variant{ProductID: "p1", Options: map[string]string{"size": "M"}, SKU: "TS-M"})
+
+	tests := []struct {
+		name    string
+		v       interface{}
+		wantErr error
+	}{
+		{name: "unsupported", v: struct{}{}, wantErr: repo.ErrUnsupportedType},
+		{name: "same options", v: model.Variant{ProductID: "p1", Options: map[string]string{" Size ": "M"}}, wantErr: repo.ErrDuplicateVariant},
+		{name: "same sku", v: model.Variant{ProductID: "p2", Options: map[string]string{"size": "L"}, SKU: "TS-M"}, wantErr: repo.ErrDuplicateSKU},
+		{name: "same options of another product", v: model.Variant{ProductID: "p2", Options: map[string]string{"size": "M"}}, wantErr: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := r.Create(ctx, tt.v); err != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := r.Create(ctx, model.Variant{ProductID: "p1"}); err == nil {
+		t.Errorf("Create() without options error = nil")
+	}
+
+	id, err := r.Create(ctx, model.Variant{ID: "ignored", ProductID: "p1", Options: map[string]string{"Colour": " red ", "size": "L"}, Price: 120, Weight: 2, Quantity: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == "" || id == "ignored" {
+		t.Fatalf("Create() id = %q, want a new generated id", id)
+	}
+	vrt := fetchVariant(t, r, id)
+	want := map[string]string{"colour": "red", "size": "L"}
+	if vrt == nil || vrt.ProductID != "p1" || !reflect.DeepEqual(vrt.Options, want) || vrt.Price != 120 || vrt.Weight != 2 || vrt.Quantity != 3 || vrt.CreatedAt.IsZero() {
+		t.Errorf("Fetch() = %#v", vrt)
+	}
+	if vrt := fetchVariant(t, r, "unavailable_id"); vrt != nil {
+		t.Errorf("Fetch() = %#v, want nil", vrt)
+	}
+}
+
+func testVariantUpdate(t *testing.T, r repo.Variant) {
+	ids := createVariants(t, r,
+		model.Variant{ProductID: "p1", Options: map[string]string{"size": "M"}, SKU: "TS-M"},
+		model.Variant{ProductID: "p1", Options: map[string]string{"size": "L"}, SKU: "TS-L"},
+	)
+
+	vrt := fetchVariant(t, r, ids[1])
+	vrt.Options = map[string]string{"size": "M"}
+	if err := r.Update(ctx, ids[1], *vrt); err != repo.ErrDuplicateVariant {
+		t.Errorf("Update() to used options error = %v, want %v", err, repo.ErrDuplicateVariant)
+	}
+	vrt.Options = map[string]string{"size": "XL"}
+	vrt.SKU = "TS-M"
+	if err := r.Update(ctx, ids[1], *vrt); err != repo.ErrDuplicateSKU {
+		t.Errorf("Update() to used sku error = %v, want %v", err, repo.ErrDuplicateSKU)
+	}
+	vrt.SKU = "TS-XL"
+	vrt.ProductID = "p2"
+	vrt.Price = 150
+	vrt.Quantity = 4
+	if err := r.Update(ctx, ids[1], *vrt); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	got := fetchVariant(t, r, ids[1])
+	if got == nil || got.ProductID != "p1" || got.Options["size"] != "XL" || got.SKU != "TS-XL" || got.Price != 150 || got.Quantity != 4 {
+		t.Errorf("Fetch() after Update() = %#v", got)
+	}
+}
+
+func testVariantSearchDelete(t *testing.T, r repo.Variant) {
+	ids := createVariants(t, r,
+		model.Variant{ProductID: "p1", Options: map[string]string{"size": "M"}},
+		model.Variant{ProductID: "p2", Options: map[string]string{"size": "M"}},
+		model.Variant{ProductID: "p1", Options: map[string]string{"size": "L"}},
+	)
+
+	tests := []struct {
+		name string
+		q    repo.Query
+		want []string
+	}{
+		{name: "all", q: repo.Query{}, want: ids},
+		{name: "product", q: repo.Query{"product_id": {"p1"}}, want: []string{ids[0], ids[2]}},
+		{name: "injection", q: repo.Query{"product_id": {"p1' OR '1'='1"}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Search(ctx, tt.q, 0, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			assertIDs(t, "Search()", variantIDs(t, res), tt.want)
+
+			n, err := r.SearchCount(ctx, tt.q)
+			if err != nil || n != len(tt.want) {
+				t.Errorf("SearchCount() = %v, %v, want %v", n, err, len(tt.want))
+			}
+		})
+	}
+
+	if err := r.Delete(ctx, ids[0]); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if vrt := fetchVariant(t, r, ids[0]); vrt != nil {
+		t.Errorf("Fetch() after Delete() = %#v, want nil", vrt)
+	}
+	if _, err := r.Create(ctx, model.Variant{ProductID: "p1", Options: map[string]string{"size": "M"}}); err != nil {
+		t.Errorf("Create() with options of a deleted variant error = %v", err)
+	}
+}
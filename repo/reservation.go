@@ -0,0 +1,189 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/model"
+)
+
+// Reservation interface is the repo wrapper of stock reservation
+// reservations are searched by product_id, status and expires_before
+type Reservation interface {
+	Creator
+	Fetcher
+	Searcher
+	StatusSetter
+	ProductRemover
+}
+
+// reservationColumns are the selected columns of a reservation in scan order
+const reservationColumns = `"id", "product_id", "quantity", "status", "expires_at", "created_at", "updated_at"`
+
+func scanReservation(row infra.Row) (model.Reservation, error) {
+	rsv := model.Reservation{}
+	var sts string
+	err := row.Scan(&rsv.ID, &rsv.ProductID, &rsv.Quantity, &sts, &rsv.ExpiresAt, &rsv.CreatedAt, &rsv.UpdatedAt)
+	rsv.Status = model.ReservationStatus(sts)
+	return rsv, err
+}
+
+// Clerk is an implementation of Reservation
+type Clerk struct {
+	table string
+	db    infra.DB
+}
+
+// NewClerk returns a new Clerk with table name tab
+// it returns ErrInvalidTable if tab is not a valid sql identifier
+func NewClerk(tab string, db infra.DB) (*Clerk, error) {
+	if !isIdent(tab) {
+		return nil, ErrInvalidTable
+	}
+	return &Clerk{
+		table: tab,
+		db:    db,
+	}, nil
+}
+
+// Create creates a new reservation
+func (c *Clerk) Create(ctx context.Context, v interface{}) (string, error) {
+	ctx = infra.WithOperation(ctx, "reservation.create")
+	rsv, ok := v.(model.Reservation)
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+	rsv.ID = uuid.NewV4().String()
+
+	if err := rsv.Validate(); err != nil {
+		return "", err
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %s ("id", "product_id", "quantity", "status", "expires_at") VALUES($1, $2, $3, $4, $5)`, c.table)
+	err := c.db.Exec(ctx, stmt, rsv.ID, rsv.ProductID, rsv.Quantity, string(rsv.Status), rsv.ExpiresAt)
+	if err != nil {
+		return "", err
+	}
+	return rsv.ID, nil
+}
+
+// Fetch returns a model.Reservation finding by its id
+func (c *Clerk) Fetch(ctx context.Context, id string) (interface{}, error) {
+	ctx = infra.WithOperation(ctx, "reservation.fetch")
+
+	row, err := c.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE "id"=$1`, reservationColumns, c.table), id)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	if !row.Next() {
+		return nil, nil
+	}
+	rsv, err := scanReservation(row)
+	if err != nil {
+		return nil, err
+	}
+	return rsv, nil
+}
+
+// Search returns the reservations of query q ordered by their expiry
+func (c *Clerk) Search(ctx context.Context, q Query, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "reservation.search")
+	qstmt, vals := buildReservationQuery(q)
+	str := fmt.Sprintf(`SELECT %s FROM %s`, reservationColumns, c.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+	str = str + fmt.Sprintf(` ORDER BY "expires_at", "id" OFFSET $%d LIMIT $%d`, len(vals)+1, len(vals)+2)
+	vals = append(vals, skip, limit)
+
+	rows, err := c.db.Query(ctx, str, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rsvs := []interface{}{}
+	for rows.Next() {
+		rsv, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		rsvs = append(rsvs, rsv)
+	}
+	return rsvs, nil
+}
+
+// SearchCount returns number of reservations that matches query
+func (c *Clerk) SearchCount(ctx context.Context, q Query) (int, error) {
+	ctx = infra.WithOperation(ctx, "reservation.search_count")
+	qstmt, vals := buildReservationQuery(q)
+	str := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, c.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+
+	rows, err := c.db.Query(ctx, str, vals...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// SetStatus changes the status of a reservation from from to to
+// it returns ErrStaleStatus if the reservation is not in status from
+// so that concurrent transitions of a reservation can not both succeed
+func (c *Clerk) SetStatus(ctx context.Context, id string, from, to string) error {
+	ctx = infra.WithOperation(ctx, "reservation.set_status")
+	stmt := fmt.Sprintf(`UPDATE %s SET ("status", "updated_at") = ($1, CURRENT_TIMESTAMP)
+		WHERE "id"=$2 AND "status"=$3 RETURNING "id"`, c.table)
+
+	rows, err := c.db.Query(ctx, stmt, to, id, from)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return ErrStaleStatus
+	}
+	return nil
+}
+
+func buildReservationQuery(q Query) (string, []interface{}) {
+	conds := []string{}
+	vals := []interface{}{}
+	if pdtID := q["product_id"]; len(pdtID) != 0 {
+		vals = append(vals, pdtID[0])
+		conds = append(conds, fmt.Sprintf(`"product_id" = $%d`, len(vals)))
+	}
+	if sts := q["status"]; len(sts) != 0 {
+		vals = append(vals, fmt.Sprint(sts[0]))
+		conds = append(conds, fmt.Sprintf(`"status" = $%d`, len(vals)))
+	}
+	if exp := q["expires_before"]; len(exp) != 0 {
+		vals = append(vals, exp[0])
+		conds = append(conds, fmt.Sprintf(`"expires_at" <= $%d`, len(vals)))
+	}
+	return strings.Join(conds, " AND "), vals
+}
+
+// RemoveProducts permanently removes the reservations of the products of pdtIDs
+func (c *Clerk) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	ctx = infra.WithOperation(ctx, "reservation.remove_products")
+	return removeProducts(ctx, c.db, c.table, pdtIDs)
+}
@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/model"
+)
+
+// Stock interface is the repo wrapper of the stock movement ledger
+// movements are append only, they are searched by product_id, location_id and type
+type Stock interface {
+	Creator
+	Searcher
+	ProductRemover
+}
+
+// stockMovementColumns are the selected columns of a stock movement in scan order
+const stockMovementColumns = `"id", "product_id", "location_id", "type", "quantity", "reason", "reference", "created_at"`
+
+// Keeper is an implementation of Stock
+type Keeper struct {
+	table string
+	db    infra.DB
+}
+
+// NewKeeper returns a new Keeper with table name tab
+// it returns ErrInvalidTable if tab is not a valid sql identifier
+func NewKeeper(tab string, db infra.DB) (*Keeper, error) {
+	if !isIdent(tab) {
+		return nil, ErrInvalidTable
+	}
+	return &Keeper{
+		table: tab,
+		db:    db,
+	}, nil
+}
+
+// Create appends a new stock movement to the ledger
+func (k *Keeper) Create(ctx context.Context, v interface{}) (string, error) {
+	ctx = infra.WithOperation(ctx, "stock.create")
+	mvt, ok := v.(model.StockMovement)
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+	mvt.ID = uuid.NewV4().String()
+
+	if err := mvt.Validate(); err != nil {
+		return "", err
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %s ("id", "product_id", "location_id", "type", "quantity", "reason", "reference") VALUES($1, $2, $3, $4, $5, $6, $7)`, k.table)
+	err := k.db.Exec(ctx, stmt, mvt.ID, mvt.ProductID, mvt.LocationID, string(mvt.Type), mvt.Quantity, mvt.Reason, mvt.Reference)
+	if err != nil {
+		return "", err
+	}
+	return mvt.ID, nil
+}
+
+// Search returns the stock movements of query q latest first
+func (k *Keeper) Search(ctx context.Context, q Query, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "stock.search")
+	qstmt, vals := buildStockQuery(q)
+	str := fmt.Sprintf(`SELECT %s FROM %s`, stockMovementColumns, k.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+	str = str + fmt.Sprintf(` ORDER BY "created_at" DESC, "id" OFFSET $%d LIMIT $%d`, len(vals)+1, len(vals)+2)
+	vals = append(vals, skip, limit)
+
+	rows, err := k.db.Query(ctx, str, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mvts := []interface{}{}
+	for rows.Next() {
+		mvt := model.StockMovement{}
+		var typ string
+		err = rows.Scan(&mvt.ID, &mvt.ProductID, &mvt.LocationID, &typ, &mvt.Quantity, &mvt.Reason, &mvt.Reference, &mvt.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		mvt.Type = model.MovementType(typ)
+		mvts = append(mvts, mvt)
+	}
+	return mvts, nil
+}
+
+// SearchCount returns number of stock movements that matches query
+func (k *Keeper) SearchCount(ctx context.Context, q Query) (int, error) {
+	ctx = infra.WithOperation(ctx, "stock.search_count")
+	qstmt, vals := buildStockQuery(q)
+	str := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, k.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+
+	rows, err := k.db.Query(ctx, str, vals...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func buildStockQuery(q Query) (string, []interface{}) {
+	str := ""
+	vals := []interface{}{}
+	if pdtID := q["product_id"]; len(pdtID) != 0 {
+		str = `"product_id" = $1`
+		vals = append(vals, pdtID[0])
+	}
+	if loc := q["location_id"]; len(loc) != 0 {
+		if len(vals) != 0 {
+			str = str + " AND "
+		}
+		vals = append(vals, fmt.Sprint(loc[0]))
+		str = str + fmt.Sprintf(`"location_id" = $%d`, len(vals))
+	}
+	if typ := q["type"]; len(typ) != 0 {
+		if len(vals) != 0 {
+			str = str + " AND "
+		}
+		vals = append(vals, fmt.Sprint(typ[0]))
+		str = str + fmt.Sprintf(`"type" = $%d`, len(vals))
+	}
+	return str, vals
+}
+
+// RemoveProducts permanently removes the stock movements of the products of pdtIDs
+func (k *Keeper) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	ctx = infra.WithOperation(ctx, "stock.remove_products")
+	return removeProducts(ctx, k.db, k.table, pdtIDs)
+}
@@ -0,0 +1,165 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/msyrus/simple-product-inv/infra"
+)
+
+// Repos holds a set of repos that share the same underlying storage
+type Repos struct {
+	Product     Product
+	Rating      Rating
+	Stock       Stock
+	Reservation Reservation
+	Location    Location
+	StockLevel  StockLevel
+	Category    Category
+	Variant     Variant
+	Price       Price
+	Promotion   Promotion
+}
+
+// UnitOfWork runs fn with Repos bound to a single transaction
+// changes made through the Repos are committed if fn returns nil
+// and discarded otherwise
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(r Repos) error) error
+}
+
+// Tables holds the sql table names of the repos
+type Tables struct {
+	Products       string
+	Ratings        string
+	StockMovements string
+	Reservations   string
+	Locations      string
+	StockLevels    string
+	Categories     string
+	// ProductCategories holds the product category assignments
+	ProductCategories string
+	ProductTags       string
+	Variants          string
+	PriceChanges      string
+	Promotions        string
+	// RatingSummaries holds the per product rating summaries
+	RatingSummaries string
+}
+
+// DefaultTables holds the table names used by the migrations
+var DefaultTables = Tables{
+	Products:          "products",
+	Ratings:           "ratings",
+	StockMovements:    "stock_movements",
+	Reservations:      "reservations",
+	Locations:         "locations",
+	StockLevels:       "stock_levels",
+	Categories:        "categories",
+	ProductCategories: "product_categories",
+	ProductTags:       "product_tags",
+	Variants:          "product_variants",
+	PriceChanges:      "price_changes",
+	Promotions:        "promotions",
+	RatingSummaries:   "rating_summaries",
+}
+
+// NewSQLRepos returns sql Repos using tables tabs of db
+// db can either be an infra.DB or an infra.Tx
+func NewSQLRepos(db infra.DB, tabs Tables) (Repos, error) {
+	chf, err := NewChef(tabs.Products, db)
+	if err != nil {
+		return Repos{}, err
+	}
+	ptr, err := NewPorter(tabs.StockLevels, db)
+	if err != nil {
+		return Repos{}, err
+	}
+	chf.levels = ptr.table
+	ctc, err := NewCritic(tabs.Ratings, db)
+	if err != nil {
+		return Repos{}, err
+	}
+	if !isIdent(tabs.RatingSummaries) {
+		return Repos{}, ErrInvalidTable
+	}
+	ctc.summaries = tabs.RatingSummaries
+	chf.ratings = ctc.summaries
+	kpr, err := NewKeeper(tabs.StockMovements, db)
+	if err != nil {
+		return Repos{}, err
+	}
+	clk, err := NewClerk(tabs.Reservations, db)
+	if err != nil {
+		return Repos{}, err
+	}
+	sct, err := NewScout(tabs.Locations, db)
+	if err != nil {
+		return Repos{}, err
+	}
+	cur, err := NewCurator(tabs.Categories, db)
+	if err != nil {
+		return Repos{}, err
+	}
+	if !isIdent(tabs.ProductCategories) {
+		return Repos{}, ErrInvalidTable
+	}
+	cur.assigns = tabs.ProductCategories
+	chf.assigns = cur.assigns
+	if !isIdent(tabs.ProductTags) {
+		return Repos{}, ErrInvalidTable
+	}
+	chf.tags = tabs.ProductTags
+	tlr, err := NewTailor(tabs.Variants, db)
+	if err != nil {
+		return Repos{}, err
+	}
+	apr, err := NewAppraiser(tabs.PriceChanges, db)
+	if err != nil {
+		return Repos{}, err
+	}
+	hrd, err := NewHerald(tabs.Promotions, db)
+	if err != nil {
+		return Repos{}, err
+	}
+	return Repos{
+		Product:     chf,
+		Rating:      ctc,
+		Stock:       kpr,
+		Reservation: clk,
+		Location:    sct,
+		StockLevel:  ptr,
+		Category:    cur,
+		Variant:     tlr,
+		Price:       apr,
+		Promotion:   hrd,
+	}, nil
+}
+
+// SQLUnitOfWork is the sql implementation of UnitOfWork
+type SQLUnitOfWork struct {
+	db   infra.TxDB
+	tabs Tables
+}
+
+// NewSQLUnitOfWork returns a new SQLUnitOfWork with tables tabs of db
+// it returns ErrInvalidTable if any of tabs is not a valid sql identifier
+func NewSQLUnitOfWork(db infra.TxDB, tabs Tables) (*SQLUnitOfWork, error) {
+	if _, err := NewSQLRepos(db, tabs); err != nil {
+		return nil, err
+	}
+	return &SQLUnitOfWork{
+		db:   db,
+		tabs: tabs,
+	}, nil
+}
+
+// Do runs fn within a db transaction
+func (u *SQLUnitOfWork) Do(ctx context.Context, fn func(r Repos) error) error {
+	return infra.WithTx(ctx, u.db, func(tx infra.Tx) error {
+		r, err := NewSQLRepos(tx, u.tabs)
+		if err != nil {
+			return err
+		}
+		return fn(r)
+	})
+}
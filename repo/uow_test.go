@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/msyrus/simple-product-inv/mock_infra"
+	"github.com/msyrus/simple-product-inv/model"
+)
+
+func TestNewSQLUnitOfWork(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db := mock_infra.NewMockTxDB(mockCtrl)
+
+	tests := []struct {
+		name    string
+		tabs    Tables
+		wantErr bool
+	}{
+		{
+			tabs:    DefaultTables,
+			wantErr: false,
+		},
+		{
+			tabs:    Tables{Products: "products", Ratings: "ratings; --"},
+			wantErr: true,
+		},
+		{
+			tabs:    Tables{Ratings: "ratings"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSQLUnitOfWork(db, tt.tabs); (err != nil) != tt.wantErr {
+				t.Errorf("NewSQLUnitOfWork() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSQLUnitOfWork_Do(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db := mock_infra.NewMockTxDB(mockCtrl)
+	tx := mock_infra.NewMockTx(mockCtrl)
+	uow, _ := NewSQLUnitOfWork(db, DefaultTables)
+
+	pdt := model.Product{Name: "Test", Price: 100, Weight: 1}
+	rat := model.Rating{ProductID: "1", User: model.User{ID: "u1"}, Value: 3}
+
+	gomock.InOrder(
+		db.EXPECT().Begin(gomock.Any()).Return(tx, nil),
+		tx.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), pdt.Name, pdt.Price, "", pdt.Weight, pdt.SKU, pdt.Barcode, "draft").Return(nil),
+		tx.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), rat.ProductID, rat.User.ID, "", rat.Value, "", "", "approved", "").Return(nil),
+		tx.EXPECT().Commit().Return(nil),
+
+		db.EXPECT().Begin(gomock.Any()).Return(tx, nil),
+		tx.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), pdt.Name, pdt.Price, "", pdt.Weight, pdt.SKU, pdt.Barcode, "draft").Return(nil),
+		tx.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), rat.ProductID, rat.User.ID, "", rat.Value, "", "", "approved", "").Return(sql.ErrConnDone),
+		tx.EXPECT().Rollback().Return(nil),
+	)
+
+	fn := func(r Repos) error {
+		if _, err := r.Product.Create(context.Background(), pdt); err != nil {
+			return err
+		}
+		_, err := r.Rating.Create(context.Background(), rat)
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{
+			wantErr: false,
+		},
+		{
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := uow.Do(context.Background(), fn); (err != nil) != tt.wantErr {
+				t.Errorf("SQLUnitOfWork.Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
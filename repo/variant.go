@@ -0,0 +1,204 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/infra"
+	"github.com/msyrus/simple-product-inv/model"
+)
+
+// Variant interface is the repo wrapper of product variant
+// variants are searched by product_id
+type Variant interface {
+	Creator
+	Fetcher
+	Updater
+	Deleter
+	Searcher
+	ProductRemover
+}
+
+// variantColumns are the selected columns of a variant in scan order
+// the options are stored encoded by model.EncodeOptions
+const variantColumns = `"id", "product_id", "options", "sku", "price", "weight", "quantity", "created_at", "updated_at"`
+
+func scanVariant(row infra.Row) (model.Variant, error) {
+	vrt := model.Variant{}
+	opts := ""
+	err := row.Scan(&vrt.ID, &vrt.ProductID, &opts, &vrt.SKU, &vrt.Price, &vrt.Weight, &vrt.Quantity, &vrt.CreatedAt, &vrt.UpdatedAt)
+	vrt.Options = model.DecodeOptions(opts)
+	return vrt, err
+}
+
+// Tailor is an implementation of Variant
+type Tailor struct {
+	table string
+	db    infra.DB
+}
+
+// NewTailor returns a new Tailor with table name tab
+// it returns ErrInvalidTable if tab is not a valid sql identifier
+func NewTailor(tab string, db infra.DB) (*Tailor, error) {
+	if !isIdent(tab) {
+		return nil, ErrInvalidTable
+	}
+	return &Tailor{
+		table: tab,
+		db:    db,
+	}, nil
+}
+
+// Create creates a new variant
+// it returns ErrDuplicateVariant if the product has a variant of the same options
+func (t *Tailor) Create(ctx context.Context, v interface{}) (string, error) {
+	ctx = infra.WithOperation(ctx, "variant.create")
+	vrt, ok := v.(model.Variant)
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+	vrt.ID = uuid.NewV4().String()
+	vrt.Options = model.NormalizeOptions(vrt.Options)
+
+	if err := vrt.Validate(); err != nil {
+		return "", err
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %s ("id", "product_id", "options", "sku", "price", "weight", "quantity") VALUES($1, $2, $3, $4, $5, $6, $7)`, t.table)
+	err := t.db.Exec(ctx, stmt, vrt.ID, vrt.ProductID, model.EncodeOptions(vrt.Options), vrt.SKU, vrt.Price, vrt.Weight, vrt.Quantity)
+	if err != nil {
+		return "", duplicateVariant(err)
+	}
+	return vrt.ID, nil
+}
+
+// Fetch returns a model.Variant finding by its id
+func (t *Tailor) Fetch(ctx context.Context, id string) (interface{}, error) {
+	ctx = infra.WithOperation(ctx, "variant.fetch")
+
+	row, err := t.db.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE "id"=$1`, variantColumns, t.table), id)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+
+	if !row.Next() {
+		return nil, nil
+	}
+	vrt, err := scanVariant(row)
+	if err != nil {
+		return nil, err
+	}
+	return vrt, nil
+}
+
+// Update updates a variant, its product is never changed
+func (t *Tailor) Update(ctx context.Context, id string, v interface{}) error {
+	ctx = infra.WithOperation(ctx, "variant.update")
+	vrt, ok := v.(model.Variant)
+	if !ok {
+		return ErrUnsupportedType
+	}
+	vrt.Options = model.NormalizeOptions(vrt.Options)
+	if err := vrt.Validate(); err != nil {
+		return err
+	}
+
+	stmt := fmt.Sprintf(`UPDATE %s SET ("options", "sku", "price", "weight", "quantity", "updated_at") = ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		WHERE "id"=$6`, t.table)
+	err := t.db.Exec(ctx, stmt, model.EncodeOptions(vrt.Options), vrt.SKU, vrt.Price, vrt.Weight, vrt.Quantity, id)
+	return duplicateVariant(err)
+}
+
+// Delete deletes a variant
+func (t *Tailor) Delete(ctx context.Context, id string) error {
+	ctx = infra.WithOperation(ctx, "variant.delete")
+	return t.db.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE "id"=$1`, t.table), id)
+}
+
+// Search returns the variants of query q in creation order
+func (t *Tailor) Search(ctx context.Context, q Query, skip, limit int) ([]interface{}, error) {
+	ctx = infra.WithOperation(ctx, "variant.search")
+	qstmt, vals := buildVariantQuery(q)
+	str := fmt.Sprintf(`SELECT %s FROM %s`, variantColumns, t.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+	str = str + fmt.Sprintf(` ORDER BY "created_at", "id" OFFSET $%d LIMIT $%d`, len(vals)+1, len(vals)+2)
+	vals = append(vals, skip, limit)
+
+	rows, err := t.db.Query(ctx, str, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vrts := []interface{}{}
+	for rows.Next() {
+		vrt, err := scanVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		vrts = append(vrts, vrt)
+	}
+	return vrts, nil
+}
+
+// SearchCount returns number of variants that matches query
+func (t *Tailor) SearchCount(ctx context.Context, q Query) (int, error) {
+	ctx = infra.WithOperation(ctx, "variant.search_count")
+	qstmt, vals := buildVariantQuery(q)
+	str := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, t.table)
+	if len(vals) != 0 {
+		str = str + " WHERE " + qstmt
+	}
+
+	rows, err := t.db.Query(ctx, str, vals...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+	var n int
+	if err := rows.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// duplicateVariant maps the unique violations of the variants
+// to ErrDuplicateVariant and ErrDuplicateSKU
+func duplicateVariant(err error) error {
+	uerr, ok := err.(infra.UniqueViolationError)
+	if !ok {
+		return err
+	}
+	switch uerr.Constraint {
+	case "product_variants_options_key":
+		return ErrDuplicateVariant
+	case "product_variants_sku_key":
+		return ErrDuplicateSKU
+	}
+	return err
+}
+
+func buildVariantQuery(q Query) (string, []interface{}) {
+	str := ""
+	vals := []interface{}{}
+	if pdtID := q["product_id"]; len(pdtID) != 0 {
+		vals = append(vals, fmt.Sprint(pdtID[0]))
+		str = fmt.Sprintf(`"product_id" = $%d`, len(vals))
+	}
+	return str, vals
+}
+
+// RemoveProducts permanently removes the variants of the products of pdtIDs
+func (t *Tailor) RemoveProducts(ctx context.Context, pdtIDs []string) error {
+	ctx = infra.WithOperation(ctx, "variant.remove_products")
+	return removeProducts(ctx, t.db, t.table, pdtIDs)
+}
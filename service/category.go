@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+
+	"github.com/msyrus/simple-product-inv/log"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Category holds fields and dependencies to serve product categories
+type Category struct {
+	catRepo repo.Category
+	pdtRepo repo.Product
+	uow     repo.UnitOfWork
+	olgr    log.Logger
+	elgr    log.Logger
+}
+
+// CategoryOpt represents options for NewCategory
+type CategoryOpt interface {
+	Apply(c *Category)
+}
+
+// CategoryOptFunc is an implementation of CategoryOpt
+type CategoryOptFunc func(c *Category)
+
+// Apply calls f
+func (f CategoryOptFunc) Apply(c *Category) {
+	f(c)
+}
+
+// SetCategoryOutputLogger sets Category service output logger
+func SetCategoryOutputLogger(l log.Logger) CategoryOpt {
+	return CategoryOptFunc(func(c *Category) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		c.olgr = l
+	})
+}
+
+// SetCategoryErrorLogger sets Category service error logger
+func SetCategoryErrorLogger(l log.Logger) CategoryOpt {
+	return CategoryOptFunc(func(c *Category) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		c.elgr = l
+	})
+}
+
+// SetCategoryUnitOfWork sets the UnitOfWork used by Category service
+// without it the checks and the changes they guard are not atomic
+func SetCategoryUnitOfWork(u repo.UnitOfWork) CategoryOpt {
+	return CategoryOptFunc(func(c *Category) {
+		c.uow = u
+	})
+}
+
+// NewCategory returns a new Category service
+func NewCategory(cat repo.Category, pdt repo.Product, opts ...CategoryOpt) *Category {
+	c := &Category{
+		catRepo: cat,
+		pdtRepo: pdt,
+		olgr:    log.DefaultOutputLogger,
+		elgr:    log.DefaultErrorLogger,
+	}
+	for _, opt := range opts {
+		opt.Apply(c)
+	}
+	return c
+}
+
+// Add creates a new category under its parent if it has one
+func (c *Category) Add(ctx context.Context, cat model.Category) (string, error) {
+	c.olgr.Println("creating category", cat)
+	var id string
+	err := c.transact(ctx, func(r repo.Repos) error {
+		if err := checkParent(ctx, r.Category, "", cat.ParentID); err != nil {
+			return err
+		}
+		var err error
+		id, err = r.Category.Create(ctx, cat)
+		return err
+	})
+	if err != nil {
+		c.elgr.Println("failed to create category", cat, err)
+		return "", err
+	}
+	c.olgr.Println("created category", id)
+	return id, nil
+}
+
+// Get returns a model.Category finding by its id
+func (c *Category) Get(ctx context.Context, id string) (*model.Category, error) {
+	c.olgr.Println("fetching category by id", id)
+	cat, err := fetchCategory(ctx, c.catRepo, id)
+	if err != nil {
+		c.elgr.Println("failed to fetch category by id", id, err)
+		return nil, err
+	}
+	c.olgr.Println("fetched category by id", id)
+	return cat, nil
+}
+
+// Update updates a category finding it with id
+// it returns model.ValidationError if the new parent is the category or one of its descendants
+func (c *Category) Update(ctx context.Context, id string, cat model.Category) error {
+	c.olgr.Println("updating category by id", id)
+	err := c.transact(ctx, func(r repo.Repos) error {
+		// the tree is locked so that a concurrent update can not close a cycle
+		if err := r.Category.LockTree(ctx); err != nil {
+			return err
+		}
+		if err := checkParent(ctx, r.Category, id, cat.ParentID); err != nil {
+			return err
+		}
+		return r.Category.Update(ctx, id, cat)
+	})
+	if err != nil {
+		c.elgr.Println("failed to update category by id", id, err)
+		return err
+	}
+	c.olgr.Println("updated category by id", id)
+	return nil
+}
+
+// Remove deletes a category by its id, its products are unassigned from it
+// it returns ErrCategoryHasChildren if the category has subcategories
+func (c *Category) Remove(ctx context.Context, id string) error {
+	c.olgr.Println("deleting category by id", id)
+	err := c.transact(ctx, func(r repo.Repos) error {
+		if _, err := fetchCategory(ctx, r.Category, id); err != nil {
+			return err
+		}
+		n, err := r.Category.SearchCount(ctx, repo.Query{"parent_id": {id}})
+		if err != nil {
+			return err
+		}
+		if n != 0 {
+			return ErrCategoryHasChildren
+		}
+		return r.Category.Delete(ctx, id)
+	})
+	if err != nil {
+		c.elgr.Println("failed to delete category by id", id, err)
+		return err
+	}
+	c.olgr.Println("deleted category by id", id)
+	return nil
+}
+
+// List returns the categories with skip and limit ordered by name
+func (c *Category) List(ctx context.Context, skip, limit int) ([]model.Category, error) {
+	c.olgr.Println("listing categories", skip, limit)
+	res, err := c.catRepo.List(ctx, skip, limit)
+	if err != nil {
+		c.elgr.Println("failed to list categories", skip, limit, err)
+		return nil, err
+	}
+	cats, err := c.assert(res)
+	if err != nil {
+		return nil, err
+	}
+	c.olgr.Println("listed categories", skip, limit)
+	return cats, nil
+}
+
+// Count returns number of categories
+func (c *Category) Count(ctx context.Context) (int, error) {
+	c.olgr.Println("counting categories")
+	n, err := c.catRepo.Count(ctx)
+	if err != nil {
+		c.elgr.Println("failed to count categories", err)
+		return 0, err
+	}
+	c.olgr.Println("counted categories")
+	return n, nil
+}
+
+// Descendants returns ids along with the ids of all of their descendant categories
+func (c *Category) Descendants(ctx context.Context, ids []string) ([]string, error) {
+	c.olgr.Println("finding descendants of categories", ids)
+	all, err := c.catRepo.Descendants(ctx, ids)
+	if err != nil {
+		c.elgr.Println("failed to find descendants of categories", ids, err)
+		return nil, err
+	}
+	c.olgr.Println("found descendants of categories", all)
+	return all, nil
+}
+
+// Assign replaces the categories of a product with the categories of catIDs
+func (c *Category) Assign(ctx context.Context, pdtID string, catIDs []string) error {
+	c.olgr.Println("assigning categories of product", pdtID, catIDs)
+	err := c.transact(ctx, func(r repo.Repos) error {
+		pdtI, err := r.Product.Fetch(ctx, pdtID)
+		if err != nil {
+			return err
+		}
+		if pdtI == nil {
+			return ErrProductNotFound
+		}
+		for _, id := range catIDs {
+			if _, err := fetchCategory(ctx, r.Category, id); err != nil {
+				return err
+			}
+		}
+		return r.Category.Assign(ctx, pdtID, catIDs)
+	})
+	if err != nil {
+		c.elgr.Println("failed to assign categories of product", pdtID, err)
+		return err
+	}
+	c.olgr.Println("assigned categories of product", pdtID)
+	return nil
+}
+
+// ProductCategories returns the categories a product is assigned to
+func (c *Category) ProductCategories(ctx context.Context, pdtID string) ([]model.Category, error) {
+	c.olgr.Println("listing categories of product", pdtID)
+	pdtI, err := c.pdtRepo.Fetch(ctx, pdtID)
+	if err != nil {
+		c.elgr.Println("failed to fetch product", pdtID, err)
+		return nil, err
+	}
+	if pdtI == nil {
+		return nil, ErrProductNotFound
+	}
+	ids, err := c.catRepo.Assigned(ctx, pdtID)
+	if err != nil {
+		c.elgr.Println("failed to list categories of product", pdtID, err)
+		return nil, err
+	}
+	cats := []model.Category{}
+	for _, id := range ids {
+		cat, err := fetchCategory(ctx, c.catRepo, id)
+		if err != nil {
+			c.elgr.Println("failed to fetch category", id, err)
+			return nil, err
+		}
+		cats = append(cats, *cat)
+	}
+	c.olgr.Println("listed categories of product", pdtID)
+	return cats, nil
+}
+
+// assigned returns the category ids of the products of pdtIDs by product id
+func (c *Category) assigned(ctx context.Context, pdtIDs []string) (map[string][]string, error) {
+	return c.catRepo.AssignedAll(ctx, pdtIDs)
+}
+
+func (c *Category) assert(res []interface{}) ([]model.Category, error) {
+	cats := []model.Category{}
+	for _, re := range res {
+		cat, ok := re.(model.Category)
+		if !ok {
+			c.elgr.Printf("failed to assert model.Category %#v\n", re)
+			return nil, ErrFailedToAssert
+		}
+		cats = append(cats, cat)
+	}
+	return cats, nil
+}
+
+// transact runs fn with the repos bound to a single unit of work
+// if no UnitOfWork is set fn runs with the service repos directly
+func (c *Category) transact(ctx context.Context, fn func(r repo.Repos) error) error {
+	if c.uow == nil {
+		return fn(repo.Repos{
+			Product:  c.pdtRepo,
+			Category: c.catRepo,
+		})
+	}
+	return c.uow.Do(ctx, fn)
+}
+
+// checkParent checks that parentID is a valid parent of category id
+// the parent must exist and must not be the category or one of its descendants
+// id is empty for a category that is not created yet
+func checkParent(ctx context.Context, rep repo.Category, id, parentID string) error {
+	seen := map[string]bool{}
+	for cur := parentID; cur != "" && !seen[cur]; {
+		if cur == id {
+			return model.ValidationError{"ParentID": []string{"creates a cycle"}}
+		}
+		seen[cur] = true
+
+		cat, err := fetchCategory(ctx, rep, cur)
+		if err == ErrCategoryNotFound && cur == parentID {
+			return model.ValidationError{"ParentID": []string{"does not exist"}}
+		}
+		if err == ErrCategoryNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		cur = cat.ParentID
+	}
+	return nil
+}
+
+// fetchCategory returns the category of id from rep
+// it returns ErrCategoryNotFound if there is none
+func fetchCategory(ctx context.Context, rep repo.Category, id string) (*model.Category, error) {
+	catI, err := rep.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if catI == nil {
+		return nil, ErrCategoryNotFound
+	}
+	cat, ok := catI.(model.Category)
+	if !ok {
+		return nil, ErrFailedToAssert
+	}
+	return &cat, nil
+}
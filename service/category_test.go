@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+)
+
+func TestCategory_Tree(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	catSvc := NewCategory(rps.Category, rps.Product,
+		SetCategoryUnitOfWork(store),
+		SetCategoryOutputLogger(nil),
+		SetCategoryErrorLogger(nil),
+	)
+
+	add := func(name, parentID string) string {
+		id, err := catSvc.Add(ctx, model.Category{Name: name, ParentID: parentID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	food := add("Food", "")
+	fruit := add("Fruit", food)
+	mango := add("Mango", fruit)
+	drink := add("Drink", "")
+
+	if _, err := catSvc.Add(ctx, model.Category{Name: "Apple", ParentID: "unavailable_id"}); err == nil {
+		t.Errorf("Category.Add() with unknown parent error = nil")
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		parentID string
+		wantErr  bool
+	}{
+		{name: "self", id: food, parentID: food, wantErr: true},
+		{name: "child", id: food, parentID: fruit, wantErr: true},
+		{name: "grandchild", id: food, parentID: mango, wantErr: true},
+		{name: "unknown", id: drink, parentID: "unavailable_id", wantErr: true},
+		{name: "sibling", id: drink, parentID: food, wantErr: false},
+		{name: "root", id: drink, parentID: "", wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cat, err := catSvc.Get(ctx, tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cat.ParentID = tt.parentID
+			err = catSvc.Update(ctx, tt.id, *cat)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Category.Update() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, ok := err.(model.ValidationError); tt.wantErr && !ok {
+				t.Errorf("Category.Update() error = %#v, want model.ValidationError", err)
+			}
+		})
+	}
+
+	got, err := catSvc.Descendants(ctx, []string{food, drink})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	want := []string{food, fruit, mango, drink}
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Category.Descendants() = %v, want %v", got, want)
+	}
+
+	if err := catSvc.Remove(ctx, fruit); err != ErrCategoryHasChildren {
+		t.Errorf("Category.Remove() error = %v, want %v", err, ErrCategoryHasChildren)
+	}
+	if err := catSvc.Remove(ctx, mango); err != nil {
+		t.Errorf("Category.Remove() error = %v", err)
+	}
+	if err := catSvc.Remove(ctx, mango); err != ErrCategoryNotFound {
+		t.Errorf("Category.Remove() error = %v, want %v", err, ErrCategoryNotFound)
+	}
+}
+
+func TestCategory_UpdateConcurrent(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	catSvc := NewCategory(rps.Category, rps.Product,
+		SetCategoryUnitOfWork(store),
+		SetCategoryOutputLogger(nil),
+		SetCategoryErrorLogger(nil),
+	)
+
+	ids := []string{}
+	for _, name := range []string{"Food", "Drink"} {
+		id, err := catSvc.Add(ctx, model.Category{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	// each category is moved under the other, only one of the moves may succeed
+	errs := make(chan error, 2)
+	for i, id := range ids {
+		go func(id, parentID string) {
+			errs <- catSvc.Update(ctx, id, model.Category{ID: id, Name: "Moved", ParentID: parentID})
+		}(id, ids[1-i])
+	}
+	failed := 0
+	for range ids {
+		if err := <-errs; err != nil {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("Category.Update() concurrent failures = %v, want 1", failed)
+	}
+
+	if n, err := rps.Category.SearchCount(ctx, repo.Query{"parent_id": {""}}); n != 1 || err != nil {
+		t.Errorf("root categories after Category.Update() = %v, %v, want 1", n, err)
+	}
+}
+
+func TestCategory_Assign(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	catSvc := NewCategory(rps.Category, rps.Product,
+		SetCategoryUnitOfWork(store),
+		SetCategoryOutputLogger(nil),
+		SetCategoryErrorLogger(nil),
+	)
+	pdtSvc := NewProduct(rps.Product, NewRating(rps.Rating),
+		SetProductCategoryService(catSvc),
+		SetProductOutputLogger(nil),
+		SetProductErrorLogger(nil),
+	)
+
+	food, _ := catSvc.Add(ctx, model.Category{Name: "Food"})
+	fruit, _ := catSvc.Add(ctx, model.Category{Name: "Fruit", ParentID: food})
+	rice, _ := pdtSvc.Add(ctx, model.Product{Name: "Rice", Price: 100, Weight: 1})
+	mango, _ := pdtSvc.Add(ctx, model.Product{Name: "Mango", Price: 100, Weight: 1})
+
+	if err := catSvc.Assign(ctx, "unavailable_id", []string{food}); err != ErrProductNotFound {
+		t.Errorf("Category.Assign() error = %v, want %v", err, ErrProductNotFound)
+	}
+	if err := catSvc.Assign(ctx, rice, []string{food, "unavailable_id"}); err != ErrCategoryNotFound {
+		t.Errorf("Category.Assign() error = %v, want %v", err, ErrCategoryNotFound)
+	}
+	if err := catSvc.Assign(ctx, rice, []string{food}); err != nil {
+		t.Fatal(err)
+	}
+	if err := catSvc.Assign(ctx, mango, []string{fruit}); err != nil {
+		t.Fatal(err)
+	}
+
+	cats, err := catSvc.ProductCategories(ctx, mango)
+	if err != nil || len(cats) != 1 || cats[0].ID != fruit {
+		t.Errorf("Category.ProductCategories() = %v, %v, want [%v]", cats, err, fruit)
+	}
+
+	tests := []struct {
+		name string
+		prms url.Values
+		want []string
+	}{
+		{name: "category", prms: url.Values{"category": {food}}, want: []string{rice}},
+		{name: "descendants", prms: url.Values{"category": {food}, "descendants": {"true"}}, want: []string{rice, mango}},
+		{name: "many", prms: url.Values{"category": {food, fruit}}, want: []string{rice, mango}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdts, err := pdtSvc.Find(ctx, tt.prms, 0, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, pdt := range pdts {
+				got = append(got, pdt.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Product.Find() = %v, want %v", got, tt.want)
+			}
+			if n, err := pdtSvc.Count(ctx, tt.prms); err != nil || n != len(tt.want) {
+				t.Errorf("Product.Count() = %v, %v, want %v", n, err, len(tt.want))
+			}
+		})
+	}
+}
@@ -17,6 +17,66 @@ func (e NotFoundError) Error() string {
 // ErrProductNotFound error is returned when a product not found
 var ErrProductNotFound = NotFoundError{"product"}
 
+// ErrReservationNotFound error is returned when a reservation not found
+var ErrReservationNotFound = NotFoundError{"reservation"}
+
+// ErrLocationNotFound error is returned when a location not found
+var ErrLocationNotFound = NotFoundError{"location"}
+
+// ErrCategoryNotFound error is returned when a category not found
+var ErrCategoryNotFound = NotFoundError{"category"}
+
+// ErrVariantNotFound error is returned when a variant not found
+var ErrVariantNotFound = NotFoundError{"variant"}
+
+// ErrPromotionNotFound error is returned when a promotion not found
+var ErrPromotionNotFound = NotFoundError{"promotion"}
+
+// ErrRatingNotFound error is returned when a rating not found
+var ErrRatingNotFound = NotFoundError{"rating"}
+
+// ConflictError holds the reason a request conflicts with the current state
+type ConflictError struct {
+	reason string
+}
+
+func (e ConflictError) Error() string {
+	return e.reason
+}
+
+// ErrInsufficientStock error is returned when a product has fewer units than required
+var ErrInsufficientStock = ConflictError{"insufficient stock"}
+
+// ErrReservationNotPending error is returned when a reservation is already confirmed, cancelled or expired
+var ErrReservationNotPending = ConflictError{"reservation is not pending"}
+
+// ErrReservationExpired error is returned when a reservation is past its expiry
+var ErrReservationExpired = ConflictError{"reservation is expired"}
+
+// ErrCategoryHasChildren error is returned when a category with subcategories is removed
+var ErrCategoryHasChildren = ConflictError{"category has subcategories"}
+
+// ErrSKUExists error is returned when a sku is already used by another product
+var ErrSKUExists = ConflictError{"sku already exists"}
+
+// ErrBarcodeExists error is returned when a barcode is already used by another product
+var ErrBarcodeExists = ConflictError{"barcode already exists"}
+
+// ErrInvalidTransition error is returned when a product can not move from its status to the requested one
+var ErrInvalidTransition = ConflictError{"invalid product status transition"}
+
+// ErrVariantExists error is returned when a product already has a variant of the same options
+var ErrVariantExists = ConflictError{"variant options already exist"}
+
+// ErrRatingExists error is returned when a user has already rated the product
+var ErrRatingExists = ConflictError{"product is already rated by the user"}
+
+// ErrInvalidReviewTransition error is returned when a review can not move from its status to the requested one
+var ErrInvalidReviewTransition = ConflictError{"invalid review status transition"}
+
+// ErrOwnReview error is returned when a user moderates a review of its own
+var ErrOwnReview = ConflictError{"review is written by the moderator"}
+
 type noOpLogger struct{}
 
 func (l *noOpLogger) Print(...interface{}) {
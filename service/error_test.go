@@ -21,3 +21,23 @@ func TestNotFoundError_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestConflictError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		e    ConflictError
+		want string
+	}{
+		{
+			e:    ErrInsufficientStock,
+			want: "insufficient stock",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.Error(); got != tt.want {
+				t.Errorf("ConflictError.Error() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+
+	"github.com/msyrus/simple-product-inv/log"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Location holds fields and dependencies to serve stock locations
+type Location struct {
+	locRepo repo.Location
+	olgr    log.Logger
+	elgr    log.Logger
+}
+
+// LocationOpt represents options for NewLocation
+type LocationOpt interface {
+	Apply(l *Location)
+}
+
+// LocationOptFunc is an implementation of LocationOpt
+type LocationOptFunc func(l *Location)
+
+// Apply calls f
+func (f LocationOptFunc) Apply(l *Location) {
+	f(l)
+}
+
+// SetLocationOutputLogger sets Location service output logger
+func SetLocationOutputLogger(lgr log.Logger) LocationOpt {
+	return LocationOptFunc(func(l *Location) {
+		if lgr == nil {
+			lgr = &noOpLogger{}
+		}
+		l.olgr = lgr
+	})
+}
+
+// SetLocationErrorLogger sets Location service error logger
+func SetLocationErrorLogger(lgr log.Logger) LocationOpt {
+	return LocationOptFunc(func(l *Location) {
+		if lgr == nil {
+			lgr = &noOpLogger{}
+		}
+		l.elgr = lgr
+	})
+}
+
+// NewLocation returns a new Location service
+func NewLocation(rep repo.Location, opts ...LocationOpt) *Location {
+	l := &Location{
+		locRepo: rep,
+		olgr:    log.DefaultOutputLogger,
+		elgr:    log.DefaultErrorLogger,
+	}
+	for _, opt := range opts {
+		opt.Apply(l)
+	}
+	return l
+}
+
+// Add creates a new location
+func (l *Location) Add(ctx context.Context, loc model.Location) (string, error) {
+	l.olgr.Println("creating location", loc)
+	id, err := l.locRepo.Create(ctx, loc)
+	if err != nil {
+		l.elgr.Println("failed to create location", loc, err)
+		return "", err
+	}
+	l.olgr.Println("created location", id)
+	return id, nil
+}
+
+// Get returns a model.Location finding by its id
+func (l *Location) Get(ctx context.Context, id string) (*model.Location, error) {
+	l.olgr.Println("fetching location by id", id)
+	loc, err := fetchLocation(ctx, l.locRepo, id)
+	if err != nil {
+		l.elgr.Println("failed to fetch location by id", id, err)
+		return nil, err
+	}
+	l.olgr.Println("fetched location by id", id)
+	return loc, nil
+}
+
+// Update updates a location finding it with id
+func (l *Location) Update(ctx context.Context, id string, loc model.Location) error {
+	l.olgr.Println("updating location by id", id)
+	if err := l.locRepo.Update(ctx, id, loc); err != nil {
+		l.elgr.Println("failed to update location by id", id, err)
+		return err
+	}
+	l.olgr.Println("updated location by id", id)
+	return nil
+}
+
+// List returns the locations with skip and limit ordered by name
+func (l *Location) List(ctx context.Context, skip, limit int) ([]model.Location, error) {
+	l.olgr.Println("listing locations", skip, limit)
+	res, err := l.locRepo.List(ctx, skip, limit)
+	if err != nil {
+		l.elgr.Println("failed to list locations", skip, limit, err)
+		return nil, err
+	}
+	locs := []model.Location{}
+	for _, re := range res {
+		loc, ok := re.(model.Location)
+		if !ok {
+			l.elgr.Printf("failed to assert model.Location %#v\n", re)
+			return nil, ErrFailedToAssert
+		}
+		locs = append(locs, loc)
+	}
+	l.olgr.Println("listed locations", skip, limit)
+	return locs, nil
+}
+
+// Count returns number of locations
+func (l *Location) Count(ctx context.Context) (int, error) {
+	l.olgr.Println("counting locations")
+	n, err := l.locRepo.Count(ctx)
+	if err != nil {
+		l.elgr.Println("failed to count locations", err)
+		return 0, err
+	}
+	l.olgr.Println("counted locations")
+	return n, nil
+}
+
+// fetchLocation returns the location of id from rep
+// it returns ErrLocationNotFound if there is none
+func fetchLocation(ctx context.Context, rep repo.Location, id string) (*model.Location, error) {
+	locI, err := rep.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if locI == nil {
+		return nil, ErrLocationNotFound
+	}
+	loc, ok := locI.(model.Location)
+	if !ok {
+		return nil, ErrFailedToAssert
+	}
+	return &loc, nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+)
+
+func TestLocation(t *testing.T) {
+	rps := memory.NewStore().Repos()
+	ctx := context.Background()
+	locSvc := NewLocation(rps.Location, SetLocationOutputLogger(nil), SetLocationErrorLogger(nil))
+
+	ids := []string{}
+	for _, name := range []string{"Dhaka", "Chittagong"} {
+		id, err := locSvc.Add(ctx, model.Location{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if _, err := locSvc.Add(ctx, model.Location{}); err == nil {
+		t.Errorf("Location.Add() without name error = nil, want validation error")
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		loc     model.Location
+		wantErr bool
+	}{
+		{name: "update", id: ids[0], loc: model.Location{ID: ids[0], Name: "Dhaka", Address: "Tejgaon"}},
+		{name: "empty name", id: ids[0], loc: model.Location{ID: ids[0]}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := locSvc.Update(ctx, tt.id, tt.loc); (err != nil) != tt.wantErr {
+				t.Fatalf("Location.Update() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	loc, err := locSvc.Get(ctx, ids[0])
+	if err != nil || loc.Name != "Dhaka" || loc.Address != "Tejgaon" {
+		t.Errorf("Location.Get() = %v, %v, want the updated location", loc, err)
+	}
+	if _, err := locSvc.Get(ctx, "unavailable_id"); err != ErrLocationNotFound {
+		t.Errorf("Location.Get() error = %v, want %v", err, ErrLocationNotFound)
+	}
+
+	if n, err := locSvc.Count(ctx); n != 2 || err != nil {
+		t.Errorf("Location.Count() = %v, %v, want 2", n, err)
+	}
+	locs, err := locSvc.List(ctx, 0, 10)
+	if err != nil || len(locs) != 2 || locs[0].ID != ids[1] || locs[1].ID != ids[0] {
+		t.Errorf("Location.List() = %v, %v, want the locations by name", locs, err)
+	}
+}
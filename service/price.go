@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/log"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// DefaultPriceInterval is the default interval between two applies of the due scheduled prices
+const DefaultPriceInterval = 30 * time.Second
+
+// priceBatch is the number of due scheduled prices applied per search
+const priceBatch = 100
+
+// Price holds fields and dependencies to keep the price history of products
+type Price struct {
+	prcRepo repo.Price
+	pdtRepo repo.Product
+	uow     repo.UnitOfWork
+	now     func() time.Time
+	olgr    log.Logger
+	elgr    log.Logger
+}
+
+// PriceOpt represents options for NewPrice
+type PriceOpt interface {
+	Apply(s *Price)
+}
+
+// PriceOptFunc is an implementation of PriceOpt
+type PriceOptFunc func(s *Price)
+
+// Apply calls f
+func (f PriceOptFunc) Apply(s *Price) {
+	f(s)
+}
+
+// SetPriceOutputLogger sets Price service output logger
+func SetPriceOutputLogger(l log.Logger) PriceOpt {
+	return PriceOptFunc(func(s *Price) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		s.olgr = l
+	})
+}
+
+// SetPriceErrorLogger sets Price service error logger
+func SetPriceErrorLogger(l log.Logger) PriceOpt {
+	return PriceOptFunc(func(s *Price) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		s.elgr = l
+	})
+}
+
+// SetPriceUnitOfWork sets the UnitOfWork used by Price service
+// without it a product price and its history are not changed atomically
+func SetPriceUnitOfWork(u repo.UnitOfWork) PriceOpt {
+	return PriceOptFunc(func(s *Price) {
+		s.uow = u
+	})
+}
+
+// SetPriceClock sets the func Price service reads the current time from
+func SetPriceClock(now func() time.Time) PriceOpt {
+	return PriceOptFunc(func(s *Price) {
+		if now == nil {
+			now = time.Now
+		}
+		s.now = now
+	})
+}
+
+// NewPrice returns a new Price service
+func NewPrice(prc repo.Price, pdt repo.Product, opts ...PriceOpt) *Price {
+	s := &Price{
+		prcRepo: prc,
+		pdtRepo: pdt,
+		now:     time.Now,
+		olgr:    log.DefaultOutputLogger,
+		elgr:    log.DefaultErrorLogger,
+	}
+	for _, opt := range opts {
+		opt.Apply(s)
+	}
+	return s
+}
+
+// Schedule changes the price of a product at time at and returns the price change id
+// a zero or past at changes the price right away, a later one is scheduled
+// and applied by ApplyDue once it is due
+func (s *Price) Schedule(ctx context.Context, pdtID string, price int, at time.Time) (string, error) {
+	s.olgr.Println("scheduling price of", pdtID, price, at)
+	now := s.now()
+	var id string
+	err := s.transact(ctx, func(r repo.Repos) error {
+		pdt, err := s.product(ctx, r.Product, pdtID)
+		if err != nil {
+			return err
+		}
+		if at.After(now) {
+			id, err = r.Price.Create(ctx, model.PriceChange{
+				ProductID:   pdtID,
+				Price:       price,
+				Status:      model.PriceScheduled,
+				EffectiveAt: at,
+			})
+			return err
+		}
+		id, err = s.apply(ctx, r, *pdt, price, now)
+		return err
+	})
+	if err != nil {
+		s.elgr.Println("failed to schedule price of", pdtID, err)
+		return "", err
+	}
+	s.olgr.Println("scheduled price of", pdtID, id)
+	return id, nil
+}
+
+// History returns the price changes of a product with skip and limit ordered by their effective time
+func (s *Price) History(ctx context.Context, pdtID string, skip, limit int) ([]model.PriceChange, error) {
+	s.olgr.Println("listing prices of", pdtID, skip, limit)
+	res, err := s.prcRepo.Search(ctx, repo.Query{"product_id": {pdtID}}, skip, limit)
+	if err != nil {
+		s.elgr.Println("failed to list prices of", pdtID, skip, limit, err)
+		return nil, err
+	}
+	prcs := []model.PriceChange{}
+	for _, re := range res {
+		prc, ok := re.(model.PriceChange)
+		if !ok {
+			s.elgr.Printf("failed to assert model.PriceChange %#v\n", re)
+			return nil, ErrFailedToAssert
+		}
+		prcs = append(prcs, prc)
+	}
+	s.olgr.Println("listed prices of", pdtID, skip, limit)
+	return prcs, nil
+}
+
+// Count returns number of price changes of a product
+// it returns ErrProductNotFound if there is no such product
+func (s *Price) Count(ctx context.Context, pdtID string) (int, error) {
+	s.olgr.Println("counting prices of", pdtID)
+	if _, err := s.product(ctx, s.pdtRepo, pdtID); err != nil {
+		s.elgr.Println("failed to fetch product", pdtID, err)
+		return 0, err
+	}
+	n, err := s.prcRepo.SearchCount(ctx, repo.Query{"product_id": {pdtID}})
+	if err != nil {
+		s.elgr.Println("failed to count prices of", pdtID, err)
+		return 0, err
+	}
+	s.olgr.Println("counted prices of", pdtID)
+	return n, nil
+}
+
+// ApplyDue applies the scheduled prices past their effective time
+// and returns the number of applied prices, the ones of deleted products
+// are cancelled and the ones applied concurrently are skipped
+func (s *Price) ApplyDue(ctx context.Context) (int, error) {
+	q := repo.Query{
+		"status":           {string(model.PriceScheduled)},
+		"effective_before": {s.now()},
+	}
+	n := 0
+	for {
+		res, err := s.prcRepo.Search(ctx, q, 0, priceBatch)
+		if err != nil {
+			s.elgr.Println("failed to search due prices", err)
+			return n, err
+		}
+		for _, re := range res {
+			prc, ok := re.(model.PriceChange)
+			if !ok {
+				s.elgr.Printf("failed to assert model.PriceChange %#v\n", re)
+				return n, ErrFailedToAssert
+			}
+			applied := false
+			err := s.transact(ctx, func(r repo.Repos) error {
+				to := model.PriceApplied
+				pdt, err := s.product(ctx, r.Product, prc.ProductID)
+				if err == ErrProductNotFound {
+					to = model.PriceCancelled
+				} else if err != nil {
+					return err
+				}
+				err = r.Price.SetStatus(ctx, prc.ID, string(model.PriceScheduled), string(to))
+				if err != nil || to == model.PriceCancelled {
+					return err
+				}
+				applied = true
+				return r.Product.SetPrice(ctx, pdt.ID, prc.Price)
+			})
+			if err == repo.ErrStaleStatus {
+				continue
+			}
+			if err != nil {
+				s.elgr.Println("failed to apply price", prc.ID, err)
+				return n, err
+			}
+			if applied {
+				n++
+			}
+		}
+		if len(res) < priceBatch {
+			break
+		}
+	}
+	if n != 0 {
+		s.olgr.Println("applied scheduled prices", n)
+	}
+	return n, nil
+}
+
+// RunScheduler applies the due scheduled prices every interval until ctx is done
+// a zero interval means DefaultPriceInterval
+func (s *Price) RunScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPriceInterval
+	}
+	tkr := time.NewTicker(interval)
+	defer tkr.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tkr.C:
+			s.ApplyDue(ctx)
+		}
+	}
+}
+
+// apply changes the price of pdt right away and records it as applied at at
+func (s *Price) apply(ctx context.Context, r repo.Repos, pdt model.Product, price int, at time.Time) (string, error) {
+	id, err := s.record(ctx, r.Price, pdt.ID, price, at)
+	if err != nil {
+		return "", err
+	}
+	if err := r.Product.SetPrice(ctx, pdt.ID, price); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// record stores price as the applied price of product pdtID since at
+func (s *Price) record(ctx context.Context, rep repo.Price, pdtID string, price int, at time.Time) (string, error) {
+	if at.IsZero() {
+		at = s.now()
+	}
+	return rep.Create(ctx, model.PriceChange{
+		ProductID:   pdtID,
+		Price:       price,
+		Status:      model.PriceApplied,
+		EffectiveAt: at,
+	})
+}
+
+// product returns the product of id from rep
+func (s *Price) product(ctx context.Context, rep repo.Product, id string) (*model.Product, error) {
+	pdtI, err := rep.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pdtI == nil {
+		return nil, ErrProductNotFound
+	}
+	pdt, ok := pdtI.(model.Product)
+	if !ok {
+		s.elgr.Printf("failed to assert model.Product %#v\n", pdtI)
+		return nil, ErrFailedToAssert
+	}
+	return &pdt, nil
+}
+
+// transact runs fn with the repos bound to a single unit of work
+// if no UnitOfWork is set fn runs with the service repos directly
+func (s *Price) transact(ctx context.Context, fn func(r repo.Repos) error) error {
+	if s.uow == nil {
+		return fn(repo.Repos{
+			Product: s.pdtRepo,
+			Price:   s.prcRepo,
+		})
+	}
+	return s.uow.Do(ctx, fn)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+)
+
+func TestPrice(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	now := time.Now()
+	prcSvc := NewPrice(rps.Price, rps.Product,
+		SetPriceUnitOfWork(store),
+		SetPriceClock(func() time.Time { return now }),
+		SetPriceOutputLogger(nil),
+		SetPriceErrorLogger(nil),
+	)
+	pdtSvc := NewProduct(rps.Product, NewRating(rps.Rating),
+		SetProductUnitOfWork(store),
+		SetProductPriceService(prcSvc),
+		SetProductOutputLogger(nil),
+		SetProductErrorLogger(nil),
+	)
+
+	id, err := pdtSvc.Add(ctx, model.Product{Name: "Shirt", Price: 100, Weight: 1, Tags: []string{"summer"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdt, _ := pdtSvc.Get(ctx, id)
+	pdt.Tags = nil
+	if err := pdtSvc.Update(ctx, id, *pdt); err != nil {
+		t.Fatalf("Product.Update() same price error = %v", err)
+	}
+	pdt.Price = 90
+	if err := pdtSvc.Update(ctx, id, *pdt); err != nil {
+		t.Fatalf("Product.Update() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		pdtID     string
+		price     int
+		at        time.Time
+		wantPrice int
+		wantErr   bool
+	}{
+		{name: "unknown product", pdtID: "unavailable_id", price: 80, wantPrice: 90, wantErr: true},
+		{name: "invalid price", pdtID: id, price: 0, at: now.Add(time.Hour), wantPrice: 90, wantErr: true},
+		{name: "scheduled", pdtID: id, price: 70, at: now.Add(time.Hour), wantPrice: 90},
+		{name: "right away", pdtID: id, price: 80, wantPrice: 80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := prcSvc.Schedule(ctx, tt.pdtID, tt.price, tt.at); (err != nil) != tt.wantErr {
+				t.Fatalf("Price.Schedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if pdt, _ := pdtSvc.Get(ctx, id); pdt.Price != tt.wantPrice {
+				t.Errorf("Product.Get() price = %v, want %v", pdt.Price, tt.wantPrice)
+			}
+		})
+	}
+
+	if n, err := prcSvc.ApplyDue(ctx); err != nil || n != 0 {
+		t.Errorf("Price.ApplyDue() before due = %v, %v, want 0", n, err)
+	}
+	now = now.Add(2 * time.Hour)
+	if n, err := prcSvc.ApplyDue(ctx); err != nil || n != 1 {
+		t.Errorf("Price.ApplyDue() = %v, %v, want 1", n, err)
+	}
+	if pdt, _ := pdtSvc.Get(ctx, id); pdt.Price != 70 || len(pdt.Tags) != 1 {
+		t.Errorf("Product.Get() after ApplyDue() = %#v, want price 70 with its tags", pdt)
+	}
+
+	prcs, err := prcSvc.History(ctx, id, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{100, 90, 80, 70}
+	if len(prcs) != len(want) {
+		t.Fatalf("Price.History() = %v, want prices %v", prcs, want)
+	}
+	for i, prc := range prcs {
+		if prc.Price != want[i] || prc.Status != model.PriceApplied {
+			t.Errorf("Price.History()[%d] = %v %v, want %v applied", i, prc.Price, prc.Status, want[i])
+		}
+	}
+	if n, err := prcSvc.Count(ctx, "unavailable_id"); err != ErrProductNotFound {
+		t.Errorf("Price.Count() = %v, %v, want %v", n, err, ErrProductNotFound)
+	}
+}
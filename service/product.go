@@ -1,21 +1,32 @@
 package service
 
 import (
-	"fmt"
+	"context"
 	"net/url"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/msyrus/simple-product-inv/log"
 	"github.com/msyrus/simple-product-inv/model"
 	"github.com/msyrus/simple-product-inv/repo"
 )
 
+// purgeBatch is the number of deleted products purged per unit of work
+const purgeBatch = 100
+
 // Product holds fields and dependencies to serve product
 type Product struct {
 	pdtRepo repo.Product
 	olgr    log.Logger
 	elgr    log.Logger
 	ratSvc  *Rating
+	catSvc  *Category
+	vrtSvc  *Variant
+	prcSvc  *Price
+	prmSvc  *Promotion
+	rates   model.ExchangeRates
+	uow     repo.UnitOfWork
 }
 
 // ProductOpt represents options for NewProduct
@@ -51,6 +62,54 @@ func SetProductErrorLogger(l log.Logger) ProductOpt {
 	})
 }
 
+// SetProductUnitOfWork sets the UnitOfWork used by Product service
+// for operations that span multiple repos, without it they are not atomic
+func SetProductUnitOfWork(u repo.UnitOfWork) ProductOpt {
+	return ProductOptFunc(func(p *Product) {
+		p.uow = u
+	})
+}
+
+// SetProductCategoryService sets the Category service used by Product service
+// to include the descendant categories in the category filter
+func SetProductCategoryService(c *Category) ProductOpt {
+	return ProductOptFunc(func(p *Product) {
+		p.catSvc = c
+	})
+}
+
+// SetProductVariantService sets the Variant service used by Product service
+// to list the variants of a product
+func SetProductVariantService(v *Variant) ProductOpt {
+	return ProductOptFunc(func(p *Product) {
+		p.vrtSvc = v
+	})
+}
+
+// SetProductPriceService sets the Price service used by Product service
+// to record the price changes made by Add and Update
+func SetProductPriceService(s *Price) ProductOpt {
+	return ProductOptFunc(func(p *Product) {
+		p.prcSvc = s
+	})
+}
+
+// SetProductPromotionService sets the Promotion service used by Product service
+// to find the effective prices of products
+func SetProductPromotionService(s *Promotion) ProductOpt {
+	return ProductOptFunc(func(p *Product) {
+		p.prmSvc = s
+	})
+}
+
+// SetProductExchangeRates sets the base currency of the product prices
+// and the rates Product service converts them to other currencies by
+func SetProductExchangeRates(r model.ExchangeRates) ProductOpt {
+	return ProductOptFunc(func(p *Product) {
+		p.rates = r
+	})
+}
+
 // NewProduct returns a new Product service
 func NewProduct(rep repo.Product, rat *Rating, opts ...ProductOpt) *Product {
 	r := &Product{
@@ -65,22 +124,35 @@ func NewProduct(rep repo.Product, rat *Rating, opts ...ProductOpt) *Product {
 	return r
 }
 
-// Add creates a new product
-func (p *Product) Add(pdt model.Product) (string, error) {
+// Add creates a new product with its tags
+func (p *Product) Add(ctx context.Context, pdt model.Product) (string, error) {
 	p.olgr.Println("creating product", pdt)
-	nPdt, err := p.pdtRepo.Create(pdt)
+	var nPdt string
+	err := p.transact(ctx, func(r repo.Repos) error {
+		var err error
+		nPdt, err = r.Product.Create(ctx, pdt)
+		if err != nil || p.prcSvc == nil {
+			return err
+		}
+		_, err = p.prcSvc.record(ctx, r.Price, nPdt, pdt.Price, time.Time{})
+		return err
+	})
 	if err != nil {
 		p.elgr.Println("failed to create product", pdt)
-		return "", err
+		return "", codeConflict(err)
 	}
 	p.olgr.Println("created product", pdt)
 	return nPdt, nil
 }
 
 // Get returns a model.Product finding by its id
-func (p *Product) Get(id string) (*model.Product, error) {
+func (p *Product) Get(ctx context.Context, id string) (*model.Product, error) {
+	return p.get(ctx, p.pdtRepo, id)
+}
+
+func (p *Product) get(ctx context.Context, rep repo.Product, id string) (*model.Product, error) {
 	p.olgr.Println("fetching product by id", id)
-	pdtI, err := p.pdtRepo.Fetch(id)
+	pdtI, err := rep.Fetch(ctx, id)
 	if err != nil {
 		p.elgr.Println("failed to fetch product by id", id, err)
 		return nil, err
@@ -98,23 +170,118 @@ func (p *Product) Get(id string) (*model.Product, error) {
 	return &pdt, nil
 }
 
+// GetBySKU returns a model.Product finding by its sku
+func (p *Product) GetBySKU(ctx context.Context, sku string) (*model.Product, error) {
+	return p.getByCode(ctx, "sku", sku)
+}
+
+// GetByBarcode returns a model.Product finding by its barcode
+func (p *Product) GetByBarcode(ctx context.Context, code string) (*model.Product, error) {
+	return p.getByCode(ctx, "barcode", code)
+}
+
+// getByCode returns the product whose field key exactly matches code
+func (p *Product) getByCode(ctx context.Context, key, code string) (*model.Product, error) {
+	p.olgr.Println("fetching product by", key, code)
+	if code == "" {
+		return nil, ErrProductNotFound
+	}
+	res, err := p.pdtRepo.Search(ctx, repo.Query{key: {code}}, 0, 1)
+	if err != nil {
+		p.elgr.Println("failed to fetch product by", key, code, err)
+		return nil, err
+	}
+	if len(res) == 0 {
+		p.elgr.Println("failed product not found", key, code)
+		return nil, ErrProductNotFound
+	}
+	pdt, ok := res[0].(model.Product)
+	if !ok {
+		p.elgr.Printf("failed to assert model.Product %#v\n", res[0])
+		return nil, ErrFailedToAssert
+	}
+	p.olgr.Println("fetched product by", key, code)
+	return &pdt, nil
+}
+
+// codeConflict maps the duplicate product code errors of repo
+// to ErrSKUExists and ErrBarcodeExists
+func codeConflict(err error) error {
+	switch err {
+	case repo.ErrDuplicateSKU:
+		return ErrSKUExists
+	case repo.ErrDuplicateBarcode:
+		return ErrBarcodeExists
+	}
+	return err
+}
+
 // Update updates a product finding it with id
-func (p *Product) Update(id string, rec model.Product) error {
+// its tags are replaced unless rec.Tags is nil
+func (p *Product) Update(ctx context.Context, id string, rec model.Product) error {
 	p.olgr.Println("updating product by id", id)
-	fmt.Printf("%#v\n", rec)
-	err := p.pdtRepo.Update(id, rec)
+	err := p.transact(ctx, func(r repo.Repos) error {
+		if p.prcSvc == nil {
+			return r.Product.Update(ctx, id, rec)
+		}
+		old, err := p.prcSvc.product(ctx, r.Product, id)
+		if err == ErrProductNotFound {
+			return r.Product.Update(ctx, id, rec)
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.Product.Update(ctx, id, rec); err != nil || old.Price == rec.Price {
+			return err
+		}
+		_, err = p.prcSvc.record(ctx, r.Price, id, rec.Price, time.Time{})
+		return err
+	})
 	if err != nil {
 		p.elgr.Println("failed to update product by id", id, err)
-		return err
+		return codeConflict(err)
 	}
 	p.olgr.Println("updated product by id", id)
 	return nil
 }
 
+// Transition moves a product finding it with id to status to and returns it
+// it returns ErrInvalidTransition if the product can not move from its status to to
+func (p *Product) Transition(ctx context.Context, id string, to model.ProductStatus) (*model.Product, error) {
+	p.olgr.Println("changing status of product", id, to)
+	if !to.Valid() {
+		return nil, model.ValidationError{"Status": []string{"is invalid"}}
+	}
+	var pdt *model.Product
+	err := p.transact(ctx, func(r repo.Repos) error {
+		old, err := p.get(ctx, r.Product, id)
+		if err != nil {
+			return err
+		}
+		if !old.Status.CanTransition(to) {
+			return ErrInvalidTransition
+		}
+		if err := r.Product.SetStatus(ctx, id, string(old.Status), string(to)); err != nil {
+			return err
+		}
+		pdt, err = p.get(ctx, r.Product, id)
+		return err
+	})
+	if err == repo.ErrStaleStatus {
+		err = ErrInvalidTransition
+	}
+	if err != nil {
+		p.elgr.Println("failed to change status of product", id, to, err)
+		return nil, err
+	}
+	p.olgr.Println("changed status of product", id, to)
+	return pdt, nil
+}
+
 // Remove deletes a product by its id
-func (p *Product) Remove(id string) error {
+func (p *Product) Remove(ctx context.Context, id string) error {
 	p.olgr.Println("deleting product by id", id)
-	err := p.pdtRepo.Delete(id)
+	err := p.pdtRepo.Delete(ctx, id)
 	if err != nil {
 		p.elgr.Println("failed to delete product by id", id, err)
 		return err
@@ -123,16 +290,121 @@ func (p *Product) Remove(id string) error {
 	return nil
 }
 
+// Deleted returns the deleted products, the latest deleted first
+func (p *Product) Deleted(ctx context.Context, skip, limit int) ([]model.Product, error) {
+	p.olgr.Println("listing deleted products")
+	res, err := p.pdtRepo.ListDeleted(ctx, skip, limit)
+	if err != nil {
+		p.elgr.Println("failed to list deleted products", err)
+		return nil, err
+	}
+	pdts := []model.Product{}
+	for _, re := range res {
+		pdt, ok := re.(model.Product)
+		if !ok {
+			p.elgr.Printf("failed to assert model.Product %#v\n", re)
+			return nil, ErrFailedToAssert
+		}
+		pdts = append(pdts, pdt)
+	}
+	p.olgr.Println("listed deleted products")
+	return pdts, nil
+}
+
+// CountDeleted returns the number of deleted products
+func (p *Product) CountDeleted(ctx context.Context) (int, error) {
+	p.olgr.Println("counting deleted products")
+	n, err := p.pdtRepo.CountDeleted(ctx)
+	if err != nil {
+		p.elgr.Println("failed to count deleted products", err)
+		return 0, err
+	}
+	p.olgr.Println("counted deleted products")
+	return n, nil
+}
+
+// Restore undeletes a deleted product and returns it
+// it returns ErrProductNotFound if there is no deleted product of id and
+// ErrSKUExists or ErrBarcodeExists if its codes are used by another product
+func (p *Product) Restore(ctx context.Context, id string) (*model.Product, error) {
+	p.olgr.Println("restoring product by id", id)
+	var pdt *model.Product
+	err := p.transact(ctx, func(r repo.Repos) error {
+		if err := r.Product.Restore(ctx, id); err != nil {
+			return err
+		}
+		var err error
+		pdt, err = p.get(ctx, r.Product, id)
+		return err
+	})
+	if err == repo.ErrNotDeleted {
+		err = ErrProductNotFound
+	}
+	if err != nil {
+		p.elgr.Println("failed to restore product by id", id, err)
+		return nil, codeConflict(err)
+	}
+	p.olgr.Println("restored product by id", id)
+	return pdt, nil
+}
+
+// Purge permanently removes the products deleted before t with their ratings,
+// stock movements, reservations, stock levels, variants and price changes,
+// drops them from the promotion targets and returns the number of purged products
+// every batch of purgeBatch products is removed in its own unit of work
+func (p *Product) Purge(ctx context.Context, t time.Time) (int, error) {
+	p.olgr.Println("purging products deleted before", t)
+	n := 0
+	for {
+		var ids []string
+		err := p.transact(ctx, func(r repo.Repos) error {
+			var err error
+			ids, err = r.Product.Purge(ctx, t, purgeBatch)
+			if err != nil {
+				return err
+			}
+			return removeProducts(ctx, r, ids)
+		})
+		if err != nil {
+			p.elgr.Println("failed to purge products deleted before", t, err)
+			return n, err
+		}
+		n += len(ids)
+		if len(ids) < purgeBatch {
+			break
+		}
+	}
+	p.olgr.Println("purged products deleted before", t, n)
+	return n, nil
+}
+
+// removeProducts permanently removes the ratings, stock, variant and price entries of the products of ids from r
+// and drops the products from the promotion targets, the repos missing from r without a UnitOfWork are skipped
+func removeProducts(ctx context.Context, r repo.Repos, ids []string) error {
+	for _, rm := range []repo.ProductRemover{r.Rating, r.Stock, r.Reservation, r.StockLevel, r.Variant, r.Price, r.Promotion} {
+		if rm == nil {
+			continue
+		}
+		if err := rm.RemoveProducts(ctx, ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Find returns Products that matches query q with skip and limit
-func (p *Product) Find(prms url.Values, skip, limit int) ([]model.Product, error) {
+func (p *Product) Find(ctx context.Context, prms url.Values, skip, limit int) ([]model.Product, error) {
 	p.olgr.Println("listing products", prms, skip, limit)
 	var res []interface{}
-	var err error
-	q := buildProductQuery(prms)
+	q, err := p.query(ctx, prms)
+	if err != nil {
+		p.elgr.Println("failed to build product query", prms, err)
+		return nil, err
+	}
 	if q == nil {
-		res, err = p.pdtRepo.List(skip, limit)
+		res, err = p.pdtRepo.List(ctx, skip, limit)
 	} else {
-		res, err = p.pdtRepo.Search(q, skip, limit)
+		res, err = p.pdtRepo.Search(ctx, q, skip, limit)
 	}
 	if err != nil {
 		p.elgr.Println("failed to list products", prms, skip, limit)
@@ -152,15 +424,18 @@ func (p *Product) Find(prms url.Values, skip, limit int) ([]model.Product, error
 }
 
 // Count returns number of products that matches query q
-func (p *Product) Count(prms url.Values) (int, error) {
+func (p *Product) Count(ctx context.Context, prms url.Values) (int, error) {
 	p.olgr.Println("counting products", prms)
 	var n int
-	var err error
-	q := buildProductQuery(prms)
+	q, err := p.query(ctx, prms)
+	if err != nil {
+		p.elgr.Println("failed to build product query", prms, err)
+		return 0, err
+	}
 	if q == nil {
-		n, err = p.pdtRepo.Count()
+		n, err = p.pdtRepo.Count(ctx)
 	} else {
-		n, err = p.pdtRepo.SearchCount(q)
+		n, err = p.pdtRepo.SearchCount(ctx, q)
 	}
 	if err != nil {
 		p.elgr.Println("failed to count products", prms)
@@ -170,22 +445,304 @@ func (p *Product) Count(prms url.Values) (int, error) {
 	return n, nil
 }
 
-// Rate rates a product
-func (p *Product) Rate(id string, rate int) (string, error) {
-	pdt, err := p.Get(id)
+// Rate rates product id by the user of rat with the value and review text of rat
+// a user rates a product once, rating it again updates the rating in place
+// a review with new text waits for moderation before it is public
+// it returns ErrProductNotFound if there is no such published product
+// created reports whether the rating is new
+func (p *Product) Rate(ctx context.Context, id string, rat model.Rating) (string, bool, error) {
+	rID, created, err := p.rate(ctx, id, rat)
+	if err == ErrRatingExists {
+		// a concurrent first rating of the user was added after the lookup,
+		// rating again finds and updates it
+		rID, created, err = p.rate(ctx, id, rat)
+	}
+	return rID, created, err
+}
+
+// rate rates product id with rat in a single unit of work
+func (p *Product) rate(ctx context.Context, id string, rat model.Rating) (string, bool, error) {
+	var rID string
+	created := false
+	err := p.transact(ctx, func(r repo.Repos) error {
+		pdt, err := p.getPublished(ctx, r.Product, id)
+		if err != nil {
+			return err
+		}
+		rat.ProductID = pdt.ID
+		old, err := p.ratSvc.byUser(ctx, r.Rating, pdt.ID, rat.User.ID)
+		if err != nil {
+			return err
+		}
+		rat = p.ratSvc.screen(rat, old)
+		if old != nil {
+			rID = old.ID
+			return p.ratSvc.update(ctx, r.Rating, old.ID, rat)
+		}
+		rID, err = p.ratSvc.add(ctx, r.Rating, rat)
+		created = err == nil
+		return err
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return rID, created, nil
+}
+
+// Reviews returns the approved ratings of product id with skip and limit, the latest first
+// it returns ErrProductNotFound if there is no such published product
+func (p *Product) Reviews(ctx context.Context, id string, skip, limit int) ([]model.Rating, error) {
+	if _, err := p.getPublished(ctx, p.pdtRepo, id); err != nil {
+		return nil, err
+	}
+	return p.ratSvc.List(ctx, id, skip, limit)
+}
+
+// CountReviews returns number of approved ratings of product id
+// it returns ErrProductNotFound if there is no such published product
+func (p *Product) CountReviews(ctx context.Context, id string) (int, error) {
+	if _, err := p.getPublished(ctx, p.pdtRepo, id); err != nil {
+		return 0, err
+	}
+	return p.ratSvc.Count(ctx, id)
+}
+
+// getPublished returns the product id from rep
+// it returns ErrProductNotFound if the product is not published
+func (p *Product) getPublished(ctx context.Context, rep repo.Product, id string) (*model.Product, error) {
+	pdt, err := p.get(ctx, rep, id)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	rat := model.Rating{
-		ProductID: pdt.ID,
-		Value:     rate,
+	if pdt.Status != model.ProductPublished {
+		return nil, ErrProductNotFound
 	}
-	return p.ratSvc.Add(rat)
+	return pdt, nil
+}
+
+// RemoveReview deletes the rating rid of product id made by usr
+// it returns ErrRatingNotFound if usr has made no such rating
+func (p *Product) RemoveReview(ctx context.Context, id, rid string, usr model.User) error {
+	p.olgr.Println("deleting rating by id", id, rid, usr.ID)
+	err := p.transact(ctx, func(r repo.Repos) error {
+		rat, err := p.ratSvc.fetch(ctx, r.Rating, id, rid)
+		if err != nil {
+			return err
+		}
+		if usr.ID == "" || rat.User.ID != usr.ID {
+			return ErrRatingNotFound
+		}
+		return r.Rating.Delete(ctx, rid)
+	})
+	if err != nil {
+		p.elgr.Println("failed to delete rating by id", id, rid, err)
+		return err
+	}
+	p.olgr.Println("deleted rating by id", id, rid)
+	return nil
+}
+
+// Tags returns the usage counts of the product tags, most used first
+func (p *Product) Tags(ctx context.Context) ([]model.TagCount, error) {
+	p.olgr.Println("counting product tags")
+	cnts, err := p.pdtRepo.CountTags(ctx)
+	if err != nil {
+		p.elgr.Println("failed to count product tags", err)
+		return nil, err
+	}
+	tcs := []model.TagCount{}
+	for tag, n := range cnts {
+		tcs = append(tcs, model.TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(tcs, func(i, j int) bool {
+		if tcs[i].Count != tcs[j].Count {
+			return tcs[i].Count > tcs[j].Count
+		}
+		return tcs[i].Tag < tcs[j].Tag
+	})
+	p.olgr.Println("counted product tags")
+	return tcs, nil
+}
+
+// Variants returns every variant of a product by its id in creation order
+// it returns no variant if the Variant service is not set
+func (p *Product) Variants(ctx context.Context, id string) ([]model.Variant, error) {
+	if p.vrtSvc == nil {
+		return []model.Variant{}, nil
+	}
+	vrts, err := p.vrtSvc.all(ctx, id)
+	if err != nil {
+		p.elgr.Println("failed to list variants of product", id, err)
+		return nil, err
+	}
+	return vrts, nil
+}
+
+// PriceIn returns the price of pdt in currency cur, an empty cur means the base currency
+// it returns a ValidationError if the price can not be converted to cur
+func (p *Product) PriceIn(pdt model.Product, cur string) (model.Money, error) {
+	rates := p.exchangeRates()
+	if cur == "" {
+		cur = rates.Base
+	}
+	m, ok := rates.PriceIn(pdt, cur)
+	if !ok {
+		return model.Money{}, unsupportedCurrency()
+	}
+	return m, nil
+}
+
+// PriceRangeIn returns the price range of vrts of pdt in currency cur, an empty cur means the base currency
+// it returns a ValidationError if the prices can not be converted to cur
+func (p *Product) PriceRangeIn(pdt model.Product, vrts []model.Variant, cur string) (*model.PriceRange, error) {
+	rates := p.exchangeRates()
+	if cur == "" {
+		cur = rates.Base
+	}
+	rng, ok := rates.PriceRangeIn(pdt, vrts, cur)
+	if !ok {
+		return nil, unsupportedCurrency()
+	}
+	return rng, nil
+}
+
+// Pricing returns the pricing of every product of pdts in currency cur, an empty cur means the base currency
+// the effective prices are the prices if the Promotion service is not set
+func (p *Product) Pricing(ctx context.Context, pdts []model.Product, cur string) ([]model.Pricing, error) {
+	prms := []model.Promotion{}
+	if p.prmSvc != nil {
+		var err error
+		if prms, err = p.prmSvc.active(ctx); err != nil {
+			p.elgr.Println("failed to list active promotions", err)
+			return nil, err
+		}
+	}
+	byCat := false
+	for _, prm := range prms {
+		byCat = byCat || len(prm.CategoryIDs) != 0
+	}
+
+	catIDs := map[string][]string{}
+	if byCat && p.catSvc != nil && len(pdts) != 0 {
+		ids := []string{}
+		for _, pdt := range pdts {
+			ids = append(ids, pdt.ID)
+		}
+		var err error
+		if catIDs, err = p.catSvc.assigned(ctx, ids); err != nil {
+			p.elgr.Println("failed to list categories of products", ids, err)
+			return nil, err
+		}
+	}
+
+	rates := p.exchangeRates()
+	prs := []model.Pricing{}
+	for _, pdt := range pdts {
+		price, err := p.PriceIn(pdt, cur)
+		if err != nil {
+			return nil, err
+		}
+		targets := []model.Promotion{}
+		for _, prm := range prms {
+			if prm.Targets(pdt, catIDs[pdt.ID]) {
+				targets = append(targets, prm)
+			}
+		}
+		prs = append(prs, model.ApplyPromotions(price, targets, rates))
+	}
+	return prs, nil
+}
+
+// exchangeRates returns the exchange rates of the service
+// the base currency is model.DefaultCurrency unless the rates are set
+func (p *Product) exchangeRates() model.ExchangeRates {
+	if p.rates.Base == "" {
+		return model.ExchangeRates{Base: model.DefaultCurrency}
+	}
+	return p.rates
+}
+
+// unsupportedCurrency returns the error of a currency without exchange rate
+func unsupportedCurrency() error {
+	return model.ValidationError{"Currency": []string{"is not supported"}}
 }
 
 // AvgRating returns average rating of a product by its id
-func (p *Product) AvgRating(id string) (float64, error) {
-	return p.ratSvc.AvgRating(id)
+func (p *Product) AvgRating(ctx context.Context, id string) (float64, error) {
+	return p.ratSvc.AvgRating(ctx, id)
+}
+
+// RatingSummary returns the rating summary of a product by its id
+func (p *Product) RatingSummary(ctx context.Context, id string) (model.RatingSummary, error) {
+	return p.ratSvc.Summary(ctx, id)
+}
+
+// RatingSummaries returns the rating summaries of pdts by product id in a single lookup
+func (p *Product) RatingSummaries(ctx context.Context, pdts []model.Product) (map[string]model.RatingSummary, error) {
+	ids := []string{}
+	for _, pdt := range pdts {
+		ids = append(ids, pdt.ID)
+	}
+	return p.ratSvc.Summaries(ctx, ids)
+}
+
+// transact runs fn with the repos bound to a single unit of work
+// if no UnitOfWork is set fn runs with the service repos directly
+func (p *Product) transact(ctx context.Context, fn func(r repo.Repos) error) error {
+	if p.uow == nil {
+		return fn(repo.Repos{
+			Product: p.pdtRepo,
+			Rating:  p.ratSvc.rateRepo,
+			Price:   p.priceRepo(),
+		})
+	}
+	return p.uow.Do(ctx, fn)
+}
+
+// priceRepo returns the repo of the Price service or nil if it is not set
+func (p *Product) priceRepo() repo.Price {
+	if p.prcSvc == nil {
+		return nil
+	}
+	return p.prcSvc.prcRepo
+}
+
+// query returns the repo.Query of prms
+// the category filter includes the descendant categories if prms has descendants=true
+// it returns a ValidationError if the status, minRating or sort of prms is invalid
+func (p *Product) query(ctx context.Context, prms url.Values) (repo.Query, error) {
+	if sts := prms.Get("status"); sts != "" && !model.ProductStatus(sts).Valid() {
+		return nil, model.ValidationError{"Status": []string{"is invalid"}}
+	}
+	if rt := prms.Get("minRating"); rt != "" {
+		if f, err := strconv.ParseFloat(rt, 64); err != nil || f < 0 || f > 5 {
+			return nil, model.ValidationError{"MinRating": []string{"is invalid"}}
+		}
+	}
+	if srt := prms.Get("sort"); srt != "" && srt != "rating" && srt != "-rating" {
+		return nil, model.ValidationError{"Sort": []string{"is invalid"}}
+	}
+	q := buildProductQuery(prms)
+	if len(q["category"]) == 0 || p.catSvc == nil {
+		return q, nil
+	}
+	if desc, _ := strconv.ParseBool(prms.Get("descendants")); !desc {
+		return q, nil
+	}
+
+	ids := []string{}
+	for _, v := range q["category"] {
+		ids = append(ids, v.(string))
+	}
+	ids, err := p.catSvc.Descendants(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	q["category"] = nil
+	for _, id := range ids {
+		q.Add("category", id)
+	}
+	return q, nil
 }
 
 func buildProductQuery(prms url.Values) repo.Query {
@@ -206,11 +763,41 @@ func buildProductQuery(prms url.Values) repo.Query {
 				q.Add(k, b)
 			}
 		}
+		if k == "location" || k == "status" {
+			if v := prms.Get(k); v != "" {
+				q.Add(k, v)
+			}
+		}
+		if k == "category" {
+			for _, v := range prms[k] {
+				if v != "" {
+					q.Add(k, v)
+				}
+			}
+		}
+		if k == "tag" {
+			for _, v := range model.NormalizeTags(prms[k]) {
+				q.Add(k, v)
+			}
+		}
 		if k == "price" {
 			if d, err := strconv.Atoi(prms.Get(k)); err == nil && d > 0 {
 				q.Add(k, d)
 			}
 		}
+		if k == "minRating" {
+			if f, err := strconv.ParseFloat(prms.Get(k), 64); err == nil && f >= 0 && f <= 5 {
+				q.Add("min_rating", f)
+			}
+		}
+		if k == "sort" {
+			if v := prms.Get(k); v == "rating" || v == "-rating" {
+				q.Add(k, v)
+			}
+		}
+	}
+	if len(q["tag"]) != 0 && prms.Get("tagMatch") == "all" {
+		q.Add("tag_match", "all")
 	}
 	if len(q) == 0 {
 		return nil
@@ -1,9 +1,14 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"net/url"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/satori/go.uuid"
 
@@ -12,6 +17,7 @@ import (
 	"github.com/msyrus/simple-product-inv/mock_repo"
 	"github.com/msyrus/simple-product-inv/model"
 	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/repo/memory"
 )
 
 func TestNewProduct(t *testing.T) {
@@ -103,8 +109,8 @@ func TestProduct_Add(t *testing.T) {
 	uid := uuid.NewV4().String()
 
 	gomock.InOrder(
-		pdtRepo.EXPECT().Create(rec1).Return("", model.ValidationError{}),
-		pdtRepo.EXPECT().Create(rec2).Return(uid, nil),
+		pdtRepo.EXPECT().Create(gomock.Any(), rec1).Return("", model.ValidationError{}),
+		pdtRepo.EXPECT().Create(gomock.Any(), rec2).Return(uid, nil),
 	)
 
 	type args struct {
@@ -136,7 +142,7 @@ func TestProduct_Add(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Add(tt.args.rec)
+			got, err := tt.r.Add(context.Background(), tt.args.rec)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Product.Add() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -162,8 +168,8 @@ func TestProduct_Get(t *testing.T) {
 	rec1 := model.Product{ID: uid, Name: "Test1", Price: 100, Weight: 1, Available: true}
 
 	gomock.InOrder(
-		pdtRepo.EXPECT().Fetch("not_available_id").Return(nil, nil),
-		pdtRepo.EXPECT().Fetch(uid).Return(rec1, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "not_available_id").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), uid).Return(rec1, nil),
 	)
 
 	type args struct {
@@ -195,7 +201,7 @@ func TestProduct_Get(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Get(tt.args.id)
+			got, err := tt.r.Get(context.Background(), tt.args.id)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Product.Get() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -207,6 +213,58 @@ func TestProduct_Get(t *testing.T) {
 	}
 }
 
+func TestProduct_GetByCode(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	rateRepo := mock_repo.NewMockRating(mockCtrl)
+	pdtSvc := NewProduct(pdtRepo, NewRating(rateRepo))
+
+	uid := uuid.NewV4().String()
+	rec1 := model.Product{ID: uid, Name: "Test1", Price: 100, Weight: 1, SKU: "TS-01", Barcode: "4006381333931"}
+
+	gomock.InOrder(
+		pdtRepo.EXPECT().Search(gomock.Any(), repo.Query{"sku": {"unknown"}}, 0, 1).Return([]interface{}{}, nil),
+		pdtRepo.EXPECT().Search(gomock.Any(), repo.Query{"sku": {"TS-01"}}, 0, 1).Return([]interface{}{rec1}, nil),
+		pdtRepo.EXPECT().Search(gomock.Any(), repo.Query{"barcode": {"4006381333931"}}, 0, 1).Return([]interface{}{rec1}, nil),
+	)
+
+	tests := []struct {
+		name    string
+		get     func(ctx context.Context, code string) (*model.Product, error)
+		code    string
+		want    *model.Product
+		wantErr error
+	}{
+		{name: "empty sku", get: pdtSvc.GetBySKU, code: "", want: nil, wantErr: ErrProductNotFound},
+		{name: "unknown sku", get: pdtSvc.GetBySKU, code: "unknown", want: nil, wantErr: ErrProductNotFound},
+		{name: "sku", get: pdtSvc.GetBySKU, code: "TS-01", want: &rec1, wantErr: nil},
+		{name: "barcode", get: pdtSvc.GetByBarcode, code: "4006381333931", want: &rec1, wantErr: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get(context.Background(), tt.code)
+			if err != tt.wantErr {
+				t.Errorf("Product.GetByCode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Product.GetByCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	pdtRepo.EXPECT().Create(gomock.Any(), rec1).Return("", repo.ErrDuplicateSKU)
+	if _, err := pdtSvc.Add(context.Background(), rec1); err != ErrSKUExists {
+		t.Errorf("Product.Add() error = %v, want %v", err, ErrSKUExists)
+	}
+	pdtRepo.EXPECT().Update(gomock.Any(), uid, rec1).Return(repo.ErrDuplicateBarcode)
+	if err := pdtSvc.Update(context.Background(), uid, rec1); err != ErrBarcodeExists {
+		t.Errorf("Product.Update() error = %v, want %v", err, ErrBarcodeExists)
+	}
+}
+
 func TestProduct_Update(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -222,9 +280,9 @@ func TestProduct_Update(t *testing.T) {
 	rec1 := model.Product{Name: "Test1", Price: 100, Weight: 1, Available: true}
 
 	gomock.InOrder(
-		pdtRepo.EXPECT().Update("not_available_id", rec1).Return(nil),
-		pdtRepo.EXPECT().Update(uid, rec0).Return(model.ValidationError{}),
-		pdtRepo.EXPECT().Update(uid, rec1).Return(nil),
+		pdtRepo.EXPECT().Update(gomock.Any(), "not_available_id", rec1).Return(nil),
+		pdtRepo.EXPECT().Update(gomock.Any(), uid, rec0).Return(model.ValidationError{}),
+		pdtRepo.EXPECT().Update(gomock.Any(), uid, rec1).Return(nil),
 	)
 
 	type args struct {
@@ -264,7 +322,7 @@ func TestProduct_Update(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.r.Update(tt.args.id, tt.args.rec); (err != nil) != tt.wantErr {
+			if err := tt.r.Update(context.Background(), tt.args.id, tt.args.rec); (err != nil) != tt.wantErr {
 				t.Errorf("Product.Update() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -284,8 +342,8 @@ func TestProduct_Remove(t *testing.T) {
 	uid := uuid.NewV4().String()
 
 	gomock.InOrder(
-		pdtRepo.EXPECT().Delete("not_available_id").Return(nil),
-		pdtRepo.EXPECT().Delete(uid).Return(nil),
+		pdtRepo.EXPECT().Delete(gomock.Any(), "not_available_id").Return(nil),
+		pdtRepo.EXPECT().Delete(gomock.Any(), uid).Return(nil),
 	)
 
 	type args struct {
@@ -314,7 +372,7 @@ func TestProduct_Remove(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.r.Remove(tt.args.id); (err != nil) != tt.wantErr {
+			if err := tt.r.Remove(context.Background(), tt.args.id); (err != nil) != tt.wantErr {
 				t.Errorf("Product.Remove() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -336,12 +394,12 @@ func TestProduct_Find(t *testing.T) {
 	pdt3 := model.Product{ID: "3", Name: "Test3", Weight: 3, Price: 300, Available: false}
 
 	gomock.InOrder(
-		pdtRepo.EXPECT().List(0, 1).Return([]interface{}{pdt1}, nil),
-		pdtRepo.EXPECT().Search(repo.Query{"available": []interface{}{false}}, 0, 0).Return([]interface{}{}, nil),
-		pdtRepo.EXPECT().Search(repo.Query{"available": []interface{}{false}}, 0, 1).Return([]interface{}{pdt1}, nil),
-		pdtRepo.EXPECT().Search(repo.Query{"available": []interface{}{false}}, 1, 2).Return([]interface{}{pdt2, pdt3}, nil),
-		pdtRepo.EXPECT().Search(repo.Query{"available": []interface{}{true}}, 0, 1).Return([]interface{}{}, nil),
-		pdtRepo.EXPECT().List(0, 5).Return([]interface{}{pdt1, pdt2, pdt3}, nil),
+		pdtRepo.EXPECT().List(gomock.Any(), 0, 1).Return([]interface{}{pdt1}, nil),
+		pdtRepo.EXPECT().Search(gomock.Any(), repo.Query{"available": []interface{}{false}}, 0, 0).Return([]interface{}{}, nil),
+		pdtRepo.EXPECT().Search(gomock.Any(), repo.Query{"available": []interface{}{false}}, 0, 1).Return([]interface{}{pdt1}, nil),
+		pdtRepo.EXPECT().Search(gomock.Any(), repo.Query{"available": []interface{}{false}}, 1, 2).Return([]interface{}{pdt2, pdt3}, nil),
+		pdtRepo.EXPECT().Search(gomock.Any(), repo.Query{"available": []interface{}{true}}, 0, 1).Return([]interface{}{}, nil),
+		pdtRepo.EXPECT().List(gomock.Any(), 0, 5).Return([]interface{}{pdt1, pdt2, pdt3}, nil),
 	)
 
 	type args struct {
@@ -418,7 +476,7 @@ func TestProduct_Find(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Find(tt.args.prms, tt.args.skip, tt.args.limit)
+			got, err := tt.r.Find(context.Background(), tt.args.prms, tt.args.skip, tt.args.limit)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Product.Find() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -446,7 +504,7 @@ func TestProduct_Count(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Count(tt.args.prms)
+			got, err := tt.r.Count(context.Background(), tt.args.prms)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Product.Count() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -459,31 +517,297 @@ func TestProduct_Count(t *testing.T) {
 }
 
 func TestProduct_Rate(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	rateRepo := mock_repo.NewMockRating(mockCtrl)
+	txPdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	txRateRepo := mock_repo.NewMockRating(mockCtrl)
+	uow := mock_repo.NewMockUnitOfWork(mockCtrl)
+
+	rateSvc := NewRating(rateRepo)
+	pdtSvc := NewProduct(pdtRepo, rateSvc)
+	txPdtSvc := NewProduct(pdtRepo, rateSvc, SetProductUnitOfWork(uow))
+
+	uid := uuid.NewV4().String()
+	pdt := model.Product{ID: uid, Name: "Test1", Price: 100, Weight: 1, Available: true, Status: model.ProductPublished}
+	usr := model.User{ID: "u1", Name: "Jo"}
+	byUsr := repo.Query{"product_id": {uid}, "user_id": {usr.ID}}
+	old := model.Rating{ID: "5678", ProductID: uid, User: usr, Value: 2}
+
+	runInTx := func(ctx context.Context, fn func(repo.Repos) error) error {
+		return fn(repo.Repos{Product: txPdtRepo, Rating: txRateRepo})
+	}
+
+	gomock.InOrder(
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "not_available_id").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "draft_id").Return(model.Product{ID: "draft_id", Status: model.ProductDraft}, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), uid).Return(pdt, nil),
+		rateRepo.EXPECT().Search(gomock.Any(), byUsr, 0, 1).Return([]interface{}{}, nil),
+		rateRepo.EXPECT().Create(gomock.Any(), model.Rating{ProductID: uid, User: usr, Value: 4, Title: "Warm", Status: model.RatingPending}).Return("1234", nil),
+		uow.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(runInTx),
+		txPdtRepo.EXPECT().Fetch(gomock.Any(), uid).Return(pdt, nil),
+		txRateRepo.EXPECT().Search(gomock.Any(), byUsr, 0, 1).Return([]interface{}{old}, nil),
+		txRateRepo.EXPECT().Update(gomock.Any(), old.ID, model.Rating{ProductID: uid, User: usr, Value: 4, Status: model.RatingApproved}).Return(nil),
+		uow.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(runInTx),
+		txPdtRepo.EXPECT().Fetch(gomock.Any(), uid).Return(pdt, nil),
+		txRateRepo.EXPECT().Search(gomock.Any(), byUsr, 0, 1).Return([]interface{}{}, nil),
+		txRateRepo.EXPECT().Create(gomock.Any(), model.Rating{ProductID: uid, User: usr, Value: 9, Status: model.RatingApproved}).Return("", model.ValidationError{}),
+		uow.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(runInTx),
+		txPdtRepo.EXPECT().Fetch(gomock.Any(), uid).Return(pdt, nil),
+		txRateRepo.EXPECT().Search(gomock.Any(), byUsr, 0, 1).Return([]interface{}{}, nil),
+		txRateRepo.EXPECT().Create(gomock.Any(), model.Rating{ProductID: uid, User: usr, Value: 3, Status: model.RatingApproved}).Return("", repo.ErrDuplicateRating),
+		uow.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(runInTx),
+		txPdtRepo.EXPECT().Fetch(gomock.Any(), uid).Return(pdt, nil),
+		txRateRepo.EXPECT().Search(gomock.Any(), byUsr, 0, 1).Return([]interface{}{old}, nil),
+		txRateRepo.EXPECT().Update(gomock.Any(), old.ID, model.Rating{ProductID: uid, User: usr, Value: 3, Status: model.RatingApproved}).Return(nil),
+		uow.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(runInTx),
+		txPdtRepo.EXPECT().Fetch(gomock.Any(), uid).Return(pdt, nil),
+		txRateRepo.EXPECT().Create(gomock.Any(), model.Rating{ProductID: uid, Value: 3, Status: model.RatingApproved}).Return("", model.ValidationError{}),
+	)
+
 	type args struct {
-		id   string
-		rate int
+		id  string
+		rat model.Rating
 	}
 	tests := []struct {
-		name    string
-		r       *Product
-		args    args
-		want    string
-		wantErr bool
+		name        string
+		r           *Product
+		args        args
+		want        string
+		wantCreated bool
+		wantErr     error
 	}{
-		// TODO: Add test cases.
+		{
+			r: pdtSvc,
+			args: args{
+				id:  "not_available_id",
+				rat: model.Rating{User: usr, Value: 4},
+			},
+			want:    "",
+			wantErr: ErrProductNotFound,
+		},
+		{
+			name: "draft",
+			r:    pdtSvc,
+			args: args{
+				id:  "draft_id",
+				rat: model.Rating{User: usr, Value: 4},
+			},
+			want:    "",
+			wantErr: ErrProductNotFound,
+		},
+		{
+			r: pdtSvc,
+			args: args{
+				id:  uid,
+				rat: model.Rating{User: usr, Value: 4, Title: "Warm"},
+			},
+			want:        "1234",
+			wantCreated: true,
+		},
+		{
+			name: "rated again",
+			r:    txPdtSvc,
+			args: args{
+				id:  uid,
+				rat: model.Rating{User: usr, Value: 4},
+			},
+			want: "5678",
+		},
+		{
+			r: txPdtSvc,
+			args: args{
+				id:  uid,
+				rat: model.Rating{User: usr, Value: 9},
+			},
+			want:    "",
+			wantErr: model.ValidationError{},
+		},
+		{
+			name: "rated meanwhile",
+			r:    txPdtSvc,
+			args: args{
+				id:  uid,
+				rat: model.Rating{User: usr, Value: 3},
+			},
+			want: "5678",
+		},
+		{
+			name: "no user",
+			r:    txPdtSvc,
+			args: args{
+				id:  uid,
+				rat: model.Rating{Value: 3},
+			},
+			want:    "",
+			wantErr: model.ValidationError{},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.Rate(tt.args.id, tt.args.rate)
-			if (err != nil) != tt.wantErr {
+			got, created, err := tt.r.Rate(context.Background(), tt.args.id, tt.args.rat)
+			if !reflect.DeepEqual(err, tt.wantErr) {
 				t.Errorf("Product.Rate() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("Product.Rate() = %v, want %v", got, tt.want)
+			if got != tt.want || created != tt.wantCreated {
+				t.Errorf("Product.Rate() = %v, %v, want %v, %v", got, created, tt.want, tt.wantCreated)
+			}
+		})
+	}
+}
+
+// racingRatings is a repo.Rating whose first n searches wait for each other
+// so that the lookups of n concurrent first ratings all find no rating
+type racingRatings struct {
+	repo.Rating
+	mu      sync.Mutex
+	n       int
+	waiting sync.WaitGroup
+}
+
+func (r *racingRatings) Search(ctx context.Context, q repo.Query, skip, limit int) ([]interface{}, error) {
+	res, err := r.Rating.Search(ctx, q, skip, limit)
+	r.mu.Lock()
+	wait := r.n > 0
+	r.n--
+	r.mu.Unlock()
+	if wait {
+		r.waiting.Done()
+		r.waiting.Wait()
+	}
+	return res, err
+}
+
+func TestProduct_RateConcurrently(t *testing.T) {
+	const n = 2
+	rps := memory.NewStore().Repos()
+	ctx := context.Background()
+	rats := &racingRatings{Rating: rps.Rating, n: n}
+	rats.waiting.Add(n)
+	pdtSvc := NewProduct(rps.Product, NewRating(rats, SetRatingOutputLogger(nil), SetRatingErrorLogger(nil)), SetProductOutputLogger(nil), SetProductErrorLogger(nil))
+
+	id, err := pdtSvc.Add(ctx, model.Product{Name: "Hat", Price: 100, Weight: 1, Status: model.ProductPublished})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, n)
+	created := make(chan bool, n)
+	for i := 0; i < n; i++ {
+		go func(v int) {
+			_, c, err := pdtSvc.Rate(ctx, id, model.Rating{User: model.User{ID: "u1"}, Value: v + 1})
+			errs <- err
+			created <- c
+		}(i)
+	}
+	news := 0
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("Product.Rate() concurrent first rating error = %v", err)
+		}
+		if <-created {
+			news++
+		}
+	}
+	if news != 1 {
+		t.Errorf("Product.Rate() created %v ratings, want 1", news)
+	}
+	if sum, err := pdtSvc.RatingSummary(ctx, id); err != nil || sum.Count != 1 {
+		t.Errorf("Product.RatingSummary() = %v, %v, want a single rating", sum, err)
+	}
+}
+
+func TestProduct_Reviews(t *testing.T) {
+	s := memory.NewStore()
+	rps := s.Repos()
+	ctx := context.Background()
+	rateSvc := NewRating(rps.Rating, SetRatingUnitOfWork(s), SetRatingOutputLogger(nil), SetRatingErrorLogger(nil))
+	pdtSvc := NewProduct(rps.Product, rateSvc, SetProductUnitOfWork(s), SetProductOutputLogger(nil), SetProductErrorLogger(nil))
+
+	pdtID, err := pdtSvc.Add(ctx, model.Product{Name: "Hat", Price: 100, Weight: 1, Status: model.ProductPublished})
+	if err != nil {
+		t.Fatal(err)
+	}
+	draftID, err := pdtSvc.Add(ctx, model.Product{Name: "Cap", Price: 100, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jo, al := model.User{ID: "u1", Name: "Jo"}, model.User{ID: "u2", Name: "Al"}
+
+	joID, created, err := pdtSvc.Rate(ctx, pdtID, model.Rating{User: jo, Value: 2})
+	if err != nil || !created {
+		t.Fatalf("Product.Rate() = %v, %v, %v, want a new rating", joID, created, err)
+	}
+	alID, _, err := pdtSvc.Rate(ctx, pdtID, model.Rating{User: al, Value: 5, Title: "Warm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, created, err := pdtSvc.Rate(ctx, pdtID, model.Rating{User: jo, Value: 4, Title: "Better", Body: "Warmer once washed"}); id != joID || created || err != nil {
+		t.Errorf("Product.Rate() again = %v, %v, %v, want %v, false, nil", id, created, err, joID)
+	}
+
+	// the reviews are public once approved
+	if n, err := pdtSvc.CountReviews(ctx, pdtID); n != 0 || err != nil {
+		t.Errorf("Product.CountReviews() pending = %v, %v, want 0", n, err)
+	}
+	for _, id := range []string{alID, joID} {
+		if _, err := rateSvc.Moderate(ctx, id, model.RatingApproved, "", model.User{ID: "moderator"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := model.RatingSummary{ProductID: pdtID, Count: 2, Sum: 9, Avg: 4.5, Histogram: [5]int{0, 0, 0, 1, 1}}
+	if sum, err := pdtSvc.RatingSummary(ctx, pdtID); err != nil || !reflect.DeepEqual(sum, want) {
+		t.Errorf("Product.RatingSummary() = %v, %v, want %v", sum, err, want)
+	}
+	if n, err := pdtSvc.CountReviews(ctx, pdtID); n != 2 || err != nil {
+		t.Errorf("Product.CountReviews() = %v, %v, want 2", n, err)
+	}
+	if _, err := pdtSvc.CountReviews(ctx, "missing"); err != ErrProductNotFound {
+		t.Errorf("Product.CountReviews() missing product error = %v, want %v", err, ErrProductNotFound)
+	}
+	if _, err := pdtSvc.CountReviews(ctx, draftID); err != ErrProductNotFound {
+		t.Errorf("Product.CountReviews() draft product error = %v, want %v", err, ErrProductNotFound)
+	}
+	if _, err := pdtSvc.Reviews(ctx, draftID, 0, 10); err != ErrProductNotFound {
+		t.Errorf("Product.Reviews() draft product error = %v, want %v", err, ErrProductNotFound)
+	}
+	rats, err := pdtSvc.Reviews(ctx, pdtID, 0, 10)
+	if err != nil || len(rats) != 2 {
+		t.Fatalf("Product.Reviews() = %v, %v, want 2 ratings", rats, err)
+	}
+	if rats[0].ID != alID || rats[1].ID != joID || rats[1].Title != "Better" || rats[1].Body != "Warmer once washed" {
+		t.Errorf("Product.Reviews() = %+v, want the ratings of Al and Jo, the latest first", rats)
+	}
+
+	tests := []struct {
+		name    string
+		pdtID   string
+		id      string
+		usr     model.User
+		wantErr error
+	}{
+		{name: "of another user", pdtID: pdtID, id: joID, usr: al, wantErr: ErrRatingNotFound},
+		{name: "of another product", pdtID: "missing", id: joID, usr: jo, wantErr: ErrRatingNotFound},
+		{name: "without user", pdtID: pdtID, id: joID, wantErr: ErrRatingNotFound},
+		{name: "own", pdtID: pdtID, id: joID, usr: jo},
+		{name: "deleted", pdtID: pdtID, id: joID, usr: jo, wantErr: ErrRatingNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := pdtSvc.RemoveReview(ctx, tt.pdtID, tt.id, tt.usr); err != tt.wantErr {
+				t.Errorf("Product.RemoveReview() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
+
+	want = model.RatingSummary{ProductID: pdtID, Count: 1, Sum: 5, Avg: 5, Histogram: [5]int{0, 0, 0, 0, 1}}
+	if sum, err := pdtSvc.RatingSummary(ctx, pdtID); err != nil || !reflect.DeepEqual(sum, want) {
+		t.Errorf("Product.RatingSummary() after RemoveReview() = %v, %v, want %v", sum, err, want)
+	}
 }
 
 func TestProduct_AvgRating(t *testing.T) {
@@ -501,7 +825,7 @@ func TestProduct_AvgRating(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.r.AvgRating(tt.args.id)
+			got, err := tt.r.AvgRating(context.Background(), tt.args.id)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Product.AvgRating() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -513,6 +837,295 @@ func TestProduct_AvgRating(t *testing.T) {
 	}
 }
 
+func TestProduct_Tags(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	pdtSvc := NewProduct(pdtRepo, nil)
+
+	gomock.InOrder(
+		pdtRepo.EXPECT().CountTags(gomock.Any()).Return(nil, sql.ErrConnDone),
+		pdtRepo.EXPECT().CountTags(gomock.Any()).Return(map[string]int{"winter": 1, "summer": 2, "clearance": 2}, nil),
+	)
+
+	tests := []struct {
+		name    string
+		want    []model.TagCount
+		wantErr bool
+	}{
+		{
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			want: []model.TagCount{
+				{Tag: "clearance", Count: 2},
+				{Tag: "summer", Count: 2},
+				{Tag: "winter", Count: 1},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pdtSvc.Tags(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Product.Tags() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Product.Tags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProduct_Transition(t *testing.T) {
+	rps := memory.NewStore().Repos()
+	ctx := context.Background()
+	pdtSvc := NewProduct(rps.Product, NewRating(rps.Rating), SetProductOutputLogger(nil), SetProductErrorLogger(nil))
+
+	id, err := pdtSvc.Add(ctx, model.Product{Name: "Shirt", Price: 100, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		to      model.ProductStatus
+		wantErr error
+	}{
+		{name: "unknown product", id: "unavailable_id", to: model.ProductPublished, wantErr: ErrProductNotFound},
+		{name: "invalid status", id: id, to: "live", wantErr: model.ValidationError{"Status": []string{"is invalid"}}},
+		{name: "archive a draft", id: id, to: model.ProductArchived, wantErr: ErrInvalidTransition},
+		{name: "publish", id: id, to: model.ProductPublished},
+		{name: "publish again", id: id, to: model.ProductPublished, wantErr: ErrInvalidTransition},
+		{name: "archive", id: id, to: model.ProductArchived},
+		{name: "unarchive", id: id, to: model.ProductPublished},
+		{name: "unpublish", id: id, to: model.ProductDraft},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdt, err := pdtSvc.Transition(ctx, tt.id, tt.to)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("Product.Transition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && pdt.Status != tt.to {
+				t.Errorf("Product.Transition() status = %v, want %v", pdt.Status, tt.to)
+			}
+		})
+	}
+
+	pdt, _ := pdtSvc.Get(ctx, id)
+	if pdt.PublishedAt.IsZero() || pdt.ArchivedAt.IsZero() {
+		t.Errorf("Product.Get() = %#v, want the publish and archive times", pdt)
+	}
+	if _, err := pdtSvc.Find(ctx, url.Values{"status": {"live"}}, 0, 10); err == nil {
+		t.Errorf("Product.Find() invalid status error = nil")
+	}
+	if n, err := pdtSvc.Count(ctx, url.Values{"status": {"draft"}}); err != nil || n != 1 {
+		t.Errorf("Product.Count() drafts = %v, %v, want 1", n, err)
+	}
+}
+
+func TestProduct_Restore(t *testing.T) {
+	s := memory.NewStore()
+	rps := s.Repos()
+	ctx := context.Background()
+	pdtSvc := NewProduct(rps.Product, NewRating(rps.Rating), SetProductUnitOfWork(s), SetProductOutputLogger(nil), SetProductErrorLogger(nil))
+
+	ids := []string{}
+	for _, pdt := range []model.Product{
+		{Name: "Hat", Price: 100, Weight: 1, SKU: "HAT-1"},
+		{Name: "Cap", Price: 100, Weight: 1},
+	} {
+		id, err := pdtSvc.Add(ctx, pdt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pdtSvc.Remove(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if _, err := pdtSvc.Add(ctx, model.Product{Name: "Hat 2", Price: 100, Weight: 1, SKU: "HAT-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pdts, err := pdtSvc.Deleted(ctx, 0, 10)
+	if err != nil || len(pdts) != 2 || pdts[0].ID != ids[1] {
+		t.Errorf("Product.Deleted() = %v, %v, want the latest deleted first", pdts, err)
+	}
+	if n, err := pdtSvc.CountDeleted(ctx); err != nil || n != 2 {
+		t.Errorf("Product.CountDeleted() = %v, %v, want 2", n, err)
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{name: "sku taken", id: ids[0], wantErr: ErrSKUExists},
+		{name: "unknown product", id: "unavailable_id", wantErr: ErrProductNotFound},
+		{name: "restore", id: ids[1]},
+		{name: "restore again", id: ids[1], wantErr: ErrProductNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdt, err := pdtSvc.Restore(ctx, tt.id)
+			if err != tt.wantErr {
+				t.Fatalf("Product.Restore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (pdt == nil || pdt.ID != tt.id || pdt.Deleted) {
+				t.Errorf("Product.Restore() = %#v, want the restored product", pdt)
+			}
+		})
+	}
+	if _, err := pdtSvc.Get(ctx, ids[1]); err != nil {
+		t.Errorf("Product.Get() after Restore() error = %v", err)
+	}
+}
+
+func TestProduct_Purge(t *testing.T) {
+	s := memory.NewStore()
+	rps := s.Repos()
+	ctx := context.Background()
+	pdtSvc := NewProduct(rps.Product, NewRating(rps.Rating), SetProductUnitOfWork(s), SetProductOutputLogger(nil), SetProductErrorLogger(nil))
+
+	ids := []string{}
+	for i := 0; i < purgeBatch+2; i++ {
+		id, err := pdtSvc.Add(ctx, model.Product{Name: fmt.Sprint("Hat ", i), Price: 100, Weight: 1, Status: model.ProductPublished})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := pdtSvc.Rate(ctx, id, model.Rating{User: model.User{ID: "u1"}, Value: 4}); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	before := time.Now()
+	for _, id := range ids[1:] {
+		if err := pdtSvc.Remove(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the stock and price entries of a purged product are removed with it
+	if _, err := rps.Stock.Create(ctx, model.StockMovement{ProductID: ids[1], Type: model.MovementReceipt, Quantity: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rps.Reservation.Create(ctx, model.Reservation{ProductID: ids[1], Quantity: 1, Status: model.ReservationPending, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rps.StockLevel.AdjustLevel(ctx, ids[1], "loc1", 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rps.Variant.Create(ctx, model.Variant{ProductID: ids[1], Options: map[string]string{"size": "M"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rps.Price.Create(ctx, model.PriceChange{ProductID: ids[1], Price: 120, Status: model.PriceScheduled, EffectiveAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	prmID, err := rps.Promotion.Create(ctx, model.Promotion{Name: "Sale", Type: model.PromotionPercentage, Value: 10,
+		ProductIDs: []string{ids[0], ids[1]}, StartsAt: time.Now(), EndsAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := pdtSvc.Purge(ctx, before); err != nil || n != 0 {
+		t.Errorf("Product.Purge() before the deletes = %v, %v, want 0", n, err)
+	}
+	if n, err := pdtSvc.Purge(ctx, time.Now()); err != nil || n != purgeBatch+1 {
+		t.Errorf("Product.Purge() = %v, %v, want %v", n, err, purgeBatch+1)
+	}
+	if n, _ := pdtSvc.CountDeleted(ctx); n != 0 {
+		t.Errorf("Product.CountDeleted() after Purge() = %v, want 0", n)
+	}
+	if avg, _ := pdtSvc.AvgRating(ctx, ids[0]); avg != 4 {
+		t.Errorf("Product.AvgRating() kept product = %v, want 4", avg)
+	}
+	if avg, _ := rps.Rating.Avg(ctx, repo.Query{"product_id": {ids[1]}}, "value"); avg != 0 {
+		t.Errorf("Rating.Avg() purged product = %v, want 0", avg)
+	}
+	q := repo.Query{"product_id": {ids[1]}}
+	for name, s := range map[string]repo.Searcher{
+		"Stock": rps.Stock, "Reservation": rps.Reservation, "StockLevel": rps.StockLevel, "Variant": rps.Variant, "Price": rps.Price,
+	} {
+		if n, err := s.SearchCount(ctx, q); n != 0 || err != nil {
+			t.Errorf("%s.SearchCount() purged product = %v, %v, want 0", name, n, err)
+		}
+	}
+	if _, err := pdtSvc.Restore(ctx, ids[1]); err != ErrProductNotFound {
+		t.Errorf("Product.Restore() purged error = %v, want %v", err, ErrProductNotFound)
+	}
+	if prm, err := rps.Promotion.Fetch(ctx, prmID); err != nil || !reflect.DeepEqual(prm.(model.Promotion).ProductIDs, []string{ids[0]}) {
+		t.Errorf("Promotion after Purge() = %+v, %v, want only the kept product targeted", prm, err)
+	}
+	if _, err := pdtSvc.Get(ctx, ids[0]); err != nil {
+		t.Errorf("Product.Get() kept product error = %v", err)
+	}
+}
+
+func TestProduct_FindRating(t *testing.T) {
+	rps := memory.NewStore().Repos()
+	ctx := context.Background()
+	pdtSvc := NewProduct(rps.Product, NewRating(rps.Rating, SetRatingOutputLogger(nil), SetRatingErrorLogger(nil)), SetProductOutputLogger(nil), SetProductErrorLogger(nil))
+
+	ids := []string{}
+	for i, rate := range []int{3, 0, 5} {
+		id, err := pdtSvc.Add(ctx, model.Product{Name: fmt.Sprint("Hat ", i), Price: 100 * (i + 1), Weight: 1, Status: model.ProductPublished})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rate != 0 {
+			if _, _, err := pdtSvc.Rate(ctx, id, model.Rating{User: model.User{ID: "u1"}, Value: rate}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ids = append(ids, id)
+	}
+
+	tests := []struct {
+		name    string
+		prms    url.Values
+		want    []string
+		wantErr error
+	}{
+		{name: "min rating", prms: url.Values{"minRating": {"4"}}, want: ids[2:]},
+		{name: "best rated first", prms: url.Values{"sort": {"-rating"}}, want: []string{ids[2], ids[0], ids[1]}},
+		{name: "best rated under a price", prms: url.Values{"sort": {"-rating"}, "minRating": {"1"}, "price": {"250"}}, want: ids[:1]},
+		{name: "invalid min rating", prms: url.Values{"minRating": {"high"}}, wantErr: model.ValidationError{"MinRating": []string{"is invalid"}}},
+		{name: "min rating out of range", prms: url.Values{"minRating": {"5.5"}}, wantErr: model.ValidationError{"MinRating": []string{"is invalid"}}},
+		{name: "invalid sort", prms: url.Values{"sort": {"name"}}, wantErr: model.ValidationError{"Sort": []string{"is invalid"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdts, err := pdtSvc.Find(ctx, tt.prms, 0, 10)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("Product.Find() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			n, cerr := pdtSvc.Count(ctx, tt.prms)
+			if !reflect.DeepEqual(cerr, tt.wantErr) {
+				t.Fatalf("Product.Count() error = %v, wantErr %v", cerr, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := []string{}
+			for _, pdt := range pdts {
+				got = append(got, pdt.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Product.Find() = %v, want %v", got, tt.want)
+			}
+			if n != len(tt.want) {
+				t.Errorf("Product.Count() = %v, want %v", n, len(tt.want))
+			}
+		})
+	}
+}
+
 func Test_buildProductQuery(t *testing.T) {
 	type args struct {
 		prms url.Values
@@ -522,7 +1135,38 @@ func Test_buildProductQuery(t *testing.T) {
 		args args
 		want repo.Query
 	}{
-		// TODO: Add test cases.
+		{
+			args: args{prms: url.Values{}},
+			want: nil,
+		},
+		{
+			args: args{prms: url.Values{"name": {"Test%"}, "weight": {"0"}, "available": {"yes"}}},
+			want: repo.Query{"name": {"Test%"}},
+		},
+		{
+			args: args{prms: url.Values{"category": {"cat1", "", "cat2"}, "descendants": {"true"}}},
+			want: repo.Query{"category": {"cat1", "cat2"}},
+		},
+		{
+			args: args{prms: url.Values{"tag": {"Summer", "sale", ""}, "tagMatch": {"all"}}},
+			want: repo.Query{"tag": {"sale", "summer"}, "tag_match": {"all"}},
+		},
+		{
+			args: args{prms: url.Values{"tagMatch": {"all"}}},
+			want: nil,
+		},
+		{
+			args: args{prms: url.Values{"status": {"draft"}}},
+			want: repo.Query{"status": {"draft"}},
+		},
+		{
+			args: args{prms: url.Values{"minRating": {"4.5"}, "sort": {"-rating"}}},
+			want: repo.Query{"min_rating": {4.5}, "sort": {"-rating"}},
+		},
+		{
+			args: args{prms: url.Values{"minRating": {"6"}, "sort": {"name"}}},
+			want: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/log"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Promotion holds fields and dependencies to serve promotions
+type Promotion struct {
+	prmRepo repo.Promotion
+	catSvc  *Category
+	uow     repo.UnitOfWork
+	now     func() time.Time
+	olgr    log.Logger
+	elgr    log.Logger
+}
+
+// PromotionOpt represents options for NewPromotion
+type PromotionOpt interface {
+	Apply(s *Promotion)
+}
+
+// PromotionOptFunc is an implementation of PromotionOpt
+type PromotionOptFunc func(s *Promotion)
+
+// Apply calls f
+func (f PromotionOptFunc) Apply(s *Promotion) {
+	f(s)
+}
+
+// SetPromotionOutputLogger sets Promotion service output logger
+func SetPromotionOutputLogger(l log.Logger) PromotionOpt {
+	return PromotionOptFunc(func(s *Promotion) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		s.olgr = l
+	})
+}
+
+// SetPromotionErrorLogger sets Promotion service error logger
+func SetPromotionErrorLogger(l log.Logger) PromotionOpt {
+	return PromotionOptFunc(func(s *Promotion) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		s.elgr = l
+	})
+}
+
+// SetPromotionUnitOfWork sets the UnitOfWork used by Promotion service
+// without it the checks and the changes they guard are not atomic
+func SetPromotionUnitOfWork(u repo.UnitOfWork) PromotionOpt {
+	return PromotionOptFunc(func(s *Promotion) {
+		s.uow = u
+	})
+}
+
+// SetPromotionCategoryService sets the Category service used by Promotion service
+// so that a promotion of a category also targets the products of its subcategories
+func SetPromotionCategoryService(c *Category) PromotionOpt {
+	return PromotionOptFunc(func(s *Promotion) {
+		s.catSvc = c
+	})
+}
+
+// SetPromotionClock sets the func Promotion service reads the current time from
+func SetPromotionClock(now func() time.Time) PromotionOpt {
+	return PromotionOptFunc(func(s *Promotion) {
+		if now == nil {
+			now = time.Now
+		}
+		s.now = now
+	})
+}
+
+// NewPromotion returns a new Promotion service
+func NewPromotion(prm repo.Promotion, opts ...PromotionOpt) *Promotion {
+	s := &Promotion{
+		prmRepo: prm,
+		now:     time.Now,
+		olgr:    log.DefaultOutputLogger,
+		elgr:    log.DefaultErrorLogger,
+	}
+	for _, opt := range opts {
+		opt.Apply(s)
+	}
+	return s
+}
+
+// Add creates a new promotion
+func (s *Promotion) Add(ctx context.Context, prm model.Promotion) (string, error) {
+	s.olgr.Println("creating promotion", prm)
+	id, err := s.prmRepo.Create(ctx, prm)
+	if err != nil {
+		s.elgr.Println("failed to create promotion", err)
+		return "", err
+	}
+	s.olgr.Println("created promotion", id)
+	return id, nil
+}
+
+// Get returns a promotion by its id
+func (s *Promotion) Get(ctx context.Context, id string) (*model.Promotion, error) {
+	s.olgr.Println("fetching promotion by id", id)
+	prm, err := fetchPromotion(ctx, s.prmRepo, id)
+	if err != nil {
+		s.elgr.Println("failed to fetch promotion by id", id, err)
+		return nil, err
+	}
+	s.olgr.Println("fetched promotion by id", id)
+	return prm, nil
+}
+
+// Update updates a promotion by its id
+func (s *Promotion) Update(ctx context.Context, id string, prm model.Promotion) error {
+	s.olgr.Println("updating promotion by id", id)
+	err := s.transact(ctx, func(r repo.Repos) error {
+		if _, err := fetchPromotion(ctx, r.Promotion, id); err != nil {
+			return err
+		}
+		prm.ID = id
+		return r.Promotion.Update(ctx, id, prm)
+	})
+	if err != nil {
+		s.elgr.Println("failed to update promotion by id", id, err)
+		return err
+	}
+	s.olgr.Println("updated promotion by id", id)
+	return nil
+}
+
+// Remove deletes a promotion by its id
+func (s *Promotion) Remove(ctx context.Context, id string) error {
+	s.olgr.Println("deleting promotion by id", id)
+	err := s.transact(ctx, func(r repo.Repos) error {
+		if _, err := fetchPromotion(ctx, r.Promotion, id); err != nil {
+			return err
+		}
+		return r.Promotion.Delete(ctx, id)
+	})
+	if err != nil {
+		s.elgr.Println("failed to delete promotion by id", id, err)
+		return err
+	}
+	s.olgr.Println("deleted promotion by id", id)
+	return nil
+}
+
+// List returns the promotions with skip and limit in creation order
+// only the promotions active now are listed if active is true
+func (s *Promotion) List(ctx context.Context, active bool, skip, limit int) ([]model.Promotion, error) {
+	s.olgr.Println("listing promotions", active, skip, limit)
+	res, err := s.prmRepo.Search(ctx, s.query(active), skip, limit)
+	if err != nil {
+		s.elgr.Println("failed to list promotions", active, skip, limit, err)
+		return nil, err
+	}
+	prms, err := s.assert(res)
+	if err != nil {
+		return nil, err
+	}
+	s.olgr.Println("listed promotions", active, skip, limit)
+	return prms, nil
+}
+
+// Count returns number of promotions
+// only the promotions active now are counted if active is true
+func (s *Promotion) Count(ctx context.Context, active bool) (int, error) {
+	s.olgr.Println("counting promotions", active)
+	n, err := s.prmRepo.SearchCount(ctx, s.query(active))
+	if err != nil {
+		s.elgr.Println("failed to count promotions", active, err)
+		return 0, err
+	}
+	s.olgr.Println("counted promotions", active)
+	return n, nil
+}
+
+// active returns every promotion active now
+// the categories they target include the descendant categories
+func (s *Promotion) active(ctx context.Context) ([]model.Promotion, error) {
+	n, err := s.Count(ctx, true)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	prms, err := s.List(ctx, true, 0, n)
+	if err != nil || s.catSvc == nil {
+		return prms, err
+	}
+	for i, prm := range prms {
+		if len(prm.CategoryIDs) == 0 {
+			continue
+		}
+		ids, err := s.catSvc.Descendants(ctx, prm.CategoryIDs)
+		if err != nil {
+			s.elgr.Println("failed to find categories of promotion", prm.ID, err)
+			return nil, err
+		}
+		prms[i].CategoryIDs = ids
+	}
+	return prms, nil
+}
+
+// query returns the repo.Query of the promotions active now if active is true
+func (s *Promotion) query(active bool) repo.Query {
+	if !active {
+		return repo.Query{}
+	}
+	return repo.Query{"active_at": {s.now()}}
+}
+
+func (s *Promotion) assert(res []interface{}) ([]model.Promotion, error) {
+	prms := []model.Promotion{}
+	for _, re := range res {
+		prm, ok := re.(model.Promotion)
+		if !ok {
+			s.elgr.Printf("failed to assert model.Promotion %#v\n", re)
+			return nil, ErrFailedToAssert
+		}
+		prms = append(prms, prm)
+	}
+	return prms, nil
+}
+
+// transact runs fn with the repos bound to a single unit of work
+// if no UnitOfWork is set fn runs with the service repos directly
+func (s *Promotion) transact(ctx context.Context, fn func(r repo.Repos) error) error {
+	if s.uow == nil {
+		return fn(repo.Repos{
+			Promotion: s.prmRepo,
+		})
+	}
+	return s.uow.Do(ctx, fn)
+}
+
+// fetchPromotion returns the promotion id from rep
+// it returns ErrPromotionNotFound if there is none
+func fetchPromotion(ctx context.Context, rep repo.Promotion, id string) (*model.Promotion, error) {
+	prmI, err := rep.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if prmI == nil {
+		return nil, ErrPromotionNotFound
+	}
+	prm, ok := prmI.(model.Promotion)
+	if !ok {
+		return nil, ErrFailedToAssert
+	}
+	return &prm, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+)
+
+func TestPromotion(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	now := time.Now()
+	catSvc := NewCategory(rps.Category, rps.Product,
+		SetCategoryOutputLogger(nil),
+		SetCategoryErrorLogger(nil),
+	)
+	prmSvc := NewPromotion(rps.Promotion,
+		SetPromotionUnitOfWork(store),
+		SetPromotionCategoryService(catSvc),
+		SetPromotionClock(func() time.Time { return now }),
+		SetPromotionOutputLogger(nil),
+		SetPromotionErrorLogger(nil),
+	)
+	pdtSvc := NewProduct(rps.Product, NewRating(rps.Rating),
+		SetProductCategoryService(catSvc),
+		SetProductPromotionService(prmSvc),
+		SetProductExchangeRates(model.ExchangeRates{Base: "USD", Rates: map[string]float64{"EUR": 0.5}}),
+		SetProductOutputLogger(nil),
+		SetProductErrorLogger(nil),
+	)
+
+	clothing, err := catSvc.Add(ctx, model.Category{Name: "Clothing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shirts, err := catSvc.Add(ctx, model.Category{Name: "Shirts", ParentID: clothing})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shirt, err := pdtSvc.Add(ctx, model.Product{Name: "Shirt", Price: 1000, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := catSvc.Assign(ctx, shirt, []string{shirts}); err != nil {
+		t.Fatal(err)
+	}
+	hat, err := pdtSvc.Add(ctx, model.Product{Name: "Hat", Price: 300, Weight: 1, Tags: []string{"sale"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mug, err := pdtSvc.Add(ctx, model.Product{Name: "Mug", Price: 500, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	add := func(prm model.Promotion) string {
+		t.Helper()
+		if prm.StartsAt.IsZero() {
+			prm.StartsAt = now.Add(-time.Hour)
+			prm.EndsAt = now.Add(time.Hour)
+		}
+		id, err := prmSvc.Add(ctx, prm)
+		if err != nil {
+			t.Fatalf("Promotion.Add() error = %v", err)
+		}
+		return id
+	}
+	byCat := add(model.Promotion{Name: "clothing", Type: model.PromotionPercentage, Value: 10, CategoryIDs: []string{clothing}})
+	byTag := add(model.Promotion{Name: "sale", Type: model.PromotionFixed, Value: 400, Tags: []string{"Sale"}})
+	bxgy := add(model.Promotion{Name: "bxgy", Type: model.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1, ProductIDs: []string{shirt, hat}})
+	add(model.Promotion{Name: "future", Type: model.PromotionPercentage, Value: 50, ProductIDs: []string{shirt, hat, mug},
+		StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)})
+
+	pdts := []model.Product{}
+	for _, id := range []string{shirt, hat, mug} {
+		pdt, err := pdtSvc.Get(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pdts = append(pdts, *pdt)
+	}
+
+	tests := []struct {
+		name    string
+		cur     string
+		want    []int
+		wantIDs [][]string
+		wantErr bool
+	}{
+		{name: "base currency", want: []int{900, 0, 500}, wantIDs: [][]string{{byCat, bxgy}, {byTag, bxgy}, {}}},
+		{name: "converted", cur: "EUR", want: []int{450, 0, 250}, wantIDs: [][]string{{byCat, bxgy}, {byTag, bxgy}, {}}},
+		{name: "unsupported currency", cur: "GBP", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prs, err := pdtSvc.Pricing(ctx, pdts, tt.cur)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Product.Pricing() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i, pr := range prs {
+				if pr.Effective.Amount != tt.want[i] || !reflect.DeepEqual(pr.PromotionIDs, tt.wantIDs[i]) {
+					t.Errorf("Product.Pricing()[%d] = %v %v, want %v %v", i, pr.Effective.Amount, pr.PromotionIDs, tt.want[i], tt.wantIDs[i])
+				}
+			}
+		})
+	}
+
+	if n, err := prmSvc.Count(ctx, true); err != nil || n != 3 {
+		t.Errorf("Promotion.Count() active = %v, %v, want 3", n, err)
+	}
+	if err := prmSvc.Update(ctx, "unavailable_id", model.Promotion{}); err != ErrPromotionNotFound {
+		t.Errorf("Promotion.Update() error = %v, want %v", err, ErrPromotionNotFound)
+	}
+	if err := prmSvc.Remove(ctx, byCat); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prmSvc.Get(ctx, byCat); err != ErrPromotionNotFound {
+		t.Errorf("Promotion.Get() after Remove() error = %v, want %v", err, ErrPromotionNotFound)
+	}
+	if n, err := prmSvc.Count(ctx, false); err != nil || n != 3 {
+		t.Errorf("Promotion.Count() = %v, %v, want 3", n, err)
+	}
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"context"
+	"strings"
+
 	"github.com/msyrus/simple-product-inv/log"
 	"github.com/msyrus/simple-product-inv/model"
 	"github.com/msyrus/simple-product-inv/repo"
@@ -11,6 +14,8 @@ type Rating struct {
 	rateRepo repo.Rating
 	olgr     log.Logger
 	elgr     log.Logger
+	uow      repo.UnitOfWork
+	banned   model.BannedWords
 }
 
 // RatingOpt represents options for NewRating
@@ -46,6 +51,20 @@ func SetRatingErrorLogger(l log.Logger) RatingOpt {
 	})
 }
 
+// SetRatingUnitOfWork sets the UnitOfWork the summaries are rebuilt in
+func SetRatingUnitOfWork(u repo.UnitOfWork) RatingOpt {
+	return RatingOptFunc(func(r *Rating) {
+		r.uow = u
+	})
+}
+
+// SetRatingBannedWords sets the words and phrases the reviews are flagged for
+func SetRatingBannedWords(words []string) RatingOpt {
+	return RatingOptFunc(func(r *Rating) {
+		r.banned = model.BannedWords(words)
+	})
+}
+
 // NewRating returns a new Rating service
 func NewRating(rep repo.Rating, opts ...RatingOpt) *Rating {
 	r := &Rating{
@@ -60,25 +79,270 @@ func NewRating(rep repo.Rating, opts ...RatingOpt) *Rating {
 }
 
 // Add creates a new rating
-func (r *Rating) Add(rat model.Rating) (string, error) {
+func (r *Rating) Add(ctx context.Context, rat model.Rating) (string, error) {
+	return r.add(ctx, r.rateRepo, rat)
+}
+
+func (r *Rating) add(ctx context.Context, rep repo.Rating, rat model.Rating) (string, error) {
 	r.olgr.Println("creating rating", rat)
-	nRat, err := r.rateRepo.Create(rat)
+	nRat, err := rep.Create(ctx, rat)
 	if err != nil {
 		r.elgr.Println("failed to create rating", rat)
+		if err == repo.ErrDuplicateRating {
+			return "", ErrRatingExists
+		}
 		return "", err
 	}
 	r.olgr.Println("created rating", rat)
 	return nRat, nil
 }
 
+// screen sets the moderation status of rat replacing the rating old, if any
+// a rating without review text is approved, one with new review text is pending
+// and flagged with the banned words it contains, an unchanged review keeps its status
+func (r *Rating) screen(rat model.Rating, old *model.Rating) model.Rating {
+	switch {
+	case !rat.HasReview():
+		rat.Status, rat.Reason = model.RatingApproved, ""
+	case old != nil && old.HasReview() && old.Title == rat.Title && old.Body == rat.Body:
+		rat.Status, rat.Reason = old.Status, old.Reason
+	default:
+		rat.Status, rat.Reason = model.RatingPending, ""
+		if found := r.banned.Find(rat.Title, rat.Body); len(found) != 0 {
+			rat.Reason = "flagged for banned words: " + strings.Join(found, ", ")
+		}
+	}
+	return rat
+}
+
+func (r *Rating) update(ctx context.Context, rep repo.Rating, id string, rat model.Rating) error {
+	r.olgr.Println("updating rating by id", id)
+	if err := rep.Update(ctx, id, rat); err != nil {
+		r.elgr.Println("failed to update rating by id", id, err)
+		return err
+	}
+	r.olgr.Println("updated rating by id", id)
+	return nil
+}
+
+// byUser returns the rating of product pdtID by user usrID from rep or nil if there is none
+// the ratings without user are never returned
+func (r *Rating) byUser(ctx context.Context, rep repo.Rating, pdtID, usrID string) (*model.Rating, error) {
+	if usrID == "" {
+		return nil, nil
+	}
+	res, err := rep.Search(ctx, repo.Query{"product_id": {pdtID}, "user_id": {usrID}}, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	rat, ok := res[0].(model.Rating)
+	if !ok {
+		r.elgr.Printf("failed to assert model.Rating %#v\n", res[0])
+		return nil, ErrFailedToAssert
+	}
+	return &rat, nil
+}
+
+// fetch returns the rating id of product pdtID from rep
+// it returns ErrRatingNotFound if there is none
+func (r *Rating) fetch(ctx context.Context, rep repo.Rating, pdtID, id string) (*model.Rating, error) {
+	rat, err := r.get(ctx, rep, id)
+	if err != nil {
+		return nil, err
+	}
+	if rat.ProductID != pdtID {
+		return nil, ErrRatingNotFound
+	}
+	return rat, nil
+}
+
+// List returns the approved ratings of product pdtID with skip and limit, the latest first
+func (r *Rating) List(ctx context.Context, pdtID string, skip, limit int) ([]model.Rating, error) {
+	r.olgr.Println("listing ratings of product", pdtID, skip, limit)
+	rats, err := r.search(ctx, repo.Query{"product_id": {pdtID}, "status": {string(model.RatingApproved)}}, skip, limit)
+	if err != nil {
+		r.elgr.Println("failed to list ratings of product", pdtID, skip, limit, err)
+		return nil, err
+	}
+	r.olgr.Println("listed ratings of product", pdtID, skip, limit)
+	return rats, nil
+}
+
+// Count returns number of approved ratings of product pdtID
+func (r *Rating) Count(ctx context.Context, pdtID string) (int, error) {
+	r.olgr.Println("counting ratings of product", pdtID)
+	n, err := r.rateRepo.SearchCount(ctx, repo.Query{"product_id": {pdtID}, "status": {string(model.RatingApproved)}})
+	if err != nil {
+		r.elgr.Println("failed to count ratings of product", pdtID, err)
+		return 0, err
+	}
+	r.olgr.Println("counted ratings of product", pdtID)
+	return n, nil
+}
+
+// Pending returns the ratings waiting for moderation with skip and limit, the oldest first
+func (r *Rating) Pending(ctx context.Context, skip, limit int) ([]model.Rating, error) {
+	r.olgr.Println("listing pending ratings", skip, limit)
+	rats, err := r.search(ctx, repo.Query{"status": {string(model.RatingPending)}, "sort": {"created_at"}}, skip, limit)
+	if err != nil {
+		r.elgr.Println("failed to list pending ratings", skip, limit, err)
+		return nil, err
+	}
+	r.olgr.Println("listed pending ratings", skip, limit)
+	return rats, nil
+}
+
+// CountPending returns number of ratings waiting for moderation
+func (r *Rating) CountPending(ctx context.Context) (int, error) {
+	r.olgr.Println("counting pending ratings")
+	n, err := r.rateRepo.SearchCount(ctx, repo.Query{"status": {string(model.RatingPending)}})
+	if err != nil {
+		r.elgr.Println("failed to count pending ratings", err)
+		return 0, err
+	}
+	r.olgr.Println("counted pending ratings")
+	return n, nil
+}
+
+func (r *Rating) search(ctx context.Context, q repo.Query, skip, limit int) ([]model.Rating, error) {
+	res, err := r.rateRepo.Search(ctx, q, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+	rats := []model.Rating{}
+	for _, re := range res {
+		rat, ok := re.(model.Rating)
+		if !ok {
+			r.elgr.Printf("failed to assert model.Rating %#v\n", re)
+			return nil, ErrFailedToAssert
+		}
+		rats = append(rats, rat)
+	}
+	return rats, nil
+}
+
+// Moderate moves a rating finding it with id to status to for reason and returns it
+// a rating is rejected only for a reason, the summary of its product follows its status
+// it returns ErrInvalidReviewTransition if the rating can not move from its status to to
+// and ErrOwnReview if the moderating usr has made the rating
+func (r *Rating) Moderate(ctx context.Context, id string, to model.RatingStatus, reason string, usr model.User) (*model.Rating, error) {
+	r.olgr.Println("moderating rating", id, to)
+	if !to.Valid() {
+		return nil, model.ValidationError{"Status": []string{"is invalid"}}
+	}
+	if to == model.RatingRejected && strings.TrimSpace(reason) == "" {
+		return nil, model.ValidationError{"Reason": []string{"is required"}}
+	}
+	var rat *model.Rating
+	err := r.transact(ctx, func(rps repo.Repos) error {
+		old, err := r.get(ctx, rps.Rating, id)
+		if err != nil {
+			return err
+		}
+		if old.User.ID == usr.ID {
+			return ErrOwnReview
+		}
+		if !old.Status.CanTransition(to) {
+			return ErrInvalidReviewTransition
+		}
+		if err := rps.Rating.Moderate(ctx, id, string(old.Status), string(to), reason); err != nil {
+			return err
+		}
+		rat, err = r.get(ctx, rps.Rating, id)
+		return err
+	})
+	if err == repo.ErrStaleStatus {
+		err = ErrInvalidReviewTransition
+	}
+	if err != nil {
+		r.elgr.Println("failed to moderate rating", id, to, err)
+		return nil, err
+	}
+	r.olgr.Println("moderated rating", id, to)
+	return rat, nil
+}
+
+// get returns the rating id from rep
+// it returns ErrRatingNotFound if there is none
+func (r *Rating) get(ctx context.Context, rep repo.Rating, id string) (*model.Rating, error) {
+	ratI, err := rep.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ratI == nil {
+		return nil, ErrRatingNotFound
+	}
+	rat, ok := ratI.(model.Rating)
+	if !ok {
+		r.elgr.Printf("failed to assert model.Rating %#v\n", ratI)
+		return nil, ErrFailedToAssert
+	}
+	return &rat, nil
+}
+
 // AvgRating returns the average rating of a Product
-func (r *Rating) AvgRating(pdtID string) (float64, error) {
-	r.olgr.Println("getting avg rating of", pdtID)
-	val, err := r.rateRepo.Avg(repo.Query{"product_id": []interface{}{pdtID}}, "value")
+// it is read from the rating summary of the product
+func (r *Rating) AvgRating(ctx context.Context, pdtID string) (float64, error) {
+	sum, err := r.Summary(ctx, pdtID)
 	if err != nil {
-		r.elgr.Println("failed to get avg rating of", pdtID)
 		return 0, err
 	}
-	r.olgr.Println("got avg rating of", pdtID)
-	return val, nil
+	return sum.Avg, nil
+}
+
+// Summary returns the rating summary of a Product
+func (r *Rating) Summary(ctx context.Context, pdtID string) (model.RatingSummary, error) {
+	sums, err := r.Summaries(ctx, []string{pdtID})
+	if err != nil {
+		return model.RatingSummary{}, err
+	}
+	return sums[pdtID], nil
+}
+
+// Summaries returns the rating summaries of the products of pdtIDs by product id
+// the products without ratings have zero summaries
+func (r *Rating) Summaries(ctx context.Context, pdtIDs []string) (map[string]model.RatingSummary, error) {
+	r.olgr.Println("getting rating summaries of", len(pdtIDs), "products")
+	sums, err := r.rateRepo.Summaries(ctx, pdtIDs)
+	if err != nil {
+		r.elgr.Println("failed to get rating summaries", err)
+		return nil, err
+	}
+	for _, id := range pdtIDs {
+		if _, ok := sums[id]; !ok {
+			sums[id] = model.RatingSummary{ProductID: id}
+		}
+	}
+	r.olgr.Println("got rating summaries of", len(pdtIDs), "products")
+	return sums, nil
+}
+
+// Rebuild recomputes the rating summaries of every product from the ratings
+// and returns the number of rated products
+func (r *Rating) Rebuild(ctx context.Context) (int, error) {
+	r.olgr.Println("rebuilding rating summaries")
+	n := 0
+	err := r.transact(ctx, func(rps repo.Repos) error {
+		var err error
+		n, err = rps.Rating.RebuildSummaries(ctx)
+		return err
+	})
+	if err != nil {
+		r.elgr.Println("failed to rebuild rating summaries", err)
+		return 0, err
+	}
+	r.olgr.Println("rebuilt rating summaries of", n, "products")
+	return n, nil
+}
+
+// transact runs fn with the repos bound to a single unit of work
+// if no UnitOfWork is set fn runs with the service repo directly
+func (r *Rating) transact(ctx context.Context, fn func(rps repo.Repos) error) error {
+	if r.uow == nil {
+		return fn(repo.Repos{Rating: r.rateRepo})
+	}
+	return r.uow.Do(ctx, fn)
 }
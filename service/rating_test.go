@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/msyrus/simple-product-inv/mock_repo"
 	"github.com/msyrus/simple-product-inv/model"
 	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/repo/memory"
 )
 
 func TestNewRating(t *testing.T) {
@@ -64,6 +66,18 @@ func TestNewRating(t *testing.T) {
 				elgr:     &noOpLogger{},
 			},
 		},
+		{
+			args: args{
+				rep:  rateRepo,
+				opts: []RatingOpt{SetRatingBannedWords([]string{"scam"})},
+			},
+			want: &Rating{
+				rateRepo: rateRepo,
+				olgr:     log.DefaultOutputLogger,
+				elgr:     log.DefaultErrorLogger,
+				banned:   model.BannedWords{"scam"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -81,11 +95,11 @@ func TestRating_Add(t *testing.T) {
 	rateRepo := mock_repo.NewMockRating(mockCtrl)
 
 	rate1 := model.Rating{}
-	rate2 := model.Rating{ProductID: "1234", Value: 1}
+	rate2 := model.Rating{ProductID: "1234", User: model.User{ID: "u1"}, Value: 1}
 
 	gomock.InOrder(
-		rateRepo.EXPECT().Create(rate1).Return("", model.ValidationError{}),
-		rateRepo.EXPECT().Create(rate2).Return("1234", nil),
+		rateRepo.EXPECT().Create(gomock.Any(), rate1).Return("", model.ValidationError{}),
+		rateRepo.EXPECT().Create(gomock.Any(), rate2).Return("1234", nil),
 	)
 
 	type fields struct {
@@ -134,7 +148,7 @@ func TestRating_Add(t *testing.T) {
 				olgr:     tt.fields.olgr,
 				elgr:     tt.fields.elgr,
 			}
-			got, err := r.Add(tt.args.rat)
+			got, err := r.Add(context.Background(), tt.args.rat)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Rating.Add() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -153,8 +167,10 @@ func TestRating_AvgRating(t *testing.T) {
 	rateRepo := mock_repo.NewMockRating(mockCtrl)
 
 	gomock.InOrder(
-		rateRepo.EXPECT().Avg(repo.Query{"product_id": []interface{}{"1234"}}, "value").Return(1.5, nil),
-	// 	rateRepo.EXPECT().Create(rate2).Return("1234", nil),
+		rateRepo.EXPECT().Summaries(gomock.Any(), []string{"1234"}).Return(map[string]model.RatingSummary{
+			"1234": {ProductID: "1234", Count: 2, Sum: 3, Avg: 1.5},
+		}, nil),
+	// 	rateRepo.EXPECT().Create(gomock.Any(), rate2).Return("1234", nil),
 	)
 
 	type fields struct {
@@ -193,7 +209,7 @@ func TestRating_AvgRating(t *testing.T) {
 				olgr:     tt.fields.olgr,
 				elgr:     tt.fields.elgr,
 			}
-			got, err := r.AvgRating(tt.args.pdtID)
+			got, err := r.AvgRating(context.Background(), tt.args.pdtID)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Rating.AvgRating() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -204,3 +220,152 @@ func TestRating_AvgRating(t *testing.T) {
 		})
 	}
 }
+
+func TestRating_Summaries(t *testing.T) {
+	rps := memory.NewStore().Repos()
+	ctx := context.Background()
+	r := NewRating(rps.Rating, SetRatingOutputLogger(nil), SetRatingErrorLogger(nil))
+	for _, rat := range []model.Rating{
+		{ProductID: "1", User: model.User{ID: "u1"}, Value: 2},
+		{ProductID: "1", User: model.User{ID: "u2"}, Value: 5},
+	} {
+		if _, err := r.Add(ctx, rat); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := r.Summaries(ctx, []string{"1", "2"})
+	if err != nil {
+		t.Fatalf("Rating.Summaries() error = %v", err)
+	}
+	want := map[string]model.RatingSummary{
+		"1": {ProductID: "1", Count: 2, Sum: 7, Avg: 3.5, Histogram: [5]int{0, 1, 0, 0, 1}},
+		"2": {ProductID: "2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Rating.Summaries() = %v, want %v", got, want)
+	}
+}
+
+func TestRating_Rebuild(t *testing.T) {
+	s := memory.NewStore()
+	ctx := context.Background()
+	r := NewRating(s.Repos().Rating, SetRatingUnitOfWork(s), SetRatingOutputLogger(nil), SetRatingErrorLogger(nil))
+	for _, rat := range []model.Rating{
+		{ProductID: "1", User: model.User{ID: "u1"}, Value: 2},
+		{ProductID: "2", User: model.User{ID: "u1"}, Value: 5},
+	} {
+		if _, err := r.Add(ctx, rat); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := r.Rebuild(ctx); err != nil || n != 2 {
+		t.Errorf("Rating.Rebuild() = %v, %v, want 2", n, err)
+	}
+	if sum, err := r.Summary(ctx, "1"); err != nil || sum.Count != 1 || sum.Histogram != [5]int{0, 1, 0, 0, 0} {
+		t.Errorf("Rating.Summary() after Rebuild() = %+v, %v", sum, err)
+	}
+}
+
+func TestRating_screen(t *testing.T) {
+	r := NewRating(nil, SetRatingBannedWords([]string{"scam", "rip off"}))
+	rejected := &model.Rating{Value: 1, Title: "Bad", Status: model.RatingRejected, Reason: "rude"}
+
+	tests := []struct {
+		name       string
+		rat        model.Rating
+		old        *model.Rating
+		wantStatus model.RatingStatus
+		wantReason string
+	}{
+		{name: "value only", rat: model.Rating{Value: 4}, wantStatus: model.RatingApproved},
+		{name: "value only again", rat: model.Rating{Value: 4}, old: rejected, wantStatus: model.RatingApproved},
+		{name: "review", rat: model.Rating{Value: 4, Title: "Warm"}, wantStatus: model.RatingPending},
+		{name: "unchanged review", rat: model.Rating{Value: 2, Title: "Bad"}, old: rejected, wantStatus: model.RatingRejected, wantReason: "rude"},
+		{name: "changed review", rat: model.Rating{Value: 2, Title: "Bad", Body: "Too small"}, old: rejected, wantStatus: model.RatingPending},
+		{
+			name:       "banned words",
+			rat:        model.Rating{Value: 1, Title: "SCAM!", Body: "A total rip-off"},
+			wantStatus: model.RatingPending,
+			wantReason: "flagged for banned words: scam, rip off",
+		},
+		{name: "banned part of word", rat: model.Rating{Value: 5, Body: "Not a scampi"}, wantStatus: model.RatingPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.screen(tt.rat, tt.old)
+			if got.Status != tt.wantStatus || got.Reason != tt.wantReason {
+				t.Errorf("Rating.screen() = %q, %q, want %q, %q", got.Status, got.Reason, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestRating_Moderate(t *testing.T) {
+	s := memory.NewStore()
+	ctx := context.Background()
+	r := NewRating(s.Repos().Rating, SetRatingUnitOfWork(s), SetRatingOutputLogger(nil), SetRatingErrorLogger(nil))
+	ids := []string{}
+	for _, rat := range []model.Rating{
+		{ProductID: "1", User: model.User{ID: "u1"}, Value: 2, Title: "Small", Status: model.RatingPending},
+		{ProductID: "1", User: model.User{ID: "u2"}, Value: 5, Title: "Warm", Status: model.RatingPending},
+		{ProductID: "1", User: model.User{ID: "u3"}, Value: 3},
+	} {
+		id, err := r.Add(ctx, rat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	if n, err := r.CountPending(ctx); n != 2 || err != nil {
+		t.Errorf("Rating.CountPending() = %v, %v, want 2", n, err)
+	}
+	rats, err := r.Pending(ctx, 0, 10)
+	if err != nil || len(rats) != 2 || rats[0].ID != ids[0] || rats[1].ID != ids[1] {
+		t.Errorf("Rating.Pending() = %+v, %v, want the pending ratings, the oldest first", rats, err)
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		to         model.RatingStatus
+		reason     string
+		usrID      string
+		wantStatus model.RatingStatus
+		wantErr    error
+	}{
+		{name: "approve own", id: ids[0], to: model.RatingApproved, usrID: "u1", wantErr: ErrOwnReview},
+		{name: "approve", id: ids[0], to: model.RatingApproved, wantStatus: model.RatingApproved},
+		{name: "approve again", id: ids[0], to: model.RatingApproved, wantErr: ErrInvalidReviewTransition},
+		{name: "reject without reason", id: ids[1], to: model.RatingRejected, reason: " ", wantErr: model.ValidationError{"Reason": {"is required"}}},
+		{name: "reject", id: ids[1], to: model.RatingRejected, reason: "off topic", wantStatus: model.RatingRejected},
+		{name: "back to pending", id: ids[1], to: model.RatingPending, wantErr: ErrInvalidReviewTransition},
+		{name: "invalid status", id: ids[1], to: "hidden", wantErr: model.ValidationError{"Status": {"is invalid"}}},
+		{name: "missing", id: "missing", to: model.RatingApproved, wantErr: ErrRatingNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usr := model.User{ID: "moderator"}
+			if tt.usrID != "" {
+				usr.ID = tt.usrID
+			}
+			got, err := r.Moderate(ctx, tt.id, tt.to, tt.reason, usr)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("Rating.Moderate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (got.Status != tt.wantStatus || got.Reason != tt.reason) {
+				t.Errorf("Rating.Moderate() = %+v, want status %q for %q", got, tt.wantStatus, tt.reason)
+			}
+		})
+	}
+
+	want := model.RatingSummary{ProductID: "1", Count: 2, Sum: 5, Avg: 2.5, Histogram: [5]int{0, 1, 1, 0, 0}}
+	if sum, err := r.Summary(ctx, "1"); err != nil || !reflect.DeepEqual(sum, want) {
+		t.Errorf("Rating.Summary() after Moderate() = %v, %v, want %v", sum, err, want)
+	}
+	if n, err := r.Count(ctx, "1"); n != 2 || err != nil {
+		t.Errorf("Rating.Count() after Moderate() = %v, %v, want 2", n, err)
+	}
+}
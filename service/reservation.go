@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/log"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// DefaultReservationTTL is the ttl of a reservation made without one
+const DefaultReservationTTL = 15 * time.Minute
+
+// MaxReservationTTL is the longest ttl a reservation can be made with
+const MaxReservationTTL = 24 * time.Hour
+
+// DefaultReapInterval is the default interval between two reaps of expired reservations
+const DefaultReapInterval = 30 * time.Second
+
+// reapBatch is the number of expired reservations released per search
+const reapBatch = 100
+
+// Reservation holds fields and dependencies to hold product stock during checkout
+type Reservation struct {
+	rsvRepo repo.Reservation
+	pdtRepo repo.Product
+	stkRepo repo.Stock
+	lvlRepo repo.StockLevel
+	uow     repo.UnitOfWork
+	now     func() time.Time
+	olgr    log.Logger
+	elgr    log.Logger
+}
+
+// ReservationOpt represents options for NewReservation
+type ReservationOpt interface {
+	Apply(s *Reservation)
+}
+
+// ReservationOptFunc is an implementation of ReservationOpt
+type ReservationOptFunc func(s *Reservation)
+
+// Apply calls f
+func (f ReservationOptFunc) Apply(s *Reservation) {
+	f(s)
+}
+
+// SetReservationOutputLogger sets Reservation service output logger
+func SetReservationOutputLogger(l log.Logger) ReservationOpt {
+	return ReservationOptFunc(func(s *Reservation) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		s.olgr = l
+	})
+}
+
+// SetReservationErrorLogger sets Reservation service error logger
+func SetReservationErrorLogger(l log.Logger) ReservationOpt {
+	return ReservationOptFunc(func(s *Reservation) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		s.elgr = l
+	})
+}
+
+// SetReservationUnitOfWork sets the UnitOfWork used by Reservation service
+// without it a reservation and the reserved units are not changed atomically
+func SetReservationUnitOfWork(u repo.UnitOfWork) ReservationOpt {
+	return ReservationOptFunc(func(s *Reservation) {
+		s.uow = u
+	})
+}
+
+// SetReservationClock sets the func Reservation service reads the current time from
+func SetReservationClock(now func() time.Time) ReservationOpt {
+	return ReservationOptFunc(func(s *Reservation) {
+		if now == nil {
+			now = time.Now
+		}
+		s.now = now
+	})
+}
+
+// NewReservation returns a new Reservation service
+func NewReservation(rsv repo.Reservation, pdt repo.Product, stk repo.Stock, lvl repo.StockLevel, opts ...ReservationOpt) *Reservation {
+	s := &Reservation{
+		rsvRepo: rsv,
+		pdtRepo: pdt,
+		stkRepo: stk,
+		lvlRepo: lvl,
+		now:     time.Now,
+		olgr:    log.DefaultOutputLogger,
+		elgr:    log.DefaultErrorLogger,
+	}
+	for _, opt := range opts {
+		opt.Apply(s)
+	}
+	return s
+}
+
+// Reserve holds qty units of a product for ttl and returns the reservation id
+// a zero ttl means DefaultReservationTTL, it returns ErrInsufficientStock
+// if the product has fewer unreserved units than qty
+func (s *Reservation) Reserve(ctx context.Context, pdtID string, qty int, ttl time.Duration) (string, error) {
+	s.olgr.Println("reserving stock of", pdtID, qty, ttl)
+	if ttl == 0 {
+		ttl = DefaultReservationTTL
+	}
+	verr := model.ValidationError{}
+	if qty < 1 {
+		verr.Add("Quantity", "is invalid")
+	}
+	if ttl < 0 || ttl > MaxReservationTTL {
+		verr.Add("TTL", "is invalid")
+	}
+	if len(verr) != 0 {
+		return "", verr
+	}
+
+	rsv := model.Reservation{
+		ProductID: pdtID,
+		Quantity:  qty,
+		Status:    model.ReservationPending,
+		ExpiresAt: s.now().Add(ttl),
+	}
+	var rID string
+	err := s.transact(ctx, func(r repo.Repos) error {
+		if _, err := r.Product.AdjustReserved(ctx, pdtID, qty); err != nil {
+			if err != repo.ErrInsufficientQuantity {
+				return err
+			}
+			if _, err := s.product(ctx, r.Product, pdtID); err != nil {
+				return err
+			}
+			return ErrInsufficientStock
+		}
+
+		var err error
+		rID, err = r.Reservation.Create(ctx, rsv)
+		return err
+	})
+	if err != nil {
+		s.elgr.Println("failed to reserve stock of", pdtID, err)
+		return "", err
+	}
+	s.olgr.Println("reserved stock of", pdtID, rID)
+	return rID, nil
+}
+
+// Get returns the reservation of id made for a product
+func (s *Reservation) Get(ctx context.Context, pdtID, id string) (*model.Reservation, error) {
+	s.olgr.Println("getting reservation", id)
+	rsv, err := s.reservation(ctx, s.rsvRepo, pdtID, id)
+	if err != nil {
+		s.elgr.Println("failed to get reservation", id, err)
+		return nil, err
+	}
+	s.olgr.Println("got reservation", id)
+	return rsv, nil
+}
+
+// Confirm turns a pending reservation into a sale of its units taken from
+// the product levels in drawLevels order
+// it returns ErrReservationExpired if the reservation is past its expiry
+func (s *Reservation) Confirm(ctx context.Context, pdtID, id string) error {
+	s.olgr.Println("confirming reservation", id)
+	err := s.transact(ctx, func(r repo.Repos) error {
+		rsv, err := s.reservation(ctx, r.Reservation, pdtID, id)
+		if err != nil {
+			return err
+		}
+		if rsv.Expired(s.now()) {
+			return ErrReservationExpired
+		}
+		if err := s.release(ctx, r, *rsv, model.ReservationConfirmed); err != nil {
+			return err
+		}
+
+		mvt := model.StockMovement{
+			ProductID: pdtID,
+			Type:      model.MovementSale,
+			Quantity:  rsv.Quantity,
+			Reference: "reservation:" + rsv.ID,
+		}
+		if _, err := r.Stock.Create(ctx, mvt); err != nil {
+			return err
+		}
+		if _, err := r.Product.AdjustQuantity(ctx, pdtID, mvt.Delta()); err != nil {
+			if err == repo.ErrInsufficientQuantity {
+				return ErrInsufficientStock
+			}
+			return err
+		}
+		if r.StockLevel == nil {
+			return nil
+		}
+		return drawLevels(ctx, r.StockLevel, pdtID, rsv.Quantity)
+	})
+	if err != nil {
+		s.elgr.Println("failed to confirm reservation", id, err)
+		return err
+	}
+	s.olgr.Println("confirmed reservation", id)
+	return nil
+}
+
+// Cancel releases the units held by a pending reservation
+func (s *Reservation) Cancel(ctx context.Context, pdtID, id string) error {
+	s.olgr.Println("cancelling reservation", id)
+	err := s.transact(ctx, func(r repo.Repos) error {
+		rsv, err := s.reservation(ctx, r.Reservation, pdtID, id)
+		if err != nil {
+			return err
+		}
+		return s.release(ctx, r, *rsv, model.ReservationCancelled)
+	})
+	if err != nil {
+		s.elgr.Println("failed to cancel reservation", id, err)
+		return err
+	}
+	s.olgr.Println("cancelled reservation", id)
+	return nil
+}
+
+// Reap releases the units of the pending reservations past their expiry
+// and returns the number of released reservations, the ones confirmed or
+// cancelled concurrently are skipped
+func (s *Reservation) Reap(ctx context.Context) (int, error) {
+	q := repo.Query{
+		"status":         {string(model.ReservationPending)},
+		"expires_before": {s.now()},
+	}
+	n := 0
+	for {
+		res, err := s.rsvRepo.Search(ctx, q, 0, reapBatch)
+		if err != nil {
+			s.elgr.Println("failed to search expired reservations", err)
+			return n, err
+		}
+		for _, re := range res {
+			rsv, ok := re.(model.Reservation)
+			if !ok {
+				s.elgr.Printf("failed to assert model.Reservation %#v\n", re)
+				return n, ErrFailedToAssert
+			}
+			err := s.transact(ctx, func(r repo.Repos) error {
+				return s.release(ctx, r, rsv, model.ReservationExpired)
+			})
+			if err == ErrReservationNotPending {
+				continue
+			}
+			if err != nil {
+				s.elgr.Println("failed to expire reservation", rsv.ID, err)
+				return n, err
+			}
+			n++
+		}
+		if len(res) < reapBatch {
+			break
+		}
+	}
+	if n != 0 {
+		s.olgr.Println("expired reservations", n)
+	}
+	return n, nil
+}
+
+// RunReaper reaps the expired reservations every interval until ctx is done
+// a zero interval means DefaultReapInterval
+func (s *Reservation) RunReaper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReapInterval
+	}
+	tkr := time.NewTicker(interval)
+	defer tkr.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tkr.C:
+			s.Reap(ctx)
+		}
+	}
+}
+
+// release moves a pending reservation to status to and releases its units
+// the status change is conditional so only one of concurrent releases succeeds
+func (s *Reservation) release(ctx context.Context, r repo.Repos, rsv model.Reservation, to model.ReservationStatus) error {
+	err := r.Reservation.SetStatus(ctx, rsv.ID, string(model.ReservationPending), string(to))
+	if err == repo.ErrStaleStatus {
+		return ErrReservationNotPending
+	}
+	if err != nil {
+		return err
+	}
+	_, err = r.Product.AdjustReserved(ctx, rsv.ProductID, -rsv.Quantity)
+	return err
+}
+
+// reservation returns the reservation of id from rep
+// it returns ErrReservationNotFound if it is not made for product pdtID
+func (s *Reservation) reservation(ctx context.Context, rep repo.Reservation, pdtID, id string) (*model.Reservation, error) {
+	rsvI, err := rep.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rsvI == nil {
+		return nil, ErrReservationNotFound
+	}
+	rsv, ok := rsvI.(model.Reservation)
+	if !ok {
+		s.elgr.Printf("failed to assert model.Reservation %#v\n", rsvI)
+		return nil, ErrFailedToAssert
+	}
+	if rsv.ProductID != pdtID {
+		return nil, ErrReservationNotFound
+	}
+	return &rsv, nil
+}
+
+// product returns the product of id from rep
+func (s *Reservation) product(ctx context.Context, rep repo.Product, id string) (*model.Product, error) {
+	pdtI, err := rep.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pdtI == nil {
+		return nil, ErrProductNotFound
+	}
+	pdt, ok := pdtI.(model.Product)
+	if !ok {
+		s.elgr.Printf("failed to assert model.Product %#v\n", pdtI)
+		return nil, ErrFailedToAssert
+	}
+	return &pdt, nil
+}
+
+// transact runs fn with the repos bound to a single unit of work
+// if no UnitOfWork is set fn runs with the service repos directly
+func (s *Reservation) transact(ctx context.Context, fn func(r repo.Repos) error) error {
+	if s.uow == nil {
+		return fn(repo.Repos{
+			Product:     s.pdtRepo,
+			Stock:       s.stkRepo,
+			StockLevel:  s.lvlRepo,
+			Reservation: s.rsvRepo,
+		})
+	}
+	return s.uow.Do(ctx, fn)
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/mock_repo"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+)
+
+func TestReservation_Reserve(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	rsvRepo := mock_repo.NewMockReservation(mockCtrl)
+
+	now := time.Now()
+	rsvSvc := NewReservation(rsvRepo, pdtRepo, stkRepo, nil, SetReservationClock(func() time.Time { return now }))
+
+	uid := uuid.NewV4().String()
+	pdt := model.Product{ID: uid, Name: "Test1", Price: 100, Weight: 1, Quantity: 3, Available: true}
+
+	gomock.InOrder(
+		pdtRepo.EXPECT().AdjustReserved(gomock.Any(), "not_available_id", 1).Return(0, repo.ErrInsufficientQuantity),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "not_available_id").Return(nil, nil),
+		pdtRepo.EXPECT().AdjustReserved(gomock.Any(), uid, 4).Return(0, repo.ErrInsufficientQuantity),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), uid).Return(pdt, nil),
+		pdtRepo.EXPECT().AdjustReserved(gomock.Any(), uid, 2).Return(2, nil),
+		rsvRepo.EXPECT().Create(gomock.Any(), model.Reservation{
+			ProductID: uid,
+			Quantity:  2,
+			Status:    model.ReservationPending,
+			ExpiresAt: now.Add(DefaultReservationTTL),
+		}).Return("1234", nil),
+	)
+
+	type args struct {
+		id  string
+		qty int
+		ttl time.Duration
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr error
+	}{
+		{
+			args:    args{id: "not_available_id", qty: 1},
+			want:    "",
+			wantErr: ErrProductNotFound,
+		},
+		{
+			args:    args{id: uid, qty: 4},
+			want:    "",
+			wantErr: ErrInsufficientStock,
+		},
+		{
+			args:    args{id: uid, qty: 2},
+			want:    "1234",
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rsvSvc.Reserve(context.Background(), tt.args.id, tt.args.qty, tt.args.ttl)
+			if err != tt.wantErr {
+				t.Errorf("Reservation.Reserve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Reservation.Reserve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, args := range []args{{id: uid, qty: 0}, {id: uid, qty: 1, ttl: -time.Second}, {id: uid, qty: 1, ttl: 2 * MaxReservationTTL}} {
+		if _, err := rsvSvc.Reserve(context.Background(), args.id, args.qty, args.ttl); err == nil {
+			t.Errorf("Reservation.Reserve(%v) error = nil, want validation error", args)
+		}
+	}
+}
+
+// newMemoryReservation returns a Reservation service on a memory store
+// with a product of qty units, one unallocated and the rest at location loc1,
+// the clock of the service is read from now
+func newMemoryReservation(t *testing.T, qty int, now *time.Time) (*Reservation, repo.Repos, string) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+
+	pdtID, err := rps.Product.Create(ctx, model.Product{Name: "Test1", Price: 100, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rps.Product.AdjustQuantity(ctx, pdtID, qty); err != nil {
+		t.Fatal(err)
+	}
+	for loc, n := range map[string]int{"": 1, "loc1": qty - 1} {
+		if _, err := rps.StockLevel.AdjustLevel(ctx, pdtID, loc, n); n > 0 && err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := NewReservation(rps.Reservation, rps.Product, rps.Stock, rps.StockLevel,
+		SetReservationUnitOfWork(store),
+		SetReservationClock(func() time.Time { return *now }),
+		SetReservationOutputLogger(nil),
+		SetReservationErrorLogger(nil),
+	)
+	return svc, rps, pdtID
+}
+
+func fetchStockOf(t *testing.T, rps repo.Repos, pdtID string) (int, int) {
+	t.Helper()
+	v, err := rps.Product.Fetch(context.Background(), pdtID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdt := v.(model.Product)
+	return pdt.Quantity, pdt.Reserved
+}
+
+func TestReservation_Concurrent(t *testing.T) {
+	now := time.Now()
+	svc, rps, pdtID := newMemoryReservation(t, 10, &now)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ids := []string{}
+	conflicts := 0
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := svc.Reserve(context.Background(), pdtID, 1, time.Minute)
+			mu.Lock()
+			defer mu.Unlock()
+			switch err {
+			case nil:
+				ids = append(ids, id)
+			case ErrInsufficientStock:
+				conflicts++
+			default:
+				t.Errorf("Reservation.Reserve() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(ids) != 10 || conflicts != 15 {
+		t.Fatalf("Reservation.Reserve() reserved %d and conflicted %d, want 10 and 15", len(ids), conflicts)
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- svc.Confirm(context.Background(), pdtID, ids[0]) }()
+	go func() { errs <- svc.Cancel(context.Background(), pdtID, ids[0]) }()
+	e1, e2 := <-errs, <-errs
+	if (e1 == nil) == (e2 == nil) || (e1 != ErrReservationNotPending && e2 != ErrReservationNotPending) {
+		t.Errorf("Confirm() and Cancel() errors = %v, %v, want exactly one ErrReservationNotPending", e1, e2)
+	}
+
+	q, r := fetchStockOf(t, rps, pdtID)
+	if r != 9 || (q != 10 && q != 9) {
+		t.Errorf("stock after concurrent Confirm() and Cancel() = %d reserved %d", q, r)
+	}
+}
+
+func TestReservation_Lifecycle(t *testing.T) {
+	now := time.Now()
+	svc, rps, pdtID := newMemoryReservation(t, 5, &now)
+	ctx := context.Background()
+
+	confirmed, err := svc.Reserve(ctx, pdtID, 2, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, err := svc.Reserve(ctx, pdtID, 1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := svc.Reserve(ctx, pdtID, 2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Reserve(ctx, pdtID, 1, time.Minute); err != ErrInsufficientStock {
+		t.Errorf("Reservation.Reserve() error = %v, want %v", err, ErrInsufficientStock)
+	}
+	if _, err := svc.Get(ctx, "other_product", confirmed); err != ErrReservationNotFound {
+		t.Errorf("Reservation.Get() error = %v, want %v", err, ErrReservationNotFound)
+	}
+
+	if err := svc.Confirm(ctx, pdtID, confirmed); err != nil {
+		t.Errorf("Reservation.Confirm() error = %v", err)
+	}
+	if err := svc.Cancel(ctx, pdtID, cancelled); err != nil {
+		t.Errorf("Reservation.Cancel() error = %v", err)
+	}
+	if err := svc.Cancel(ctx, pdtID, confirmed); err != ErrReservationNotPending {
+		t.Errorf("Reservation.Cancel() error = %v, want %v", err, ErrReservationNotPending)
+	}
+
+	now = now.Add(2 * time.Second)
+	if err := svc.Confirm(ctx, pdtID, expired); err != ErrReservationExpired {
+		t.Errorf("Reservation.Confirm() error = %v, want %v", err, ErrReservationExpired)
+	}
+	n, err := svc.Reap(ctx)
+	if err != nil || n != 1 {
+		t.Errorf("Reservation.Reap() = %v, %v, want 1", n, err)
+	}
+	if n, _ := svc.Reap(ctx); n != 0 {
+		t.Errorf("Reservation.Reap() again = %v, want 0", n)
+	}
+
+	rsv, err := svc.Get(ctx, pdtID, expired)
+	if err != nil || rsv.Status != model.ReservationExpired {
+		t.Errorf("Reservation.Get() = %v, %v, want expired", rsv, err)
+	}
+	if q, r := fetchStockOf(t, rps, pdtID); q != 3 || r != 0 {
+		t.Errorf("stock = %d reserved %d, want 3 reserved 0", q, r)
+	}
+	if lvls := levelsOf(t, rps, pdtID); !reflect.DeepEqual(lvls, map[string]int{"": 0, "loc1": 3}) {
+		t.Errorf("levels after Reservation.Confirm() = %v, want the unallocated unit taken first", lvls)
+	}
+	mvts, err := rps.Stock.SearchCount(ctx, repo.Query{"product_id": {pdtID}, "type": {string(model.MovementSale)}})
+	if err != nil || mvts != 1 {
+		t.Errorf("sale movements = %v, %v, want 1", mvts, err)
+	}
+}
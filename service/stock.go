@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+
+	"github.com/msyrus/simple-product-inv/log"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Stock holds fields and dependencies to serve product stock
+type Stock struct {
+	stkRepo repo.Stock
+	pdtRepo repo.Product
+	lvlRepo repo.StockLevel
+	locRepo repo.Location
+	uow     repo.UnitOfWork
+	olgr    log.Logger
+	elgr    log.Logger
+}
+
+// StockOpt represents options for NewStock
+type StockOpt interface {
+	Apply(s *Stock)
+}
+
+// StockOptFunc is an implementation of StockOpt
+type StockOptFunc func(s *Stock)
+
+// Apply calls f
+func (f StockOptFunc) Apply(s *Stock) {
+	f(s)
+}
+
+// SetStockOutputLogger sets Stock service output logger
+func SetStockOutputLogger(l log.Logger) StockOpt {
+	return StockOptFunc(func(s *Stock) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		s.olgr = l
+	})
+}
+
+// SetStockErrorLogger sets Stock service error logger
+func SetStockErrorLogger(l log.Logger) StockOpt {
+	return StockOptFunc(func(s *Stock) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		s.elgr = l
+	})
+}
+
+// SetStockUnitOfWork sets the UnitOfWork used by Stock service
+// without it a movement and the quantity change are not atomic
+func SetStockUnitOfWork(u repo.UnitOfWork) StockOpt {
+	return StockOptFunc(func(s *Stock) {
+		s.uow = u
+	})
+}
+
+// NewStock returns a new Stock service
+func NewStock(stk repo.Stock, pdt repo.Product, lvl repo.StockLevel, loc repo.Location, opts ...StockOpt) *Stock {
+	s := &Stock{
+		stkRepo: stk,
+		pdtRepo: pdt,
+		lvlRepo: lvl,
+		locRepo: loc,
+		olgr:    log.DefaultOutputLogger,
+		elgr:    log.DefaultErrorLogger,
+	}
+	for _, opt := range opts {
+		opt.Apply(s)
+	}
+	return s
+}
+
+// Move records a stock movement of a product and applies it to its quantity
+// and to its level at the location of the movement, a movement without a
+// location adds to the unallocated level and takes from the levels in drawLevels order
+// it returns ErrInsufficientStock if the movement takes more than on hand
+func (s *Stock) Move(ctx context.Context, pdtID string, mvt model.StockMovement) (string, error) {
+	s.olgr.Println("moving stock of", pdtID, mvt)
+	mvt.ProductID = pdtID
+	if mvt.Type == model.MovementTransfer {
+		return "", model.ValidationError{"Type": []string{"is invalid"}}
+	}
+
+	var mID string
+	err := s.transact(ctx, func(r repo.Repos) error {
+		if _, err := s.product(ctx, r.Product, pdtID); err != nil {
+			return err
+		}
+		if mvt.LocationID != "" {
+			if _, err := fetchLocation(ctx, r.Location, mvt.LocationID); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		mID, err = r.Stock.Create(ctx, mvt)
+		if err != nil {
+			return err
+		}
+		if _, err := r.Product.AdjustQuantity(ctx, pdtID, mvt.Delta()); err != nil {
+			if err == repo.ErrInsufficientQuantity {
+				return ErrInsufficientStock
+			}
+			return err
+		}
+		if r.StockLevel == nil {
+			return nil
+		}
+		if mvt.LocationID == "" && mvt.Delta() < 0 {
+			return drawLevels(ctx, r.StockLevel, pdtID, -mvt.Delta())
+		}
+		return adjustLevel(ctx, r.StockLevel, pdtID, mvt.LocationID, mvt.Delta())
+	})
+	if err != nil {
+		s.elgr.Println("failed to move stock of", pdtID, err)
+		return "", err
+	}
+	s.olgr.Println("moved stock of", pdtID, mvt)
+	return mID, nil
+}
+
+// Transfer moves units of a product between two locations and returns
+// the ids of the outgoing and incoming movements, the quantity of the
+// product does not change, an empty From moves the unallocated units
+// it returns ErrInsufficientStock if the source has fewer units than the transfer
+func (s *Stock) Transfer(ctx context.Context, pdtID string, trf model.Transfer) ([]string, error) {
+	s.olgr.Println("transferring stock of", pdtID, trf)
+	if err := trf.Validate(); err != nil {
+		return nil, err
+	}
+
+	mvts := []model.StockMovement{
+		{
+			ProductID:  pdtID,
+			LocationID: trf.From,
+			Type:       model.MovementTransfer,
+			Quantity:   -trf.Quantity,
+			Reason:     "transfer to " + trf.To,
+			Reference:  trf.Reference,
+		},
+		{
+			ProductID:  pdtID,
+			LocationID: trf.To,
+			Type:       model.MovementTransfer,
+			Quantity:   trf.Quantity,
+			Reason:     "transfer from " + locationName(trf.From),
+			Reference:  trf.Reference,
+		},
+	}
+	mIDs := []string{}
+	err := s.transact(ctx, func(r repo.Repos) error {
+		mIDs = mIDs[:0]
+		if _, err := s.product(ctx, r.Product, pdtID); err != nil {
+			return err
+		}
+		for _, mvt := range mvts {
+			if mvt.LocationID == "" {
+				continue
+			}
+			if _, err := fetchLocation(ctx, r.Location, mvt.LocationID); err != nil {
+				return err
+			}
+		}
+		for _, mvt := range mvts {
+			if err := adjustLevel(ctx, r.StockLevel, pdtID, mvt.LocationID, mvt.Delta()); err != nil {
+				return err
+			}
+			mID, err := r.Stock.Create(ctx, mvt)
+			if err != nil {
+				return err
+			}
+			mIDs = append(mIDs, mID)
+		}
+		return nil
+	})
+	if err != nil {
+		s.elgr.Println("failed to transfer stock of", pdtID, err)
+		return nil, err
+	}
+	s.olgr.Println("transferred stock of", pdtID, trf)
+	return mIDs, nil
+}
+
+// locationName returns locID or unallocated for the empty location
+func locationName(locID string) string {
+	if locID == "" {
+		return "unallocated"
+	}
+	return locID
+}
+
+// adjustLevel adds delta to the level of a product at a location
+func adjustLevel(ctx context.Context, rep repo.StockLevel, pdtID, locID string, delta int) error {
+	if _, err := rep.AdjustLevel(ctx, pdtID, locID, delta); err != nil {
+		if err == repo.ErrInsufficientQuantity {
+			return ErrInsufficientStock
+		}
+		return err
+	}
+	return nil
+}
+
+// drawLevels takes qty units of a product from its levels, the unallocated
+// level first and then the locations in order
+// it returns ErrInsufficientStock if the levels hold fewer units than qty
+func drawLevels(ctx context.Context, rep repo.StockLevel, pdtID string, qty int) error {
+	q := repo.Query{"product_id": {pdtID}}
+	n, err := rep.SearchCount(ctx, q)
+	if err != nil {
+		return err
+	}
+	res := []interface{}{}
+	if n != 0 {
+		if res, err = rep.Search(ctx, q, 0, n); err != nil {
+			return err
+		}
+	}
+	for _, re := range res {
+		if qty == 0 {
+			break
+		}
+		lvl, ok := re.(model.StockLevel)
+		if !ok {
+			return ErrFailedToAssert
+		}
+		take := lvl.Quantity
+		if take > qty {
+			take = qty
+		}
+		if take == 0 {
+			continue
+		}
+		if err := adjustLevel(ctx, rep, pdtID, lvl.LocationID, -take); err != nil {
+			return err
+		}
+		qty -= take
+	}
+	if qty != 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// Get returns the current stock of a product with its levels per location
+func (s *Stock) Get(ctx context.Context, pdtID string) (*model.Stock, error) {
+	s.olgr.Println("getting stock of", pdtID)
+	pdt, err := s.product(ctx, s.pdtRepo, pdtID)
+	if err != nil {
+		s.elgr.Println("failed to get stock of", pdtID, err)
+		return nil, err
+	}
+	lvls, err := s.levels(ctx, pdtID)
+	if err != nil {
+		s.elgr.Println("failed to get stock levels of", pdtID, err)
+		return nil, err
+	}
+	s.olgr.Println("got stock of", pdtID)
+	return &model.Stock{
+		ProductID: pdt.ID,
+		Quantity:  pdt.Quantity,
+		Reserved:  pdt.Reserved,
+		Available: pdt.Available,
+		Levels:    lvls,
+	}, nil
+}
+
+// levels returns every stock level of a product
+func (s *Stock) levels(ctx context.Context, pdtID string) ([]model.StockLevel, error) {
+	q := repo.Query{"product_id": {pdtID}}
+	n, err := s.lvlRepo.SearchCount(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	lvls := []model.StockLevel{}
+	if n == 0 {
+		return lvls, nil
+	}
+	res, err := s.lvlRepo.Search(ctx, q, 0, n)
+	if err != nil {
+		return nil, err
+	}
+	for _, re := range res {
+		lvl, ok := re.(model.StockLevel)
+		if !ok {
+			s.elgr.Printf("failed to assert model.StockLevel %#v\n", re)
+			return nil, ErrFailedToAssert
+		}
+		lvls = append(lvls, lvl)
+	}
+	return lvls, nil
+}
+
+// Movements returns the stock movements of a product latest first
+func (s *Stock) Movements(ctx context.Context, pdtID string, skip, limit int) ([]model.StockMovement, error) {
+	s.olgr.Println("listing stock movements of", pdtID, skip, limit)
+	res, err := s.stkRepo.Search(ctx, stockQuery(pdtID), skip, limit)
+	if err != nil {
+		s.elgr.Println("failed to list stock movements of", pdtID, err)
+		return nil, err
+	}
+	mvts := []model.StockMovement{}
+	for _, re := range res {
+		mvt, ok := re.(model.StockMovement)
+		if !ok {
+			s.elgr.Printf("failed to assert model.StockMovement %#v\n", re)
+			return nil, ErrFailedToAssert
+		}
+		mvts = append(mvts, mvt)
+	}
+	s.olgr.Println("listed stock movements of", pdtID, skip, limit)
+	return mvts, nil
+}
+
+// CountMovements returns number of stock movements of a product
+// it returns ErrProductNotFound if there is no such product
+func (s *Stock) CountMovements(ctx context.Context, pdtID string) (int, error) {
+	s.olgr.Println("counting stock movements of", pdtID)
+	if _, err := s.product(ctx, s.pdtRepo, pdtID); err != nil {
+		s.elgr.Println("failed to fetch product", pdtID, err)
+		return 0, err
+	}
+	n, err := s.stkRepo.SearchCount(ctx, stockQuery(pdtID))
+	if err != nil {
+		s.elgr.Println("failed to count stock movements of", pdtID, err)
+		return 0, err
+	}
+	s.olgr.Println("counted stock movements of", pdtID)
+	return n, nil
+}
+
+// product returns the product of id from rep
+func (s *Stock) product(ctx context.Context, rep repo.Product, id string) (*model.Product, error) {
+	pdtI, err := rep.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pdtI == nil {
+		return nil, ErrProductNotFound
+	}
+	pdt, ok := pdtI.(model.Product)
+	if !ok {
+		s.elgr.Printf("failed to assert model.Product %#v\n", pdtI)
+		return nil, ErrFailedToAssert
+	}
+	return &pdt, nil
+}
+
+// transact runs fn with the repos bound to a single unit of work
+// if no UnitOfWork is set fn runs with the service repos directly
+func (s *Stock) transact(ctx context.Context, fn func(r repo.Repos) error) error {
+	if s.uow == nil {
+		return fn(repo.Repos{
+			Product:    s.pdtRepo,
+			Stock:      s.stkRepo,
+			StockLevel: s.lvlRepo,
+			Location:   s.locRepo,
+		})
+	}
+	return s.uow.Do(ctx, fn)
+}
+
+func stockQuery(pdtID string) repo.Query {
+	return repo.Query{"product_id": []interface{}{pdtID}}
+}
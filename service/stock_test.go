@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/satori/go.uuid"
+
+	"github.com/msyrus/simple-product-inv/mock_repo"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+)
+
+func TestStock_Move(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	txPdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	txStkRepo := mock_repo.NewMockStock(mockCtrl)
+	uow := mock_repo.NewMockUnitOfWork(mockCtrl)
+
+	stkSvc := NewStock(stkRepo, pdtRepo, nil, nil)
+	txStkSvc := NewStock(stkRepo, pdtRepo, nil, nil, SetStockUnitOfWork(uow))
+
+	uid := uuid.NewV4().String()
+	pdt := model.Product{ID: uid, Name: "Test1", Price: 100, Weight: 1}
+	rcpt := model.StockMovement{ProductID: uid, Type: model.MovementReceipt, Quantity: 5}
+	sale := model.StockMovement{ProductID: uid, Type: model.MovementSale, Quantity: 9}
+
+	runInTx := func(ctx context.Context, fn func(repo.Repos) error) error {
+		return fn(repo.Repos{Product: txPdtRepo, Stock: txStkRepo})
+	}
+
+	gomock.InOrder(
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "not_available_id").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), uid).Return(pdt, nil),
+		stkRepo.EXPECT().Create(gomock.Any(), rcpt).Return("1234", nil),
+		pdtRepo.EXPECT().AdjustQuantity(gomock.Any(), uid, 5).Return(5, nil),
+		uow.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(runInTx),
+		txPdtRepo.EXPECT().Fetch(gomock.Any(), uid).Return(pdt, nil),
+		txStkRepo.EXPECT().Create(gomock.Any(), sale).Return("5678", nil),
+		txPdtRepo.EXPECT().AdjustQuantity(gomock.Any(), uid, -9).Return(0, repo.ErrInsufficientQuantity),
+	)
+
+	type args struct {
+		id  string
+		mvt model.StockMovement
+	}
+	tests := []struct {
+		name    string
+		r       *Stock
+		args    args
+		want    string
+		wantErr error
+	}{
+		{
+			r: stkSvc,
+			args: args{
+				id:  "not_available_id",
+				mvt: rcpt,
+			},
+			want:    "",
+			wantErr: ErrProductNotFound,
+		},
+		{
+			r: stkSvc,
+			args: args{
+				id:  uid,
+				mvt: rcpt,
+			},
+			want:    "1234",
+			wantErr: nil,
+		},
+		{
+			r: txStkSvc,
+			args: args{
+				id:  uid,
+				mvt: sale,
+			},
+			want:    "",
+			wantErr: ErrInsufficientStock,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.Move(context.Background(), tt.args.id, tt.args.mvt)
+			if err != tt.wantErr {
+				t.Errorf("Stock.Move() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Stock.Move() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStock_Get(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	lvlRepo := mock_repo.NewMockStockLevel(mockCtrl)
+
+	stkSvc := NewStock(stkRepo, pdtRepo, lvlRepo, nil)
+
+	uid := uuid.NewV4().String()
+	pdt := model.Product{ID: uid, Name: "Test1", Price: 100, Weight: 1, Quantity: 3, Available: true}
+
+	gomock.InOrder(
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "not_available_id").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), uid).Return(pdt, nil),
+		lvlRepo.EXPECT().SearchCount(gomock.Any(), repo.Query{"product_id": {uid}}).Return(1, nil),
+		lvlRepo.EXPECT().Search(gomock.Any(), repo.Query{"product_id": {uid}}, 0, 1).Return([]interface{}{
+			model.StockLevel{ProductID: uid, LocationID: "loc1", Quantity: 3},
+		}, nil),
+	)
+
+	tests := []struct {
+		name    string
+		id      string
+		want    *model.Stock
+		wantErr bool
+	}{
+		{
+			id:      "not_available_id",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			id: uid,
+			want: &model.Stock{ProductID: uid, Quantity: 3, Available: true, Levels: []model.StockLevel{
+				{ProductID: uid, LocationID: "loc1", Quantity: 3},
+			}},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stkSvc.Get(context.Background(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Stock.Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Stock.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStock_Transfer(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	stkSvc := NewStock(rps.Stock, rps.Product, rps.StockLevel, rps.Location,
+		SetStockUnitOfWork(store),
+		SetStockOutputLogger(nil),
+		SetStockErrorLogger(nil),
+	)
+
+	pdtID, err := rps.Product.Create(ctx, model.Product{Name: "Test1", Price: 100, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dhk, _ := rps.Location.Create(ctx, model.Location{Name: "Dhaka"})
+	ctg, _ := rps.Location.Create(ctx, model.Location{Name: "Chittagong"})
+	if _, err := stkSvc.Move(ctx, pdtID, model.StockMovement{LocationID: dhk, Type: model.MovementReceipt, Quantity: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		trf     model.Transfer
+		wantErr error
+		want    map[string]int
+	}{
+		{
+			name:    "unknown location",
+			trf:     model.Transfer{From: dhk, To: "unavailable_id", Quantity: 1},
+			wantErr: ErrLocationNotFound,
+			want:    map[string]int{dhk: 5},
+		},
+		{
+			name:    "insufficient",
+			trf:     model.Transfer{From: dhk, To: ctg, Quantity: 6},
+			wantErr: ErrInsufficientStock,
+			want:    map[string]int{dhk: 5},
+		},
+		{
+			name: "transfer",
+			trf:  model.Transfer{From: dhk, To: ctg, Quantity: 2, Reference: "TR-1"},
+			want: map[string]int{dhk: 3, ctg: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := stkSvc.Transfer(ctx, pdtID, tt.trf)
+			if err != tt.wantErr {
+				t.Fatalf("Stock.Transfer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(ids) != 2 {
+				t.Errorf("Stock.Transfer() = %v, want 2 movement ids", ids)
+			}
+
+			stk, err := stkSvc.Get(ctx, pdtID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]int{}
+			for _, lvl := range stk.Levels {
+				got[lvl.LocationID] = lvl.Quantity
+			}
+			if stk.Quantity != 5 || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Stock.Get() quantity = %v levels = %v, want 5 %v", stk.Quantity, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := stkSvc.Move(ctx, pdtID, model.StockMovement{Type: model.MovementTransfer, LocationID: dhk, Quantity: 1}); err == nil {
+		t.Errorf("Stock.Move() of a transfer error = nil, want validation error")
+	}
+	if _, err := stkSvc.Move(ctx, pdtID, model.StockMovement{LocationID: ctg, Type: model.MovementSale, Quantity: 3}); err != ErrInsufficientStock {
+		t.Errorf("Stock.Move() sale over location level error = %v, want %v", err, ErrInsufficientStock)
+	}
+}
+
+func TestStock_TransferUnallocated(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	stkSvc := NewStock(rps.Stock, rps.Product, rps.StockLevel, rps.Location,
+		SetStockUnitOfWork(store),
+		SetStockOutputLogger(nil),
+		SetStockErrorLogger(nil),
+	)
+
+	pdtID, err := rps.Product.Create(ctx, model.Product{Name: "Test1", Price: 100, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dhk, _ := rps.Location.Create(ctx, model.Location{Name: "Dhaka"})
+	// the stock received before locations is backfilled into the unallocated level
+	if _, err := rps.Product.AdjustQuantity(ctx, pdtID, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rps.StockLevel.AdjustLevel(ctx, pdtID, "", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		trf     model.Transfer
+		wantErr error
+		want    map[string]int
+	}{
+		{
+			name:    "insufficient",
+			trf:     model.Transfer{To: dhk, Quantity: 6},
+			wantErr: ErrInsufficientStock,
+			want:    map[string]int{"": 5},
+		},
+		{
+			name: "transfer",
+			trf:  model.Transfer{To: dhk, Quantity: 3},
+			want: map[string]int{"": 2, dhk: 3},
+		},
+		{
+			name:    "transfer back",
+			trf:     model.Transfer{From: dhk, Quantity: 1},
+			wantErr: model.ValidationError{"To": []string{"is empty"}},
+			want:    map[string]int{"": 2, dhk: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := stkSvc.Transfer(ctx, pdtID, tt.trf)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("Stock.Transfer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := levelsOf(t, rps, pdtID); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("levels = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	res, err := rps.Stock.Search(ctx, repo.Query{"product_id": {pdtID}}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reasons := []string{}
+	for _, re := range res {
+		reasons = append(reasons, re.(model.StockMovement).Reason)
+	}
+	sort.Strings(reasons)
+	if want := []string{"transfer from unallocated", "transfer to " + dhk}; !reflect.DeepEqual(reasons, want) {
+		t.Errorf("movement reasons = %v, want %v", reasons, want)
+	}
+}
+
+// levelsOf returns the stock levels of product pdtID in rps by location
+// it fails t unless the levels sum up to the quantity of the product
+func levelsOf(t *testing.T, rps repo.Repos, pdtID string) map[string]int {
+	t.Helper()
+	res, err := rps.StockLevel.Search(context.Background(), repo.Query{"product_id": {pdtID}}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lvls, sum := map[string]int{}, 0
+	for _, re := range res {
+		lvl := re.(model.StockLevel)
+		lvls[lvl.LocationID] = lvl.Quantity
+		sum += lvl.Quantity
+	}
+	pdtI, err := rps.Product.Fetch(context.Background(), pdtID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qty := pdtI.(model.Product).Quantity; sum != qty {
+		t.Errorf("sum of levels = %v, want quantity %v", sum, qty)
+	}
+	return lvls
+}
+
+func TestStock_MoveLevels(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	stkSvc := NewStock(rps.Stock, rps.Product, rps.StockLevel, rps.Location,
+		SetStockUnitOfWork(store),
+		SetStockOutputLogger(nil),
+		SetStockErrorLogger(nil),
+	)
+
+	pdtID, err := rps.Product.Create(ctx, model.Product{Name: "Test1", Price: 100, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dhk, _ := rps.Location.Create(ctx, model.Location{Name: "Dhaka"})
+
+	tests := []struct {
+		name    string
+		mvt     model.StockMovement
+		wantErr error
+		want    map[string]int
+	}{
+		{
+			name: "unallocated receipt",
+			mvt:  model.StockMovement{Type: model.MovementReceipt, Quantity: 3},
+			want: map[string]int{"": 3},
+		},
+		{
+			name: "located receipt",
+			mvt:  model.StockMovement{LocationID: dhk, Type: model.MovementReceipt, Quantity: 4},
+			want: map[string]int{"": 3, dhk: 4},
+		},
+		{
+			name: "unallocated sale",
+			mvt:  model.StockMovement{Type: model.MovementSale, Quantity: 5},
+			want: map[string]int{"": 0, dhk: 2},
+		},
+		{
+			name:    "unallocated sale over quantity",
+			mvt:     model.StockMovement{Type: model.MovementSale, Quantity: 3},
+			wantErr: ErrInsufficientStock,
+			want:    map[string]int{"": 0, dhk: 2},
+		},
+		{
+			name: "unallocated adjustment",
+			mvt:  model.StockMovement{Type: model.MovementAdjustment, Quantity: -1, Reason: "damaged"},
+			want: map[string]int{"": 0, dhk: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := stkSvc.Move(ctx, pdtID, tt.mvt); err != tt.wantErr {
+				t.Fatalf("Stock.Move() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := levelsOf(t, rps, pdtID); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("levels after Stock.Move() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,29 +1,211 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/migration"
 	"github.com/msyrus/simple-product-inv/version"
 )
 
+// DefaultCheckTimeout is the time a check may take before it is failed
+const DefaultCheckTimeout = 2 * time.Second
+
+// ErrPendingMigrations is returned by MigrationChecker when the schema is behind
+var ErrPendingMigrations = errors.New("service: pending migrations")
+
+// Checker checks a dependency of the system
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an implementation of Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Pinger is a dependency that can be pinged, like a database
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingChecker returns a Checker that pings p
+func PingChecker(p Pinger) Checker {
+	return CheckerFunc(p.Ping)
+}
+
+// PendingMigrator lists the migrations that are not applied yet
+type PendingMigrator interface {
+	Pending(ctx context.Context) ([]migration.Migration, error)
+}
+
+// MigrationChecker returns a Checker that fails while m has pending migrations
+func MigrationChecker(m PendingMigrator) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		pdg, err := m.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pdg) != 0 {
+			return fmt.Errorf("%w: %d behind, latest %d_%s", ErrPendingMigrations, len(pdg), pdg[len(pdg)-1].Version, pdg[len(pdg)-1].Name)
+		}
+		return nil
+	})
+}
+
+// check is a registered Checker with its last failure
+type check struct {
+	name     string
+	checker  Checker
+	critical bool
+
+	mu          sync.Mutex
+	lastErr     error
+	lastErrorAt time.Time
+}
+
+// CheckResult holds the outcome of a single check run
+type CheckResult struct {
+	Name     string
+	Critical bool
+	OK       bool
+	Latency  time.Duration
+	// LastError is the latest failure of the check, it is kept after
+	// the check recovers so that flapping dependencies are visible
+	LastError   error
+	LastErrorAt time.Time
+}
+
+// Report holds the outcome of a set of checks
+// it is not OK if any critical check failed
+type Report struct {
+	OK     bool
+	Checks []CheckResult
+}
+
 // System is the basic implementation of System
 type System struct {
+	timeout time.Duration
+	ready   []*check
+	health  []*check
+}
+
+// SystemOpt represents options for NewSystem
+type SystemOpt interface {
+	Apply(s *System)
+}
+
+// SystemOptFunc is an implementation of SystemOpt
+type SystemOptFunc func(s *System)
+
+// Apply calls f
+func (f SystemOptFunc) Apply(s *System) {
+	f(s)
+}
+
+// SetSystemCheckTimeout sets the time a check may take before it is failed
+func SetSystemCheckTimeout(d time.Duration) SystemOpt {
+	return SystemOptFunc(func(s *System) {
+		if d <= 0 {
+			d = DefaultCheckTimeout
+		}
+		s.timeout = d
+	})
+}
+
+// AddReadyCheck registers c as a check of Ready
+// the system is not ready while a critical check fails
+func AddReadyCheck(name string, c Checker, critical bool) SystemOpt {
+	return SystemOptFunc(func(s *System) {
+		s.ready = append(s.ready, &check{name: name, checker: c, critical: critical})
+	})
+}
+
+// AddHealthCheck registers c as a check of Health
+// the system is not healthy while a critical check fails
+func AddHealthCheck(name string, c Checker, critical bool) SystemOpt {
+	return SystemOptFunc(func(s *System) {
+		s.health = append(s.health, &check{name: name, checker: c, critical: critical})
+	})
 }
 
 // NewSystem returns a new System service
-func NewSystem() *System {
-	return &System{}
+func NewSystem(opts ...SystemOpt) *System {
+	s := &System{
+		timeout: DefaultCheckTimeout,
+	}
+	for _, opt := range opts {
+		opt.Apply(s)
+	}
+	return s
 }
 
-// Ready returns if the system is ready to serve
-func (s *System) Ready() (bool, error) {
-	return true, nil
+// Ready runs the ready checks and returns if the system is ready to serve
+func (s *System) Ready(ctx context.Context) Report {
+	return s.run(ctx, s.ready)
 }
 
-// Health returns system health
-func (s *System) Health() (bool, error) {
-	return true, nil
+// Health runs the health checks and returns system health
+func (s *System) Health(ctx context.Context) Report {
+	return s.run(ctx, s.health)
 }
 
 // Version returns system version
 func (s *System) Version() string {
 	return version.Version
 }
+
+// run runs chks concurrently, each bounded by the check timeout
+func (s *System) run(ctx context.Context, chks []*check) Report {
+	rep := Report{
+		OK:     true,
+		Checks: make([]CheckResult, len(chks)),
+	}
+
+	wg := sync.WaitGroup{}
+	for i, c := range chks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			rep.Checks[i] = s.runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, res := range rep.Checks {
+		if res.Critical && !res.OK {
+			rep.OK = false
+		}
+	}
+	return rep
+}
+
+func (s *System) runCheck(ctx context.Context, c *check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.checker.Check(ctx)
+	lat := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.lastErr = err
+		c.lastErrorAt = start
+	}
+	return CheckResult{
+		Name:        c.name,
+		Critical:    c.critical,
+		OK:          err == nil,
+		Latency:     lat,
+		LastError:   c.lastErr,
+		LastErrorAt: c.lastErrorAt,
+	}
+}
@@ -1,79 +1,196 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/msyrus/simple-product-inv/migration"
 	"github.com/msyrus/simple-product-inv/version"
 )
 
 func TestNewSystem(t *testing.T) {
 	tests := []struct {
 		name string
+		opts []SystemOpt
 		want *System
 	}{
 		{
-			want: &System{},
+			want: &System{timeout: DefaultCheckTimeout},
+		},
+		{
+			opts: []SystemOpt{SetSystemCheckTimeout(time.Second)},
+			want: &System{timeout: time.Second},
+		},
+		{
+			opts: []SystemOpt{SetSystemCheckTimeout(0)},
+			want: &System{timeout: DefaultCheckTimeout},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewSystem(); !reflect.DeepEqual(got, tt.want) {
+			if got := NewSystem(tt.opts...); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewSystem() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func okCheck(context.Context) error {
+	return nil
+}
+
+func failCheck(context.Context) error {
+	return errors.New("check failed")
+}
+
+func slowCheck(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
 func TestSystem_Ready(t *testing.T) {
 	tests := []struct {
 		name    string
 		s       *System
 		want    bool
-		wantErr bool
+		wantOKs []bool
 	}{
 		{
 			s:       NewSystem(),
 			want:    true,
-			wantErr: false,
+			wantOKs: []bool{},
+		},
+		{
+			name: "all ok",
+			s: NewSystem(
+				AddReadyCheck("a", CheckerFunc(okCheck), true),
+				AddReadyCheck("b", CheckerFunc(okCheck), false),
+			),
+			want:    true,
+			wantOKs: []bool{true, true},
+		},
+		{
+			name: "non critical failure",
+			s: NewSystem(
+				AddReadyCheck("a", CheckerFunc(okCheck), true),
+				AddReadyCheck("b", CheckerFunc(failCheck), false),
+			),
+			want:    true,
+			wantOKs: []bool{true, false},
+		},
+		{
+			name: "critical failure",
+			s: NewSystem(
+				AddReadyCheck("a", CheckerFunc(failCheck), true),
+				AddReadyCheck("b", CheckerFunc(okCheck), false),
+			),
+			want:    false,
+			wantOKs: []bool{false, true},
+		},
+		{
+			name: "timeout",
+			s: NewSystem(
+				SetSystemCheckTimeout(time.Millisecond),
+				AddReadyCheck("a", CheckerFunc(slowCheck), true),
+			),
+			want:    false,
+			wantOKs: []bool{false},
+		},
+		{
+			name: "health checks are not run",
+			s: NewSystem(
+				AddHealthCheck("a", CheckerFunc(failCheck), true),
+			),
+			want:    true,
+			wantOKs: []bool{},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.s.Ready()
-			if (err != nil) != tt.wantErr {
-				t.Errorf("System.Ready() error = %v, wantErr %v", err, tt.wantErr)
-				return
+			got := tt.s.Ready(context.Background())
+			if got.OK != tt.want {
+				t.Errorf("System.Ready() OK = %v, want %v", got.OK, tt.want)
 			}
-			if got != tt.want {
-				t.Errorf("System.Ready() = %v, want %v", got, tt.want)
+			oks := []bool{}
+			for _, res := range got.Checks {
+				oks = append(oks, res.OK)
+				if res.OK == (res.LastError != nil) {
+					t.Errorf("System.Ready() check %s OK = %v, LastError = %v", res.Name, res.OK, res.LastError)
+				}
+			}
+			if !reflect.DeepEqual(oks, tt.wantOKs) {
+				t.Errorf("System.Ready() checks = %v, want %v", oks, tt.wantOKs)
 			}
 		})
 	}
 }
 
 func TestSystem_Health(t *testing.T) {
+	fail := true
+	flaky := func(context.Context) error {
+		if fail {
+			return errors.New("flaky")
+		}
+		return nil
+	}
+	s := NewSystem(AddHealthCheck("flaky", CheckerFunc(flaky), true))
+
+	got := s.Health(context.Background())
+	if got.OK {
+		t.Fatalf("System.Health() OK = %v, want %v", got.OK, false)
+	}
+	failedAt := got.Checks[0].LastErrorAt
+
+	fail = false
+	got = s.Health(context.Background())
+	if !got.OK {
+		t.Fatalf("System.Health() OK = %v, want %v", got.OK, true)
+	}
+	res := got.Checks[0]
+	if res.LastError == nil || !res.LastErrorAt.Equal(failedAt) {
+		t.Errorf("System.Health() LastError = %v at %v, want kept from %v", res.LastError, res.LastErrorAt, failedAt)
+	}
+}
+
+type pendingMigrator struct {
+	pdg []migration.Migration
+	err error
+}
+
+func (m pendingMigrator) Pending(context.Context) ([]migration.Migration, error) {
+	return m.pdg, m.err
+}
+
+func TestMigrationChecker(t *testing.T) {
+	errPending := errors.New("pending failed")
+
 	tests := []struct {
 		name    string
-		s       *System
-		want    bool
-		wantErr bool
+		m       PendingMigrator
+		wantErr error
 	}{
 		{
-			s:       NewSystem(),
-			want:    true,
-			wantErr: false,
+			name: "current",
+			m:    pendingMigrator{pdg: []migration.Migration{}},
+		},
+		{
+			name:    "behind",
+			m:       pendingMigrator{pdg: []migration.Migration{{Version: 2, Name: "tags"}}},
+			wantErr: ErrPendingMigrations,
+		},
+		{
+			name:    "error",
+			m:       pendingMigrator{err: errPending},
+			wantErr: errPending,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.s.Health()
-			if (err != nil) != tt.wantErr {
-				t.Errorf("System.Health() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if got != tt.want {
-				t.Errorf("System.Health() = %v, want %v", got, tt.want)
+			if err := MigrationChecker(tt.m).Check(context.Background()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("MigrationChecker().Check() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
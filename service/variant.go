@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+
+	"github.com/msyrus/simple-product-inv/log"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+)
+
+// Variant holds fields and dependencies to serve product variants
+type Variant struct {
+	vrtRepo repo.Variant
+	pdtRepo repo.Product
+	uow     repo.UnitOfWork
+	olgr    log.Logger
+	elgr    log.Logger
+}
+
+// VariantOpt represents options for NewVariant
+type VariantOpt interface {
+	Apply(v *Variant)
+}
+
+// VariantOptFunc is an implementation of VariantOpt
+type VariantOptFunc func(v *Variant)
+
+// Apply calls f
+func (f VariantOptFunc) Apply(v *Variant) {
+	f(v)
+}
+
+// SetVariantOutputLogger sets Variant service output logger
+func SetVariantOutputLogger(l log.Logger) VariantOpt {
+	return VariantOptFunc(func(v *Variant) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		v.olgr = l
+	})
+}
+
+// SetVariantErrorLogger sets Variant service error logger
+func SetVariantErrorLogger(l log.Logger) VariantOpt {
+	return VariantOptFunc(func(v *Variant) {
+		if l == nil {
+			l = &noOpLogger{}
+		}
+		v.elgr = l
+	})
+}
+
+// SetVariantUnitOfWork sets the UnitOfWork used by Variant service
+// without it the checks and the changes they guard are not atomic
+func SetVariantUnitOfWork(u repo.UnitOfWork) VariantOpt {
+	return VariantOptFunc(func(v *Variant) {
+		v.uow = u
+	})
+}
+
+// NewVariant returns a new Variant service
+func NewVariant(vrt repo.Variant, pdt repo.Product, opts ...VariantOpt) *Variant {
+	v := &Variant{
+		vrtRepo: vrt,
+		pdtRepo: pdt,
+		olgr:    log.DefaultOutputLogger,
+		elgr:    log.DefaultErrorLogger,
+	}
+	for _, opt := range opts {
+		opt.Apply(v)
+	}
+	return v
+}
+
+// Add creates a new variant of product pdtID
+func (v *Variant) Add(ctx context.Context, pdtID string, vrt model.Variant) (string, error) {
+	v.olgr.Println("creating variant of product", pdtID, vrt)
+	var id string
+	err := v.transact(ctx, func(r repo.Repos) error {
+		if err := checkProduct(ctx, r.Product, pdtID); err != nil {
+			return err
+		}
+		vrt.ProductID = pdtID
+		var err error
+		id, err = r.Variant.Create(ctx, vrt)
+		return err
+	})
+	if err != nil {
+		v.elgr.Println("failed to create variant of product", pdtID, err)
+		return "", variantConflict(err)
+	}
+	v.olgr.Println("created variant", id)
+	return id, nil
+}
+
+// Get returns the variant id of product pdtID
+func (v *Variant) Get(ctx context.Context, pdtID, id string) (*model.Variant, error) {
+	v.olgr.Println("fetching variant by id", pdtID, id)
+	if err := checkProduct(ctx, v.pdtRepo, pdtID); err != nil {
+		v.elgr.Println("failed to fetch product", pdtID, err)
+		return nil, err
+	}
+	vrt, err := fetchVariant(ctx, v.vrtRepo, pdtID, id)
+	if err != nil {
+		v.elgr.Println("failed to fetch variant by id", pdtID, id, err)
+		return nil, err
+	}
+	v.olgr.Println("fetched variant by id", pdtID, id)
+	return vrt, nil
+}
+
+// Update updates the variant id of product pdtID
+func (v *Variant) Update(ctx context.Context, pdtID, id string, vrt model.Variant) error {
+	v.olgr.Println("updating variant by id", pdtID, id)
+	err := v.transact(ctx, func(r repo.Repos) error {
+		if err := checkProduct(ctx, r.Product, pdtID); err != nil {
+			return err
+		}
+		if _, err := fetchVariant(ctx, r.Variant, pdtID, id); err != nil {
+			return err
+		}
+		vrt.ProductID = pdtID
+		return r.Variant.Update(ctx, id, vrt)
+	})
+	if err != nil {
+		v.elgr.Println("failed to update variant by id", pdtID, id, err)
+		return variantConflict(err)
+	}
+	v.olgr.Println("updated variant by id", pdtID, id)
+	return nil
+}
+
+// Remove deletes the variant id of product pdtID
+func (v *Variant) Remove(ctx context.Context, pdtID, id string) error {
+	v.olgr.Println("deleting variant by id", pdtID, id)
+	err := v.transact(ctx, func(r repo.Repos) error {
+		if _, err := fetchVariant(ctx, r.Variant, pdtID, id); err != nil {
+			return err
+		}
+		return r.Variant.Delete(ctx, id)
+	})
+	if err != nil {
+		v.elgr.Println("failed to delete variant by id", pdtID, id, err)
+		return err
+	}
+	v.olgr.Println("deleted variant by id", pdtID, id)
+	return nil
+}
+
+// List returns the variants of product pdtID with skip and limit in creation order
+func (v *Variant) List(ctx context.Context, pdtID string, skip, limit int) ([]model.Variant, error) {
+	v.olgr.Println("listing variants of product", pdtID, skip, limit)
+	res, err := v.vrtRepo.Search(ctx, repo.Query{"product_id": {pdtID}}, skip, limit)
+	if err != nil {
+		v.elgr.Println("failed to list variants of product", pdtID, skip, limit, err)
+		return nil, err
+	}
+	vrts := []model.Variant{}
+	for _, re := range res {
+		vrt, ok := re.(model.Variant)
+		if !ok {
+			v.elgr.Printf("failed to assert model.Variant %#v\n", re)
+			return nil, ErrFailedToAssert
+		}
+		vrts = append(vrts, vrt)
+	}
+	v.olgr.Println("listed variants of product", pdtID, skip, limit)
+	return vrts, nil
+}
+
+// Count returns number of variants of product pdtID
+// it returns ErrProductNotFound if there is no such product
+func (v *Variant) Count(ctx context.Context, pdtID string) (int, error) {
+	v.olgr.Println("counting variants of product", pdtID)
+	if err := checkProduct(ctx, v.pdtRepo, pdtID); err != nil {
+		v.elgr.Println("failed to fetch product", pdtID, err)
+		return 0, err
+	}
+	n, err := v.vrtRepo.SearchCount(ctx, repo.Query{"product_id": {pdtID}})
+	if err != nil {
+		v.elgr.Println("failed to count variants of product", pdtID, err)
+		return 0, err
+	}
+	v.olgr.Println("counted variants of product", pdtID)
+	return n, nil
+}
+
+// all returns every variant of product pdtID in creation order
+func (v *Variant) all(ctx context.Context, pdtID string) ([]model.Variant, error) {
+	n, err := v.vrtRepo.SearchCount(ctx, repo.Query{"product_id": {pdtID}})
+	if err != nil {
+		return nil, err
+	}
+	return v.List(ctx, pdtID, 0, n)
+}
+
+// transact runs fn with the repos bound to a single unit of work
+// if no UnitOfWork is set fn runs with the service repos directly
+func (v *Variant) transact(ctx context.Context, fn func(r repo.Repos) error) error {
+	if v.uow == nil {
+		return fn(repo.Repos{
+			Product: v.pdtRepo,
+			Variant: v.vrtRepo,
+		})
+	}
+	return v.uow.Do(ctx, fn)
+}
+
+// variantConflict maps the duplicate variant errors of repo
+// to ErrVariantExists and ErrSKUExists
+func variantConflict(err error) error {
+	switch err {
+	case repo.ErrDuplicateVariant:
+		return ErrVariantExists
+	case repo.ErrDuplicateSKU:
+		return ErrSKUExists
+	}
+	return err
+}
+
+// checkProduct returns ErrProductNotFound if rep has no product of id
+func checkProduct(ctx context.Context, rep repo.Product, id string) error {
+	pdtI, err := rep.Fetch(ctx, id)
+	if err != nil {
+		return err
+	}
+	if pdtI == nil {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// fetchVariant returns the variant id of product pdtID from rep
+// it returns ErrVariantNotFound if there is none
+func fetchVariant(ctx context.Context, rep repo.Variant, pdtID, id string) (*model.Variant, error) {
+	vrtI, err := rep.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if vrtI == nil {
+		return nil, ErrVariantNotFound
+	}
+	vrt, ok := vrtI.(model.Variant)
+	if !ok {
+		return nil, ErrFailedToAssert
+	}
+	if vrt.ProductID != pdtID {
+		return nil, ErrVariantNotFound
+	}
+	return &vrt, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+)
+
+func TestVariant(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	vrtSvc := NewVariant(rps.Variant, rps.Product,
+		SetVariantUnitOfWork(store),
+		SetVariantOutputLogger(nil),
+		SetVariantErrorLogger(nil),
+	)
+	pdtSvc := NewProduct(rps.Product, NewRating(rps.Rating),
+		SetProductVariantService(vrtSvc),
+		SetProductOutputLogger(nil),
+		SetProductErrorLogger(nil),
+	)
+
+	shirt, err := rps.Product.Create(ctx, model.Product{Name: "Shirt", Price: 100, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hat, err := rps.Product.Create(ctx, model.Product{Name: "Hat", Price: 50, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	small, err := vrtSvc.Add(ctx, shirt, model.Variant{Options: map[string]string{"size": "S"}, SKU: "SH-S"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		pdtID   string
+		vrt     model.Variant
+		wantErr error
+	}{
+		{name: "unknown product", pdtID: "unavailable_id", vrt: model.Variant{Options: map[string]string{"size": "M"}}, wantErr: ErrProductNotFound},
+		{name: "same options", pdtID: shirt, vrt: model.Variant{Options: map[string]string{"Size": "S"}}, wantErr: ErrVariantExists},
+		{name: "same sku", pdtID: hat, vrt: model.Variant{Options: map[string]string{"size": "S"}, SKU: "SH-S"}, wantErr: ErrSKUExists},
+		{name: "price override", pdtID: shirt, vrt: model.Variant{Options: map[string]string{"size": "XL"}, Price: 130}, wantErr: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := vrtSvc.Add(ctx, tt.pdtID, tt.vrt); err != tt.wantErr {
+				t.Errorf("Variant.Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := vrtSvc.Get(ctx, hat, small); err != ErrVariantNotFound {
+		t.Errorf("Variant.Get() of another product error = %v, want %v", err, ErrVariantNotFound)
+	}
+	if err := vrtSvc.Remove(ctx, hat, small); err != ErrVariantNotFound {
+		t.Errorf("Variant.Remove() of another product error = %v, want %v", err, ErrVariantNotFound)
+	}
+	vrt, err := vrtSvc.Get(ctx, shirt, small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vrt.Price = 90
+	vrt.Quantity = 4
+	if err := vrtSvc.Update(ctx, shirt, small, *vrt); err != nil {
+		t.Fatalf("Variant.Update() error = %v", err)
+	}
+
+	vrts, err := pdtSvc.Variants(ctx, shirt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdt, _ := pdtSvc.Get(ctx, shirt)
+	if got := model.PriceRangeOf(*pdt, vrts); len(vrts) != 2 || !reflect.DeepEqual(got, &model.PriceRange{Min: 90, Max: 130}) {
+		t.Errorf("Product.Variants() = %v, price range %v, want 2 variants from 90 to 130", vrts, got)
+	}
+	if n, err := vrtSvc.Count(ctx, hat); err != nil || n != 0 {
+		t.Errorf("Variant.Count() = %v, %v, want 0", n, err)
+	}
+
+	if err := vrtSvc.Remove(ctx, shirt, small); err != nil {
+		t.Fatalf("Variant.Remove() error = %v", err)
+	}
+	if n, err := vrtSvc.Count(ctx, shirt); err != nil || n != 1 {
+		t.Errorf("Variant.Count() after Remove() = %v, %v, want 1", n, err)
+	}
+}
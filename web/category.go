@@ -0,0 +1,164 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+// CategoryController holds necessary fields to serve category handlers
+type CategoryController struct {
+	catSvc *service.Category
+}
+
+// NewCategoryController returns a new CategoryController with the svc
+func NewCategoryController(svc *service.Category) *CategoryController {
+	return &CategoryController{
+		catSvc: svc,
+	}
+}
+
+type categoryBody struct {
+	Name     string `json:"name"`
+	ParentID string `json:"parentId"`
+}
+
+// Create is the category create handler
+func (c *CategoryController) Create(w http.ResponseWriter, r *http.Request) {
+	body := categoryBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	cat := model.Category{
+		Name:     body.Name,
+		ParentID: body.ParentID,
+	}
+	id, err := c.catSvc.Add(r.Context(), cat)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusCreated, id, nil)
+}
+
+// Get serves a category with its id from url param {id}
+func (c *CategoryController) Get(w http.ResponseWriter, r *http.Request) {
+	cat, err := c.catSvc.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespCategory(*cat), nil)
+}
+
+// List serves a list of categories
+func (c *CategoryController) List(w http.ResponseWriter, r *http.Request) {
+	skip, limit := getSkipLimit(r, 20)
+
+	n, err := c.catSvc.Count(r.Context())
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	pgr := resp.NewPager(n, skip, limit)
+
+	if n <= skip {
+		ServeData(w, r, http.StatusOK, []struct{}{}, pgr)
+		return
+	}
+
+	cats, err := c.catSvc.List(r.Context(), skip, limit)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespCategories(cats), pgr)
+}
+
+// Update updates a category finding it with its id from url param {id}
+func (c *CategoryController) Update(w http.ResponseWriter, r *http.Request) {
+	body := categoryBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	cat, err := c.catSvc.Get(r.Context(), id)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	cat.Name = body.Name
+	cat.ParentID = body.ParentID
+	if err := c.catSvc.Update(r.Context(), id, *cat); err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, cat.ID, nil)
+}
+
+// Delete deletes a category with its id from url param {id}
+func (c *CategoryController) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := c.catSvc.Remove(r.Context(), chi.URLParam(r, "id")); err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, true, nil)
+}
+
+type assignCategoriesBody struct {
+	CategoryIDs []string `json:"categoryIds"`
+}
+
+// Assign replaces the categories of a product with its id from url param {id}
+func (c *CategoryController) Assign(w http.ResponseWriter, r *http.Request) {
+	body := assignCategoriesBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := c.catSvc.Assign(r.Context(), id, body.CategoryIDs); err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, id, nil)
+}
+
+// ProductCategories serves the categories of a product with its id from url param {id}
+func (c *CategoryController) ProductCategories(w http.ResponseWriter, r *http.Request) {
+	cats, err := c.catSvc.ProductCategories(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespCategories(cats), nil)
+}
+
+func toRespCategory(cat model.Category) resp.Category {
+	return resp.Category{
+		ID:        cat.ID,
+		Name:      cat.Name,
+		ParentID:  cat.ParentID,
+		CreatedAt: cat.CreatedAt,
+		UpdatedAt: cat.UpdatedAt,
+	}
+}
+
+func toRespCategories(cats []model.Category) []resp.Category {
+	rs := []resp.Category{}
+	for _, cat := range cats {
+		rs = append(rs, toRespCategory(cat))
+	}
+	return rs
+}
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+func TestCategoryController(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	catSvc := service.NewCategory(rps.Category, rps.Product, service.SetCategoryUnitOfWork(store),
+		service.SetCategoryOutputLogger(nil),
+		service.SetCategoryErrorLogger(nil),
+	)
+	c := NewCategoryController(catSvc)
+
+	pdtID, err := rps.Product.Create(ctx, model.Product{Name: "Hat", Price: 100, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wear, err := catSvc.Add(ctx, model.Category{Name: "Wear"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hats, err := catSvc.Add(ctx, model.Category{Name: "Hats", ParentID: wear})
+	if err != nil {
+		t.Fatal(err)
+	}
+	toys, err := catSvc.Add(ctx, model.Category{Name: "Toys"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func(method, target, id, body string) *http.Request {
+		req, err := http.NewRequest(method, target, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "" {
+			injectChiURLParam(req, "id", id)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "create bad body", handler: c.Create, r: newRequest("POST", "/", "", `{"name":`), wantCode: http.StatusBadRequest},
+		{name: "create without name", handler: c.Create, r: newRequest("POST", "/", "", `{}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "create under unknown parent", handler: c.Create, r: newRequest("POST", "/", "", `{"name":"Shoes","parentId":"unavailable_id"}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "create", handler: c.Create, r: newRequest("POST", "/", "", `{"name":"Shoes","parentId":"`+wear+`"}`), wantCode: http.StatusCreated},
+		{name: "get unknown", handler: c.Get, r: newRequest("GET", "/unavailable_id", "unavailable_id", ""), wantCode: http.StatusNotFound},
+		{name: "get", handler: c.Get, r: newRequest("GET", "/"+hats, hats, ""), wantCode: http.StatusOK},
+		{name: "update bad body", handler: c.Update, r: newRequest("PUT", "/"+toys, toys, `{"name":`), wantCode: http.StatusBadRequest},
+		{name: "update unknown", handler: c.Update, r: newRequest("PUT", "/unavailable_id", "unavailable_id", `{"name":"Games"}`), wantCode: http.StatusNotFound},
+		{name: "update into a cycle", handler: c.Update, r: newRequest("PUT", "/"+wear, wear, `{"name":"Wear","parentId":"`+hats+`"}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "update", handler: c.Update, r: newRequest("PUT", "/"+toys, toys, `{"name":"Games"}`), wantCode: http.StatusOK},
+		{name: "delete with children", handler: c.Delete, r: newRequest("DELETE", "/"+wear, wear, ""), wantCode: http.StatusConflict},
+		{name: "delete unknown", handler: c.Delete, r: newRequest("DELETE", "/unavailable_id", "unavailable_id", ""), wantCode: http.StatusNotFound},
+		{name: "delete", handler: c.Delete, r: newRequest("DELETE", "/"+toys, toys, ""), wantCode: http.StatusOK},
+		{name: "assign bad body", handler: c.Assign, r: newRequest("PUT", "/"+pdtID+"/categories", pdtID, `{"categoryIds":`), wantCode: http.StatusBadRequest},
+		{name: "assign to unknown product", handler: c.Assign, r: newRequest("PUT", "/unavailable_id/categories", "unavailable_id", `{"categoryIds":["`+hats+`"]}`), wantCode: http.StatusNotFound},
+		{name: "assign unknown category", handler: c.Assign, r: newRequest("PUT", "/"+pdtID+"/categories", pdtID, `{"categoryIds":["unavailable_id"]}`), wantCode: http.StatusNotFound},
+		{name: "assign", handler: c.Assign, r: newRequest("PUT", "/"+pdtID+"/categories", pdtID, `{"categoryIds":["`+hats+`"]}`), wantCode: http.StatusOK},
+		{name: "categories of unknown product", handler: c.ProductCategories, r: newRequest("GET", "/unavailable_id/categories", "unavailable_id", ""), wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.handler(rr, tt.r)
+			if rr.Code != tt.wantCode {
+				t.Errorf("CategoryController %s Code = %v, want %v", tt.name, rr.Code, tt.wantCode)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	c.ProductCategories(rr, newRequest("GET", "/"+pdtID+"/categories", pdtID, ""))
+	body := struct {
+		Data []resp.Category `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != hats || body.Data[0].ParentID != wear {
+		t.Errorf("CategoryController.ProductCategories() = %+v, want the assigned category under its parent", body.Data)
+	}
+}
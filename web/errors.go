@@ -54,6 +54,20 @@ func ServeNotFound(w http.ResponseWriter, r *http.Request, err error) {
 	resp.Render(w, r, re)
 }
 
+// ServeConflict serves http Conflict
+func ServeConflict(w http.ResponseWriter, r *http.Request, err error) {
+	re := resp.Response{
+		Code: http.StatusConflict,
+		Errors: []resp.Error{
+			{
+				ID:      generateErrorID(10),
+				Message: err.Error(),
+			},
+		},
+	}
+	resp.Render(w, r, re)
+}
+
 // ServeUnprocessableEntity serves http UnprocessableEntity
 func ServeUnprocessableEntity(w http.ResponseWriter, r *http.Request, err error, dtl map[string]interface{}) {
 	re := resp.Response{
@@ -94,6 +108,8 @@ func ServeError(w http.ResponseWriter, r *http.Request, err error) {
 		ServeUnprocessableEntity(w, r, err, dtl)
 	case service.NotFoundError:
 		ServeNotFound(w, r, err)
+	case service.ConflictError:
+		ServeConflict(w, r, err)
 	default:
 		ServeInternalServerError(w, r, err)
 	}
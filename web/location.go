@@ -0,0 +1,122 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+// LocationController holds necessary fields to serve location handlers
+type LocationController struct {
+	locSvc *service.Location
+}
+
+// NewLocationController returns a new LocationController with the svc
+func NewLocationController(svc *service.Location) *LocationController {
+	return &LocationController{
+		locSvc: svc,
+	}
+}
+
+type locationBody struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// Create is the location create handler
+func (c *LocationController) Create(w http.ResponseWriter, r *http.Request) {
+	body := locationBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	loc := model.Location{
+		Name:    body.Name,
+		Address: body.Address,
+	}
+	id, err := c.locSvc.Add(r.Context(), loc)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusCreated, id, nil)
+}
+
+// Get serves a location with its id from url param {id}
+func (c *LocationController) Get(w http.ResponseWriter, r *http.Request) {
+	loc, err := c.locSvc.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespLocation(*loc), nil)
+}
+
+// List serves a list of locations
+func (c *LocationController) List(w http.ResponseWriter, r *http.Request) {
+	skip, limit := getSkipLimit(r, 20)
+
+	n, err := c.locSvc.Count(r.Context())
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	pgr := resp.NewPager(n, skip, limit)
+
+	if n <= skip {
+		ServeData(w, r, http.StatusOK, []struct{}{}, pgr)
+		return
+	}
+
+	locs, err := c.locSvc.List(r.Context(), skip, limit)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	rs := []resp.Location{}
+	for _, loc := range locs {
+		rs = append(rs, toRespLocation(loc))
+	}
+	ServeData(w, r, http.StatusOK, rs, pgr)
+}
+
+// Update updates a location finding it with its id from url param {id}
+func (c *LocationController) Update(w http.ResponseWriter, r *http.Request) {
+	body := locationBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	loc, err := c.locSvc.Get(r.Context(), id)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	loc.Name = body.Name
+	loc.Address = body.Address
+	if err := c.locSvc.Update(r.Context(), id, *loc); err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, loc.ID, nil)
+}
+
+func toRespLocation(loc model.Location) resp.Location {
+	return resp.Location{
+		ID:        loc.ID,
+		Name:      loc.Name,
+		Address:   loc.Address,
+		CreatedAt: loc.CreatedAt,
+		UpdatedAt: loc.UpdatedAt,
+	}
+}
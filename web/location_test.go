@@ -0,0 +1,154 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/msyrus/simple-product-inv/mock_repo"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/service"
+)
+
+func TestLocationController_Create(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	locRepo := mock_repo.NewMockLocation(mockCtrl)
+	locSvc := service.NewLocation(locRepo)
+
+	newRequest := func(body string) *http.Request {
+		req, err := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	gomock.InOrder(
+		locRepo.EXPECT().Create(gomock.Any(), model.Location{Address: "Agrabad"}).Return("", model.ValidationError{"Name": {"is empty"}}),
+		locRepo.EXPECT().Create(gomock.Any(), model.Location{Name: "Dhaka", Address: "Tejgaon"}).Return("loc_id", nil),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "bad body", r: newRequest(`{"name":`), wantCode: http.StatusBadRequest},
+		{name: "invalid", r: newRequest(`{"address":"Agrabad"}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "create", r: newRequest(`{"name":"Dhaka","address":"Tejgaon"}`), wantCode: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &LocationController{
+				locSvc: locSvc,
+			}
+			rr := httptest.NewRecorder()
+			c.Create(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("LocationController.Create() Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestLocationController_Get(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	locRepo := mock_repo.NewMockLocation(mockCtrl)
+	locSvc := service.NewLocation(locRepo)
+
+	req1, err := http.NewRequest("GET", "/unavailable_id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injectChiURLParam(req1, "id", "unavailable_id")
+
+	req2, err := http.NewRequest("GET", "/loc_id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injectChiURLParam(req2, "id", "loc_id")
+
+	gomock.InOrder(
+		locRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "loc_id").Return(model.Location{ID: "loc_id", Name: "Dhaka"}, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "loc_id").Return(nil, errors.New("db failed")),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "unknown", r: req1, wantCode: http.StatusNotFound},
+		{name: "location", r: req2, wantCode: http.StatusOK},
+		{name: "db failed", r: req2, wantCode: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &LocationController{
+				locSvc: locSvc,
+			}
+			rr := httptest.NewRecorder()
+			c.Get(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("LocationController.Get() Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestLocationController_Update(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	locRepo := mock_repo.NewMockLocation(mockCtrl)
+	locSvc := service.NewLocation(locRepo)
+
+	newRequest := func(id, body string) *http.Request {
+		req, err := http.NewRequest("PUT", "/"+id, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		injectChiURLParam(req, "id", id)
+		return req
+	}
+
+	loc := model.Location{ID: "loc_id", Name: "Dhaka", Address: "Tejgaon"}
+	gomock.InOrder(
+		locRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "loc_id").Return(loc, nil),
+		locRepo.EXPECT().Update(gomock.Any(), "loc_id", model.Location{ID: "loc_id"}).Return(model.ValidationError{"Name": {"is empty"}}),
+		locRepo.EXPECT().Fetch(gomock.Any(), "loc_id").Return(loc, nil),
+		locRepo.EXPECT().Update(gomock.Any(), "loc_id", model.Location{ID: "loc_id", Name: "Dhaka", Address: "Banani"}).Return(nil),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "bad body", r: newRequest("loc_id", `{"name":`), wantCode: http.StatusBadRequest},
+		{name: "unknown", r: newRequest("unavailable_id", `{"name":"Dhaka"}`), wantCode: http.StatusNotFound},
+		{name: "invalid", r: newRequest("loc_id", `{"name":""}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "update", r: newRequest("loc_id", `{"name":"Dhaka","address":"Banani"}`), wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &LocationController{
+				locSvc: locSvc,
+			}
+			rr := httptest.NewRecorder()
+			c.Update(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("LocationController.Update() Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
@@ -2,18 +2,71 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
+
+	"github.com/msyrus/simple-product-inv/model"
 )
 
+// UserNameHeader is the request header holding the display name of the user
+const UserNameHeader = "X-User-Name"
+
+// UserRoleHeader is the request header holding the role of the user
+const UserRoleHeader = "X-User-Role"
+
+// moderatorRoles are the user roles allowed to moderate reviews
+var moderatorRoles = map[string]bool{
+	"moderator": true,
+	"admin":     true,
+}
+
 // Auth middleware checks API authorization
 func Auth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tok := r.Header.Get("Authorization")
-		// NOTE: token validation goes here
-		// currently any non empty token is valid
-		if tok == "" {
+		if !Authorized(r) {
 			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+// Moderator middleware checks API authorization of a moderator or an admin
+func Moderator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !Authorized(r) {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		// NOTE: the role goes from the validated token here
+		// currently the role is named by UserRoleHeader
+		if !moderatorRoles[strings.TrimSpace(r.Header.Get(UserRoleHeader))] {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Authorized reports whether r is made by an authorized caller
+// public handlers use it to serve more to authorized callers
+func Authorized(r *http.Request) bool {
+	tok := r.Header.Get("Authorization")
+	// NOTE: token validation goes here
+	// currently any non empty token is valid
+	return tok != ""
+}
+
+// User returns the user r is made by, it reports false if r is not authorized
+// the user is identified by the bearer token and named by UserNameHeader
+func User(r *http.Request) (model.User, bool) {
+	if !Authorized(r) {
+		return model.User{}, false
+	}
+	// NOTE: the user goes from the validated token here
+	// currently the token is the user id
+	tok := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	return model.User{
+		ID:   tok,
+		Name: strings.TrimSpace(r.Header.Get(UserNameHeader)),
+	}, true
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"github.com/msyrus/simple-product-inv/metrics"
+)
+
+// unmatchedRoute labels requests that matched no route
+const unmatchedRoute = "unmatched"
+
+// statusWriter records the status code written to a http.ResponseWriter
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// routePattern returns the chi route pattern matched by r
+// the wildcards of nested mounts are collapsed, chi leaves some of them
+func routePattern(r *http.Request) string {
+	rctx, ok := r.Context().Value(chi.RouteCtxKey).(*chi.Context)
+	if !ok {
+		return unmatchedRoute
+	}
+	ptn := strings.Join(rctx.RoutePatterns, "")
+	for strings.Contains(ptn, "/*/") {
+		ptn = strings.Replace(ptn, "/*/", "/", -1)
+	}
+	if ptn == "" {
+		return unmatchedRoute
+	}
+	return ptn
+}
+
+// Metrics returns a middleware that counts and times requests into reg
+// requests are labelled by method, chi route pattern and status code
+// so that path parameters like product ids do not explode the series
+func Metrics(reg *metrics.Registry) Middleware {
+	requests := metrics.NewCounterVec("http_requests_total", "Number of http requests.", "method", "route", "code")
+	duration := metrics.NewHistogramVec("http_request_duration_seconds", "Duration of http requests.", nil, "method", "route", "code")
+	reg.Register(requests, duration)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w}
+			defer func() {
+				code := sw.code
+				if code == 0 {
+					code = http.StatusOK
+				}
+				route := routePattern(r)
+				c := strconv.Itoa(code)
+				requests.Inc(r.Method, route, c)
+				duration.Observe(time.Since(start).Seconds(), r.Method, route, c)
+			}()
+
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
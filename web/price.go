@@ -0,0 +1,92 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+// PriceController holds necessary fields to serve product price handlers
+type PriceController struct {
+	prcSvc *service.Price
+}
+
+// NewPriceController returns a new PriceController with the svc
+func NewPriceController(svc *service.Price) *PriceController {
+	return &PriceController{
+		prcSvc: svc,
+	}
+}
+
+type createPriceBody struct {
+	Price int `json:"price"`
+	// EffectiveAt is optional, the price changes right away without it
+	EffectiveAt time.Time `json:"effectiveAt"`
+}
+
+// Create changes or schedules the price of a product with its id from url param {id}
+func (c *PriceController) Create(w http.ResponseWriter, r *http.Request) {
+	body := createPriceBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	id, err := c.prcSvc.Schedule(r.Context(), chi.URLParam(r, "id"), body.Price, body.EffectiveAt)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusCreated, id, nil)
+}
+
+// List serves the price history of a product with its id from url param {id}
+func (c *PriceController) List(w http.ResponseWriter, r *http.Request) {
+	skip, limit := getSkipLimit(r, 20)
+	id := chi.URLParam(r, "id")
+
+	n, err := c.prcSvc.Count(r.Context(), id)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	pgr := resp.NewPager(n, skip, limit)
+
+	if n <= skip {
+		ServeData(w, r, http.StatusOK, []struct{}{}, pgr)
+		return
+	}
+
+	prcs, err := c.prcSvc.History(r.Context(), id, skip, limit)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespPriceChanges(prcs), pgr)
+}
+
+func toRespPriceChange(prc model.PriceChange) resp.PriceChange {
+	return resp.PriceChange{
+		ID:          prc.ID,
+		ProductID:   prc.ProductID,
+		Price:       prc.Price,
+		Status:      string(prc.Status),
+		EffectiveAt: prc.EffectiveAt,
+		CreatedAt:   prc.CreatedAt,
+		UpdatedAt:   prc.UpdatedAt,
+	}
+}
+
+func toRespPriceChanges(prcs []model.PriceChange) []resp.PriceChange {
+	rs := []resp.PriceChange{}
+	for _, prc := range prcs {
+		rs = append(rs, toRespPriceChange(prc))
+	}
+	return rs
+}
@@ -0,0 +1,87 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+func TestPriceController(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	prcSvc := service.NewPrice(rps.Price, rps.Product, service.SetPriceUnitOfWork(store),
+		service.SetPriceClock(func() time.Time { return now }),
+		service.SetPriceOutputLogger(nil),
+		service.SetPriceErrorLogger(nil),
+	)
+	c := NewPriceController(prcSvc)
+
+	pdtID, err := rps.Product.Create(ctx, model.Product{Name: "Hat", Price: 100, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := now.Add(time.Hour).Format(time.RFC3339)
+
+	newRequest := func(method, id, body string) *http.Request {
+		req, err := http.NewRequest(method, "/"+id+"/prices", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		injectChiURLParam(req, "id", id)
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "create bad body", handler: c.Create, r: newRequest("POST", pdtID, `{"price":`), wantCode: http.StatusBadRequest},
+		{name: "create without price", handler: c.Create, r: newRequest("POST", pdtID, `{}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "schedule without price", handler: c.Create, r: newRequest("POST", pdtID, `{"effectiveAt":"`+later+`"}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "create of unknown product", handler: c.Create, r: newRequest("POST", "unavailable_id", `{"price":120}`), wantCode: http.StatusNotFound},
+		{name: "create", handler: c.Create, r: newRequest("POST", pdtID, `{"price":120}`), wantCode: http.StatusCreated},
+		{name: "schedule", handler: c.Create, r: newRequest("POST", pdtID, `{"price":150,"effectiveAt":"`+later+`"}`), wantCode: http.StatusCreated},
+		{name: "list of unknown product", handler: c.List, r: newRequest("GET", "unavailable_id", ""), wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.handler(rr, tt.r)
+			if rr.Code != tt.wantCode {
+				t.Errorf("PriceController %s Code = %v, want %v", tt.name, rr.Code, tt.wantCode)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	c.List(rr, newRequest("GET", pdtID, ""))
+	body := struct {
+		Data []resp.PriceChange `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	prices := map[string]int{}
+	for _, prc := range body.Data {
+		prices[prc.Status] = prc.Price
+	}
+	if len(body.Data) != 2 || prices[string(model.PriceApplied)] != 120 || prices[string(model.PriceScheduled)] != 150 {
+		t.Errorf("PriceController.List() = %+v, want the applied and the scheduled price", body.Data)
+	}
+	if pdtI, err := rps.Product.Fetch(ctx, pdtID); err != nil || pdtI.(model.Product).Price != 120 {
+		t.Errorf("Product.Fetch() = %v, %v, want price 120 until the scheduled one is due", pdtI, err)
+	}
+}
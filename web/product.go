@@ -5,11 +5,14 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 
 	"github.com/msyrus/simple-product-inv/model"
 	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/middleware"
 	"github.com/msyrus/simple-product-inv/web/resp"
 )
 
@@ -29,14 +32,23 @@ func parseJSON(r io.Reader, v interface{}) error {
 	return json.NewDecoder(r).Decode(v)
 }
 
+type moneyBody struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+}
+
 type createProductBody struct {
-	Name      string `json:"name"`
-	Price     int    `json:"price"`
-	Weight    int    `json:"weight"`
-	Available bool   `json:"available"`
+	Name    string      `json:"name"`
+	Price   int         `json:"price"`
+	Prices  []moneyBody `json:"prices"`
+	Weight  int         `json:"weight"`
+	SKU     string      `json:"sku"`
+	Barcode string      `json:"barcode"`
+	Tags    []string    `json:"tags"`
 }
 
 // Create is the product create handler
+// the product is always created as a draft and published through its status transitions
 func (c *ProductController) Create(w http.ResponseWriter, r *http.Request) {
 	body := createProductBody{}
 	if err := parseJSON(r.Body, &body); err != nil {
@@ -45,12 +57,16 @@ func (c *ProductController) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	pdt := model.Product{
-		Name:      body.Name,
-		Price:     body.Price,
-		Weight:    body.Weight,
-		Available: body.Available,
+		Name:    body.Name,
+		Price:   body.Price,
+		Prices:  toModelPrices(body.Prices),
+		Weight:  body.Weight,
+		SKU:     body.SKU,
+		Barcode: body.Barcode,
+		Tags:    body.Tags,
+		Status:  model.ProductDraft,
 	}
-	rID, err := c.pdtSvc.Add(pdt)
+	rID, err := c.pdtSvc.Add(r.Context(), pdt)
 	if err != nil {
 		ServeError(w, r, err)
 		return
@@ -63,27 +79,95 @@ func (c *ProductController) Create(w http.ResponseWriter, r *http.Request) {
 // Get serves a product with its id from url param {id}
 func (c *ProductController) Get(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	pdt, err := c.pdtSvc.Get(id)
+	pdt, err := c.pdtSvc.Get(r.Context(), id)
+	c.serveProduct(w, r, pdt, err)
+}
+
+// BySKU serves a product with its sku from url param {sku}
+func (c *ProductController) BySKU(w http.ResponseWriter, r *http.Request) {
+	sku := chi.URLParam(r, "sku")
+	pdt, err := c.pdtSvc.GetBySKU(r.Context(), sku)
+	c.serveProduct(w, r, pdt, err)
+}
+
+// ByBarcode serves a product with its barcode from url param {code}
+func (c *ProductController) ByBarcode(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	pdt, err := c.pdtSvc.GetByBarcode(r.Context(), code)
+	c.serveProduct(w, r, pdt, err)
+}
+
+// Visible is a middleware serving the sub resources of a product with its id
+// from url param {id} only if the product is visible to the caller
+func (c *ProductController) Visible(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pdt, err := c.pdtSvc.Get(r.Context(), chi.URLParam(r, "id"))
+		if err := visible(r, pdt, err); err != nil {
+			ServeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// visible returns the fetch error err of pdt or service.ErrProductNotFound
+// if pdt is not visible to the caller of r
+// a product that is not published is visible to authorized callers only
+func visible(r *http.Request, pdt *model.Product, err error) error {
+	if err == nil && pdt.Status != model.ProductPublished && !middleware.Authorized(r) {
+		return service.ErrProductNotFound
+	}
+	return err
+}
+
+// serveProduct serves the fetched pdt with its rating and variants or the fetch error err
+// a product is served only if it is visible to the caller
+func (c *ProductController) serveProduct(w http.ResponseWriter, r *http.Request, pdt *model.Product, err error) {
+	if err := visible(r, pdt, err); err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	sum, err := c.pdtSvc.RatingSummary(r.Context(), pdt.ID)
 	if err != nil {
 		ServeError(w, r, err)
 		return
 	}
-	rt, err := c.pdtSvc.AvgRating(pdt.ID)
+	vrts, err := c.pdtSvc.Variants(r.Context(), pdt.ID)
 	if err != nil {
 		ServeError(w, r, err)
 		return
 	}
-	ServeData(w, r, http.StatusOK, toRespProduct(*pdt, rt), nil)
-	return
+	cur := currencyParam(r)
+	prs, err := c.pdtSvc.Pricing(r.Context(), []model.Product{*pdt}, cur)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	rs := toRespProduct(*pdt, sum)
+	setPricing(&rs, prs[0])
+	if len(vrts) != 0 {
+		rng, err := c.pdtSvc.PriceRangeIn(*pdt, vrts, cur)
+		if err != nil {
+			ServeError(w, r, err)
+			return
+		}
+		rs.Variants = toRespVariants(vrts)
+		rs.PriceRange = &resp.PriceRange{Min: rng.Min, Max: rng.Max}
+	}
+	ServeData(w, r, http.StatusOK, rs, nil)
 }
 
 // List serves a list of products
-// it also filters with query params
+// it also filters with query params, only the published products are listed
+// unless an authorized caller asks for another status
 func (c *ProductController) List(w http.ResponseWriter, r *http.Request) {
 	skip, limit := getSkipLimit(r, 20)
 	prms := r.URL.Query()
+	if prms.Get("status") == "" || !middleware.Authorized(r) {
+		prms.Set("status", string(model.ProductPublished))
+	}
 
-	n, err := c.pdtSvc.Count(prms)
+	n, err := c.pdtSvc.Count(r.Context(), prms)
 	if err != nil {
 		ServeError(w, r, err)
 		return
@@ -96,31 +180,110 @@ func (c *ProductController) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pdts, err := c.pdtSvc.Find(prms, skip, limit)
+	pdts, err := c.pdtSvc.Find(r.Context(), prms, skip, limit)
 	if err != nil {
 		ServeError(w, r, err)
 		return
 	}
 
-	rs := []resp.Product{}
-	for _, pdt := range pdts {
-		rt, err := c.pdtSvc.AvgRating(pdt.ID)
-		if err != nil {
-			ServeError(w, r, err)
-			return
-		}
-		rs = append(rs, toRespProduct(pdt, rt))
+	rs, err := c.respProducts(r, pdts)
+	if err != nil {
+		ServeError(w, r, err)
+		return
 	}
 
 	ServeData(w, r, http.StatusOK, rs, pgr)
 	return
 }
 
+// Deleted serves a list of the deleted products, the latest deleted first
+func (c *ProductController) Deleted(w http.ResponseWriter, r *http.Request) {
+	skip, limit := getSkipLimit(r, 20)
+
+	n, err := c.pdtSvc.CountDeleted(r.Context())
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	pgr := resp.NewPager(n, skip, limit)
+
+	if n <= skip {
+		ServeData(w, r, http.StatusOK, []struct{}{}, pgr)
+		return
+	}
+
+	pdts, err := c.pdtSvc.Deleted(r.Context(), skip, limit)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	rs, err := c.respProducts(r, pdts)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	ServeData(w, r, http.StatusOK, rs, pgr)
+}
+
+// respProducts returns the response objects of pdts with their ratings and pricing
+// the ratings and prices of every product are looked up at once
+func (c *ProductController) respProducts(r *http.Request, pdts []model.Product) ([]resp.Product, error) {
+	prs, err := c.pdtSvc.Pricing(r.Context(), pdts, currencyParam(r))
+	if err != nil {
+		return nil, err
+	}
+	sums, err := c.pdtSvc.RatingSummaries(r.Context(), pdts)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := []resp.Product{}
+	for i, pdt := range pdts {
+		re := toRespProduct(pdt, sums[pdt.ID])
+		setPricing(&re, prs[i])
+		rs = append(rs, re)
+	}
+	return rs, nil
+}
+
+// Restore restores a deleted product with its id from url param {id}
+func (c *ProductController) Restore(w http.ResponseWriter, r *http.Request) {
+	pdt, err := c.pdtSvc.Restore(r.Context(), chi.URLParam(r, "id"))
+	c.serveProduct(w, r, pdt, err)
+}
+
+// Publish publishes a product with its id from url param {id}
+func (c *ProductController) Publish(w http.ResponseWriter, r *http.Request) {
+	c.transition(w, r, model.ProductPublished)
+}
+
+// Unpublish moves a published product with its id from url param {id} back to draft
+func (c *ProductController) Unpublish(w http.ResponseWriter, r *http.Request) {
+	c.transition(w, r, model.ProductDraft)
+}
+
+// Archive archives a published product with its id from url param {id}
+func (c *ProductController) Archive(w http.ResponseWriter, r *http.Request) {
+	c.transition(w, r, model.ProductArchived)
+}
+
+// transition moves the product of url param {id} to status to and serves it
+func (c *ProductController) transition(w http.ResponseWriter, r *http.Request, to model.ProductStatus) {
+	pdt, err := c.pdtSvc.Transition(r.Context(), chi.URLParam(r, "id"), to)
+	c.serveProduct(w, r, pdt, err)
+}
+
 type updateProductBody struct {
-	Name      string `json:"name"`
-	Price     int    `json:"price"`
-	Weight    int    `json:"weight"`
-	Available bool   `json:"available"`
+	Name  string `json:"name"`
+	Price int    `json:"price"`
+	// Prices are replaced only if they are given
+	Prices  []moneyBody `json:"prices"`
+	Weight  int         `json:"weight"`
+	SKU     string      `json:"sku"`
+	Barcode string      `json:"barcode"`
 }
 
 // Update updates a product finding it with its id from url param {id}
@@ -132,7 +295,7 @@ func (c *ProductController) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id := chi.URLParam(r, "id")
-	pdt, err := c.pdtSvc.Get(id)
+	pdt, err := c.pdtSvc.Get(r.Context(), id)
 	if err != nil {
 		ServeError(w, r, err)
 		return
@@ -140,9 +303,13 @@ func (c *ProductController) Update(w http.ResponseWriter, r *http.Request) {
 
 	pdt.Name = body.Name
 	pdt.Price = body.Price
+	if body.Prices != nil {
+		pdt.Prices = toModelPrices(body.Prices)
+	}
 	pdt.Weight = body.Weight
-	pdt.Available = body.Available
-	if err := c.pdtSvc.Update(id, *pdt); err != nil {
+	pdt.SKU = body.SKU
+	pdt.Barcode = body.Barcode
+	if err := c.pdtSvc.Update(r.Context(), id, *pdt); err != nil {
 		ServeError(w, r, err)
 		return
 	}
@@ -151,10 +318,13 @@ func (c *ProductController) Update(w http.ResponseWriter, r *http.Request) {
 }
 
 type updatePartProductBody struct {
-	Name      *string `json:"name"`
-	Price     *int    `json:"price"`
-	Weight    *int    `json:"weight"`
-	Available *bool   `json:"available"`
+	Name    *string      `json:"name"`
+	Price   *int         `json:"price"`
+	Prices  *[]moneyBody `json:"prices"`
+	Weight  *int         `json:"weight"`
+	SKU     *string      `json:"sku"`
+	Barcode *string      `json:"barcode"`
+	Tags    *[]string    `json:"tags"`
 }
 
 // UpdatePartial updates a product partially with request body finding it with its id from url param {id}
@@ -166,7 +336,7 @@ func (c *ProductController) UpdatePartial(w http.ResponseWriter, r *http.Request
 	}
 
 	id := chi.URLParam(r, "id")
-	pdt, err := c.pdtSvc.Get(id)
+	pdt, err := c.pdtSvc.Get(r.Context(), id)
 	if err != nil {
 		ServeError(w, r, err)
 		return
@@ -177,14 +347,26 @@ func (c *ProductController) UpdatePartial(w http.ResponseWriter, r *http.Request
 	if body.Price != nil {
 		pdt.Price = *body.Price
 	}
+	if body.Prices != nil {
+		pdt.Prices = toModelPrices(*body.Prices)
+	}
 	if body.Weight != nil {
 		pdt.Weight = *body.Weight
 	}
-	if body.Available != nil {
-		pdt.Available = *body.Available
+	if body.SKU != nil {
+		pdt.SKU = *body.SKU
+	}
+	if body.Barcode != nil {
+		pdt.Barcode = *body.Barcode
+	}
+	if body.Tags != nil {
+		pdt.Tags = *body.Tags
+		if pdt.Tags == nil {
+			pdt.Tags = []string{}
+		}
 	}
 
-	if err := c.pdtSvc.Update(id, *pdt); err != nil {
+	if err := c.pdtSvc.Update(r.Context(), id, *pdt); err != nil {
 		ServeError(w, r, err)
 		return
 	}
@@ -195,7 +377,7 @@ func (c *ProductController) UpdatePartial(w http.ResponseWriter, r *http.Request
 // Delete deletes a product with its id from url param {id}
 func (c *ProductController) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	err := c.pdtSvc.Remove(id)
+	err := c.pdtSvc.Remove(r.Context(), id)
 	if err != nil {
 		if _, ok := err.(service.NotFoundError); !ok {
 			ServeError(w, r, err)
@@ -207,10 +389,13 @@ func (c *ProductController) Delete(w http.ResponseWriter, r *http.Request) {
 }
 
 type rateProductBody struct {
-	Value int `json:"value"`
+	Value int    `json:"value"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
 }
 
-// Rate rates a product with its id from url param {id}
+// Rate rates a product with its id from url param {id} by the requesting user
+// it serves Created for a new rating and OK for an updated one
 func (c *ProductController) Rate(w http.ResponseWriter, r *http.Request) {
 	body := rateProductBody{}
 	if err := parseJSON(r.Body, &body); err != nil {
@@ -218,16 +403,69 @@ func (c *ProductController) Rate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	usr, _ := middleware.User(r)
+	rat := model.Rating{
+		User:  usr,
+		Value: body.Value,
+		Title: strings.TrimSpace(body.Title),
+		Body:  strings.TrimSpace(body.Body),
+	}
 	id := chi.URLParam(r, "id")
-	rID, err := c.pdtSvc.Rate(id, body.Value)
+	rID, created, err := c.pdtSvc.Rate(r.Context(), id, rat)
 	if err != nil {
 		ServeError(w, r, err)
 		return
 	}
-	ServeData(w, r, http.StatusCreated, rID, nil)
+	code := http.StatusOK
+	if created {
+		code = http.StatusCreated
+	}
+	ServeData(w, r, code, rID, nil)
 	return
 }
 
+// Reviews serves the approved ratings of the product with its id from url param {id}, the latest first
+func (c *ProductController) Reviews(w http.ResponseWriter, r *http.Request) {
+	skip, limit := getSkipLimit(r, 20)
+	id := chi.URLParam(r, "id")
+
+	n, err := c.pdtSvc.CountReviews(r.Context(), id)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	pgr := resp.NewPager(n, skip, limit)
+
+	if n <= skip {
+		ServeData(w, r, http.StatusOK, []struct{}{}, pgr)
+		return
+	}
+
+	rats, err := c.pdtSvc.Reviews(r.Context(), id, skip, limit)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	rs := []resp.Review{}
+	for _, rat := range rats {
+		rs = append(rs, toRespReview(rat))
+	}
+	ServeData(w, r, http.StatusOK, rs, pgr)
+}
+
+// RemoveReview deletes the rating with its id from url param {rid} of the product
+// with its id from url param {id}, only the rating of the requesting user is deleted
+func (c *ProductController) RemoveReview(w http.ResponseWriter, r *http.Request) {
+	usr, _ := middleware.User(r)
+	err := c.pdtSvc.RemoveReview(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "rid"), usr)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, true, nil)
+}
+
 func getSkipLimit(r *http.Request, dlimit int) (int, int) {
 	q := r.URL.Query()
 	skip, _ := strconv.Atoi(q.Get("skip"))
@@ -245,13 +483,108 @@ func getSkipLimit(r *http.Request, dlimit int) (int, int) {
 	return skip, limit
 }
 
-func toRespProduct(pdt model.Product, rating float64) resp.Product {
+// Tags serves the usage counts of the product tags
+func (c *ProductController) Tags(w http.ResponseWriter, r *http.Request) {
+	tcs, err := c.pdtSvc.Tags(r.Context())
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	rs := []resp.TagCount{}
+	for _, tc := range tcs {
+		rs = append(rs, resp.TagCount{
+			Tag:   tc.Tag,
+			Count: tc.Count,
+		})
+	}
+	ServeData(w, r, http.StatusOK, rs, nil)
+}
+
+// currencyParam returns the currency requested by query param currency
+func currencyParam(r *http.Request) string {
+	return strings.ToUpper(r.URL.Query().Get("currency"))
+}
+
+// setPricing sets the price, effective price and promotions of rs to the ones of pr
+func setPricing(rs *resp.Product, pr model.Pricing) {
+	rs.Price = pr.Price.Amount
+	rs.Currency = pr.Price.Currency
+	rs.EffectivePrice = pr.Effective.Amount
+	rs.PromotionIDs = pr.PromotionIDs
+}
+
+func toModelPrices(body []moneyBody) []model.Money {
+	prcs := []model.Money{}
+	for _, m := range body {
+		prcs = append(prcs, model.Money{Amount: m.Amount, Currency: m.Currency})
+	}
+	return prcs
+}
+
+func toRespReview(rat model.Rating) resp.Review {
+	return resp.Review{
+		ID:        rat.ID,
+		ProductID: rat.ProductID,
+		UserID:    rat.User.ID,
+		UserName:  rat.User.Name,
+		Value:     rat.Value,
+		Title:     rat.Title,
+		Body:      rat.Body,
+		Status:    string(rat.Status),
+		Reason:    rat.Reason,
+		CreatedAt: rat.CreatedAt,
+		UpdatedAt: rat.UpdatedAt,
+	}
+}
+
+func toRespProduct(pdt model.Product, sum model.RatingSummary) resp.Product {
+	tags := pdt.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	var dltAt *time.Time
+	if pdt.Deleted {
+		dltAt = timeOrNil(pdt.DeletedAt)
+	}
 	return resp.Product{
 		ID:        pdt.ID,
 		Name:      pdt.Name,
 		Price:     pdt.Price,
+		Prices:    toRespPrices(pdt.Prices),
 		Weight:    pdt.Weight,
+		SKU:       pdt.SKU,
+		Barcode:   pdt.Barcode,
+		Quantity:  pdt.Quantity,
 		Available: pdt.Available,
-		AvgRating: rating,
+		Tags:      tags,
+		AvgRating: sum.Avg,
+		Status:    string(pdt.Status),
+		// the transition times are omitted until the first transition
+		PublishedAt: timeOrNil(pdt.PublishedAt),
+		ArchivedAt:  timeOrNil(pdt.ArchivedAt),
+		DeletedAt:   dltAt,
+		// the effective price is the price until the promotions are applied
+		EffectivePrice: pdt.Price,
+		PromotionIDs:   []string{},
+		// the ratings are served from the stored summary
+		RatingCount:     sum.Count,
+		RatingHistogram: sum.Histogram[:],
+	}
+}
+
+// timeOrNil returns nil for the zero time and a pointer to t otherwise
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func toRespPrices(prcs []model.Money) []resp.Money {
+	rs := []resp.Money{}
+	for _, m := range prcs {
+		rs = append(rs, resp.Money{Amount: m.Amount, Currency: m.Currency})
 	}
+	return rs
 }
@@ -3,18 +3,23 @@ package web
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/golang/mock/gomock"
 	"github.com/msyrus/simple-product-inv/mock_repo"
 	"github.com/msyrus/simple-product-inv/model"
-	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/repo/memory"
 	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/middleware"
 	"github.com/msyrus/simple-product-inv/web/resp"
 	uuid "github.com/satori/go.uuid"
 )
@@ -96,9 +101,9 @@ func TestProductController_Create(t *testing.T) {
 
 	uid := uuid.NewV4().String()
 	gomock.InOrder(
-		pdtRepo.EXPECT().Create(gomock.Any()).Return("", model.ValidationError{}),
-		pdtRepo.EXPECT().Create(gomock.Any()).Return("", errors.New("db failed")),
-		pdtRepo.EXPECT().Create(gomock.Any()).Return(uid, nil),
+		pdtRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return("", model.ValidationError{}),
+		pdtRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return("", errors.New("db failed")),
+		pdtRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(uid, nil),
 	)
 
 	type fields struct {
@@ -176,10 +181,12 @@ func TestProductController_Get(t *testing.T) {
 	injectChiURLParam(req2, "id", "valid_id")
 
 	gomock.InOrder(
-		pdtRepo.EXPECT().Fetch("unavailable_id").Return(nil, nil),
-		pdtRepo.EXPECT().Fetch("valid_id").Return(model.Product{ID: "valid_id"}, nil),
-		rateRepo.EXPECT().Avg(repo.Query{"product_id": []interface{}{"valid_id"}}, "value").Return(1.5, nil),
-		pdtRepo.EXPECT().Fetch("valid_id").Return(nil, errors.New("db failed")),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(model.Product{ID: "valid_id", Status: model.ProductPublished}, nil),
+		rateRepo.EXPECT().Summaries(gomock.Any(), []string{"valid_id"}).Return(map[string]model.RatingSummary{
+			"valid_id": {ProductID: "valid_id", Count: 2, Sum: 3, Avg: 1.5, Histogram: [5]int{1, 1, 0, 0, 0}},
+		}, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(nil, errors.New("db failed")),
 	)
 
 	type fields struct {
@@ -298,12 +305,12 @@ func TestProductController_Update(t *testing.T) {
 	injectChiURLParam(req4, "id", "valid_id")
 
 	gomock.InOrder(
-		pdtRepo.EXPECT().Fetch("unavailable_id").Return(nil, nil),
-		pdtRepo.EXPECT().Fetch("valid_id").Return(model.Product{ID: "valid_id", Name: "Test1", Price: 100, Weight: 1, Available: false}, nil),
-		pdtRepo.EXPECT().Update("valid_id", model.Product{ID: "valid_id", Name: "Test2", Price: 200, Weight: 2, Available: true}).Return(nil),
-		pdtRepo.EXPECT().Fetch("valid_id").Return(nil, errors.New("db failed")),
-		pdtRepo.EXPECT().Fetch("valid_id").Return(model.Product{ID: "valid_id", Name: "Test2", Price: 200, Weight: 2, Available: true}, nil),
-		pdtRepo.EXPECT().Update("valid_id", model.Product{ID: "valid_id", Name: "", Price: 100, Weight: 1, Available: true}).Return(model.ValidationError{}),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(model.Product{ID: "valid_id", Name: "Test1", Price: 100, Weight: 1, Available: false}, nil),
+		pdtRepo.EXPECT().Update(gomock.Any(), "valid_id", model.Product{ID: "valid_id", Name: "Test2", Price: 200, Weight: 2, Available: false}).Return(nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(nil, errors.New("db failed")),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(model.Product{ID: "valid_id", Name: "Test2", Price: 200, Weight: 2, Available: true}, nil),
+		pdtRepo.EXPECT().Update(gomock.Any(), "valid_id", model.Product{ID: "valid_id", Name: "", Price: 100, Weight: 1, Available: true}).Return(model.ValidationError{}),
 	)
 
 	type fields struct {
@@ -411,12 +418,12 @@ func TestProductController_UpdatePartial(t *testing.T) {
 	injectChiURLParam(req4, "id", "valid_id")
 
 	gomock.InOrder(
-		pdtRepo.EXPECT().Fetch("unavailable_id").Return(nil, nil),
-		pdtRepo.EXPECT().Fetch("valid_id").Return(model.Product{ID: "valid_id", Name: "Test1", Price: 100, Weight: 1, Available: false}, nil),
-		pdtRepo.EXPECT().Update("valid_id", model.Product{ID: "valid_id", Name: "Test2", Price: 200, Weight: 2, Available: true}).Return(nil),
-		pdtRepo.EXPECT().Fetch("valid_id").Return(nil, errors.New("db failed")),
-		pdtRepo.EXPECT().Fetch("valid_id").Return(model.Product{ID: "valid_id", Name: "Test2", Price: 200, Weight: 2, Available: true}, nil),
-		pdtRepo.EXPECT().Update("valid_id", model.Product{ID: "valid_id", Name: "Test2", Price: 100, Weight: 1, Available: true}).Return(nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(model.Product{ID: "valid_id", Name: "Test1", Price: 100, Weight: 1, Available: false}, nil),
+		pdtRepo.EXPECT().Update(gomock.Any(), "valid_id", model.Product{ID: "valid_id", Name: "Test2", Price: 200, Weight: 2, Available: false}).Return(nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(nil, errors.New("db failed")),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(model.Product{ID: "valid_id", Name: "Test2", Price: 200, Weight: 2, Available: true}, nil),
+		pdtRepo.EXPECT().Update(gomock.Any(), "valid_id", model.Product{ID: "valid_id", Name: "Test2", Price: 100, Weight: 1, Available: true}).Return(nil),
 	)
 
 	type fields struct {
@@ -507,9 +514,9 @@ func TestProductController_Delete(t *testing.T) {
 	injectChiURLParam(req3, "id", "valid_id")
 
 	gomock.InOrder(
-		pdtRepo.EXPECT().Delete("unavailable_id").Return(nil),
-		pdtRepo.EXPECT().Delete("valid_id").Return(errors.New("db failed")),
-		pdtRepo.EXPECT().Delete("valid_id").Return(nil),
+		pdtRepo.EXPECT().Delete(gomock.Any(), "unavailable_id").Return(nil),
+		pdtRepo.EXPECT().Delete(gomock.Any(), "valid_id").Return(errors.New("db failed")),
+		pdtRepo.EXPECT().Delete(gomock.Any(), "valid_id").Return(nil),
 	)
 
 	type fields struct {
@@ -558,29 +565,166 @@ func TestProductController_Delete(t *testing.T) {
 	}
 }
 
+func TestProductController_CreateDraft(t *testing.T) {
+	rps := memory.NewStore().Repos()
+	pdtSvc := service.NewProduct(rps.Product, service.NewRating(rps.Rating),
+		service.SetProductOutputLogger(nil),
+		service.SetProductErrorLogger(nil),
+	)
+	c := NewProductController(pdtSvc)
+
+	for _, body := range []string{
+		`{"name":"Hat","price":100,"weight":1}`,
+		`{"name":"Cap","price":100,"weight":1,"status":"published"}`,
+		`{"name":"Bag","price":100,"weight":1,"status":"archived"}`,
+	} {
+		req, err := http.NewRequest("POST", "/", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		c.Create(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("ProductController.Create(%s) Code = %v, want %v", body, rr.Code, http.StatusCreated)
+		}
+		res := struct {
+			Data string `json:"data"`
+		}{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		pdt, err := pdtSvc.Get(context.Background(), res.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pdt.Status != model.ProductDraft {
+			t.Errorf("ProductController.Create(%s) Status = %v, want %v", body, pdt.Status, model.ProductDraft)
+		}
+	}
+}
+
 func TestProductController_Rate(t *testing.T) {
-	type fields struct {
-		pdtSvc *service.Product
+	rps := memory.NewStore().Repos()
+	ctx := context.Background()
+	ratSvc := service.NewRating(rps.Rating, service.SetRatingOutputLogger(nil), service.SetRatingErrorLogger(nil))
+	pdtSvc := service.NewProduct(rps.Product, ratSvc,
+		service.SetProductOutputLogger(nil),
+		service.SetProductErrorLogger(nil),
+	)
+	c := NewProductController(pdtSvc)
+
+	id, err := pdtSvc.Add(ctx, model.Product{Name: "Hat", Price: 100, Weight: 1, Status: model.ProductPublished})
+	if err != nil {
+		t.Fatal(err)
 	}
-	type args struct {
-		w http.ResponseWriter
-		r *http.Request
+
+	newRequest := func(method, body, usr string, params ...string) *http.Request {
+		req, err := http.NewRequest(method, "/", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rctx := chi.NewRouteContext()
+		for i := 0; i+1 < len(params); i += 2 {
+			rctx.URLParams.Add(params[i], params[i+1])
+		}
+		*req = *req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		if usr != "" {
+			req.Header.Set("Authorization", "Bearer "+usr)
+			req.Header.Set(middleware.UserNameHeader, "Name of "+usr)
+		}
+		return req
 	}
-	tests := []struct {
-		name   string
-		fields fields
-		args   args
+
+	rates := []struct {
+		name     string
+		id       string
+		body     string
+		usr      string
+		wantCode int
 	}{
-		// TODO: Add test cases.
+		{name: "new", id: id, body: `{"value":2,"title":" Warm "}`, usr: "u1", wantCode: http.StatusCreated},
+		{name: "again", id: id, body: `{"value":4,"title":"Warm","body":"Warmer once washed"}`, usr: "u1", wantCode: http.StatusOK},
+		{name: "other user", id: id, body: `{"value":5}`, usr: "u2", wantCode: http.StatusCreated},
+		{name: "invalid value", id: id, body: `{"value":6}`, usr: "u3", wantCode: http.StatusUnprocessableEntity},
+		{name: "bad body", id: id, body: `{"value":`, usr: "u3", wantCode: http.StatusBadRequest},
+		{name: "unknown product", id: "unavailable_id", body: `{"value":5}`, usr: "u3", wantCode: http.StatusNotFound},
 	}
-	for _, tt := range tests {
+	ids := map[string]string{}
+	for _, tt := range rates {
 		t.Run(tt.name, func(t *testing.T) {
-			c := &ProductController{
-				pdtSvc: tt.fields.pdtSvc,
+			rr := httptest.NewRecorder()
+			c.Rate(rr, newRequest("POST", tt.body, tt.usr, "id", tt.id))
+			if rr.Code != tt.wantCode {
+				t.Fatalf("ProductController.Rate() Code = %v, want %v", rr.Code, tt.wantCode)
+			}
+			res := struct {
+				Data string `json:"data"`
+			}{}
+			json.Unmarshal(rr.Body.Bytes(), &res)
+			if rr.Code < 300 {
+				ids[tt.usr] = res.Data
+			}
+		})
+	}
+
+	// the review of u1 is public once approved
+	if _, err := ratSvc.Moderate(ctx, ids["u1"], model.RatingApproved, "", model.User{ID: "moderator"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	c.Reviews(rr, newRequest("GET", "", "", "id", id))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("ProductController.Reviews() Code = %v, want %v", rr.Code, http.StatusOK)
+	}
+	list := struct {
+		Data []resp.Review `json:"data"`
+		Meta resp.Pager    `json:"meta"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 2 || list.Meta.Total != 2 {
+		t.Fatalf("ProductController.Reviews() = %+v, want 2 reviews", list)
+	}
+	rev := list.Data[1]
+	if rev.ID != ids["u1"] || rev.UserID != "u1" || rev.UserName != "Name of u1" || rev.Value != 4 || rev.Title != "Warm" || rev.Body != "Warmer once washed" {
+		t.Errorf("ProductController.Reviews() = %+v, want the updated review of u1", rev)
+	}
+
+	rr = httptest.NewRecorder()
+	c.Reviews(rr, newRequest("GET", "", "", "id", "unavailable_id"))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("ProductController.Reviews() unknown product Code = %v, want %v", rr.Code, http.StatusNotFound)
+	}
+
+	removes := []struct {
+		name     string
+		rid      string
+		usr      string
+		wantCode int
+	}{
+		{name: "of another user", rid: ids["u1"], usr: "u2", wantCode: http.StatusNotFound},
+		{name: "own", rid: ids["u1"], usr: "u1", wantCode: http.StatusOK},
+		{name: "deleted", rid: ids["u1"], usr: "u1", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range removes {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			c.RemoveReview(rr, newRequest("DELETE", "", tt.usr, "id", id, "rid", tt.rid))
+			if rr.Code != tt.wantCode {
+				t.Errorf("ProductController.RemoveReview() Code = %v, want %v", rr.Code, tt.wantCode)
 			}
-			c.Rate(tt.args.w, tt.args.r)
 		})
 	}
+
+	h := productHandlers(c, nil, nil, nil, nil, nil)
+	req, _ := http.NewRequest("POST", "/"+id+"/rating", strings.NewReader(`{"value":5}`))
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("POST /{id}/rating without token Code = %v, want %v", rr.Code, http.StatusUnauthorized)
+	}
 }
 
 func Test_getSkipLimit(t *testing.T) {
@@ -611,8 +755,8 @@ func Test_getSkipLimit(t *testing.T) {
 
 func Test_toRespProduct(t *testing.T) {
 	type args struct {
-		pdt    model.Product
-		rating float64
+		pdt model.Product
+		sum model.RatingSummary
 	}
 	tests := []struct {
 		name string
@@ -623,9 +767,496 @@ func Test_toRespProduct(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := toRespProduct(tt.args.pdt, tt.args.rating); !reflect.DeepEqual(got, tt.want) {
+			if got := toRespProduct(tt.args.pdt, tt.args.sum); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("toRespProduct() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+type ctxKey string
+
+type ctxValueMatcher struct {
+	key ctxKey
+	val string
+}
+
+func (m ctxValueMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	return ok && ctx.Value(m.key) == m.val
+}
+
+func (m ctxValueMatcher) String() string {
+	return "context with " + string(m.key) + "=" + m.val
+}
+
+func TestProductController_GetPropagatesContext(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	rateRepo := mock_repo.NewMockRating(mockCtrl)
+
+	rateSvc := service.NewRating(rateRepo)
+	pdtSvc := service.NewProduct(pdtRepo, rateSvc)
+
+	req, err := http.NewRequest("GET", "/valid_id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), ctxKey("req"), "test"))
+	injectChiURLParam(req, "id", "valid_id")
+
+	m := ctxValueMatcher{key: "req", val: "test"}
+	gomock.InOrder(
+		pdtRepo.EXPECT().Fetch(m, "valid_id").Return(model.Product{ID: "valid_id", Status: model.ProductPublished}, nil),
+		rateRepo.EXPECT().Summaries(m, []string{"valid_id"}).Return(map[string]model.RatingSummary{}, nil),
+	)
+
+	c := NewProductController(pdtSvc)
+	rr := httptest.NewRecorder()
+	c.Get(rr, req)
+	if got := rr.Code; got != http.StatusOK {
+		t.Errorf("ProductController.Get() Code = %v, want %v", got, http.StatusOK)
+	}
+}
+
+func TestProductController_GetVariants(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	vrtSvc := service.NewVariant(rps.Variant, rps.Product, service.SetVariantOutputLogger(nil), service.SetVariantErrorLogger(nil))
+	pdtSvc := service.NewProduct(rps.Product, service.NewRating(rps.Rating),
+		service.SetProductVariantService(vrtSvc),
+		service.SetProductOutputLogger(nil),
+		service.SetProductErrorLogger(nil),
+	)
+
+	id, err := rps.Product.Create(ctx, model.Product{Name: "Shirt", Price: 100, Weight: 1, Status: model.ProductPublished})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, vrt := range []model.Variant{
+		{Options: map[string]string{"size": "M"}},
+		{Options: map[string]string{"size": "XL"}, Price: 130},
+	} {
+		if _, err := vrtSvc.Add(ctx, id, vrt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest("GET", "/"+id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injectChiURLParam(req, "id", id)
+	rr := httptest.NewRecorder()
+	NewProductController(pdtSvc).Get(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("ProductController.Get() Code = %v, want %v", rr.Code, http.StatusOK)
+	}
+
+	body := struct {
+		Data resp.Product `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if got := body.Data; len(got.Variants) != 2 || got.PriceRange == nil || *got.PriceRange != (resp.PriceRange{Min: 100, Max: 130}) {
+		t.Errorf("ProductController.Get() = %+v, want 2 variants from 100 to 130", got)
+	}
+}
+
+func TestProductController_GetCurrency(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	pdtSvc := service.NewProduct(rps.Product, service.NewRating(rps.Rating),
+		service.SetProductExchangeRates(model.ExchangeRates{Base: "USD", Rates: map[string]float64{"EUR": 0.9}}),
+		service.SetProductOutputLogger(nil),
+		service.SetProductErrorLogger(nil),
+	)
+
+	id, err := rps.Product.Create(ctx, model.Product{Name: "Shirt", Price: 1000, Weight: 1, Status: model.ProductPublished, Prices: []model.Money{{Amount: 799, Currency: "GBP"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+		want     resp.Money
+	}{
+		{name: "base", query: "", wantCode: http.StatusOK, want: resp.Money{Amount: 1000, Currency: "USD"}},
+		{name: "converted", query: "?currency=eur", wantCode: http.StatusOK, want: resp.Money{Amount: 900, Currency: "EUR"}},
+		{name: "set price", query: "?currency=GBP", wantCode: http.StatusOK, want: resp.Money{Amount: 799, Currency: "GBP"}},
+		{name: "no rate", query: "?currency=JPY", wantCode: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/"+id+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			injectChiURLParam(req, "id", id)
+			rr := httptest.NewRecorder()
+			NewProductController(pdtSvc).Get(rr, req)
+			if rr.Code != tt.wantCode {
+				t.Fatalf("ProductController.Get() Code = %v, want %v", rr.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			body := struct {
+				Data resp.Product `json:"data"`
+			}{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if got := (resp.Money{Amount: body.Data.Price, Currency: body.Data.Currency}); got != tt.want {
+				t.Errorf("ProductController.Get() price = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductController_GetEffectivePrice(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	prmSvc := service.NewPromotion(rps.Promotion, service.SetPromotionOutputLogger(nil), service.SetPromotionErrorLogger(nil))
+	pdtSvc := service.NewProduct(rps.Product, service.NewRating(rps.Rating),
+		service.SetProductPromotionService(prmSvc),
+		service.SetProductOutputLogger(nil),
+		service.SetProductErrorLogger(nil),
+	)
+
+	id, err := rps.Product.Create(ctx, model.Product{Name: "Shirt", Price: 1000, Weight: 1, Status: model.ProductPublished})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prmID, err := prmSvc.Add(ctx, model.Promotion{Name: "Sale", Type: model.PromotionPercentage, Value: 25, ProductIDs: []string{id},
+		StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "/"+id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injectChiURLParam(req, "id", id)
+	rr := httptest.NewRecorder()
+	NewProductController(pdtSvc).Get(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("ProductController.Get() Code = %v, want %v", rr.Code, http.StatusOK)
+	}
+
+	body := struct {
+		Data resp.Product `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if got := body.Data; got.Price != 1000 || got.EffectivePrice != 750 || !reflect.DeepEqual(got.PromotionIDs, []string{prmID}) {
+		t.Errorf("ProductController.Get() = %+v, want price 1000 effective 750 by %v", got, prmID)
+	}
+}
+
+func TestProductController_Status(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	pdtSvc := service.NewProduct(rps.Product, service.NewRating(rps.Rating),
+		service.SetProductOutputLogger(nil),
+		service.SetProductErrorLogger(nil),
+	)
+	c := NewProductController(pdtSvc)
+
+	draft, err := pdtSvc.Add(ctx, model.Product{Name: "Shirt", Price: 100, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	published, err := pdtSvc.Add(ctx, model.Product{Name: "Hat", Price: 100, Weight: 1, Status: model.ProductPublished})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func(method, target, id string, auth bool) *http.Request {
+		req, err := http.NewRequest(method, target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "" {
+			injectChiURLParam(req, "id", id)
+		}
+		if auth {
+			req.Header.Set("Authorization", "token")
+		}
+		return req
+	}
+
+	gets := []struct {
+		name     string
+		id       string
+		auth     bool
+		wantCode int
+	}{
+		{name: "public draft", id: draft, wantCode: http.StatusNotFound},
+		{name: "authorized draft", id: draft, auth: true, wantCode: http.StatusOK},
+		{name: "public published", id: published, wantCode: http.StatusOK},
+	}
+	for _, tt := range gets {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			c.Get(rr, newRequest("GET", "/"+tt.id, tt.id, tt.auth))
+			if rr.Code != tt.wantCode {
+				t.Errorf("ProductController.Get() Code = %v, want %v", rr.Code, tt.wantCode)
+			}
+		})
+	}
+
+	lists := []struct {
+		name     string
+		query    string
+		auth     bool
+		wantCode int
+		want     []string
+	}{
+		{name: "public", want: []string{published}, wantCode: http.StatusOK},
+		{name: "public asks for drafts", query: "?status=draft", want: []string{published}, wantCode: http.StatusOK},
+		{name: "authorized", auth: true, want: []string{published}, wantCode: http.StatusOK},
+		{name: "authorized asks for drafts", query: "?status=draft", auth: true, want: []string{draft}, wantCode: http.StatusOK},
+		{name: "authorized asks for invalid status", query: "?status=live", auth: true, wantCode: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range lists {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			c.List(rr, newRequest("GET", "/"+tt.query, "", tt.auth))
+			if rr.Code != tt.wantCode {
+				t.Fatalf("ProductController.List() Code = %v, want %v", rr.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			body := struct {
+				Data []resp.Product `json:"data"`
+			}{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, pdt := range body.Data {
+				ids = append(ids, pdt.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("ProductController.List() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	c.Archive(rr, newRequest("POST", "/"+draft+"/archive", draft, true))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("ProductController.Archive() draft Code = %v, want %v", rr.Code, http.StatusConflict)
+	}
+	rr = httptest.NewRecorder()
+	c.Publish(rr, newRequest("POST", "/"+draft+"/publish", draft, true))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("ProductController.Publish() Code = %v, want %v", rr.Code, http.StatusOK)
+	}
+	body := struct {
+		Data resp.Product `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data.Status != string(model.ProductPublished) || body.Data.PublishedAt == nil || body.Data.ArchivedAt != nil {
+		t.Errorf("ProductController.Publish() = %+v, want published with its publish time", body.Data)
+	}
+}
+
+func TestProductController_Restore(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	pdtSvc := service.NewProduct(rps.Product, service.NewRating(rps.Rating),
+		service.SetProductOutputLogger(nil),
+		service.SetProductErrorLogger(nil),
+	)
+	c := NewProductController(pdtSvc)
+
+	id, err := pdtSvc.Add(ctx, model.Product{Name: "Hat", Price: 100, Weight: 1, Status: model.ProductPublished})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pdtSvc.Remove(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "/deleted", nil)
+	rr := httptest.NewRecorder()
+	c.Deleted(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("ProductController.Deleted() Code = %v, want %v", rr.Code, http.StatusOK)
+	}
+	list := struct {
+		Data []resp.Product `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 || list.Data[0].ID != id || list.Data[0].DeletedAt == nil {
+		t.Errorf("ProductController.Deleted() = %+v, want the deleted product with its delete time", list.Data)
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		wantCode int
+	}{
+		{name: "restore", id: id, wantCode: http.StatusOK},
+		{name: "not deleted", id: id, wantCode: http.StatusNotFound},
+		{name: "unknown", id: "unavailable_id", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/"+tt.id+"/restore", nil)
+			injectChiURLParam(req, "id", tt.id)
+			rr := httptest.NewRecorder()
+			c.Restore(rr, req)
+			if rr.Code != tt.wantCode {
+				t.Errorf("ProductController.Restore() Code = %v, want %v", rr.Code, tt.wantCode)
+			}
+		})
+	}
+
+	req, _ = http.NewRequest("GET", "/"+id, nil)
+	injectChiURLParam(req, "id", id)
+	rr = httptest.NewRecorder()
+	c.Get(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("ProductController.Get() after Restore() Code = %v, want %v", rr.Code, http.StatusOK)
+	}
+}
+
+func TestProductController_ListRatings(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	rps := memory.NewStore().Repos()
+	rateRepo := mock_repo.NewMockRating(mockCtrl)
+	ctx := context.Background()
+	pdtSvc := service.NewProduct(rps.Product, service.NewRating(rateRepo, service.SetRatingOutputLogger(nil), service.SetRatingErrorLogger(nil)),
+		service.SetProductOutputLogger(nil),
+		service.SetProductErrorLogger(nil),
+	)
+	c := NewProductController(pdtSvc)
+
+	ids := []string{}
+	for i := 0; i < 3; i++ {
+		id, err := pdtSvc.Add(ctx, model.Product{Name: fmt.Sprint("Hat ", i), Price: 100, Weight: 1, Status: model.ProductPublished})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	// the whole page is rated by a single lookup and never per product
+	rateRepo.EXPECT().Summaries(gomock.Any(), ids).Return(map[string]model.RatingSummary{
+		ids[0]: {ProductID: ids[0], Count: 2, Sum: 9, Avg: 4.5, Histogram: [5]int{0, 0, 0, 1, 1}},
+		ids[2]: {ProductID: ids[2], Count: 1, Sum: 1, Avg: 1, Histogram: [5]int{1, 0, 0, 0, 0}},
+	}, nil).Times(1)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := httptest.NewRecorder()
+	c.List(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("ProductController.List() Code = %v, want %v", rr.Code, http.StatusOK)
+	}
+	body := struct {
+		Data []resp.Product `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	got := []float64{}
+	cnts := []int{}
+	for _, pdt := range body.Data {
+		got = append(got, pdt.AvgRating)
+		cnts = append(cnts, pdt.RatingCount)
+	}
+	if want := []float64{4.5, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ProductController.List() ratings = %v, want %v", got, want)
+	}
+	if want := []int{2, 0, 1}; !reflect.DeepEqual(cnts, want) {
+		t.Errorf("ProductController.List() rating counts = %v, want %v", cnts, want)
+	}
+	if want := []int{0, 0, 0, 1, 1}; !reflect.DeepEqual(body.Data[0].RatingHistogram, want) {
+		t.Errorf("ProductController.List() rating histogram = %v, want %v", body.Data[0].RatingHistogram, want)
+	}
+}
+
+func TestProductController_Visible(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	vrtSvc := service.NewVariant(rps.Variant, rps.Product, service.SetVariantOutputLogger(nil), service.SetVariantErrorLogger(nil))
+	pdtSvc := service.NewProduct(rps.Product, service.NewRating(rps.Rating, service.SetRatingOutputLogger(nil), service.SetRatingErrorLogger(nil)),
+		service.SetProductVariantService(vrtSvc),
+		service.SetProductOutputLogger(nil),
+		service.SetProductErrorLogger(nil),
+	)
+	stkSvc := service.NewStock(rps.Stock, rps.Product, rps.StockLevel, rps.Location, service.SetStockUnitOfWork(store),
+		service.SetStockOutputLogger(nil), service.SetStockErrorLogger(nil))
+	catSvc := service.NewCategory(rps.Category, rps.Product, service.SetCategoryUnitOfWork(store),
+		service.SetCategoryOutputLogger(nil), service.SetCategoryErrorLogger(nil))
+	prcSvc := service.NewPrice(rps.Price, rps.Product, service.SetPriceUnitOfWork(store),
+		service.SetPriceOutputLogger(nil), service.SetPriceErrorLogger(nil))
+	h := productHandlers(NewProductController(pdtSvc), NewStockController(stkSvc), nil,
+		NewCategoryController(catSvc), NewVariantController(vrtSvc), NewPriceController(prcSvc))
+
+	ids := map[model.ProductStatus]string{}
+	vids := map[model.ProductStatus]string{}
+	for _, sts := range []model.ProductStatus{model.ProductDraft, model.ProductPublished} {
+		id, err := rps.Product.Create(ctx, model.Product{Name: "Shirt", Price: 100, Weight: 1, Status: sts})
+		if err != nil {
+			t.Fatal(err)
+		}
+		vid, err := vrtSvc.Add(ctx, id, model.Variant{Options: map[string]string{"size": "M"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[sts], vids[sts] = id, vid
+	}
+
+	paths := []string{"/{id}", "/{id}/categories", "/{id}/variants", "/{id}/variants/{vid}", "/{id}/prices", "/{id}/stock"}
+	tests := []struct {
+		name     string
+		status   model.ProductStatus
+		auth     bool
+		wantCode int
+	}{
+		{"anonymous draft", model.ProductDraft, false, http.StatusNotFound},
+		{"authorized draft", model.ProductDraft, true, http.StatusOK},
+		{"anonymous published", model.ProductPublished, false, http.StatusOK},
+	}
+	for _, tt := range tests {
+		for _, p := range paths {
+			path := strings.NewReplacer("{id}", ids[tt.status], "{vid}", vids[tt.status]).Replace(p)
+			t.Run(tt.name+" "+p, func(t *testing.T) {
+				req := httptest.NewRequest("GET", path, nil)
+				if tt.auth {
+					req.Header.Set("Authorization", "Bearer user")
+				}
+				rr := httptest.NewRecorder()
+				h.ServeHTTP(rr, req)
+				if rr.Code != tt.wantCode {
+					t.Errorf("GET %s Code = %v, want %v", path, rr.Code, tt.wantCode)
+				}
+			})
+		}
+	}
+}
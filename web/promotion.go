@@ -0,0 +1,157 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+// PromotionController holds necessary fields to serve promotion handlers
+type PromotionController struct {
+	prmSvc *service.Promotion
+}
+
+// NewPromotionController returns a new PromotionController with the svc
+func NewPromotionController(svc *service.Promotion) *PromotionController {
+	return &PromotionController{
+		prmSvc: svc,
+	}
+}
+
+type promotionBody struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Value       int       `json:"value"`
+	BuyQuantity int       `json:"buyQuantity"`
+	GetQuantity int       `json:"getQuantity"`
+	ProductIDs  []string  `json:"productIds"`
+	CategoryIDs []string  `json:"categoryIds"`
+	Tags        []string  `json:"tags"`
+	StartsAt    time.Time `json:"startsAt"`
+	EndsAt      time.Time `json:"endsAt"`
+}
+
+func (b promotionBody) promotion() model.Promotion {
+	return model.Promotion{
+		Name:        b.Name,
+		Type:        model.PromotionType(b.Type),
+		Value:       b.Value,
+		BuyQuantity: b.BuyQuantity,
+		GetQuantity: b.GetQuantity,
+		ProductIDs:  b.ProductIDs,
+		CategoryIDs: b.CategoryIDs,
+		Tags:        b.Tags,
+		StartsAt:    b.StartsAt,
+		EndsAt:      b.EndsAt,
+	}
+}
+
+// Create is the promotion create handler
+func (c *PromotionController) Create(w http.ResponseWriter, r *http.Request) {
+	body := promotionBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	id, err := c.prmSvc.Add(r.Context(), body.promotion())
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusCreated, id, nil)
+}
+
+// Get serves a promotion with its id from url param {id}
+func (c *PromotionController) Get(w http.ResponseWriter, r *http.Request) {
+	prm, err := c.prmSvc.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespPromotion(*prm), nil)
+}
+
+// List serves a list of promotions
+// only the promotions active now are listed with query param active=true
+func (c *PromotionController) List(w http.ResponseWriter, r *http.Request) {
+	skip, limit := getSkipLimit(r, 20)
+	active, _ := strconv.ParseBool(r.URL.Query().Get("active"))
+
+	n, err := c.prmSvc.Count(r.Context(), active)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	pgr := resp.NewPager(n, skip, limit)
+
+	if n <= skip {
+		ServeData(w, r, http.StatusOK, []struct{}{}, pgr)
+		return
+	}
+
+	prms, err := c.prmSvc.List(r.Context(), active, skip, limit)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespPromotions(prms), pgr)
+}
+
+// Update replaces a promotion finding it with its id from url param {id}
+func (c *PromotionController) Update(w http.ResponseWriter, r *http.Request) {
+	body := promotionBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := c.prmSvc.Update(r.Context(), id, body.promotion()); err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, id, nil)
+}
+
+// Delete deletes a promotion with its id from url param {id}
+func (c *PromotionController) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := c.prmSvc.Remove(r.Context(), chi.URLParam(r, "id")); err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, true, nil)
+}
+
+func toRespPromotion(prm model.Promotion) resp.Promotion {
+	return resp.Promotion{
+		ID:          prm.ID,
+		Name:        prm.Name,
+		Type:        string(prm.Type),
+		Value:       prm.Value,
+		BuyQuantity: prm.BuyQuantity,
+		GetQuantity: prm.GetQuantity,
+		ProductIDs:  append([]string{}, prm.ProductIDs...),
+		CategoryIDs: append([]string{}, prm.CategoryIDs...),
+		Tags:        append([]string{}, prm.Tags...),
+		StartsAt:    prm.StartsAt,
+		EndsAt:      prm.EndsAt,
+		CreatedAt:   prm.CreatedAt,
+		UpdatedAt:   prm.UpdatedAt,
+	}
+}
+
+func toRespPromotions(prms []model.Promotion) []resp.Promotion {
+	rs := []resp.Promotion{}
+	for _, prm := range prms {
+		rs = append(rs, toRespPromotion(prm))
+	}
+	return rs
+}
@@ -0,0 +1,105 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+func TestPromotionController(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	prmSvc := service.NewPromotion(rps.Promotion, service.SetPromotionUnitOfWork(store),
+		service.SetPromotionClock(func() time.Time { return now }),
+		service.SetPromotionOutputLogger(nil),
+		service.SetPromotionErrorLogger(nil),
+	)
+	c := NewPromotionController(prmSvc)
+
+	sale, err := prmSvc.Add(ctx, model.Promotion{Name: "Sale", Type: model.PromotionPercentage, Value: 10, Tags: []string{"summer"},
+		StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	soon, err := prmSvc.Add(ctx, model.Promotion{Name: "Soon", Type: model.PromotionFixed, Value: 20, ProductIDs: []string{"1"},
+		StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	span := `"startsAt":"` + now.Add(time.Hour).Format(time.RFC3339) + `","endsAt":"` + now.Add(2*time.Hour).Format(time.RFC3339) + `"`
+	newRequest := func(method, target, id, body string) *http.Request {
+		req, err := http.NewRequest(method, target, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "" {
+			injectChiURLParam(req, "id", id)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "create bad body", handler: c.Create, r: newRequest("POST", "/", "", `{"name":`), wantCode: http.StatusBadRequest},
+		{name: "create without targets", handler: c.Create, r: newRequest("POST", "/", "", `{"name":"Deal","type":"fixed","value":5,`+span+`}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "create without span", handler: c.Create, r: newRequest("POST", "/", "", `{"name":"Deal","type":"fixed","value":5,"tags":["deal"]}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "create", handler: c.Create, r: newRequest("POST", "/", "", `{"name":"Deal","type":"fixed","value":5,"tags":["deal"],`+span+`}`), wantCode: http.StatusCreated},
+		{name: "get unknown", handler: c.Get, r: newRequest("GET", "/unavailable_id", "unavailable_id", ""), wantCode: http.StatusNotFound},
+		{name: "get", handler: c.Get, r: newRequest("GET", "/"+sale, sale, ""), wantCode: http.StatusOK},
+		{name: "update bad body", handler: c.Update, r: newRequest("PUT", "/"+soon, soon, `{"name":`), wantCode: http.StatusBadRequest},
+		{name: "update unknown", handler: c.Update, r: newRequest("PUT", "/unavailable_id", "unavailable_id", `{"name":"Soon","type":"fixed","value":30,"productIds":["1"],`+span+`}`), wantCode: http.StatusNotFound},
+		{name: "update invalid", handler: c.Update, r: newRequest("PUT", "/"+soon, soon, `{"name":"Soon","type":"percentage","value":120,"productIds":["1"],`+span+`}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "update", handler: c.Update, r: newRequest("PUT", "/"+soon, soon, `{"name":"Soon","type":"fixed","value":30,"productIds":["1"],`+span+`}`), wantCode: http.StatusOK},
+		{name: "delete unknown", handler: c.Delete, r: newRequest("DELETE", "/unavailable_id", "unavailable_id", ""), wantCode: http.StatusNotFound},
+		{name: "delete", handler: c.Delete, r: newRequest("DELETE", "/"+soon, soon, ""), wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.handler(rr, tt.r)
+			if rr.Code != tt.wantCode {
+				t.Errorf("PromotionController %s Code = %v, want %v", tt.name, rr.Code, tt.wantCode)
+			}
+		})
+	}
+
+	lists := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{name: "all", want: 2},
+		{name: "active", query: "?active=true", want: 1},
+	}
+	for _, tt := range lists {
+		t.Run("list "+tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			c.List(rr, newRequest("GET", "/"+tt.query, "", ""))
+			body := struct {
+				Data []resp.Promotion `json:"data"`
+			}{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if rr.Code != http.StatusOK || len(body.Data) != tt.want {
+				t.Errorf("PromotionController.List() = %v %+v, want %v promotions", rr.Code, body.Data, tt.want)
+			}
+		})
+	}
+}
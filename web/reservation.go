@@ -0,0 +1,89 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+// ReservationController holds necessary fields to serve reservation handlers
+type ReservationController struct {
+	rsvSvc *service.Reservation
+}
+
+// NewReservationController returns a new ReservationController with the svc
+func NewReservationController(svc *service.Reservation) *ReservationController {
+	return &ReservationController{
+		rsvSvc: svc,
+	}
+}
+
+type createReservationBody struct {
+	Quantity int `json:"quantity"`
+	// TTL is in seconds
+	TTL int `json:"ttl"`
+}
+
+// Create reserves stock of a product with its id from url param {id}
+func (c *ReservationController) Create(w http.ResponseWriter, r *http.Request) {
+	body := createReservationBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	rID, err := c.rsvSvc.Reserve(r.Context(), id, body.Quantity, time.Duration(body.TTL)*time.Second)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusCreated, rID, nil)
+}
+
+// Get serves a reservation with its id from url param {rid}
+func (c *ReservationController) Get(w http.ResponseWriter, r *http.Request) {
+	rsv, err := c.rsvSvc.Get(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "rid"))
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespReservation(*rsv), nil)
+}
+
+// Confirm confirms a reservation with its id from url param {rid}
+func (c *ReservationController) Confirm(w http.ResponseWriter, r *http.Request) {
+	rID := chi.URLParam(r, "rid")
+	if err := c.rsvSvc.Confirm(r.Context(), chi.URLParam(r, "id"), rID); err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, rID, nil)
+}
+
+// Cancel cancels a reservation with its id from url param {rid}
+func (c *ReservationController) Cancel(w http.ResponseWriter, r *http.Request) {
+	rID := chi.URLParam(r, "rid")
+	if err := c.rsvSvc.Cancel(r.Context(), chi.URLParam(r, "id"), rID); err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, rID, nil)
+}
+
+func toRespReservation(rsv model.Reservation) resp.Reservation {
+	return resp.Reservation{
+		ID:        rsv.ID,
+		ProductID: rsv.ProductID,
+		Quantity:  rsv.Quantity,
+		Status:    string(rsv.Status),
+		ExpiresAt: rsv.ExpiresAt,
+		CreatedAt: rsv.CreatedAt,
+		UpdatedAt: rsv.UpdatedAt,
+	}
+}
@@ -0,0 +1,242 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/msyrus/simple-product-inv/mock_repo"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/service"
+)
+
+// newReservationRequest returns a request on reservation rid of product id
+func newReservationRequest(t *testing.T, method, id, rid, body string) *http.Request {
+	req, err := http.NewRequest(method, "/"+id+"/reservations/"+rid, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	rctx.URLParams.Add("rid", rid)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestNewReservationController(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	rsvSvc := service.NewReservation(mock_repo.NewMockReservation(mockCtrl), mock_repo.NewMockProduct(mockCtrl), mock_repo.NewMockStock(mockCtrl), mock_repo.NewMockStockLevel(mockCtrl))
+
+	if got, want := NewReservationController(rsvSvc), (&ReservationController{rsvSvc: rsvSvc}); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewReservationController() = %v, want %v", got, want)
+	}
+}
+
+func TestReservationController_Create(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	rsvRepo := mock_repo.NewMockReservation(mockCtrl)
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	lvlRepo := mock_repo.NewMockStockLevel(mockCtrl)
+
+	now := time.Now()
+	rsvSvc := service.NewReservation(rsvRepo, pdtRepo, stkRepo, lvlRepo, service.SetReservationClock(func() time.Time { return now }))
+
+	gomock.InOrder(
+		pdtRepo.EXPECT().AdjustReserved(gomock.Any(), "unavailable_id", 1).Return(0, repo.ErrInsufficientQuantity),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		pdtRepo.EXPECT().AdjustReserved(gomock.Any(), "valid_id", 5).Return(0, repo.ErrInsufficientQuantity),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(model.Product{ID: "valid_id", Quantity: 3}, nil),
+		pdtRepo.EXPECT().AdjustReserved(gomock.Any(), "valid_id", 2).Return(0, errors.New("db failed")),
+		pdtRepo.EXPECT().AdjustReserved(gomock.Any(), "valid_id", 2).Return(2, nil),
+		rsvRepo.EXPECT().Create(gomock.Any(), model.Reservation{
+			ProductID: "valid_id",
+			Quantity:  2,
+			Status:    model.ReservationPending,
+			ExpiresAt: now.Add(time.Minute),
+		}).Return("rsv_id", nil),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "bad body", r: newReservationRequest(t, "POST", "valid_id", "", `{"quantity":`), wantCode: http.StatusBadRequest},
+		{name: "invalid quantity", r: newReservationRequest(t, "POST", "valid_id", "", `{"quantity":0}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "invalid ttl", r: newReservationRequest(t, "POST", "valid_id", "", `{"quantity":1,"ttl":90000}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "unknown product", r: newReservationRequest(t, "POST", "unavailable_id", "", `{"quantity":1}`), wantCode: http.StatusNotFound},
+		{name: "insufficient stock", r: newReservationRequest(t, "POST", "valid_id", "", `{"quantity":5}`), wantCode: http.StatusConflict},
+		{name: "db failed", r: newReservationRequest(t, "POST", "valid_id", "", `{"quantity":2}`), wantCode: http.StatusInternalServerError},
+		{name: "reserve", r: newReservationRequest(t, "POST", "valid_id", "", `{"quantity":2,"ttl":60}`), wantCode: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &ReservationController{
+				rsvSvc: rsvSvc,
+			}
+			rr := httptest.NewRecorder()
+			c.Create(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("ReservationController.Create() Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestReservationController_Get(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	rsvRepo := mock_repo.NewMockReservation(mockCtrl)
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	lvlRepo := mock_repo.NewMockStockLevel(mockCtrl)
+
+	rsvSvc := service.NewReservation(rsvRepo, pdtRepo, stkRepo, lvlRepo)
+
+	rsv := model.Reservation{ID: "rsv_id", ProductID: "valid_id", Quantity: 2, Status: model.ReservationPending}
+	gomock.InOrder(
+		rsvRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		rsvRepo.EXPECT().Fetch(gomock.Any(), "rsv_id").Return(rsv, nil),
+		rsvRepo.EXPECT().Fetch(gomock.Any(), "rsv_id").Return(rsv, nil),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "unknown reservation", r: newReservationRequest(t, "GET", "valid_id", "unavailable_id", ""), wantCode: http.StatusNotFound},
+		{name: "reservation of another product", r: newReservationRequest(t, "GET", "other_id", "rsv_id", ""), wantCode: http.StatusNotFound},
+		{name: "reservation", r: newReservationRequest(t, "GET", "valid_id", "rsv_id", ""), wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &ReservationController{
+				rsvSvc: rsvSvc,
+			}
+			rr := httptest.NewRecorder()
+			c.Get(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("ReservationController.Get() Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestReservationController_Confirm(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	rsvRepo := mock_repo.NewMockReservation(mockCtrl)
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	lvlRepo := mock_repo.NewMockStockLevel(mockCtrl)
+
+	now := time.Now()
+	rsvSvc := service.NewReservation(rsvRepo, pdtRepo, stkRepo, lvlRepo, service.SetReservationClock(func() time.Time { return now }))
+
+	rsv := model.Reservation{ID: "rsv_id", ProductID: "valid_id", Quantity: 2, Status: model.ReservationPending, ExpiresAt: now.Add(time.Minute)}
+	expired := rsv
+	expired.ExpiresAt = now
+	gomock.InOrder(
+		rsvRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		rsvRepo.EXPECT().Fetch(gomock.Any(), "rsv_id").Return(expired, nil),
+		rsvRepo.EXPECT().Fetch(gomock.Any(), "rsv_id").Return(rsv, nil),
+		rsvRepo.EXPECT().SetStatus(gomock.Any(), "rsv_id", string(model.ReservationPending), string(model.ReservationConfirmed)).Return(repo.ErrStaleStatus),
+		rsvRepo.EXPECT().Fetch(gomock.Any(), "rsv_id").Return(rsv, nil),
+		rsvRepo.EXPECT().SetStatus(gomock.Any(), "rsv_id", string(model.ReservationPending), string(model.ReservationConfirmed)).Return(nil),
+		pdtRepo.EXPECT().AdjustReserved(gomock.Any(), "valid_id", -2).Return(0, nil),
+		stkRepo.EXPECT().Create(gomock.Any(), model.StockMovement{
+			ProductID: "valid_id",
+			Type:      model.MovementSale,
+			Quantity:  2,
+			Reference: "reservation:rsv_id",
+		}).Return("mvt_id", nil),
+		pdtRepo.EXPECT().AdjustQuantity(gomock.Any(), "valid_id", -2).Return(1, nil),
+		lvlRepo.EXPECT().SearchCount(gomock.Any(), repo.Query{"product_id": {"valid_id"}}).Return(1, nil),
+		lvlRepo.EXPECT().Search(gomock.Any(), repo.Query{"product_id": {"valid_id"}}, 0, 1).Return([]interface{}{
+			model.StockLevel{ProductID: "valid_id", Quantity: 3},
+		}, nil),
+		lvlRepo.EXPECT().AdjustLevel(gomock.Any(), "valid_id", "", -2).Return(1, nil),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "unknown reservation", r: newReservationRequest(t, "POST", "valid_id", "unavailable_id", ""), wantCode: http.StatusNotFound},
+		{name: "expired", r: newReservationRequest(t, "POST", "valid_id", "rsv_id", ""), wantCode: http.StatusConflict},
+		{name: "not pending", r: newReservationRequest(t, "POST", "valid_id", "rsv_id", ""), wantCode: http.StatusConflict},
+		{name: "confirm", r: newReservationRequest(t, "POST", "valid_id", "rsv_id", ""), wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &ReservationController{
+				rsvSvc: rsvSvc,
+			}
+			rr := httptest.NewRecorder()
+			c.Confirm(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("ReservationController.Confirm() Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestReservationController_Cancel(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	rsvRepo := mock_repo.NewMockReservation(mockCtrl)
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	lvlRepo := mock_repo.NewMockStockLevel(mockCtrl)
+
+	rsvSvc := service.NewReservation(rsvRepo, pdtRepo, stkRepo, lvlRepo)
+
+	rsv := model.Reservation{ID: "rsv_id", ProductID: "valid_id", Quantity: 2, Status: model.ReservationPending}
+	gomock.InOrder(
+		rsvRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		rsvRepo.EXPECT().Fetch(gomock.Any(), "rsv_id").Return(rsv, nil),
+		rsvRepo.EXPECT().SetStatus(gomock.Any(), "rsv_id", string(model.ReservationPending), string(model.ReservationCancelled)).Return(nil),
+		pdtRepo.EXPECT().AdjustReserved(gomock.Any(), "valid_id", -2).Return(0, nil),
+		rsvRepo.EXPECT().Fetch(gomock.Any(), "rsv_id").Return(rsv, nil),
+		rsvRepo.EXPECT().SetStatus(gomock.Any(), "rsv_id", string(model.ReservationPending), string(model.ReservationCancelled)).Return(repo.ErrStaleStatus),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "unknown reservation", r: newReservationRequest(t, "POST", "valid_id", "unavailable_id", ""), wantCode: http.StatusNotFound},
+		{name: "cancel", r: newReservationRequest(t, "POST", "valid_id", "rsv_id", ""), wantCode: http.StatusOK},
+		{name: "not pending", r: newReservationRequest(t, "POST", "valid_id", "rsv_id", ""), wantCode: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &ReservationController{
+				rsvSvc: rsvSvc,
+			}
+			rr := httptest.NewRecorder()
+			c.Cancel(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("ReservationController.Cancel() Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
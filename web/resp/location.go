@@ -0,0 +1,18 @@
+package resp
+
+import "time"
+
+// Location presents the response object of a stock location
+type Location struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// StockLevel presents the response object of a product stock at a location
+type StockLevel struct {
+	LocationID string `json:"locationId"`
+	Quantity   int    `json:"quantity"`
+}
@@ -0,0 +1,14 @@
+package resp
+
+import "time"
+
+// PriceChange presents the response object of a product price change
+type PriceChange struct {
+	ID          string    `json:"id"`
+	ProductID   string    `json:"productId"`
+	Price       int       `json:"price"`
+	Status      string    `json:"status"`
+	EffectiveAt time.Time `json:"effectiveAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
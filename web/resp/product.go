@@ -1,11 +1,47 @@
 package resp
 
+import "time"
+
 // Product presents the response object of a product
 type Product struct {
-	ID        string  `json:"id"`
-	Name      string  `json:"name"`
-	Price     int     `json:"price"`
-	Weight    int     `json:"weight"`
-	Available bool    `json:"available"`
-	AvgRating float64 `json:"avgRating"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Price is in Currency, the base currency unless another one is requested
+	Price     int      `json:"price"`
+	Currency  string   `json:"currency"`
+	Prices    []Money  `json:"prices,omitempty"`
+	Weight    int      `json:"weight"`
+	SKU       string   `json:"sku,omitempty"`
+	Barcode   string   `json:"barcode,omitempty"`
+	Quantity  int      `json:"quantity"`
+	Available bool     `json:"available"`
+	Tags      []string `json:"tags"`
+	AvgRating float64  `json:"avgRating"`
+	Status    string   `json:"status"`
+	// PublishedAt and ArchivedAt are the last times the product was published and archived
+	PublishedAt *time.Time `json:"publishedAt,omitempty"`
+	ArchivedAt  *time.Time `json:"archivedAt,omitempty"`
+	// DeletedAt is served for the deleted products only
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	// EffectivePrice is the Price after the promotions of PromotionIDs are applied
+	EffectivePrice int      `json:"effectivePrice"`
+	PromotionIDs   []string `json:"promotionIds"`
+	// RatingHistogram holds the number of ratings of every value, 1 star first
+	RatingCount     int   `json:"ratingCount"`
+	RatingHistogram []int `json:"ratingHistogram"`
+	// Variants and PriceRange are served with a single product only
+	Variants   []Variant   `json:"variants,omitempty"`
+	PriceRange *PriceRange `json:"priceRange,omitempty"`
+}
+
+// Money presents an amount in the minor unit of an ISO 4217 currency
+type Money struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// TagCount presents the response object of a tag with its number of products
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
 }
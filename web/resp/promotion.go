@@ -0,0 +1,20 @@
+package resp
+
+import "time"
+
+// Promotion presents the response object of a promotion
+type Promotion struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Value       int       `json:"value,omitempty"`
+	BuyQuantity int       `json:"buyQuantity,omitempty"`
+	GetQuantity int       `json:"getQuantity,omitempty"`
+	ProductIDs  []string  `json:"productIds"`
+	CategoryIDs []string  `json:"categoryIds"`
+	Tags        []string  `json:"tags"`
+	StartsAt    time.Time `json:"startsAt"`
+	EndsAt      time.Time `json:"endsAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
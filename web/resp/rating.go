@@ -0,0 +1,19 @@
+package resp
+
+import "time"
+
+// Review presents the response object of a product rating with its review text
+// the ratings made before the users rated have no user
+type Review struct {
+	ID        string    `json:"id"`
+	ProductID string    `json:"productId"`
+	UserID    string    `json:"userId,omitempty"`
+	UserName  string    `json:"userName,omitempty"`
+	Value     int       `json:"value"`
+	Title     string    `json:"title,omitempty"`
+	Body      string    `json:"body,omitempty"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
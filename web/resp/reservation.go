@@ -0,0 +1,14 @@
+package resp
+
+import "time"
+
+// Reservation presents the response object of a stock reservation
+type Reservation struct {
+	ID        string    `json:"id"`
+	ProductID string    `json:"productId"`
+	Quantity  int       `json:"quantity"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,26 @@
+package resp
+
+import "time"
+
+// Stock presents the response object of a product stock
+type Stock struct {
+	ProductID string `json:"productId"`
+	Quantity  int    `json:"quantity"`
+	Reserved  int    `json:"reserved"`
+	Available bool   `json:"available"`
+
+	Levels []StockLevel `json:"levels"`
+}
+
+// StockMovement presents the response object of a stock movement
+type StockMovement struct {
+	ID        string `json:"id"`
+	ProductID string `json:"productId"`
+	// LocationID is empty for a movement not bound to a location
+	LocationID string    `json:"locationId,omitempty"`
+	Type       string    `json:"type"`
+	Quantity   int       `json:"quantity"`
+	Reason     string    `json:"reason,omitempty"`
+	Reference  string    `json:"reference,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
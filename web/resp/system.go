@@ -0,0 +1,25 @@
+package resp
+
+import "time"
+
+// Check statuses
+const (
+	CheckUp   = "up"
+	CheckDown = "down"
+)
+
+// Check presents the response object of a system check
+type Check struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Critical    bool       `json:"critical"`
+	LatencyMS   float64    `json:"latencyMs"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// System presents the response object of system health or readiness
+type System struct {
+	Status string  `json:"status"`
+	Checks []Check `json:"checks"`
+}
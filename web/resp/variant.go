@@ -0,0 +1,23 @@
+package resp
+
+import "time"
+
+// Variant presents the response object of a product variant
+// a zero price or weight is inherited from the product
+type Variant struct {
+	ID        string            `json:"id"`
+	ProductID string            `json:"productId"`
+	Options   map[string]string `json:"options"`
+	SKU       string            `json:"sku,omitempty"`
+	Price     int               `json:"price"`
+	Weight    int               `json:"weight"`
+	Quantity  int               `json:"quantity"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// PriceRange presents the response object of the price range of the product variants
+type PriceRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
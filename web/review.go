@@ -0,0 +1,85 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/middleware"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+// ReviewController holds necessary fields to serve review moderation handlers
+type ReviewController struct {
+	ratSvc *service.Rating
+}
+
+// NewReviewController returns a new ReviewController with the svc
+func NewReviewController(svc *service.Rating) *ReviewController {
+	return &ReviewController{
+		ratSvc: svc,
+	}
+}
+
+// Pending serves the reviews waiting for moderation, the oldest first
+func (c *ReviewController) Pending(w http.ResponseWriter, r *http.Request) {
+	skip, limit := getSkipLimit(r, 20)
+
+	n, err := c.ratSvc.CountPending(r.Context())
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	pgr := resp.NewPager(n, skip, limit)
+
+	if n <= skip {
+		ServeData(w, r, http.StatusOK, []struct{}{}, pgr)
+		return
+	}
+
+	rats, err := c.ratSvc.Pending(r.Context(), skip, limit)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	rs := []resp.Review{}
+	for _, rat := range rats {
+		rs = append(rs, toRespReview(rat))
+	}
+	ServeData(w, r, http.StatusOK, rs, pgr)
+}
+
+// Approve approves a review with its id from url param {rid}
+func (c *ReviewController) Approve(w http.ResponseWriter, r *http.Request) {
+	c.moderate(w, r, model.RatingApproved, "")
+}
+
+type rejectReviewBody struct {
+	Reason string `json:"reason"`
+}
+
+// Reject rejects a review with its id from url param {rid} for the reason of the body
+func (c *ReviewController) Reject(w http.ResponseWriter, r *http.Request) {
+	body := rejectReviewBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+	c.moderate(w, r, model.RatingRejected, strings.TrimSpace(body.Reason))
+}
+
+// moderate moves the review of url param {rid} to status to for reason and serves it
+// the review is moderated by the requesting user
+func (c *ReviewController) moderate(w http.ResponseWriter, r *http.Request, to model.RatingStatus, reason string) {
+	usr, _ := middleware.User(r)
+	rat, err := c.ratSvc.Moderate(r.Context(), chi.URLParam(r, "rid"), to, reason, usr)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespReview(*rat), nil)
+}
@@ -0,0 +1,116 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/middleware"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+func TestReviewController(t *testing.T) {
+	s := memory.NewStore()
+	rps := s.Repos()
+	ctx := context.Background()
+	ratSvc := service.NewRating(rps.Rating, service.SetRatingUnitOfWork(s), service.SetRatingBannedWords([]string{"scam"}),
+		service.SetRatingOutputLogger(nil), service.SetRatingErrorLogger(nil))
+	pdtSvc := service.NewProduct(rps.Product, ratSvc, service.SetProductUnitOfWork(s),
+		service.SetProductOutputLogger(nil), service.SetProductErrorLogger(nil))
+	h := reviewHandlers(NewReviewController(ratSvc))
+
+	id, err := pdtSvc.Add(ctx, model.Product{Name: "Hat", Price: 100, Weight: 1, Status: model.ProductPublished})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, rat := range []model.Rating{
+		{User: model.User{ID: "u1"}, Value: 1, Title: "A scam"},
+		{User: model.User{ID: "u2"}, Value: 5, Title: "Warm"},
+		{User: model.User{ID: "u3"}, Value: 3},
+	} {
+		rid, _, err := pdtSvc.Rate(ctx, id, rat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, rid)
+	}
+
+	serve := func(method, url, body, usrID, role string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+usrID)
+		req.Header.Set(middleware.UserRoleHeader, role)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve("GET", "/pending?limit=1", "", "admin", "admin")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /pending Code = %v, want %v", rr.Code, http.StatusOK)
+	}
+	list := struct {
+		Data []resp.Review `json:"data"`
+		Meta resp.Pager    `json:"meta"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 || list.Meta.Total != 2 {
+		t.Fatalf("GET /pending = %+v, want 1 of 2 reviews", list)
+	}
+	if rev := list.Data[0]; rev.ID != ids[0] || rev.Status != "pending" || rev.Reason != "flagged for banned words: scam" {
+		t.Errorf("GET /pending = %+v, want the flagged review of u1 first", rev)
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		body     string
+		usrID    string
+		role     string
+		wantCode int
+	}{
+		{name: "reject without role", url: "/" + ids[0] + "/reject", body: `{"reason":"abusive"}`, usrID: "u2", wantCode: http.StatusForbidden},
+		{name: "reject as customer", url: "/" + ids[0] + "/reject", body: `{"reason":"abusive"}`, usrID: "u2", role: "customer", wantCode: http.StatusForbidden},
+		{name: "approve own", url: "/" + ids[1] + "/approve", usrID: "u2", role: "moderator", wantCode: http.StatusConflict},
+		{name: "reject", url: "/" + ids[0] + "/reject", body: `{"reason":"abusive"}`, wantCode: http.StatusOK},
+		{name: "reject again", url: "/" + ids[0] + "/reject", body: `{"reason":"abusive"}`, wantCode: http.StatusConflict},
+		{name: "reject without reason", url: "/" + ids[1] + "/reject", body: `{"reason":" "}`, wantCode: http.StatusUnprocessableEntity},
+		{name: "reject bad body", url: "/" + ids[1] + "/reject", body: `{"reason":`, wantCode: http.StatusBadRequest},
+		{name: "approve", url: "/" + ids[1] + "/approve", wantCode: http.StatusOK},
+		{name: "approve unknown", url: "/unknown/approve", wantCode: http.StatusNotFound},
+		{name: "approve rejected", url: "/" + ids[0] + "/approve", wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.usrID == "" {
+				tt.usrID, tt.role = "admin", "admin"
+			}
+			if rr := serve("POST", tt.url, tt.body, tt.usrID, tt.role); rr.Code != tt.wantCode {
+				t.Errorf("POST %s Code = %v, want %v", tt.url, rr.Code, tt.wantCode)
+			}
+		})
+	}
+
+	want := model.RatingSummary{ProductID: id, Count: 3, Sum: 9, Avg: 3, Histogram: [5]int{1, 0, 1, 0, 1}}
+	if sum, err := pdtSvc.RatingSummary(ctx, id); err != nil || sum != want {
+		t.Errorf("Product.RatingSummary() after moderation = %v, %v, want %v", sum, err, want)
+	}
+
+	req, _ := http.NewRequest("GET", "/pending", nil)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("GET /pending without token Code = %v, want %v", rr.Code, http.StatusUnauthorized)
+	}
+}
@@ -13,7 +13,7 @@ import (
 )
 
 // NewRouter returns a http.Handler with all API registered
-func NewRouter(pdtCtrl *ProductController, sysCtl *SystemController) http.Handler {
+func NewRouter(pdtCtrl *ProductController, sysCtl *SystemController, stkCtrl *StockController, rsvCtrl *ReservationController, locCtrl *LocationController, catCtrl *CategoryController, vrtCtrl *VariantController, prcCtrl *PriceController, prmCtrl *PromotionController, rvwCtrl *ReviewController) http.Handler {
 	router := chi.NewRouter()
 
 	router.Use(middleware.Recover)
@@ -23,7 +23,12 @@ func NewRouter(pdtCtrl *ProductController, sysCtl *SystemController) http.Handle
 	router.MethodNotAllowed(MethodNotAllowed)
 
 	router.Route("/", func(r chi.Router) {
-		r.Mount("/products", productHandlers(pdtCtrl))
+		r.Mount("/products", productHandlers(pdtCtrl, stkCtrl, rsvCtrl, catCtrl, vrtCtrl, prcCtrl))
+		r.Mount("/locations", locationHandlers(locCtrl))
+		r.Mount("/categories", categoryHandlers(catCtrl))
+		r.Mount("/promotions", promotionHandlers(prmCtrl))
+		r.Mount("/reviews", reviewHandlers(rvwCtrl))
+		r.Get("/tags", pdtCtrl.Tags)
 		r.Mount("/system", systemHandlers(sysCtl))
 		r.Mount("/debug", debugHandlers())
 	})
@@ -54,16 +59,88 @@ func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 }
 
-func productHandlers(ctrl *ProductController) http.Handler {
+func productHandlers(ctrl *ProductController, stkCtrl *StockController, rsvCtrl *ReservationController, catCtrl *CategoryController, vrtCtrl *VariantController, prcCtrl *PriceController) http.Handler {
 	h := chi.NewRouter()
 	h.Group(func(r chi.Router) {
 		r.Get("/", ctrl.List)
 		r.With(middleware.Auth).Post("/", ctrl.Create)
+		r.Get("/by-sku/{sku}", ctrl.BySKU)
+		r.Get("/by-barcode/{code}", ctrl.ByBarcode)
+		r.With(middleware.Auth).Get("/deleted", ctrl.Deleted)
 		r.Get("/{id}", ctrl.Get)
 		r.With(middleware.Auth).Put("/{id}", ctrl.Update)
 		r.With(middleware.Auth).Patch("/{id}", ctrl.UpdatePartial)
 		r.With(middleware.Auth).Delete("/{id}", ctrl.Delete)
-		r.Post("/{id}/rating", ctrl.Rate)
+		r.With(middleware.Auth).Post("/{id}/publish", ctrl.Publish)
+		r.With(middleware.Auth).Post("/{id}/unpublish", ctrl.Unpublish)
+		r.With(middleware.Auth).Post("/{id}/archive", ctrl.Archive)
+		r.With(middleware.Auth).Post("/{id}/restore", ctrl.Restore)
+		r.With(middleware.Auth).Post("/{id}/rating", ctrl.Rate)
+		r.With(ctrl.Visible).Get("/{id}/reviews", ctrl.Reviews)
+		r.With(middleware.Auth).Delete("/{id}/reviews/{rid}", ctrl.RemoveReview)
+		r.With(ctrl.Visible).Get("/{id}/categories", catCtrl.ProductCategories)
+		r.With(middleware.Auth).Put("/{id}/categories", catCtrl.Assign)
+		r.With(ctrl.Visible).Get("/{id}/variants", vrtCtrl.List)
+		r.With(middleware.Auth).Post("/{id}/variants", vrtCtrl.Create)
+		r.With(ctrl.Visible).Get("/{id}/variants/{vid}", vrtCtrl.Get)
+		r.With(middleware.Auth).Put("/{id}/variants/{vid}", vrtCtrl.Update)
+		r.With(middleware.Auth).Delete("/{id}/variants/{vid}", vrtCtrl.Delete)
+		r.With(ctrl.Visible).Get("/{id}/prices", prcCtrl.List)
+		r.With(middleware.Auth).Post("/{id}/prices", prcCtrl.Create)
+		r.With(ctrl.Visible).Get("/{id}/stock", stkCtrl.Get)
+		r.With(middleware.Auth).Get("/{id}/stock/movements", stkCtrl.Movements)
+		r.With(middleware.Auth).Post("/{id}/stock/movements", stkCtrl.Move)
+		r.With(middleware.Auth).Post("/{id}/stock/transfers", stkCtrl.Transfer)
+		r.With(middleware.Auth).Post("/{id}/reservations", rsvCtrl.Create)
+		r.With(middleware.Auth).Get("/{id}/reservations/{rid}", rsvCtrl.Get)
+		r.With(middleware.Auth).Post("/{id}/reservations/{rid}/confirm", rsvCtrl.Confirm)
+		r.With(middleware.Auth).Post("/{id}/reservations/{rid}/cancel", rsvCtrl.Cancel)
+	})
+	return h
+}
+
+func locationHandlers(ctrl *LocationController) http.Handler {
+	h := chi.NewRouter()
+	h.Group(func(r chi.Router) {
+		r.Get("/", ctrl.List)
+		r.With(middleware.Auth).Post("/", ctrl.Create)
+		r.Get("/{id}", ctrl.Get)
+		r.With(middleware.Auth).Put("/{id}", ctrl.Update)
+	})
+	return h
+}
+
+func categoryHandlers(ctrl *CategoryController) http.Handler {
+	h := chi.NewRouter()
+	h.Group(func(r chi.Router) {
+		r.Get("/", ctrl.List)
+		r.With(middleware.Auth).Post("/", ctrl.Create)
+		r.Get("/{id}", ctrl.Get)
+		r.With(middleware.Auth).Put("/{id}", ctrl.Update)
+		r.With(middleware.Auth).Delete("/{id}", ctrl.Delete)
+	})
+	return h
+}
+
+func promotionHandlers(ctrl *PromotionController) http.Handler {
+	h := chi.NewRouter()
+	h.Group(func(r chi.Router) {
+		r.Get("/", ctrl.List)
+		r.With(middleware.Auth).Post("/", ctrl.Create)
+		r.Get("/{id}", ctrl.Get)
+		r.With(middleware.Auth).Put("/{id}", ctrl.Update)
+		r.With(middleware.Auth).Delete("/{id}", ctrl.Delete)
+	})
+	return h
+}
+
+func reviewHandlers(ctrl *ReviewController) http.Handler {
+	h := chi.NewRouter()
+	h.Group(func(r chi.Router) {
+		r.Use(middleware.Moderator)
+		r.Get("/pending", ctrl.Pending)
+		r.Post("/{rid}/approve", ctrl.Approve)
+		r.Post("/{rid}/reject", ctrl.Reject)
 	})
 	return h
 }
@@ -76,6 +153,7 @@ func systemHandlers(ctrl *SystemController) http.Handler {
 	h.Group(func(r chi.Router) {
 		r.Get("/health", ctrl.Health)
 		r.Get("/ready", ctrl.Ready)
+		r.Get("/metrics", ctrl.Metrics)
 	})
 	return h
 }
@@ -0,0 +1,158 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+// StockController holds necessary fields to serve stock handlers
+type StockController struct {
+	stkSvc *service.Stock
+}
+
+// NewStockController returns a new StockController with the svc
+func NewStockController(svc *service.Stock) *StockController {
+	return &StockController{
+		stkSvc: svc,
+	}
+}
+
+type createStockMovementBody struct {
+	LocationID string `json:"locationId"`
+	Type       string `json:"type"`
+	Quantity   int    `json:"quantity"`
+	Reason     string `json:"reason"`
+	Reference  string `json:"reference"`
+}
+
+// Move records a stock movement of a product with its id from url param {id}
+func (c *StockController) Move(w http.ResponseWriter, r *http.Request) {
+	body := createStockMovementBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	mvt := model.StockMovement{
+		LocationID: body.LocationID,
+		Type:       model.MovementType(body.Type),
+		Quantity:   body.Quantity,
+		Reason:     body.Reason,
+		Reference:  body.Reference,
+	}
+	id := chi.URLParam(r, "id")
+	mID, err := c.stkSvc.Move(r.Context(), id, mvt)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusCreated, mID, nil)
+}
+
+type createTransferBody struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Quantity  int    `json:"quantity"`
+	Reference string `json:"reference"`
+}
+
+// Transfer moves stock of a product with its id from url param {id} between two locations
+// it serves the ids of the outgoing and incoming movements
+func (c *StockController) Transfer(w http.ResponseWriter, r *http.Request) {
+	body := createTransferBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	trf := model.Transfer{
+		From:      body.From,
+		To:        body.To,
+		Quantity:  body.Quantity,
+		Reference: body.Reference,
+	}
+	id := chi.URLParam(r, "id")
+	mIDs, err := c.stkSvc.Transfer(r.Context(), id, trf)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusCreated, mIDs, nil)
+}
+
+// Get serves the stock of a product with its id from url param {id}
+func (c *StockController) Get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	stk, err := c.stkSvc.Get(r.Context(), id)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespStock(*stk), nil)
+}
+
+// Movements serves the stock movements of a product with its id from url param {id}
+func (c *StockController) Movements(w http.ResponseWriter, r *http.Request) {
+	skip, limit := getSkipLimit(r, 20)
+	id := chi.URLParam(r, "id")
+
+	n, err := c.stkSvc.CountMovements(r.Context(), id)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	pgr := resp.NewPager(n, skip, limit)
+
+	if n <= skip {
+		ServeData(w, r, http.StatusOK, []struct{}{}, pgr)
+		return
+	}
+
+	mvts, err := c.stkSvc.Movements(r.Context(), id, skip, limit)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	rs := []resp.StockMovement{}
+	for _, mvt := range mvts {
+		rs = append(rs, toRespStockMovement(mvt))
+	}
+	ServeData(w, r, http.StatusOK, rs, pgr)
+}
+
+func toRespStock(stk model.Stock) resp.Stock {
+	lvls := []resp.StockLevel{}
+	for _, lvl := range stk.Levels {
+		lvls = append(lvls, resp.StockLevel{
+			LocationID: lvl.LocationID,
+			Quantity:   lvl.Quantity,
+		})
+	}
+	return resp.Stock{
+		ProductID: stk.ProductID,
+		Quantity:  stk.Quantity,
+		Reserved:  stk.Reserved,
+		Available: stk.Available,
+		Levels:    lvls,
+	}
+}
+
+func toRespStockMovement(mvt model.StockMovement) resp.StockMovement {
+	return resp.StockMovement{
+		ID:         mvt.ID,
+		ProductID:  mvt.ProductID,
+		LocationID: mvt.LocationID,
+		Type:       string(mvt.Type),
+		Quantity:   mvt.Quantity,
+		Reason:     mvt.Reason,
+		Reference:  mvt.Reference,
+		CreatedAt:  mvt.CreatedAt,
+	}
+}
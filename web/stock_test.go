@@ -0,0 +1,336 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/msyrus/simple-product-inv/mock_repo"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo"
+	"github.com/msyrus/simple-product-inv/service"
+)
+
+func TestNewStockController(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	stkSvc := service.NewStock(mock_repo.NewMockStock(mockCtrl), mock_repo.NewMockProduct(mockCtrl), mock_repo.NewMockStockLevel(mockCtrl), mock_repo.NewMockLocation(mockCtrl))
+
+	if got, want := NewStockController(stkSvc), (&StockController{stkSvc: stkSvc}); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewStockController() = %v, want %v", got, want)
+	}
+}
+
+func TestStockController_Move(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	lvlRepo := mock_repo.NewMockStockLevel(mockCtrl)
+	locRepo := mock_repo.NewMockLocation(mockCtrl)
+
+	stkSvc := service.NewStock(stkRepo, pdtRepo, lvlRepo, locRepo)
+
+	newRequest := func(id, body string) *http.Request {
+		req, err := http.NewRequest("POST", "/"+id+"/stock/movements", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		injectChiURLParam(req, "id", id)
+		return req
+	}
+
+	pdt := model.Product{ID: "valid_id", Quantity: 2}
+	gomock.InOrder(
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		stkRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return("", model.ValidationError{"Quantity": {"is invalid"}}),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		stkRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return("mvt_id", nil),
+		pdtRepo.EXPECT().AdjustQuantity(gomock.Any(), "valid_id", -3).Return(0, repo.ErrInsufficientQuantity),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(nil, errors.New("db failed")),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "unavailable_loc").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		stkRepo.EXPECT().Create(gomock.Any(), model.StockMovement{ProductID: "valid_id", Type: model.MovementReceipt, Quantity: 5}).Return("mvt_id", nil),
+		pdtRepo.EXPECT().AdjustQuantity(gomock.Any(), "valid_id", 5).Return(7, nil),
+		lvlRepo.EXPECT().AdjustLevel(gomock.Any(), "valid_id", "", 5).Return(5, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "loc_id").Return(model.Location{ID: "loc_id", Name: "Dhaka"}, nil),
+		stkRepo.EXPECT().Create(gomock.Any(), model.StockMovement{ProductID: "valid_id", LocationID: "loc_id", Type: model.MovementReceipt, Quantity: 5}).Return("mvt_id", nil),
+		pdtRepo.EXPECT().AdjustQuantity(gomock.Any(), "valid_id", 5).Return(12, nil),
+		lvlRepo.EXPECT().AdjustLevel(gomock.Any(), "valid_id", "loc_id", 5).Return(5, nil),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "bad body", r: newRequest("valid_id", `{"type":`), wantCode: http.StatusBadRequest},
+		{name: "unknown product", r: newRequest("unavailable_id", `{"type":"receipt","quantity":5}`), wantCode: http.StatusNotFound},
+		{name: "invalid movement", r: newRequest("valid_id", `{"type":"receipt"}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "insufficient stock", r: newRequest("valid_id", `{"type":"sale","quantity":3}`), wantCode: http.StatusConflict},
+		{name: "db failed", r: newRequest("valid_id", `{"type":"receipt","quantity":5}`), wantCode: http.StatusInternalServerError},
+		{name: "transfer type", r: newRequest("valid_id", `{"type":"transfer","quantity":5}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "unknown location", r: newRequest("valid_id", `{"type":"receipt","quantity":5,"locationId":"unavailable_loc"}`), wantCode: http.StatusNotFound},
+		{name: "receipt", r: newRequest("valid_id", `{"type":"receipt","quantity":5}`), wantCode: http.StatusCreated},
+		{name: "located receipt", r: newRequest("valid_id", `{"type":"receipt","quantity":5,"locationId":"loc_id"}`), wantCode: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &StockController{
+				stkSvc: stkSvc,
+			}
+			rr := httptest.NewRecorder()
+			c.Move(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("StockController.Move() Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestStockController_Transfer(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	lvlRepo := mock_repo.NewMockStockLevel(mockCtrl)
+	locRepo := mock_repo.NewMockLocation(mockCtrl)
+
+	stkSvc := service.NewStock(stkRepo, pdtRepo, lvlRepo, locRepo)
+
+	newRequest := func(id, body string) *http.Request {
+		req, err := http.NewRequest("POST", "/"+id+"/stock/transfers", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		injectChiURLParam(req, "id", id)
+		return req
+	}
+
+	pdt := model.Product{ID: "valid_id", Quantity: 5}
+	dhk := model.Location{ID: "dhk", Name: "Dhaka"}
+	ctg := model.Location{ID: "ctg", Name: "Chittagong"}
+	gomock.InOrder(
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "dhk").Return(dhk, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "unavailable_loc").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "dhk").Return(dhk, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "ctg").Return(ctg, nil),
+		lvlRepo.EXPECT().AdjustLevel(gomock.Any(), "valid_id", "dhk", -9).Return(0, repo.ErrInsufficientQuantity),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "dhk").Return(dhk, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "ctg").Return(ctg, nil),
+		lvlRepo.EXPECT().AdjustLevel(gomock.Any(), "valid_id", "dhk", -2).Return(3, nil),
+		stkRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return("out_id", nil),
+		lvlRepo.EXPECT().AdjustLevel(gomock.Any(), "valid_id", "ctg", 2).Return(2, nil),
+		stkRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return("in_id", nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		locRepo.EXPECT().Fetch(gomock.Any(), "ctg").Return(ctg, nil),
+		lvlRepo.EXPECT().AdjustLevel(gomock.Any(), "valid_id", "", -2).Return(0, nil),
+		stkRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return("out_id", nil),
+		lvlRepo.EXPECT().AdjustLevel(gomock.Any(), "valid_id", "ctg", 2).Return(4, nil),
+		stkRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return("in_id", nil),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "bad body", r: newRequest("valid_id", `{"from":`), wantCode: http.StatusBadRequest},
+		{name: "same location", r: newRequest("valid_id", `{"from":"dhk","to":"dhk","quantity":2}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "unknown product", r: newRequest("unavailable_id", `{"from":"dhk","to":"ctg","quantity":2}`), wantCode: http.StatusNotFound},
+		{name: "unknown location", r: newRequest("valid_id", `{"from":"dhk","to":"unavailable_loc","quantity":2}`), wantCode: http.StatusNotFound},
+		{name: "insufficient stock", r: newRequest("valid_id", `{"from":"dhk","to":"ctg","quantity":9}`), wantCode: http.StatusConflict},
+		{name: "transfer", r: newRequest("valid_id", `{"from":"dhk","to":"ctg","quantity":2}`), wantCode: http.StatusCreated},
+		{name: "transfer unallocated", r: newRequest("valid_id", `{"to":"ctg","quantity":2}`), wantCode: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &StockController{
+				stkSvc: stkSvc,
+			}
+			rr := httptest.NewRecorder()
+			c.Transfer(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("StockController.Transfer() Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestStockController_Get(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	lvlRepo := mock_repo.NewMockStockLevel(mockCtrl)
+	locRepo := mock_repo.NewMockLocation(mockCtrl)
+
+	stkSvc := service.NewStock(stkRepo, pdtRepo, lvlRepo, locRepo)
+
+	req1, err := http.NewRequest("GET", "/unavailable_id/stock", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injectChiURLParam(req1, "id", "unavailable_id")
+
+	req2, err := http.NewRequest("GET", "/valid_id/stock", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injectChiURLParam(req2, "id", "valid_id")
+
+	gomock.InOrder(
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(model.Product{ID: "valid_id", Quantity: 3, Available: true}, nil),
+		lvlRepo.EXPECT().SearchCount(gomock.Any(), repo.Query{"product_id": {"valid_id"}}).Return(1, nil),
+		lvlRepo.EXPECT().Search(gomock.Any(), repo.Query{"product_id": {"valid_id"}}, 0, 1).Return([]interface{}{
+			model.StockLevel{ProductID: "valid_id", LocationID: "loc_id", Quantity: 3},
+		}, nil),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "unknown product", r: req1, wantCode: http.StatusNotFound},
+		{name: "stock", r: req2, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &StockController{
+				stkSvc: stkSvc,
+			}
+			rr := httptest.NewRecorder()
+			c.Get(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("StockController.Get() Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestStockController_Movements(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	lvlRepo := mock_repo.NewMockStockLevel(mockCtrl)
+	locRepo := mock_repo.NewMockLocation(mockCtrl)
+
+	stkSvc := service.NewStock(stkRepo, pdtRepo, lvlRepo, locRepo)
+
+	req1, err := http.NewRequest("GET", "/valid_id/stock/movements", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injectChiURLParam(req1, "id", "valid_id")
+
+	req2, err := http.NewRequest("GET", "/valid_id/stock/movements?skip=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injectChiURLParam(req2, "id", "valid_id")
+
+	req3, err := http.NewRequest("GET", "/unavailable_id/stock/movements", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injectChiURLParam(req3, "id", "unavailable_id")
+
+	pdt := model.Product{ID: "valid_id", Quantity: 5}
+	q := repo.Query{"product_id": []interface{}{"valid_id"}}
+	gomock.InOrder(
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		stkRepo.EXPECT().SearchCount(gomock.Any(), q).Return(1, nil),
+		stkRepo.EXPECT().Search(gomock.Any(), q, 0, 20).Return([]interface{}{
+			model.StockMovement{ID: "mvt_id", ProductID: "valid_id", Type: model.MovementReceipt, Quantity: 5},
+		}, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		stkRepo.EXPECT().SearchCount(gomock.Any(), q).Return(1, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "unavailable_id").Return(nil, nil),
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(pdt, nil),
+		stkRepo.EXPECT().SearchCount(gomock.Any(), q).Return(0, errors.New("db failed")),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "movements", r: req1, wantCode: http.StatusOK},
+		{name: "skipped all", r: req2, wantCode: http.StatusOK},
+		{name: "unknown product", r: req3, wantCode: http.StatusNotFound},
+		{name: "db failed", r: req1, wantCode: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &StockController{
+				stkSvc: stkSvc,
+			}
+			rr := httptest.NewRecorder()
+			c.Movements(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("StockController.Movements() Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestStockController_MovementsRoute(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	stkRepo := mock_repo.NewMockStock(mockCtrl)
+	pdtRepo := mock_repo.NewMockProduct(mockCtrl)
+	lvlRepo := mock_repo.NewMockStockLevel(mockCtrl)
+	locRepo := mock_repo.NewMockLocation(mockCtrl)
+
+	stkSvc := service.NewStock(stkRepo, pdtRepo, lvlRepo, locRepo)
+	h := productHandlers(&ProductController{}, NewStockController(stkSvc), &ReservationController{},
+		&CategoryController{}, &VariantController{}, &PriceController{})
+
+	req1 := httptest.NewRequest("GET", "/valid_id/stock/movements", nil)
+	req2 := httptest.NewRequest("GET", "/valid_id/stock/movements", nil)
+	req2.Header.Set("Authorization", "Bearer user")
+
+	q := repo.Query{"product_id": []interface{}{"valid_id"}}
+	gomock.InOrder(
+		pdtRepo.EXPECT().Fetch(gomock.Any(), "valid_id").Return(model.Product{ID: "valid_id"}, nil),
+		stkRepo.EXPECT().SearchCount(gomock.Any(), q).Return(0, nil),
+	)
+
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "anonymous", r: req1, wantCode: http.StatusUnauthorized},
+		{name: "authorized", r: req2, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, tt.r)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("GET /{id}/stock/movements Code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
@@ -2,38 +2,78 @@ package web
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
 )
 
 // SystemController holds necessary fields to serve system handlers
 type SystemController struct {
 	sysSvc *service.System
+	mtr    http.Handler
 }
 
 // NewSystemController returns new SystemController
-func NewSystemController(svc *service.System) *SystemController {
+// mtr serves the metrics, metrics are not found if it is nil
+func NewSystemController(svc *service.System, mtr http.Handler) *SystemController {
 	return &SystemController{
 		sysSvc: svc,
+		mtr:    mtr,
 	}
 }
 
 // Health is the system health check handler
+// it serves http ServiceUnavailable if a critical check fails
 func (c *SystemController) Health(w http.ResponseWriter, r *http.Request) {
-	ok, err := c.sysSvc.Health()
-	if err != nil {
-		ServeError(w, r, err)
-		return
-	}
-	ServeData(w, r, http.StatusOK, ok, nil)
+	serveReport(w, r, c.sysSvc.Health(r.Context()))
 }
 
 // Ready is the system ready check handler
+// it serves http ServiceUnavailable if a critical check fails
 func (c *SystemController) Ready(w http.ResponseWriter, r *http.Request) {
-	ok, err := c.sysSvc.Ready()
-	if err != nil {
-		ServeError(w, r, err)
+	serveReport(w, r, c.sysSvc.Ready(r.Context()))
+}
+
+// Metrics is the system metrics handler
+func (c *SystemController) Metrics(w http.ResponseWriter, r *http.Request) {
+	if c.mtr == nil {
+		NotFoundHandler(w, r)
+		return
+	}
+	c.mtr.ServeHTTP(w, r)
+}
+
+func serveReport(w http.ResponseWriter, r *http.Request, rep service.Report) {
+	data := resp.System{
+		Status: resp.CheckUp,
+		Checks: []resp.Check{},
+	}
+	for _, res := range rep.Checks {
+		chk := resp.Check{
+			Name:      res.Name,
+			Status:    resp.CheckUp,
+			Critical:  res.Critical,
+			LatencyMS: float64(res.Latency) / float64(time.Millisecond),
+		}
+		if !res.OK {
+			chk.Status = resp.CheckDown
+		}
+		if res.LastError != nil {
+			at := res.LastErrorAt.UTC()
+			chk.LastError = res.LastError.Error()
+			chk.LastErrorAt = &at
+		}
+		data.Checks = append(data.Checks, chk)
+	}
+
+	if !rep.OK {
+		data.Status = resp.CheckDown
+		resp.Render(w, r, resp.Response{
+			Code: http.StatusServiceUnavailable,
+			Data: data,
+		})
 		return
 	}
-	ServeData(w, r, http.StatusOK, ok, nil)
+	ServeData(w, r, http.StatusOK, data, nil)
 }
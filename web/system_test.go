@@ -2,19 +2,24 @@ package web
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
 
+	"github.com/msyrus/simple-product-inv/metrics"
 	"github.com/msyrus/simple-product-inv/service"
 )
 
 func TestNewSystemController(t *testing.T) {
 	sysSvc := service.NewSystem()
+	reg := metrics.NewRegistry()
 
 	type args struct {
 		svc *service.System
+		mtr http.Handler
 	}
 	tests := []struct {
 		name string
@@ -27,10 +32,17 @@ func TestNewSystemController(t *testing.T) {
 			},
 			want: &SystemController{sysSvc: sysSvc},
 		},
+		{
+			args: args{
+				svc: sysSvc,
+				mtr: reg,
+			},
+			want: &SystemController{sysSvc: sysSvc, mtr: reg},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewSystemController(tt.args.svc); !reflect.DeepEqual(got, tt.want) {
+			if got := NewSystemController(tt.args.svc, tt.args.mtr); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewSystemController() = %v, want %v", got, tt.want)
 			}
 		})
@@ -39,6 +51,9 @@ func TestNewSystemController(t *testing.T) {
 
 func TestSystemController_Health(t *testing.T) {
 	sysSvc := service.NewSystem()
+	okSvc := service.NewSystem(service.AddHealthCheck("ok", service.CheckerFunc(func(context.Context) error {
+		return nil
+	}), true))
 
 	req, err := http.NewRequest("GET", "/health", nil)
 	if err != nil {
@@ -61,7 +76,15 @@ func TestSystemController_Health(t *testing.T) {
 			},
 			r:        req,
 			wantCode: http.StatusOK,
-			wantBody: []byte(`{"data":true}`),
+			wantBody: []byte(`{"data":{"status":"up","checks":[]}}`),
+		},
+		{
+			fields: fields{
+				sysSvc: okSvc,
+			},
+			r:        req,
+			wantCode: http.StatusOK,
+			wantBody: []byte(`{"name":"ok","status":"up","critical":true,"latencyMs":`),
 		},
 	}
 	for _, tt := range tests {
@@ -74,7 +97,7 @@ func TestSystemController_Health(t *testing.T) {
 			if got := rr.Code; got != tt.wantCode {
 				t.Errorf("SystemController.Health() Code = %v, want %v", got, tt.wantCode)
 			}
-			if got := rr.Body.Bytes(); bytes.Compare(got, tt.wantBody) != 0 {
+			if got := rr.Body.Bytes(); !bytes.Contains(got, tt.wantBody) {
 				t.Errorf("SystemController.Health() Body = %v, want %v", string(got), string(tt.wantBody))
 			}
 		})
@@ -83,6 +106,9 @@ func TestSystemController_Health(t *testing.T) {
 
 func TestSystemController_Ready(t *testing.T) {
 	sysSvc := service.NewSystem()
+	failSvc := service.NewSystem(service.AddReadyCheck("db", service.CheckerFunc(func(context.Context) error {
+		return errors.New("connection refused")
+	}), true))
 
 	req, err := http.NewRequest("GET", "/health", nil)
 	if err != nil {
@@ -105,7 +131,15 @@ func TestSystemController_Ready(t *testing.T) {
 			},
 			r:        req,
 			wantCode: http.StatusOK,
-			wantBody: []byte(`{"data":true}`),
+			wantBody: []byte(`{"data":{"status":"up","checks":[]}}`),
+		},
+		{
+			fields: fields{
+				sysSvc: failSvc,
+			},
+			r:        req,
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: []byte(`"status":"down"`),
 		},
 	}
 	for _, tt := range tests {
@@ -118,9 +152,52 @@ func TestSystemController_Ready(t *testing.T) {
 			if got := rr.Code; got != tt.wantCode {
 				t.Errorf("SystemController.Ready() Code = %v, want %v", got, tt.wantCode)
 			}
-			if got := rr.Body.Bytes(); bytes.Compare(got, tt.wantBody) != 0 {
+			if got := rr.Body.Bytes(); !bytes.Contains(got, tt.wantBody) {
 				t.Errorf("SystemController.Ready() Body = %v, want %v", string(got), string(tt.wantBody))
 			}
 		})
 	}
 }
+
+func TestSystemController_Metrics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	reg.Register(metrics.NewGaugeFunc("products_active", "Active products.", func(context.Context) (float64, error) {
+		return 3, nil
+	}))
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		mtr      http.Handler
+		wantCode int
+		wantBody []byte
+	}{
+		{
+			mtr:      reg,
+			wantCode: http.StatusOK,
+			wantBody: []byte("products_active 3\n"),
+		},
+		{
+			mtr:      nil,
+			wantCode: http.StatusNotFound,
+			wantBody: []byte(http.StatusText(http.StatusNotFound)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSystemController(service.NewSystem(), tt.mtr)
+			rr := httptest.NewRecorder()
+			c.Metrics(rr, req)
+			if got := rr.Code; got != tt.wantCode {
+				t.Errorf("SystemController.Metrics() Code = %v, want %v", got, tt.wantCode)
+			}
+			if got := rr.Body.Bytes(); !bytes.Contains(got, tt.wantBody) {
+				t.Errorf("SystemController.Metrics() Body = %v, want %v", string(got), string(tt.wantBody))
+			}
+		})
+	}
+}
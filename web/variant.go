@@ -0,0 +1,148 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+// VariantController holds necessary fields to serve product variant handlers
+type VariantController struct {
+	vrtSvc *service.Variant
+}
+
+// NewVariantController returns a new VariantController with the svc
+func NewVariantController(svc *service.Variant) *VariantController {
+	return &VariantController{
+		vrtSvc: svc,
+	}
+}
+
+type variantBody struct {
+	Options  map[string]string `json:"options"`
+	SKU      string            `json:"sku"`
+	Price    int               `json:"price"`
+	Weight   int               `json:"weight"`
+	Quantity int               `json:"quantity"`
+}
+
+// Create creates a variant of the product with its id from url param {id}
+func (c *VariantController) Create(w http.ResponseWriter, r *http.Request) {
+	body := variantBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	vrt := model.Variant{
+		Options:  body.Options,
+		SKU:      body.SKU,
+		Price:    body.Price,
+		Weight:   body.Weight,
+		Quantity: body.Quantity,
+	}
+	id, err := c.vrtSvc.Add(r.Context(), chi.URLParam(r, "id"), vrt)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusCreated, id, nil)
+}
+
+// Get serves a variant with its id from url param {vid} of the product from url param {id}
+func (c *VariantController) Get(w http.ResponseWriter, r *http.Request) {
+	vrt, err := c.vrtSvc.Get(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "vid"))
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespVariant(*vrt), nil)
+}
+
+// List serves the variants of the product with its id from url param {id}
+func (c *VariantController) List(w http.ResponseWriter, r *http.Request) {
+	skip, limit := getSkipLimit(r, 20)
+	id := chi.URLParam(r, "id")
+
+	n, err := c.vrtSvc.Count(r.Context(), id)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	pgr := resp.NewPager(n, skip, limit)
+
+	if n <= skip {
+		ServeData(w, r, http.StatusOK, []struct{}{}, pgr)
+		return
+	}
+
+	vrts, err := c.vrtSvc.List(r.Context(), id, skip, limit)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, toRespVariants(vrts), pgr)
+}
+
+// Update updates a variant with its id from url param {vid} of the product from url param {id}
+func (c *VariantController) Update(w http.ResponseWriter, r *http.Request) {
+	body := variantBody{}
+	if err := parseJSON(r.Body, &body); err != nil {
+		ServeBadRequest(w, r, err)
+		return
+	}
+
+	id, vid := chi.URLParam(r, "id"), chi.URLParam(r, "vid")
+	vrt, err := c.vrtSvc.Get(r.Context(), id, vid)
+	if err != nil {
+		ServeError(w, r, err)
+		return
+	}
+
+	vrt.Options = body.Options
+	vrt.SKU = body.SKU
+	vrt.Price = body.Price
+	vrt.Weight = body.Weight
+	vrt.Quantity = body.Quantity
+	if err := c.vrtSvc.Update(r.Context(), id, vid, *vrt); err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, vrt.ID, nil)
+}
+
+// Delete deletes a variant with its id from url param {vid} of the product from url param {id}
+func (c *VariantController) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := c.vrtSvc.Remove(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "vid")); err != nil {
+		ServeError(w, r, err)
+		return
+	}
+	ServeData(w, r, http.StatusOK, true, nil)
+}
+
+func toRespVariant(vrt model.Variant) resp.Variant {
+	return resp.Variant{
+		ID:        vrt.ID,
+		ProductID: vrt.ProductID,
+		Options:   vrt.Options,
+		SKU:       vrt.SKU,
+		Price:     vrt.Price,
+		Weight:    vrt.Weight,
+		Quantity:  vrt.Quantity,
+		CreatedAt: vrt.CreatedAt,
+		UpdatedAt: vrt.UpdatedAt,
+	}
+}
+
+func toRespVariants(vrts []model.Variant) []resp.Variant {
+	rs := []resp.Variant{}
+	for _, vrt := range vrts {
+		rs = append(rs, toRespVariant(vrt))
+	}
+	return rs
+}
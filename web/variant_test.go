@@ -0,0 +1,98 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/msyrus/simple-product-inv/model"
+	"github.com/msyrus/simple-product-inv/repo/memory"
+	"github.com/msyrus/simple-product-inv/service"
+	"github.com/msyrus/simple-product-inv/web/resp"
+)
+
+func TestVariantController(t *testing.T) {
+	store := memory.NewStore()
+	rps := store.Repos()
+	ctx := context.Background()
+	vrtSvc := service.NewVariant(rps.Variant, rps.Product, service.SetVariantUnitOfWork(store),
+		service.SetVariantOutputLogger(nil),
+		service.SetVariantErrorLogger(nil),
+	)
+	c := NewVariantController(vrtSvc)
+
+	pdtID, err := rps.Product.Create(ctx, model.Product{Name: "Hat", Price: 100, Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	red, err := vrtSvc.Add(ctx, pdtID, model.Variant{Options: map[string]string{"color": "red"}, SKU: "HAT-RED"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blue, err := vrtSvc.Add(ctx, pdtID, model.Variant{Options: map[string]string{"color": "blue"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func(method, id, vid, body string) *http.Request {
+		req, err := http.NewRequest(method, "/"+id+"/variants/"+vid, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		rctx.URLParams.Add("vid", vid)
+		return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		r        *http.Request
+		wantCode int
+	}{
+		{name: "create bad body", handler: c.Create, r: newRequest("POST", pdtID, "", `{"options":`), wantCode: http.StatusBadRequest},
+		{name: "create without options", handler: c.Create, r: newRequest("POST", pdtID, "", `{"price":120}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "create with negative price", handler: c.Create, r: newRequest("POST", pdtID, "", `{"options":{"color":"green"},"price":-1}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "create of unknown product", handler: c.Create, r: newRequest("POST", "unavailable_id", "", `{"options":{"color":"green"}}`), wantCode: http.StatusNotFound},
+		{name: "create with same options", handler: c.Create, r: newRequest("POST", pdtID, "", `{"options":{"color":"red"}}`), wantCode: http.StatusConflict},
+		{name: "create with same sku", handler: c.Create, r: newRequest("POST", pdtID, "", `{"options":{"color":"green"},"sku":"HAT-RED"}`), wantCode: http.StatusConflict},
+		{name: "create", handler: c.Create, r: newRequest("POST", pdtID, "", `{"options":{"color":"green"}}`), wantCode: http.StatusCreated},
+		{name: "list of unknown product", handler: c.List, r: newRequest("GET", "unavailable_id", "", ""), wantCode: http.StatusNotFound},
+		{name: "get unknown", handler: c.Get, r: newRequest("GET", pdtID, "unavailable_id", ""), wantCode: http.StatusNotFound},
+		{name: "get of another product", handler: c.Get, r: newRequest("GET", "unavailable_id", red, ""), wantCode: http.StatusNotFound},
+		{name: "get", handler: c.Get, r: newRequest("GET", pdtID, red, ""), wantCode: http.StatusOK},
+		{name: "update bad body", handler: c.Update, r: newRequest("PUT", pdtID, blue, `{"options":`), wantCode: http.StatusBadRequest},
+		{name: "update unknown", handler: c.Update, r: newRequest("PUT", pdtID, "unavailable_id", `{"options":{"color":"navy"}}`), wantCode: http.StatusNotFound},
+		{name: "update invalid", handler: c.Update, r: newRequest("PUT", pdtID, blue, `{"options":{"color":"navy"},"quantity":-1}`), wantCode: http.StatusUnprocessableEntity},
+		{name: "update to same options", handler: c.Update, r: newRequest("PUT", pdtID, blue, `{"options":{"color":"red"}}`), wantCode: http.StatusConflict},
+		{name: "update", handler: c.Update, r: newRequest("PUT", pdtID, blue, `{"options":{"color":"navy"},"price":150}`), wantCode: http.StatusOK},
+		{name: "delete unknown", handler: c.Delete, r: newRequest("DELETE", pdtID, "unavailable_id", ""), wantCode: http.StatusNotFound},
+		{name: "delete", handler: c.Delete, r: newRequest("DELETE", pdtID, red, ""), wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.handler(rr, tt.r)
+			if rr.Code != tt.wantCode {
+				t.Errorf("VariantController %s Code = %v, want %v", tt.name, rr.Code, tt.wantCode)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	c.Get(rr, newRequest("GET", pdtID, blue, ""))
+	body := struct {
+		Data resp.Variant `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if got := body.Data; got.Options["color"] != "navy" || got.Price != 150 {
+		t.Errorf("VariantController.Get() = %+v, want the updated variant", got)
+	}
+}